@@ -1,21 +1,22 @@
 package account
 
 import (
-	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"math/big"
 
+	"github.com/blockchain-research/gridlock/commitment"
 	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/ledger"
 	pb "github.com/blockchain-research/gridlock/proto"
 	"github.com/blockchain-research/gridlock/zkrangeproof"
 	"github.com/golang/protobuf/proto"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-var logger = shim.NewLogger("gridlock")
+var logger = ledger.NewLogger("gridlock")
 
-func MintAccount(stub shim.ChaincodeStubInterface, args []string) error {
+func MintAccount(stub ledger.Ledger, args []string) error {
 	logger.Info("Mint accounts")
 	if len(args) != 1 {
 		return errors.New("Need exactly one argument: <base64-encoded-object>")
@@ -29,57 +30,264 @@ func MintAccount(stub shim.ChaincodeStubInterface, args []string) error {
 	err = proto.Unmarshal(mintAccountBytes, mintAccount)
 	if err != nil {
 		logger.Error("Failed to unmarshal MintAccount")
+		return err
+	}
+	err = common.ValidateCurrency(mintAccount.Currency)
+	if err != nil {
+		return err
+	}
+	//a single aggregated range proof may cover all the accounts,
+	//otherwise the proofs of every account are verified together
+	var success bool
+	if len(mintAccount.Zkrp) > 0 {
+		success, err = verifyAggregatedAccounts(stub, mintAccount)
+	} else {
+		success, err = verifyAccounts(stub, mintAccount.Currency, mintAccount.Accounts)
+	}
+	if err != nil {
+		return err
+	}
+	if success != true {
+		logger.Error("Verification of accounts failed")
+		return errors.New("MintAcount is not valid")
+	}
+	success, err = verifySupply(stub, mintAccount)
+	if err != nil {
+		return err
+	}
+	if success != true {
+		logger.Error("Verification of the total supply failed")
+		return errors.New("MintAcount is not valid")
+	}
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, mintAccount.Currency)
+	if err != nil {
+		return err
 	}
 	for _, account := range mintAccount.Accounts {
-		//verify the account
-		success, err := verifyAccount(stub, account)
+		cmBalance, err := common.NormalizeCommitment(scheme, account.CmBalance)
 		if err != nil {
 			return err
 		}
-		if success != true {
-			logger.Error("Verification of account failed")
-			return errors.New("MintAcount is not valid")
+		//update account, a stored account keeps its reserve
+		key := common.InCurrency(common.AccountTable, mintAccount.Currency) + fmt.Sprint(account.BankId)
+		storedAccountBytes, err := stub.Get(key)
+		if err != nil {
+			logger.Error("Failed to read account table")
+			return err
+		}
+		storedAccount := &pb.StoredBankAccount{}
+		err = proto.Unmarshal(storedAccountBytes, storedAccount)
+		if err != nil {
+			logger.Error("Failed to unmarshal storedAccount")
+			return err
+		}
+		storedAccount.CmBalance = cmBalance
+		err = common.AddAccountToLedger(stub, key, storedAccount)
+		if err != nil {
+			return err
 		}
-		//update account
-		err = common.AddAccountToLedger(
-			stub,
-			common.AccountTable+fmt.Sprint(account.BankId),
-			&pb.StoredBankAccount{
-				CmBalance: account.CmBalance,
-			},
-		)
 	}
-	return nil
+	//payments may be made in the currency once it has accounts
+	return common.AddCurrencyToLedger(stub, mintAccount.Currency)
 }
 
-func verifyAccount(stub shim.ChaincodeStubInterface, account *pb.BankAccount) (bool, error) {
+//verifyAccounts checks the bank ids of all the accounts and the range proof of every cmBalance in the currency,
+//the proofs encoded with the same scheme are batch verified when the scheme supports it
+func verifyAccounts(stub ledger.Ledger, currency string, accounts []*pb.BankAccount) (bool, error) {
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+	proofTypes := []pb.RangeProofType{}
+	zkrps := map[pb.RangeProofType][][]byte{}
+	cms := map[pb.RangeProofType][]commitment.Commitment{}
+	bankIds := map[pb.RangeProofType][]int32{}
+	for _, account := range accounts {
+		if account.BankId > common.NumOfBanks || account.BankId <= 0 {
+			logger.Infof("Invalid bank Id %d", account.BankId)
+			return false, nil
+		}
+		cmBalance, ok := scheme.Unmarshal(account.CmBalance)
+		if !ok {
+			logger.Info("Invalid cmBalance")
+			return false, nil
+		}
+		if _, ok := zkrps[account.ZkrpType]; !ok {
+			proofTypes = append(proofTypes, account.ZkrpType)
+		}
+		zkrps[account.ZkrpType] = append(zkrps[account.ZkrpType], account.Zkrp)
+		cms[account.ZkrpType] = append(cms[account.ZkrpType], cmBalance)
+		bankIds[account.ZkrpType] = append(bankIds[account.ZkrpType], account.BankId)
+	}
 
-	if account.BankId > common.NumOfBanks || account.BankId <= 0 {
-		logger.Info("Invalid bank Id %s", account.BankId)
-		return false, nil
+	for _, proofType := range proofTypes {
+		//get the verifier of the scheme the proofs are encoded with
+		verifier, err := common.GetRangeProofVerifierFromLedger(stub, currency, proofType)
+		if err != nil {
+			logger.Info("Failed to read parameters from ledger")
+			return false, err
+		}
+
+		//check every cmBalance's range proof
+		points, err := common.RangeProofPoints(cms[proofType]...)
+		if err != nil {
+			return false, err
+		}
+		result, bad, err := zkrangeproof.VerifyCommitments(verifier, zkrps[proofType], points)
+		if err != nil {
+			logger.Error("Failed to verify the zero knowledge range proof")
+			if bad >= 0 {
+				return false, fmt.Errorf("account of bank %d: %w", bankIds[proofType][bad], err)
+			}
+			return false, err
+		}
+		if result != true {
+			logger.Errorf("The zero knowledge range proof verification failed. The committed value in account balance of bank %d is not within range.", bankIds[proofType][bad])
+			return false, zkrangeproof.ErrVerificationFailed
+		}
+	}
+	logger.Info("The committed account balances are within range")
+
+	return true, nil
+}
+
+//verifyAggregatedAccounts checks the bank ids of all the accounts and the aggregated range proof of their cmBalance
+func verifyAggregatedAccounts(stub ledger.Ledger, mintAccount *pb.MintAccount) (bool, error) {
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, mintAccount.Currency)
+	if err != nil {
+		return false, err
+	}
+	cms := []commitment.Commitment{}
+	for _, account := range mintAccount.Accounts {
+		if account.BankId > common.NumOfBanks || account.BankId <= 0 {
+			logger.Infof("Invalid bank Id %d", account.BankId)
+			return false, nil
+		}
+		cmBalance, ok := scheme.Unmarshal(account.CmBalance)
+		if !ok {
+			logger.Info("Invalid cmBalance")
+			return false, nil
+		}
+		cms = append(cms, cmBalance)
 	}
 
-	//get stored params
-	paramsUL, err := common.GetParamsFromLedger(stub)
+	//get the verifier of the scheme the proof is encoded with
+	verifier, err := common.GetAggregateRangeProofVerifierFromLedger(stub, mintAccount.Currency, mintAccount.ZkrpType)
 	if err != nil {
 		logger.Info("Failed to read parameters from ledger")
 		return false, err
 	}
+	points, err := common.RangeProofPoints(cms...)
+	if err != nil {
+		return false, err
+	}
+	result, err := verifier.VerifyAggregateCommitment(mintAccount.Zkrp, points)
+	if err != nil {
+		logger.Error("Failed to verify the aggregated zero knowledge range proof")
+		return false, err
+	}
+	if result != true {
+		logger.Error("The aggregated zero knowledge range proof verification failed. Some committed account balance is not within range.")
+		return false, zkrangeproof.ErrVerificationFailed
+	}
+	logger.Info("The committed account balances are within range")
 
-	//check cmBalance's range proof
-	proof := new(zkrangeproof.ProofULVerifier).Unmarshal(account.Zkrp, common.L)
+	return true, nil
+}
 
-	if bytes.Compare(proof.C.Marshal(), account.CmBalance) != 0 {
-		logger.Info("The committed values does not match the one in the proof")
-		return false, nil
+//verifySupply checks zkrpSupply proves the total supply of the currency of the mint after the mint is within [0, MaxSupply]
+func verifySupply(stub ledger.Ledger, mintAccount *pb.MintAccount) (bool, error) {
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, mintAccount.Currency)
+	if err != nil {
+		return false, err
 	}
+	balances := map[int32]commitment.Commitment{}
+	for _, account := range mintAccount.Accounts {
+		if balances[account.BankId] != nil {
+			logger.Infof("Duplicate bank Id %d", account.BankId)
+			return false, nil
+		}
+		cmBalance, err := common.UnmarshalCommitment(scheme, account.CmBalance)
+		if err != nil {
+			return false, err
+		}
+		balances[account.BankId] = cmBalance
+	}
+	cmSupply, err := totalSupply(stub, scheme, mintAccount.Currency, balances, nil)
+	if err != nil {
+		return false, err
+	}
+	return verifySupplyProof(stub, mintAccount.Currency, mintAccount.ZkrpSupply, cmSupply)
+}
 
-	result, _ := zkrangeproof.VerifyUL(proof, *paramsUL)
+//totalSupply returns the commitment to the total supply of the currency, the sum of the balances and of the credit limits
+//of all the banks, where balances and limits replace those on the ledger. Payments only move value between the accounts
+//of their currency and a balance is at least minus the credit limit of its bank, so that no balance plus credit limit is
+//above the total supply
+func totalSupply(stub ledger.Ledger, scheme commitment.Scheme, currency string, balances, limits map[int32]commitment.Commitment) (commitment.Commitment, error) {
+	cmSupply := scheme.Commit(big.NewInt(0), big.NewInt(0))
+	for bankId := int32(1); bankId <= common.NumOfBanks; bankId++ {
+		cmBalance, ok := balances[bankId]
+		if !ok {
+			key := common.InCurrency(common.AccountTable, currency) + fmt.Sprint(bankId)
+			storedAccountBytes, err := stub.Get(key)
+			if err != nil {
+				logger.Error("Failed to read account table")
+				return nil, err
+			}
+			cmBalance = scheme.Commit(big.NewInt(0), big.NewInt(0))
+			if storedAccountBytes != nil {
+				storedAccount, err := common.GetAccountFromLedger(stub, key)
+				if err != nil {
+					return nil, err
+				}
+				cmBalance, err = common.UnmarshalCommitment(scheme, storedAccount.CmBalance)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		cmLimit, ok := limits[bankId]
+		if !ok {
+			var err error
+			cmLimit, err = common.GetCreditLimitFromLedger(stub, scheme, common.InCurrency(common.CreditTable, currency)+fmt.Sprint(bankId))
+			if err != nil {
+				return nil, err
+			}
+		}
+		cmSupply = cmSupply.Add(cmBalance).Add(cmLimit)
+	}
+	return cmSupply, nil
+}
+
+//verifySupplyProof checks zkrpSupply is a CCS08 interval proof that cmSupply in the currency is within [0, MaxSupply], so that the balances
+//and the credit limits stay in the lower half of the range of the proofs and the queued payments bounded by MaxPaymentAmount
+//fit in the upper half
+func verifySupplyProof(stub ledger.Ledger, currency string, zkrpSupply []byte, cmSupply commitment.Commitment) (bool, error) {
+	if len(zkrpSupply) == 0 {
+		logger.Info("Missing the total supply proof")
+		return false, nil
+	}
+	//interval proofs are built on the UL params
+	params, err := common.GetCurrencyParamsFromLedger(stub, currency)
+	if err != nil {
+		logger.Info("Failed to read parameters from ledger")
+		return false, err
+	}
+	points, err := common.RangeProofPoints(cmSupply)
+	if err != nil {
+		return false, err
+	}
+	result, err := params.VerifyIntervalCommitment(zkrpSupply, points[0], 0, common.MaxSupply(params))
+	if err != nil {
+		logger.Error("Failed to verify the total supply proof")
+		return false, err
+	}
 	if result != true {
-		logger.Error("The zero knowledge range proof verification failed. The committed value in account balance is not within range.")
-		return false, errors.New("ZKP verification failed")
+		logger.Error("The total supply proof verification failed. The committed total supply is not within range.")
+		return false, zkrangeproof.ErrVerificationFailed
 	}
-	logger.Info("The committed account balance is whtin range (0,u^l)")
+	logger.Info("The committed total supply is within range")
 
 	return true, nil
 }
@@ -0,0 +1,180 @@
+package account
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/ledger"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/blockchain-research/gridlock/zkrangeproof"
+	"github.com/golang/protobuf/proto"
+)
+
+//SetCreditLimit sets the intraday credit line of a bank in a currency, only the central bank may set it.
+//The limit stays confidential, the bank gets the opening of cmLimit from the central bank to prove its settlements.
+//The total supply, which counts the credit limits, must stay within [0, MaxSupply]. A limit without cmLimit removes the credit line
+func SetCreditLimit(stub ledger.Ledger, args []string) error {
+	logger.Info("Set credit limit")
+	if len(args) != 1 {
+		return errors.New("Need exactly one argument: <base64-encoded-creditlimit-object>")
+	}
+	err := common.CheckCentralBankFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	limitBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded credit limit")
+		return err
+	}
+	limit := &pb.CreditLimit{}
+	err = proto.Unmarshal(limitBytes, limit)
+	if err != nil {
+		logger.Error("Failed to unmarshal credit limit")
+		return err
+	}
+	if limit.BankId > common.NumOfBanks || limit.BankId <= 0 {
+		logger.Infof("Invalid bank Id %d", limit.BankId)
+		return errors.New("Invalid bank Id")
+	}
+	err = common.CheckCurrencyFromLedger(stub, limit.Currency)
+	if err != nil {
+		return err
+	}
+	key := common.InCurrency(common.CreditTable, limit.Currency) + fmt.Sprint(limit.BankId)
+	if len(limit.CmLimit) == 0 {
+		return common.DeleteCreditLimitFromLedger(stub, key)
+	}
+
+	//the limit is within [0,u^l), so that the sums the settlements prove stay far from the order of the group
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, limit.Currency)
+	if err != nil {
+		return err
+	}
+	cmLimit, ok := scheme.Unmarshal(limit.CmLimit)
+	if !ok {
+		logger.Info("Invalid cmLimit")
+		return errors.New("Invalid cmLimit")
+	}
+	verifier, err := common.GetRangeProofVerifierFromLedger(stub, limit.Currency, pb.RangeProofType_UL)
+	if err != nil {
+		logger.Info("Failed to read parameters from ledger")
+		return err
+	}
+	points, err := common.RangeProofPoints(cmLimit)
+	if err != nil {
+		return err
+	}
+	result, err := verifier.VerifyCommitment(limit.Zkrp, points[0])
+	if err != nil {
+		logger.Error("Failed to verify the credit limit proof")
+		return err
+	}
+	if result != true {
+		logger.Error("The credit limit proof verification failed. The committed limit is not within range.")
+		return zkrangeproof.ErrVerificationFailed
+	}
+
+	//the total supply with the new limit stays within [0, MaxSupply]
+	cmSupply, err := totalSupply(stub, scheme, limit.Currency, nil, map[int32]commitment.Commitment{limit.BankId: cmLimit})
+	if err != nil {
+		return err
+	}
+	success, err := verifySupplyProof(stub, limit.Currency, limit.ZkrpSupply, cmSupply)
+	if err != nil {
+		return err
+	}
+	if success != true {
+		logger.Error("Verification of the total supply failed")
+		return errors.New("The credit limit is not valid")
+	}
+
+	//the limit applies to the settlements and proposals submitted afterwards
+	return common.AddCreditLimitToLedger(stub, key, &pb.StoredCreditLimit{CmLimit: cmLimit.Marshal()})
+}
+
+//ProveRepayment records that the balance of a bank in a currency is not negative, i.e. that the bank has repaid
+//its intraday overdraft. It is the end-of-day proof of the bank, stored with the time of its transaction
+func ProveRepayment(stub ledger.Ledger, args []string) error {
+	logger.Info("Prove repayment")
+	if len(args) != 1 {
+		return errors.New("Need exactly one argument: <base64-encoded-repaymentproof-object>")
+	}
+	repaymentBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded repayment proof")
+		return err
+	}
+	repayment := &pb.RepaymentProof{}
+	err = proto.Unmarshal(repaymentBytes, repayment)
+	if err != nil {
+		logger.Error("Failed to unmarshal repayment proof")
+		return err
+	}
+	if repayment.BankId > common.NumOfBanks || repayment.BankId <= 0 {
+		logger.Infof("Invalid bank Id %d", repayment.BankId)
+		return errors.New("Invalid bank Id")
+	}
+	err = common.CheckCurrencyFromLedger(stub, repayment.Currency)
+	if err != nil {
+		return err
+	}
+
+	//the proof is of the current balance of the account
+	account, err := common.GetAccountFromLedger(stub, common.InCurrency(common.AccountTable, repayment.Currency)+fmt.Sprint(repayment.BankId))
+	if err != nil {
+		return err
+	}
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, repayment.Currency)
+	if err != nil {
+		return err
+	}
+	cmBalance, err := common.UnmarshalCommitment(scheme, account.CmBalance)
+	if err != nil {
+		return err
+	}
+	cmRepayment, ok := scheme.Unmarshal(repayment.CmBalance)
+	if !ok || !cmBalance.Equal(cmRepayment) {
+		logger.Info("The cmBalance in account from ledger is different from the cmBalance in repayment proof")
+		return errors.New("The cmBalance is not the current balance of the account")
+	}
+	verifier, err := common.GetRangeProofVerifierFromLedger(stub, repayment.Currency, repayment.ZkrpType)
+	if err != nil {
+		logger.Info("Failed to read parameters from ledger")
+		return err
+	}
+	points, err := common.RangeProofPoints(cmBalance)
+	if err != nil {
+		return err
+	}
+	result, err := verifier.VerifyCommitment(repayment.Zkrp, points[0])
+	if err != nil {
+		logger.Error("Failed to verify the repayment proof")
+		return err
+	}
+	if result != true {
+		logger.Error("The repayment proof verification failed. The committed balance is negative.")
+		return zkrangeproof.ErrVerificationFailed
+	}
+	logger.Info("The overdraft is repaid")
+
+	timestamp, err := stub.Timestamp()
+	if err != nil {
+		logger.Error("Failed to read the timestamp of the transaction")
+		return err
+	}
+	storedBytes, err := proto.Marshal(&pb.StoredRepayment{CmBalance: cmBalance.Marshal(), Timestamp: timestamp.Unix()})
+	if err != nil {
+		logger.Errorf("Unable to marshal stored repayment to protobuf")
+		return err
+	}
+	err = stub.Put(common.InCurrency(common.RepaymentTable, repayment.Currency)+fmt.Sprint(repayment.BankId), storedBytes)
+	if err != nil {
+		logger.Errorf("Failed to add repayment to ledger")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package account
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/ledger"
+	"github.com/blockchain-research/gridlock/pedersencurve"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/blockchain-research/gridlock/zkrangeproof"
+	"github.com/golang/protobuf/proto"
+)
+
+//MigrateCurve moves the UL params and the account of every bank to the curve of the new params, or replaces the legacy h of
+//the params on the same curve, see zkrangeproof.LegacyH.
+//The payment queues must be empty and every stored account, in every currency, must be migrated. The new cmBalance of an account
+//comes with a UL range proof under the new params and a proof that it commits to the same balance as the stored one.
+//Bulletproof and borromean params share the h of the old params, so they are removed and initParams sets them again.
+//An overdrawn account has no range proof of its balance, so the overdrafts must be repaid to migrate.
+//Only the central bank may migrate, once it has removed the credit limits and the reserves, which are committed
+//on the old curve, and it sets them again on the new curve.
+func MigrateCurve(stub ledger.Ledger, args []string) error {
+	logger.Info("Migrate curve")
+	if len(args) != 1 {
+		return errors.New("Need exactly one argument: <base64-encoded-object>")
+	}
+	err := common.CheckCentralBankFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	migrationBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded CurveMigration")
+		return err
+	}
+	migration := &pb.CurveMigration{}
+	err = proto.Unmarshal(migrationBytes, migration)
+	if err != nil {
+		logger.Error("Failed to unmarshal CurveMigration")
+		return err
+	}
+
+	group, err := common.GetPedersenGroupFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	if group != nil {
+		return errors.New("Only commitments on curves can be migrated")
+	}
+	oldParams, err := common.GetParamsFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	newParams, err := zkrangeproof.DecodeParamsUL(migration.ParamsUL)
+	if err != nil {
+		logger.Errorf("Invalid UL params: %s", err)
+		return err
+	}
+	//the params stay on their curve only to replace the legacy h, with which the commitments are not binding
+	if newParams.HasLegacyH() {
+		return errors.New("The h of the new UL params must be hashed, not the legacy h")
+	}
+	if newParams.Curve().ID() == oldParams.Curve().ID() && !oldParams.HasLegacyH() {
+		return fmt.Errorf("The params are already on %s", oldParams.Curve().ID())
+	}
+	//the equality proofs only hold for balances of at most pedersencurve.ValueBits bits
+	for _, params := range []*zkrangeproof.ParamsULVerifier{oldParams, newParams} {
+		if params.UpperBound().BitLen() > pedersencurve.ValueBits {
+			return errors.New("The range of the UL params is too large to migrate")
+		}
+	}
+	//the total supply was proven below the old u^l, it must stay within the new range
+	if newParams.UpperBound().Cmp(oldParams.UpperBound()) < 0 {
+		return errors.New("The range of the new UL params must not be smaller than the old one")
+	}
+
+	err = checkQueuesEmpty(stub)
+	if err != nil {
+		return err
+	}
+	err = checkNoCreditOrReserve(stub)
+	if err != nil {
+		return err
+	}
+	accounts, err := verifyMigratedAccounts(stub, migration.Accounts, oldParams, newParams)
+	if err != nil {
+		return err
+	}
+
+	//the migration is valid, replace the params and the accounts
+	err = stub.Put(common.PedersenTable+"_CURVE", migration.ParamsUL)
+	if err != nil {
+		logger.Errorf("Failed to add to ledger")
+		return err
+	}
+	//the bulletproof and borromean params share the old h, and only exist on BN256
+	for _, key := range []string{common.PedersenTable + "_BULLETPROOF", common.PedersenTable + "_BORROMEAN"} {
+		err = stub.Delete(key)
+		if err != nil {
+			logger.Errorf("Failed to delete %s from ledger", key)
+			return err
+		}
+	}
+	for _, account := range accounts {
+		cmBalance, err := common.NormalizeCommitment(pedersencurve.NewScheme(common.CurrencyGenerator(newParams.H, account.Currency)), account.CmBalance)
+		if err != nil {
+			return err
+		}
+		err = common.AddAccountToLedger(
+			stub,
+			common.InCurrency(common.AccountTable, account.Currency)+fmt.Sprint(account.BankId),
+			&pb.StoredBankAccount{
+				CmBalance: cmBalance,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	//the queues are empty, their sums are commitments to 0 on the old curve
+	return common.DeleteQueueSumsFromLedger(stub)
+}
+
+//checkQueuesEmpty checks that no bank has a payment waiting in its queues of any currency, the pending payments
+//are committed on the old curve and could not be settled afterwards
+func checkQueuesEmpty(stub ledger.Ledger) error {
+	currencies, err := common.GetCurrenciesFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	for _, currency := range currencies {
+		for bankId := int32(1); bankId <= common.NumOfBanks; bankId++ {
+			for _, table := range []string{common.InQueueTable, common.OutQueueTable} {
+				queue, err := common.GetPaymentQueueHeadFromLedger(stub, common.InCurrency(table, currency), bankId, 1)
+				if err != nil {
+					return err
+				}
+				if len(queue) > 0 {
+					logger.Errorf("The queues of bank %d are not empty", bankId)
+					return errors.New("The payment queues must be empty to migrate")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//checkNoCreditOrReserve checks that no bank has a credit limit or a reserve in any currency, they are committed
+//on the old curve and would not be proven with the migrated balances afterwards
+func checkNoCreditOrReserve(stub ledger.Ledger) error {
+	currencies, err := common.GetCurrenciesFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	for _, currency := range currencies {
+		for bankId := int32(1); bankId <= common.NumOfBanks; bankId++ {
+			limit, err := stub.Get(common.InCurrency(common.CreditTable, currency) + fmt.Sprint(bankId))
+			if err != nil {
+				logger.Error("Failed to read credit limit table")
+				return err
+			}
+			if limit != nil {
+				logger.Errorf("Bank %d has a credit limit", bankId)
+				return errors.New("The credit limits must be removed to migrate")
+			}
+			accountBytes, err := stub.Get(common.InCurrency(common.AccountTable, currency) + fmt.Sprint(bankId))
+			if err != nil {
+				logger.Error("Failed to read account table")
+				return err
+			}
+			if accountBytes == nil {
+				continue
+			}
+			account := &pb.StoredBankAccount{}
+			err = proto.Unmarshal(accountBytes, account)
+			if err != nil {
+				logger.Error("Failed to unmarshal account")
+				return err
+			}
+			if len(account.CmReserve) > 0 {
+				logger.Errorf("Bank %d has a reserve", bankId)
+				return errors.New("The reserves must be removed to migrate")
+			}
+		}
+	}
+	return nil
+}
+
+//verifyMigratedAccounts checks that accounts migrates exactly the stored accounts, that every new cmBalance
+//commits to the stored balance and that the range proofs of the new cmBalance are valid,
+//it returns the accounts ordered by currency, in the order of the currencies on the ledger, and then by bank id
+func verifyMigratedAccounts(stub ledger.Ledger, accounts []*pb.AccountMigration, oldParams, newParams *zkrangeproof.ParamsULVerifier) ([]*pb.AccountMigration, error) {
+	//the accounts are indexed by their key, which tells the currency and the bank
+	migrated := map[string]*pb.AccountMigration{}
+	for _, account := range accounts {
+		if account.BankId > common.NumOfBanks || account.BankId <= 0 {
+			logger.Infof("Invalid bank Id %d", account.BankId)
+			return nil, errors.New("Invalid bank Id")
+		}
+		err := common.ValidateCurrency(account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		key := common.InCurrency(common.AccountTable, account.Currency) + fmt.Sprint(account.BankId)
+		if _, ok := migrated[key]; ok {
+			return nil, fmt.Errorf("The account of bank %d is migrated twice", account.BankId)
+		}
+		migrated[key] = account
+	}
+	currencies, err := common.GetCurrenciesFromLedger(stub)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, currency := range currencies {
+		known[currency] = true
+	}
+	for _, account := range accounts {
+		if !known[account.Currency] {
+			return nil, fmt.Errorf("Bank %d has no account to migrate in currency %s", account.BankId, account.Currency)
+		}
+	}
+
+	oldCurve, newCurve := oldParams.Curve(), newParams.Curve()
+	ordered := []*pb.AccountMigration{}
+	for _, currency := range currencies {
+		//every currency commits with its own generator on both curves
+		oldCurrency, newCurrency := common.CurrencyParams(oldParams, currency), common.CurrencyParams(newParams, currency)
+		oldScheme, newScheme := pedersencurve.NewScheme(oldCurrency.H), pedersencurve.NewScheme(newCurrency.H)
+		accounts := []*pb.AccountMigration{}
+		zkrps := [][]byte{}
+		cms := []commitment.Commitment{}
+		for bankId := int32(1); bankId <= common.NumOfBanks; bankId++ {
+			key := common.InCurrency(common.AccountTable, currency) + fmt.Sprint(bankId)
+			storedBytes, err := stub.Get(key)
+			if err != nil {
+				logger.Error("Failed to read account table")
+				return nil, err
+			}
+			account, ok := migrated[key]
+			if storedBytes == nil {
+				if ok {
+					return nil, fmt.Errorf("Bank %d has no account to migrate", bankId)
+				}
+				continue
+			}
+			if !ok {
+				return nil, fmt.Errorf("The account of bank %d is not migrated", bankId)
+			}
+			stored, err := common.GetAccountFromLedger(stub, key)
+			if err != nil {
+				return nil, err
+			}
+			oldCm, err := common.UnmarshalCommitment(oldScheme, stored.CmBalance)
+			if err != nil {
+				return nil, err
+			}
+			newCm, err := common.UnmarshalCommitment(newScheme, account.CmBalance)
+			if err != nil {
+				return nil, err
+			}
+			points, err := common.RangeProofPoints(oldCm, newCm)
+			if err != nil {
+				return nil, err
+			}
+			equality, err := new(pedersencurve.EqualityProof).UnmarshalProto(account.EqualityProof, oldCurve, newCurve)
+			if err != nil {
+				logger.Errorf("Invalid equality proof of bank %d", bankId)
+				return nil, err
+			}
+			result, err := pedersencurve.VerifyEquality(equality, points[0], points[1], oldCurrency.H, newCurrency.H)
+			if err != nil {
+				return nil, err
+			}
+			if result != true {
+				logger.Errorf("The new cmBalance of bank %d does not commit to its balance", bankId)
+				return nil, fmt.Errorf("The new cmBalance of bank %d does not commit to its balance", bankId)
+			}
+			accounts = append(accounts, account)
+			zkrps = append(zkrps, account.Zkrp)
+			cms = append(cms, newCm)
+		}
+		if len(accounts) == 0 {
+			continue
+		}
+
+		//check every new cmBalance's range proof
+		points, err := common.RangeProofPoints(cms...)
+		if err != nil {
+			return nil, err
+		}
+		result, bad, err := zkrangeproof.VerifyCommitments(newCurrency, zkrps, points)
+		if err != nil {
+			logger.Error("Failed to verify the zero knowledge range proof")
+			if bad >= 0 {
+				return nil, fmt.Errorf("account of bank %d: %w", accounts[bad].BankId, err)
+			}
+			return nil, err
+		}
+		if result != true {
+			logger.Errorf("The zero knowledge range proof verification failed. The new cmBalance of bank %d is not within range.", accounts[bad].BankId)
+			return nil, zkrangeproof.ErrVerificationFailed
+		}
+		ordered = append(ordered, accounts...)
+	}
+	return ordered, nil
+}
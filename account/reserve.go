@@ -0,0 +1,89 @@
+package account
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/ledger"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/blockchain-research/gridlock/zkrangeproof"
+	"github.com/golang/protobuf/proto"
+)
+
+//SetReserve reserves part of the balance of a bank in a currency for its urgent payments, the payments whose
+//priority is at most common.UrgentPriority. The other payments can not draw on the reserve, so they can not drain it.
+//A reserve without cmReserve removes the reserve
+func SetReserve(stub ledger.Ledger, args []string) error {
+	logger.Info("Set reserve")
+	if len(args) != 1 {
+		return errors.New("Need exactly one argument: <base64-encoded-reservedbalance-object>")
+	}
+	//only the central bank sets the reserves
+	err := common.CheckCentralBankFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	reserveBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded reserved balance")
+		return err
+	}
+	reserve := &pb.ReservedBalance{}
+	err = proto.Unmarshal(reserveBytes, reserve)
+	if err != nil {
+		logger.Error("Failed to unmarshal reserved balance")
+		return err
+	}
+	if reserve.BankId > common.NumOfBanks || reserve.BankId <= 0 {
+		logger.Infof("Invalid bank Id %d", reserve.BankId)
+		return errors.New("Invalid bank Id")
+	}
+	err = common.ValidateCurrency(reserve.Currency)
+	if err != nil {
+		return err
+	}
+	key := common.InCurrency(common.AccountTable, reserve.Currency) + fmt.Sprint(reserve.BankId)
+	account, err := common.GetAccountFromLedger(stub, key)
+	if err != nil {
+		return err
+	}
+	if len(reserve.CmReserve) == 0 {
+		account.CmReserve = nil
+		return common.AddAccountToLedger(stub, key, account)
+	}
+
+	//a negative reserve would let the other payments overdraw the account
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, reserve.Currency)
+	if err != nil {
+		return err
+	}
+	cmReserve, ok := scheme.Unmarshal(reserve.CmReserve)
+	if !ok {
+		logger.Info("Invalid cmReserve")
+		return errors.New("Invalid cmReserve")
+	}
+	verifier, err := common.GetRangeProofVerifierFromLedger(stub, reserve.Currency, pb.RangeProofType_UL)
+	if err != nil {
+		logger.Info("Failed to read parameters from ledger")
+		return err
+	}
+	points, err := common.RangeProofPoints(cmReserve)
+	if err != nil {
+		return err
+	}
+	result, err := verifier.VerifyCommitment(reserve.Zkrp, points[0])
+	if err != nil {
+		logger.Error("Failed to verify the reserve proof")
+		return err
+	}
+	if result != true {
+		logger.Error("The reserve proof verification failed. The committed reserve is not within range.")
+		return zkrangeproof.ErrVerificationFailed
+	}
+
+	//the reserve applies to the settlements and proposals submitted afterwards
+	account.CmReserve = cmReserve.Marshal()
+	return common.AddAccountToLedger(stub, key, account)
+}
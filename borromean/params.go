@@ -0,0 +1,113 @@
+/*
+This package contains the implementation of the range proof based on Borromean ring signatures
+described in the papers:
+Borromean Ring Signatures
+Gregory Maxwell, Andrew Poelstra
+2015
+
+Confidential Assets
+Andrew Poelstra, Adam Back, Mark Friedenbach, Gregory Maxwell, Pieter Wuille
+Financial Cryptography Bitcoin Workshop 2017
+
+The proofs are over G2 of bn256, so they apply to the same Pedersen commitments
+g^x.h^r that pedersencurve and zkrangeproof use.
+*/
+
+package borromean
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+const (
+	bLG2           = 128 // length of a marshaled G2 element
+	bLG2Compressed = 65  // length of a compressed G2 element
+	bLInt          = 32  // length of a marshaled scalar
+)
+
+// g2Lengths are the lengths of the G2 elements of an encoding: compressed as written by Marshal,
+// or uncompressed as written before, which is still accepted
+var g2Lengths = []int{bLG2Compressed, bLG2}
+
+// MaxBitLength is the largest N supported, so that sums of proven values never wrap around the group order
+const MaxBitLength = 64
+
+/*
+Params contains the public parameters for proving that a committed value is in [0,2^N).
+The value is split in N/2 base 4 digits, each proven by a ring of 4 public keys.
+*/
+type Params struct {
+	G, H *bn256.G2
+	N    int64
+}
+
+/*
+Setup generates the parameters for the interval [0,2^n), n must be even.
+h is the blinding base of the Pedersen commitments being proven.
+*/
+func Setup(n int64, h *bn256.G2) (*Params, error) {
+	if n <= 0 || n > MaxBitLength || n%2 != 0 {
+		return nil, errors.New("bit length must be even and no larger than 64")
+	}
+	if h == nil {
+		return nil, errors.New("missing blinding base")
+	}
+	return &Params{
+		G: new(bn256.G2).ScalarBaseMult(big.NewInt(1)),
+		H: h,
+		N: n,
+	}, nil
+}
+
+// digits returns the number of base 4 digits, i.e. the number of rings
+func (p *Params) digits() int {
+	return int(p.N / 2)
+}
+
+/*
+Marshal is for marshaling the Params into []byte, with H compressed
+*/
+func (p *Params) Marshal() []byte {
+	return p.marshal(bLG2Compressed)
+}
+
+// marshal encodes the params with H in size bytes
+func (p *Params) marshal(size int) []byte {
+	const bLInt64 int = binary.MaxVarintLen64
+	var ret []byte
+	ret = append(ret, marshalG2(p.H, size)...)
+
+	bn := make([]byte, bLInt64, bLInt64)
+	binary.PutVarint(bn, p.N)
+	ret = append(ret, bn...)
+	return ret
+}
+
+/*
+Unmarshal is for converting []byte back into Params, with H compressed or uncompressed
+*/
+func (p *Params) Unmarshal(m []byte) (*Params, error) {
+	const bLInt64 int = binary.MaxVarintLen64
+	size := len(m) - bLInt64
+	if size != bLG2Compressed && size != bLG2 {
+		return nil, errors.New("borromean params have the wrong length")
+	}
+	h, ok := new(bn256.G2).Unmarshal(m[:size])
+	if !ok {
+		return nil, errors.New("borromean params contain an invalid H")
+	}
+	n, read := binary.Varint(m[size:])
+	if read <= 0 {
+		return nil, errors.New("borromean params contain an invalid N")
+	}
+	params, err := Setup(n, h)
+	if err != nil {
+		return nil, err
+	}
+	*p = *params
+	return p, nil
+}
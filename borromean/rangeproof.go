@@ -0,0 +1,319 @@
+package borromean
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+// ringSize is the number of public keys in every ring, one per value of a base 4 digit
+const ringSize = 4
+
+/*
+Proof contains the public output of the range proof: the commitments C_i to the digits
+v_i.4^i of the value but the last one, which follows from C = C_0...C_{N/2-1}, and a
+Borromean ring signature made of the shared challenge E0 and 4 scalars per ring.
+*/
+type Proof struct {
+	Commitments []*bn256.G2
+	E0          *big.Int
+	S           [][ringSize]*big.Int
+}
+
+/*
+Prove is used to produce the ZK range proof that the value v committed in C = g^v.h^r
+belongs to the interval [0,2^N).
+Ring i proves that C_i.g^(-j.4^i) = h^r_i for some j in {0,1,2,3} without revealing j,
+and the rings are tied together by a single challenge E0.
+*/
+func Prove(v, r *big.Int, C *bn256.G2, p *Params) (*Proof, error) {
+	if v.Sign() < 0 || v.BitLen() > int(p.N) {
+		return nil, errors.New("Could not generate proof. Value is out of range.")
+	}
+	k := p.digits()
+
+	//split the value in digits and the randomness in k shares summing up to r
+	digits := make([]int, k)
+	blindings := make([]*big.Int, k)
+	last := mod(r)
+	for i := 0; i < k; i++ {
+		digits[i] = int(new(big.Int).Rsh(v, uint(2*i)).Int64() & 3)
+		if i < k-1 {
+			blindings[i] = randomScalar()
+			last = mod(new(big.Int).Sub(last, blindings[i]))
+		}
+	}
+	blindings[k-1] = last
+
+	commitments := make([]*bn256.G2, k)
+	for i := 0; i < k; i++ {
+		value := new(big.Int).Lsh(big.NewInt(int64(digits[i])), uint(2*i))
+		commitments[i] = new(bn256.G2).Add(
+			new(bn256.G2).ScalarMult(p.G, value),
+			new(bn256.G2).ScalarMult(p.H, blindings[i]),
+		)
+	}
+	message := proofMessage(C, commitments)
+	keys := ringKeys(commitments, p)
+
+	//start every ring right after the known key and close it at the last key
+	s := make([][ringSize]*big.Int, k)
+	nonces := make([]*big.Int, k)
+	lastPoints := make([]*bn256.G2, k)
+	for i := 0; i < k; i++ {
+		nonces[i] = randomScalar()
+		R := new(bn256.G2).ScalarMult(p.H, nonces[i])
+		for j := digits[i] + 1; j < ringSize; j++ {
+			e := ringChallenge(message, R, i, j)
+			s[i][j] = randomScalar()
+			R = ringPoint(s[i][j], e, keys[i][j], p)
+		}
+		lastPoints[i] = R
+	}
+	e0 := sharedChallenge(message, lastPoints)
+
+	//go around every ring from E0 up to the known key and close the signature there
+	for i := 0; i < k; i++ {
+		e := e0
+		for j := 0; j < digits[i]; j++ {
+			s[i][j] = randomScalar()
+			e = ringChallenge(message, ringPoint(s[i][j], e, keys[i][j], p), i, j+1)
+		}
+		s[i][digits[i]] = mod(new(big.Int).Add(nonces[i], new(big.Int).Mul(e, blindings[i])))
+	}
+
+	return &Proof{
+		Commitments: commitments[:k-1],
+		E0:          e0,
+		S:           s,
+	}, nil
+}
+
+/*
+Verify is responsible for validating the proof that the value committed in C belongs to [0,2^N).
+*/
+func Verify(proof *Proof, C *bn256.G2, p *Params) (bool, error) {
+	k := p.digits()
+	if proof == nil || C == nil || proof.E0 == nil {
+		return false, errors.New("Borromean proof is incomplete")
+	}
+	if len(proof.Commitments) != k-1 || len(proof.S) != k {
+		return false, errors.New("Borromean proof does not match the bit length of the params")
+	}
+	for i := range proof.S {
+		for j := range proof.S[i] {
+			if proof.S[i][j] == nil {
+				return false, errors.New("Borromean proof is incomplete")
+			}
+		}
+	}
+
+	//the last digit commitment is C minus the others
+	commitments := make([]*bn256.G2, k)
+	last := C
+	for i, c := range proof.Commitments {
+		commitments[i] = c
+		last = new(bn256.G2).Add(last, new(bn256.G2).Neg(c))
+	}
+	commitments[k-1] = last
+	message := proofMessage(C, commitments)
+	keys := ringKeys(commitments, p)
+
+	lastPoints := make([]*bn256.G2, k)
+	for i := 0; i < k; i++ {
+		e := proof.E0
+		var R *bn256.G2
+		for j := 0; j < ringSize; j++ {
+			R = ringPoint(proof.S[i][j], e, keys[i][j], p)
+			if j < ringSize-1 {
+				e = ringChallenge(message, R, i, j+1)
+			}
+		}
+		lastPoints[i] = R
+	}
+	e0 := sharedChallenge(message, lastPoints)
+	return e0.Cmp(proof.E0) == 0, nil
+}
+
+/*
+VerifyCommitment checks that zkrp is a marshaled proof that the value committed in cm belongs to [0,2^N).
+*/
+func (p *Params) VerifyCommitment(zkrp []byte, cm *bn256.G2) (bool, error) {
+	proof, err := new(Proof).Unmarshal(zkrp)
+	if err != nil {
+		return false, err
+	}
+	return Verify(proof, cm, p)
+}
+
+// ringKeys returns the public keys C_i.g^(-j.4^i) of every ring
+func ringKeys(commitments []*bn256.G2, p *Params) [][ringSize]*bn256.G2 {
+	keys := make([][ringSize]*bn256.G2, len(commitments))
+	for i, c := range commitments {
+		step := new(bn256.G2).Neg(new(bn256.G2).ScalarMult(p.G, new(big.Int).Lsh(big.NewInt(1), uint(2*i))))
+		keys[i][0] = c
+		for j := 1; j < ringSize; j++ {
+			keys[i][j] = new(bn256.G2).Add(keys[i][j-1], step)
+		}
+	}
+	return keys
+}
+
+// ringPoint returns h^s.P^(-e)
+func ringPoint(s, e *big.Int, P *bn256.G2, p *Params) *bn256.G2 {
+	return new(bn256.G2).Add(
+		new(bn256.G2).ScalarMult(p.H, s),
+		new(bn256.G2).Neg(new(bn256.G2).ScalarMult(P, e)),
+	)
+}
+
+// proofMessage binds the signature to the proven commitment and all digit commitments
+func proofMessage(C *bn256.G2, commitments []*bn256.G2) []byte {
+	digest := sha256.New()
+	digest.Write([]byte("gridlock/borromean"))
+	digest.Write(C.Marshal())
+	for _, c := range commitments {
+		digest.Write(c.Marshal())
+	}
+	return digest.Sum(nil)
+}
+
+func ringChallenge(message []byte, R *bn256.G2, i, j int) *big.Int {
+	index := make([]byte, 8)
+	binary.BigEndian.PutUint32(index[:4], uint32(i))
+	binary.BigEndian.PutUint32(index[4:], uint32(j))
+	return hashToScalar(message, R.Marshal(), index)
+}
+
+func sharedChallenge(message []byte, lastPoints []*bn256.G2) *big.Int {
+	parts := [][]byte{}
+	for _, R := range lastPoints {
+		parts = append(parts, R.Marshal())
+	}
+	parts = append(parts, message)
+	return hashToScalar(parts...)
+}
+
+func hashToScalar(parts ...[]byte) *big.Int {
+	digest := sha256.New()
+	for _, part := range parts {
+		digest.Write(part)
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(digest.Sum(nil)), bn256.Order)
+}
+
+func randomScalar() *big.Int {
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	return r
+}
+
+func mod(x *big.Int) *big.Int {
+	return new(big.Int).Mod(x, bn256.Order)
+}
+
+func scalarBytes(x *big.Int) []byte {
+	ret := make([]byte, bLInt, bLInt)
+	b := x.Bytes()
+	copy(ret[bLInt-len(b):], b)
+	return ret
+}
+
+// marshalG2 encodes e in size bytes, either bLG2Compressed or bLG2
+func marshalG2(e *bn256.G2, size int) []byte {
+	if size == bLG2 {
+		return e.Marshal()
+	}
+	return e.MarshalCompressed()
+}
+
+/*
+Marshal is for marshaling the Proof into []byte, with compressed commitments:
+E0 || C_0 ... C_{N/2-2} || s_0,0 ... s_0,3 || ... || s_{N/2-1},3
+*/
+func (p *Proof) Marshal() []byte {
+	return p.marshal(bLG2Compressed)
+}
+
+// marshal encodes the proof with commitments of size bytes
+func (p *Proof) marshal(size int) []byte {
+	var buf bytes.Buffer
+	buf.Write(scalarBytes(p.E0))
+	for _, c := range p.Commitments {
+		buf.Write(marshalG2(c, size))
+	}
+	for i := range p.S {
+		for j := range p.S[i] {
+			buf.Write(scalarBytes(p.S[i][j]))
+		}
+	}
+	return buf.Bytes()
+}
+
+// proofRings returns the number of rings of a proof of n bytes with commitments of size bytes,
+// and whether n is such a length
+func proofRings(n int, size int) (int, bool) {
+	//len = bLInt + (k-1).size + k.4.bLInt
+	ringLength := size + ringSize*bLInt
+	if n < bLInt+ringSize*bLInt || (n+size-bLInt)%ringLength != 0 {
+		return 0, false
+	}
+	return (n + size - bLInt) / ringLength, true
+}
+
+/*
+Unmarshal is for converting []byte back into Proof, with compressed or uncompressed commitments.
+The number of rings is derived from the length.
+*/
+func (p *Proof) Unmarshal(m []byte) (*Proof, error) {
+	for _, size := range g2Lengths {
+		if k, ok := proofRings(len(m), size); ok {
+			return p.unmarshal(m, size, k)
+		}
+	}
+	return nil, errors.New("Borromean proof has the wrong length")
+}
+
+// unmarshal decodes a proof of k rings with commitments of size bytes
+func (p *Proof) unmarshal(m []byte, size int, k int) (*Proof, error) {
+	if k > MaxBitLength/2 {
+		return nil, errors.New("Borromean proof has too many rings")
+	}
+
+	e0, ok := unmarshalScalar(m[:bLInt])
+	if !ok {
+		return nil, errors.New("Borromean proof contains an invalid scalar")
+	}
+	m = m[bLInt:]
+	commitments := make([]*bn256.G2, k-1)
+	for i := range commitments {
+		c, ok := new(bn256.G2).Unmarshal(m[:size])
+		if !ok {
+			return nil, errors.New("Borromean proof contains an invalid commitment")
+		}
+		commitments[i] = c
+		m = m[size:]
+	}
+	s := make([][ringSize]*big.Int, k)
+	for i := range s {
+		for j := range s[i] {
+			s[i][j], ok = unmarshalScalar(m[:bLInt])
+			if !ok {
+				return nil, errors.New("Borromean proof contains an invalid scalar")
+			}
+			m = m[bLInt:]
+		}
+	}
+	p.E0, p.Commitments, p.S = e0, commitments, s
+	return p, nil
+}
+
+// unmarshalScalar reads a scalar and checks it is reduced modulo the group order
+func unmarshalScalar(m []byte) (*big.Int, bool) {
+	e := new(big.Int).SetBytes(m)
+	return e, e.Cmp(bn256.Order) < 0
+}
@@ -0,0 +1,173 @@
+package borromean
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/blockchain-research/gridlock/pedersencurve"
+)
+
+// commit is pedersencurve.Commit on BN256, the curve of the proofs
+func commit(x, r *big.Int, h *bn256.G2) *bn256.G2 {
+	cm, _ := pairing.ToBN256G2(pedersencurve.Commit(x, r, pairing.FromBN256G2(h)))
+	return cm
+}
+
+func setupParams(t *testing.T, n int64) *Params {
+	h := new(bn256.G2).ScalarBaseMult(big.NewInt(0).SetBytes([]byte("gridlock borromean test")))
+	p, err := Setup(n, h)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	return p
+}
+
+func TestBorromean(t *testing.T) {
+	p := setupParams(t, 32)
+	for _, x := range []int64{0, 1, 176, 1<<32 - 1} {
+		r, _ := rand.Int(rand.Reader, bn256.Order)
+		v := new(big.Int).SetInt64(x)
+		cm := commit(v, r, p.H)
+		proof, err := Prove(v, r, cm, p)
+		if err != nil {
+			t.Fatalf("Prove failed for %d: %v", x, err)
+		}
+		result, err := Verify(proof, cm, p)
+		if result != true || err != nil {
+			t.Errorf("Assert failure for %d: expected true, actual: %t, %v", x, result, err)
+		}
+	}
+}
+
+func TestBorromeanNegativeRandomness(t *testing.T) {
+	p := setupParams(t, 8)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	r.Neg(r)
+	v := new(big.Int).SetInt64(42)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+	result, _ := Verify(proof, cm, p)
+	if result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+}
+
+func TestBorromeanOutOfRange(t *testing.T) {
+	p := setupParams(t, 8)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	for _, v := range []*big.Int{big.NewInt(256), big.NewInt(-1)} {
+		cm := commit(v, r, p.H)
+		if _, err := Prove(v, r, cm, p); err == nil {
+			t.Errorf("Assert failure: expected an error for %s", v)
+		}
+	}
+}
+
+func TestBorromeanWrongCommitment(t *testing.T) {
+	p := setupParams(t, 8)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(42)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+
+	other := commit(new(big.Int).SetInt64(43), r, p.H)
+	result, _ := Verify(proof, other, p)
+	if result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+
+	proof.S[1][2] = mod(new(big.Int).Add(proof.S[1][2], big.NewInt(1)))
+	result, _ = Verify(proof, cm, p)
+	if result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+}
+
+func TestBorromeanMarshal(t *testing.T) {
+	p := setupParams(t, 16)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(1000)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+
+	proofBytes := proof.Marshal()
+	if len(proofBytes) != bLInt+7*bLG2Compressed+8*4*bLInt {
+		t.Errorf("unexpected proof size %d", len(proofBytes))
+	}
+	proof2, err := new(Proof).Unmarshal(proofBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	p2, err := new(Params).Unmarshal(p.Marshal())
+	if err != nil {
+		t.Fatalf("Unmarshal of params failed: %v", err)
+	}
+	result, err := p2.VerifyCommitment(proofBytes, cm)
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+	result, _ = Verify(proof2, cm, p2)
+	if result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+
+	if _, err := new(Proof).Unmarshal(proofBytes[:len(proofBytes)-1]); err == nil {
+		t.Error("Assert failure: expected an error for a truncated proof")
+	}
+	if _, err := new(Proof).Unmarshal(nil); err == nil {
+		t.Error("Assert failure: expected an error for an empty proof")
+	}
+	// a proof for a different bit length must be rejected
+	p8 := setupParams(t, 8)
+	if result, _ := p8.VerifyCommitment(proofBytes, cm); result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+	if _, err := new(Params).Unmarshal(p.Marshal()[1:]); err == nil {
+		t.Error("Assert failure: expected an error for truncated params")
+	}
+}
+
+func TestBorromeanUnmarshalUncompressed(t *testing.T) {
+	p := setupParams(t, 16)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(1000)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+
+	// proofs and params written before compression are still accepted
+	proofBytes := proof.marshal(bLG2)
+	if len(proofBytes) != bLInt+7*bLG2+8*4*bLInt {
+		t.Errorf("unexpected proof size %d", len(proofBytes))
+	}
+	p2, err := new(Params).Unmarshal(p.marshal(bLG2))
+	if err != nil {
+		t.Fatalf("Unmarshal of params failed: %v", err)
+	}
+	if !bytes.Equal(p2.Marshal(), p.Marshal()) {
+		t.Error("uncompressed params do not decode to the same params")
+	}
+	result, err := p2.VerifyCommitment(proofBytes, cm)
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+	proof2, err := new(Proof).Unmarshal(proofBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !bytes.Equal(proof2.Marshal(), proof.Marshal()) {
+		t.Error("an uncompressed proof does not decode to the same proof")
+	}
+}
+
+func TestSetupInvalidBitLength(t *testing.T) {
+	h := new(bn256.G2).ScalarBaseMult(big.NewInt(7))
+	for _, n := range []int64{0, 3, 66} {
+		if _, err := Setup(n, h); err == nil {
+			t.Errorf("Assert failure: expected an error for n=%d", n)
+		}
+	}
+}
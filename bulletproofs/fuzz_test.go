@@ -0,0 +1,60 @@
+package bulletproofs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+// The seed corpus of every decoder holds valid encodings, truncated and extended
+// encodings and encodings with flipped bits.
+func fuzzSeeds(f *testing.F, valid ...[]byte) {
+	for _, m := range valid {
+		f.Add(m)
+		f.Add(m[:len(m)-1])
+		f.Add(append(append([]byte{}, m...), 0))
+		flipped := append([]byte{}, m...)
+		flipped[len(m)/2] ^= 1
+		f.Add(flipped)
+	}
+	f.Add([]byte{})
+}
+
+func FuzzParamsUnmarshal(f *testing.F) {
+	h := new(bn256.G2).ScalarBaseMult(big.NewInt(0).SetBytes([]byte("gridlock bulletproofs test")))
+	for _, n := range []int64{8, 64} {
+		p, _ := Setup(n, h)
+		fuzzSeeds(f, p.Marshal(), p.marshal(bLG2))
+	}
+	f.Fuzz(func(t *testing.T, m []byte) {
+		p, err := new(Params).Unmarshal(m)
+		if err != nil {
+			return
+		}
+		again, err := new(Params).Unmarshal(p.Marshal())
+		if err != nil || again.N != p.N || !bytes.Equal(again.H.Marshal(), p.H.Marshal()) {
+			t.Fatal("decoded params do not round-trip")
+		}
+	})
+}
+
+func FuzzProofUnmarshal(f *testing.F) {
+	h := new(bn256.G2).ScalarBaseMult(big.NewInt(0).SetBytes([]byte("gridlock bulletproofs test")))
+	p, _ := Setup(8, h)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(176)
+	proof, _ := Prove(v, r, commit(v, r, p.H), p)
+	fuzzSeeds(f, proof.Marshal(), proof.marshal(bLG2))
+	f.Fuzz(func(t *testing.T, m []byte) {
+		proof, err := new(Proof).Unmarshal(m)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(proof.Marshal(), m) && !bytes.Equal(proof.marshal(bLG2), m) {
+			t.Fatal("a decoded bulletproof does not round-trip")
+		}
+	})
+}
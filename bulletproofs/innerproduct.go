@@ -0,0 +1,96 @@
+package bulletproofs
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+/*
+InnerProductProof proves knowledge of vectors a, b such that
+P = <a,Gs> + <b,Hs> + <a,b>.U using 2.log(n) group elements.
+*/
+type InnerProductProof struct {
+	L, R []*bn256.G2
+	A, B *big.Int
+}
+
+/*
+proveInnerProduct halves the vectors in every round, folding them with the
+challenge of the round, until a single pair of scalars is left.
+*/
+func proveInnerProduct(gs, hs []*bn256.G2, u *bn256.G2, a, b []*big.Int, t *transcript) *InnerProductProof {
+	proof := &InnerProductProof{}
+	for n := len(a); n > 1; n = n / 2 {
+		half := n / 2
+		cL := innerProduct(a[:half], b[half:])
+		cR := innerProduct(a[half:], b[:half])
+
+		// L = <a_lo,G_hi> + <b_hi,H_lo> + cL.U
+		L := multiExp(gs[half:], a[:half])
+		L.Add(L, multiExp(hs[:half], b[half:]))
+		L.Add(L, new(bn256.G2).ScalarMult(u, cL))
+		// R = <a_hi,G_lo> + <b_lo,H_hi> + cR.U
+		R := multiExp(gs[:half], a[half:])
+		R.Add(R, multiExp(hs[half:], b[:half]))
+		R.Add(R, new(bn256.G2).ScalarMult(u, cR))
+		proof.L = append(proof.L, L)
+		proof.R = append(proof.R, R)
+
+		x := t.challenge(L.Marshal(), R.Marshal())
+		xInv := inverse(x)
+
+		gsNext := make([]*bn256.G2, half)
+		hsNext := make([]*bn256.G2, half)
+		aNext := make([]*big.Int, half)
+		bNext := make([]*big.Int, half)
+		for i := 0; i < half; i++ {
+			// G' = x^-1.G_lo + x.G_hi, H' = x.H_lo + x^-1.H_hi
+			gsNext[i] = new(bn256.G2).ScalarMult(gs[i], xInv)
+			gsNext[i].Add(gsNext[i], new(bn256.G2).ScalarMult(gs[half+i], x))
+			hsNext[i] = new(bn256.G2).ScalarMult(hs[i], x)
+			hsNext[i].Add(hsNext[i], new(bn256.G2).ScalarMult(hs[half+i], xInv))
+			// a' = x.a_lo + x^-1.a_hi, b' = x^-1.b_lo + x.b_hi
+			aNext[i] = mod(new(big.Int).Add(new(big.Int).Mul(a[i], x), new(big.Int).Mul(a[half+i], xInv)))
+			bNext[i] = mod(new(big.Int).Add(new(big.Int).Mul(b[i], xInv), new(big.Int).Mul(b[half+i], x)))
+		}
+		gs, hs, a, b = gsNext, hsNext, aNext, bNext
+	}
+	proof.A = a[0]
+	proof.B = b[0]
+	return proof
+}
+
+/*
+verificationScalars replays the challenges of the proof and returns them together with
+the scalars s such that the folded generators are <s,Gs> and <s^-1,Hs>.
+*/
+func (proof *InnerProductProof) verificationScalars(n int64, t *transcript) ([]*big.Int, []*big.Int, error) {
+	rounds := len(proof.L)
+	if int64(1)<<uint(rounds) != n || len(proof.R) != rounds {
+		return nil, nil, errors.New("inner product proof has the wrong number of rounds")
+	}
+	challenges := make([]*big.Int, rounds)
+	for k := 0; k < rounds; k++ {
+		challenges[k] = t.challenge(proof.L[k].Marshal(), proof.R[k].Marshal())
+		if challenges[k].Sign() == 0 {
+			return nil, nil, errors.New("zero challenge in inner product proof")
+		}
+	}
+
+	// s_0 = prod x_k^-1, and index i uses x_k instead of x_k^-1 for every round k
+	// in which i falls in the upper half
+	s := make([]*big.Int, n)
+	s[0] = big.NewInt(1)
+	for _, x := range challenges {
+		s[0] = mod(new(big.Int).Mul(s[0], inverse(x)))
+	}
+	for i := int64(1); i < n; i++ {
+		j := bits.Len64(uint64(i)) - 1
+		x := challenges[rounds-1-j]
+		s[i] = mod(new(big.Int).Mul(s[i-(int64(1)<<uint(j))], new(big.Int).Mul(x, x)))
+	}
+	return challenges, s, nil
+}
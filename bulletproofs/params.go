@@ -0,0 +1,127 @@
+/*
+This package contains the implementation of the range proof scheme proposed in the paper:
+Bulletproofs: Short Proofs for Confidential Transactions and More
+Benedikt Bünz, Jonathan Bootle, Dan Boneh, Andrew Poelstra, Pieter Wuille, Greg Maxwell
+IEEE S&P 2018
+
+The proofs are over G2 of bn256, so they apply to the same Pedersen commitments
+g^x.h^r that pedersencurve and zkrangeproof use.
+*/
+
+package bulletproofs
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+// MaxBitLength is the largest N supported, so that sums of proven values never wrap around the group order
+const MaxBitLength = 64
+
+/*
+Params contains the public parameters for proving that a committed value is in [0,2^N).
+Apart from H, which must be the blinding base of the commitments, every generator is
+derived by hashing, so no trusted setup is needed.
+*/
+type Params struct {
+	G, H   *bn256.G2
+	U      *bn256.G2
+	Gs, Hs []*bn256.G2
+	N      int64
+}
+
+var (
+	generatorsMu sync.Mutex
+	gsCache      []*bn256.G2
+	hsCache      []*bn256.G2
+	uCache       *bn256.G2
+)
+
+/*
+generators returns the first n vector generators and the inner product generator.
+Hashing to G2 is expensive, so they are computed once and shared by all Params.
+*/
+func generators(n int64) ([]*bn256.G2, []*bn256.G2, *bn256.G2) {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	if uCache == nil {
+		uCache = bn256.HashToG2([]byte("gridlock/bulletproofs/U"))
+	}
+	for i := int64(len(gsCache)); i < n; i++ {
+		gsCache = append(gsCache, bn256.HashToG2([]byte("gridlock/bulletproofs/G"+strconv.FormatInt(i, 10))))
+		hsCache = append(hsCache, bn256.HashToG2([]byte("gridlock/bulletproofs/H"+strconv.FormatInt(i, 10))))
+	}
+	return gsCache[:n:n], hsCache[:n:n], uCache
+}
+
+/*
+Setup generates the parameters for the interval [0,2^n), n must be a power of two.
+h is the blinding base of the Pedersen commitments being proven.
+*/
+func Setup(n int64, h *bn256.G2) (*Params, error) {
+	if n <= 0 || n > MaxBitLength || n&(n-1) != 0 {
+		return nil, errors.New("bit length must be a power of two no larger than 64")
+	}
+	if h == nil {
+		return nil, errors.New("missing blinding base")
+	}
+	gs, hs, u := generators(n)
+	return &Params{
+		G:  new(bn256.G2).ScalarBaseMult(big.NewInt(1)),
+		H:  h,
+		U:  u,
+		Gs: gs,
+		Hs: hs,
+		N:  n,
+	}, nil
+}
+
+/*
+Marshal is for marshaling the Params into []byte, with H compressed.
+Only H and N are stored, the generators are derived again on Unmarshal.
+*/
+func (p *Params) Marshal() []byte {
+	return p.marshal(bLG2Compressed)
+}
+
+// marshal encodes the params with H in size bytes
+func (p *Params) marshal(size int) []byte {
+	const bLInt64 int = binary.MaxVarintLen64
+	var ret []byte
+	ret = append(ret, marshalG2(p.H, size)...)
+
+	bn := make([]byte, bLInt64, bLInt64)
+	binary.PutVarint(bn, p.N)
+	ret = append(ret, bn...)
+	return ret
+}
+
+/*
+Unmarshal is for converting []byte back into Params, with H compressed or uncompressed
+*/
+func (p *Params) Unmarshal(m []byte) (*Params, error) {
+	const bLInt64 int = binary.MaxVarintLen64
+	size := len(m) - bLInt64
+	if size != bLG2Compressed && size != bLG2 {
+		return nil, errors.New("bulletproof params have the wrong length")
+	}
+	h, ok := new(bn256.G2).Unmarshal(m[:size])
+	if !ok {
+		return nil, errors.New("bulletproof params contain an invalid H")
+	}
+	n, read := binary.Varint(m[size:])
+	if read <= 0 {
+		return nil, errors.New("bulletproof params contain an invalid N")
+	}
+	params, err := Setup(n, h)
+	if err != nil {
+		return nil, err
+	}
+	*p = *params
+	return p, nil
+}
@@ -0,0 +1,373 @@
+package bulletproofs
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+/*
+Proof contains the public output of the range proof: 2.log(N.m)+4 elements of G2 and 5 scalars,
+where m is the number of aggregated values rounded up to a power of two.
+*/
+type Proof struct {
+	A, S, T1, T2   *bn256.G2
+	Taux, Mu, THat *big.Int
+	IPP            *InnerProductProof
+}
+
+// MaxAggregation is the largest number of values a single proof can cover
+const MaxAggregation = 64
+
+/*
+Prove is used to produce the ZK range proof that the value v committed in V = g^v.h^gamma
+belongs to the interval [0,2^N).
+*/
+func Prove(v, gamma *big.Int, V *bn256.G2, p *Params) (*Proof, error) {
+	return ProveAggregate([]*big.Int{v}, []*big.Int{gamma}, []*bn256.G2{V}, p)
+}
+
+/*
+ProveAggregate is used to produce a single ZK range proof that every value v[j] committed
+in V[j] = g^v[j].h^gamma[j] belongs to the interval [0,2^N). The proof has 2.log(N.m)+4
+elements of G2, so it is smaller than m separate proofs. When m is not a power of two,
+the statement is padded with commitments to zero.
+*/
+func ProveAggregate(v, gamma []*big.Int, V []*bn256.G2, p *Params) (*Proof, error) {
+	if len(v) == 0 || len(v) != len(gamma) || len(v) != len(V) {
+		return nil, errors.New("Could not generate proof. The number of values, randomness and commitments differ.")
+	}
+	if len(v) > MaxAggregation {
+		return nil, errors.New("Could not generate proof. Too many values to aggregate.")
+	}
+	for _, vj := range v {
+		if vj.Sign() < 0 || vj.BitLen() > int(p.N) {
+			return nil, errors.New("Could not generate proof. Element does not belong to the interval.")
+		}
+	}
+	m := paddedLength(len(v))
+	n := p.N
+	nm := n * m
+	gs, hs, _ := generators(nm)
+	V = padCommitments(V, m)
+
+	// aL are the bits of the values and aR = aL - 1
+	aL := make([]*big.Int, nm)
+	aR := make([]*big.Int, nm)
+	// A = alpha.h + <aL,Gs> + <aR,Hs>
+	alpha := randomScalar()
+	A := new(bn256.G2).ScalarMult(p.H, alpha)
+	for j := int64(0); j < m; j++ {
+		vj := big.NewInt(0)
+		if j < int64(len(v)) {
+			vj = v[j]
+		}
+		for i := int64(0); i < n; i++ {
+			k := j*n + i
+			if vj.Bit(int(i)) == 1 {
+				aL[k] = big.NewInt(1)
+				aR[k] = big.NewInt(0)
+				A.Add(A, gs[k])
+			} else {
+				aL[k] = big.NewInt(0)
+				aR[k] = mod(big.NewInt(-1))
+				A.Add(A, new(bn256.G2).Neg(hs[k]))
+			}
+		}
+	}
+
+	// S = rho.h + <sL,Gs> + <sR,Hs>
+	sL := randomVector(nm)
+	sR := randomVector(nm)
+	rho := randomScalar()
+	S := new(bn256.G2).ScalarMult(p.H, rho)
+	S.Add(S, multiExp(gs, sL))
+	S.Add(S, multiExp(hs, sR))
+
+	t := newTranscript("gridlock/bulletproofs/range")
+	y := t.challenge(append(marshalPoints(V), A.Marshal(), S.Marshal())...)
+	z := t.challenge()
+	zj := powers(z, m+2) // zj[j+2] = z^(j+2) weights the value j
+	yn := powers(y, nm)
+	twon := powers(big.NewInt(2), n)
+
+	// l(X) = (aL - z) + sL.X, r(X) = y^nm o (aR + z + sR.X) + sum_j z^(j+2).(0..0 || 2^n || 0..0)
+	l0 := make([]*big.Int, nm)
+	r0 := make([]*big.Int, nm)
+	r1 := make([]*big.Int, nm)
+	for k := int64(0); k < nm; k++ {
+		l0[k] = mod(new(big.Int).Sub(aL[k], z))
+		r0[k] = mod(new(big.Int).Add(
+			new(big.Int).Mul(yn[k], new(big.Int).Add(aR[k], z)),
+			new(big.Int).Mul(zj[k/n+2], twon[k%n])))
+		r1[k] = mod(new(big.Int).Mul(yn[k], sR[k]))
+	}
+
+	// t(X) = <l(X),r(X)> = t0 + t1.X + t2.X^2
+	t1 := mod(new(big.Int).Add(innerProduct(l0, r1), innerProduct(sL, r0)))
+	t2 := innerProduct(sL, r1)
+	tau1 := randomScalar()
+	tau2 := randomScalar()
+	T1 := new(bn256.G2).ScalarMult(p.G, t1)
+	T1.Add(T1, new(bn256.G2).ScalarMult(p.H, tau1))
+	T2 := new(bn256.G2).ScalarMult(p.G, t2)
+	T2.Add(T2, new(bn256.G2).ScalarMult(p.H, tau2))
+
+	x := t.challenge(T1.Marshal(), T2.Marshal())
+	xx := mod(new(big.Int).Mul(x, x))
+
+	l := make([]*big.Int, nm)
+	r := make([]*big.Int, nm)
+	for k := int64(0); k < nm; k++ {
+		l[k] = mod(new(big.Int).Add(l0[k], new(big.Int).Mul(sL[k], x)))
+		r[k] = mod(new(big.Int).Add(r0[k], new(big.Int).Mul(r1[k], x)))
+	}
+	tHat := innerProduct(l, r)
+
+	// taux = tau2.x^2 + tau1.x + sum_j z^(j+2).gamma_j, mu = alpha + rho.x
+	taux := new(big.Int).Mul(tau2, xx)
+	taux.Add(taux, new(big.Int).Mul(tau1, x))
+	for j := range gamma {
+		taux.Add(taux, new(big.Int).Mul(zj[j+2], mod(gamma[j])))
+	}
+	taux = mod(taux)
+	mu := mod(new(big.Int).Add(alpha, new(big.Int).Mul(rho, x)))
+
+	// the inner product argument is over Hs' = y^-nm o Hs and U' = w.U
+	w := t.challenge(scalarBytes(taux), scalarBytes(mu), scalarBytes(tHat))
+	yInv := powers(inverse(y), nm)
+	hsPrime := make([]*bn256.G2, nm)
+	for k := int64(0); k < nm; k++ {
+		hsPrime[k] = new(bn256.G2).ScalarMult(hs[k], yInv[k])
+	}
+	uPrime := new(bn256.G2).ScalarMult(p.U, w)
+
+	return &Proof{
+		A:    A,
+		S:    S,
+		T1:   T1,
+		T2:   T2,
+		Taux: taux,
+		Mu:   mu,
+		THat: tHat,
+		IPP:  proveInnerProduct(gs, hsPrime, uPrime, l, r, t),
+	}, nil
+}
+
+/*
+Verify is used to validate the range proof against the commitment V. It returns true iff the proof is valid.
+*/
+func Verify(proof *Proof, V *bn256.G2, p *Params) (bool, error) {
+	return VerifyAggregate(proof, []*bn256.G2{V}, p)
+}
+
+/*
+VerifyAggregate is used to validate the aggregated range proof against the commitments V,
+in the order they were proven. It returns true iff the proof is valid.
+Both verification equations are folded into a single multi-exponentiation that must be the identity.
+*/
+func VerifyAggregate(proof *Proof, V []*bn256.G2, p *Params) (bool, error) {
+	if len(V) == 0 || len(V) > MaxAggregation {
+		return false, errors.New("invalid number of commitments in aggregated range proof")
+	}
+	m := paddedLength(len(V))
+	n := p.N
+	nm := n * m
+	gs, hs, _ := generators(nm)
+	V = padCommitments(V, m)
+
+	t := newTranscript("gridlock/bulletproofs/range")
+	y := t.challenge(append(marshalPoints(V), proof.A.Marshal(), proof.S.Marshal())...)
+	z := t.challenge()
+	x := t.challenge(proof.T1.Marshal(), proof.T2.Marshal())
+	w := t.challenge(scalarBytes(proof.Taux), scalarBytes(proof.Mu), scalarBytes(proof.THat))
+	if y.Sign() == 0 || x.Sign() == 0 {
+		return false, errors.New("zero challenge in range proof")
+	}
+	challenges, s, err := proof.IPP.verificationScalars(nm, t)
+	if err != nil {
+		return false, err
+	}
+
+	zj := powers(z, m+3)
+	zz := zj[2]
+	xx := mod(new(big.Int).Mul(x, x))
+	yn := powers(y, nm)
+	yInv := powers(inverse(y), nm)
+	twon := powers(big.NewInt(2), n)
+
+	// first equation: tHat.g + taux.h = sum_j z^(j+2).V_j + delta.g + x.T1 + x^2.T2
+	// with delta = (z - z^2).<1,y^nm> - sum_j z^(j+3).<1,2^n>
+	delta := new(big.Int).Mul(new(big.Int).Sub(z, zz), sum(yn))
+	for j := int64(0); j < m; j++ {
+		delta.Sub(delta, new(big.Int).Mul(zj[j+3], sum(twon)))
+	}
+	delta = mod(delta)
+	lhs := new(bn256.G2).ScalarMult(p.G, mod(new(big.Int).Sub(proof.THat, delta)))
+	lhs.Add(lhs, new(bn256.G2).ScalarMult(p.H, proof.Taux))
+	rhs := multiExp(V, zj[2:m+2])
+	rhs.Add(rhs, new(bn256.G2).ScalarMult(proof.T1, x))
+	rhs.Add(rhs, new(bn256.G2).ScalarMult(proof.T2, xx))
+	if !bytes.Equal(lhs.Marshal(), rhs.Marshal()) {
+		return false, nil
+	}
+
+	// second equation, with P = A + x.S - z.<1,Gs> + <z.y^nm + sum_j z^(j+2).(0..0 || 2^n || 0..0), Hs'>:
+	// P - mu.h + tHat.U' + sum(x_k^2.L_k + x_k^-2.R_k) = a.<s,Gs> + b.<s^-1,Hs'> + a.b.U'
+	ab := mod(new(big.Int).Mul(proof.IPP.A, proof.IPP.B))
+	points := []*bn256.G2{}
+	scalars := []*big.Int{}
+	for k := int64(0); k < nm; k++ {
+		// (a.s_k + z).G_k
+		points = append(points, gs[k])
+		scalars = append(scalars, mod(new(big.Int).Add(new(big.Int).Mul(proof.IPP.A, s[k]), z)))
+		// (b.s_k^-1.y^-k - z - z^(j+2).2^i.y^-k).H_k with k = j.n + i
+		hk := new(big.Int).Mul(new(big.Int).Mul(proof.IPP.B, inverse(s[k])), yInv[k])
+		hk.Sub(hk, z)
+		hk.Sub(hk, new(big.Int).Mul(new(big.Int).Mul(zj[k/n+2], twon[k%n]), yInv[k]))
+		points = append(points, hs[k])
+		scalars = append(scalars, mod(hk))
+	}
+	// (a.b - tHat).w.U + mu.h - A - x.S
+	points = append(points, p.U, p.H, proof.A, proof.S)
+	scalars = append(scalars,
+		mod(new(big.Int).Mul(new(big.Int).Sub(ab, proof.THat), w)),
+		proof.Mu,
+		mod(big.NewInt(-1)),
+		mod(new(big.Int).Neg(x)),
+	)
+	// - x_k^2.L_k - x_k^-2.R_k
+	for k, xk := range challenges {
+		xk2 := mod(new(big.Int).Mul(xk, xk))
+		points = append(points, proof.IPP.L[k], proof.IPP.R[k])
+		scalars = append(scalars, mod(new(big.Int).Neg(xk2)), mod(new(big.Int).Neg(inverse(xk2))))
+	}
+	return multiExp(points, scalars).IsZero(), nil
+}
+
+/*
+VerifyCommitment unmarshals zkrp and checks that it proves the value committed in cm is in [0,2^N).
+*/
+func (p *Params) VerifyCommitment(zkrp []byte, cm *bn256.G2) (bool, error) {
+	return p.VerifyAggregateCommitment(zkrp, []*bn256.G2{cm})
+}
+
+/*
+VerifyAggregateCommitment unmarshals zkrp and checks that it proves every value committed in cms is in [0,2^N).
+*/
+func (p *Params) VerifyAggregateCommitment(zkrp []byte, cms []*bn256.G2) (bool, error) {
+	proof, err := new(Proof).Unmarshal(zkrp)
+	if err != nil {
+		return false, err
+	}
+	return VerifyAggregate(proof, cms, p)
+}
+
+// paddedLength returns the smallest power of two that is at least m
+func paddedLength(m int) int64 {
+	padded := int64(1)
+	for padded < int64(m) {
+		padded <<= 1
+	}
+	return padded
+}
+
+// padCommitments appends commitments to zero with zero randomness up to m commitments
+func padCommitments(V []*bn256.G2, m int64) []*bn256.G2 {
+	padded := make([]*bn256.G2, 0, m)
+	padded = append(padded, V...)
+	for int64(len(padded)) < m {
+		padded = append(padded, new(bn256.G2).SetInfinity())
+	}
+	return padded
+}
+
+func marshalPoints(points []*bn256.G2) [][]byte {
+	ret := make([][]byte, len(points))
+	for i, e := range points {
+		ret[i] = e.Marshal()
+	}
+	return ret
+}
+
+/*
+Marshal is for marshaling the Proof into []byte, with compressed points
+*/
+func (p *Proof) Marshal() []byte {
+	return p.marshal(bLG2Compressed)
+}
+
+// marshal encodes the proof with points of size bytes
+func (p *Proof) marshal(size int) []byte {
+	var ret []byte
+	for _, e := range []*bn256.G2{p.A, p.S, p.T1, p.T2} {
+		ret = append(ret, marshalG2(e, size)...)
+	}
+	for _, e := range []*big.Int{p.Taux, p.Mu, p.THat, p.IPP.A, p.IPP.B} {
+		ret = append(ret, scalarBytes(e)...)
+	}
+	for i := range p.IPP.L {
+		ret = append(ret, marshalG2(p.IPP.L[i], size)...)
+		ret = append(ret, marshalG2(p.IPP.R[i], size)...)
+	}
+	return ret
+}
+
+// log2(MaxBitLength*MaxAggregation) rounds at most
+const maxRounds = 12
+
+// validProofLength returns true iff n is the length of a proof with points of size bytes
+func validProofLength(n int, size int) bool {
+	fixed := 4*size + 5*bLInt
+	return n >= fixed && (n-fixed)%(2*size) == 0 && (n-fixed)/(2*size) <= maxRounds
+}
+
+/*
+Unmarshal is for converting []byte back into Proof, with compressed or uncompressed points.
+The number of rounds of the inner product argument is derived from the length of m.
+*/
+func (p *Proof) Unmarshal(m []byte) (*Proof, error) {
+	for _, size := range g2Lengths {
+		if validProofLength(len(m), size) {
+			return p.unmarshal(m, size)
+		}
+	}
+	return nil, errors.New("bulletproof has the wrong length")
+}
+
+// unmarshal decodes a proof with points of size bytes
+func (p *Proof) unmarshal(m []byte, size int) (*Proof, error) {
+	fixed := 4*size + 5*bLInt
+	points := make([]*bn256.G2, 4)
+	for i := range points {
+		e, ok := new(bn256.G2).Unmarshal(m[i*size : (i+1)*size])
+		if !ok {
+			return nil, errors.New("bulletproof contains an invalid point")
+		}
+		points[i] = e
+	}
+	scalars := make([]*big.Int, 5)
+	for i := range scalars {
+		start := 4*size + i*bLInt
+		scalars[i] = new(big.Int).SetBytes(m[start : start+bLInt])
+		if scalars[i].Cmp(bn256.Order) >= 0 {
+			return nil, errors.New("bulletproof contains an invalid scalar")
+		}
+	}
+	ipp := &InnerProductProof{A: scalars[3], B: scalars[4]}
+	for index := fixed; index < len(m); index += 2 * size {
+		L, okL := new(bn256.G2).Unmarshal(m[index : index+size])
+		R, okR := new(bn256.G2).Unmarshal(m[index+size : index+2*size])
+		if !okL || !okR {
+			return nil, errors.New("bulletproof contains an invalid point")
+		}
+		ipp.L = append(ipp.L, L)
+		ipp.R = append(ipp.R, R)
+	}
+	p.A, p.S, p.T1, p.T2 = points[0], points[1], points[2], points[3]
+	p.Taux, p.Mu, p.THat = scalars[0], scalars[1], scalars[2]
+	p.IPP = ipp
+	return p, nil
+}
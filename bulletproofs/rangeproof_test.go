@@ -0,0 +1,235 @@
+package bulletproofs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"math/bits"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/blockchain-research/gridlock/pedersencurve"
+)
+
+// commit is pedersencurve.Commit on BN256, the curve of the proofs
+func commit(x, r *big.Int, h *bn256.G2) *bn256.G2 {
+	cm, _ := pairing.ToBN256G2(pedersencurve.Commit(x, r, pairing.FromBN256G2(h)))
+	return cm
+}
+
+func setupParams(t *testing.T, n int64) *Params {
+	h := new(bn256.G2).ScalarBaseMult(big.NewInt(0).SetBytes([]byte("gridlock bulletproofs test")))
+	p, err := Setup(n, h)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	return p
+}
+
+func TestBulletproof(t *testing.T) {
+	p := setupParams(t, 32)
+	for _, x := range []int64{0, 1, 176, 1<<32 - 1} {
+		r, _ := rand.Int(rand.Reader, bn256.Order)
+		v := new(big.Int).SetInt64(x)
+		cm := commit(v, r, p.H)
+		proof, err := Prove(v, r, cm, p)
+		if err != nil {
+			t.Fatalf("Prove failed for %d: %v", x, err)
+		}
+		result, err := Verify(proof, cm, p)
+		if result != true || err != nil {
+			t.Errorf("Assert failure for %d: expected true, actual: %t, %v", x, result, err)
+		}
+	}
+}
+
+func TestBulletproofNegativeRandomness(t *testing.T) {
+	p := setupParams(t, 8)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	r.Neg(r)
+	v := new(big.Int).SetInt64(42)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+	result, _ := Verify(proof, cm, p)
+	if result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+}
+
+func TestBulletproofOutOfRange(t *testing.T) {
+	p := setupParams(t, 8)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	for _, v := range []*big.Int{big.NewInt(256), big.NewInt(-1)} {
+		cm := commit(v, r, p.H)
+		if _, err := Prove(v, r, cm, p); err == nil {
+			t.Errorf("Assert failure: expected an error for %s", v)
+		}
+	}
+}
+
+func TestBulletproofWrongCommitment(t *testing.T) {
+	p := setupParams(t, 8)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(42)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+
+	other := commit(new(big.Int).SetInt64(43), r, p.H)
+	result, _ := Verify(proof, other, p)
+	if result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+
+	proof.THat = mod(new(big.Int).Add(proof.THat, big.NewInt(1)))
+	result, _ = Verify(proof, cm, p)
+	if result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+}
+
+func TestBulletproofMarshal(t *testing.T) {
+	p := setupParams(t, 16)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(1000)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+
+	proofBytes := proof.Marshal()
+	if len(proofBytes) != (2*4+4)*bLG2Compressed+5*bLInt {
+		t.Errorf("unexpected proof size %d", len(proofBytes))
+	}
+	proof2, err := new(Proof).Unmarshal(proofBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	p2, err := new(Params).Unmarshal(p.Marshal())
+	if err != nil {
+		t.Fatalf("Unmarshal of params failed: %v", err)
+	}
+	result, err := p2.VerifyCommitment(proofBytes, cm)
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+	result, _ = Verify(proof2, cm, p2)
+	if result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+
+	if _, err := new(Proof).Unmarshal(proofBytes[:len(proofBytes)-1]); err == nil {
+		t.Error("Assert failure: expected an error for a truncated proof")
+	}
+	// a proof for a different bit length must be rejected
+	p8 := setupParams(t, 8)
+	if result, _ := p8.VerifyCommitment(proofBytes, cm); result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+}
+
+func TestBulletproofUnmarshalUncompressed(t *testing.T) {
+	p := setupParams(t, 16)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	v := new(big.Int).SetInt64(1000)
+	cm := commit(v, r, p.H)
+	proof, _ := Prove(v, r, cm, p)
+
+	// proofs and params written before compression are still accepted
+	proofBytes := proof.marshal(bLG2)
+	if len(proofBytes) != (2*4+4)*bLG2+5*bLInt {
+		t.Errorf("unexpected proof size %d", len(proofBytes))
+	}
+	p2, err := new(Params).Unmarshal(p.marshal(bLG2))
+	if err != nil {
+		t.Fatalf("Unmarshal of params failed: %v", err)
+	}
+	if !bytes.Equal(p2.Marshal(), p.Marshal()) {
+		t.Error("uncompressed params do not decode to the same params")
+	}
+	result, err := p2.VerifyCommitment(proofBytes, cm)
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+	proof2, err := new(Proof).Unmarshal(proofBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !bytes.Equal(proof2.Marshal(), proof.Marshal()) {
+		t.Error("an uncompressed proof does not decode to the same proof")
+	}
+}
+
+func commitAll(p *Params, values []int64) ([]*big.Int, []*big.Int, []*bn256.G2) {
+	v := make([]*big.Int, len(values))
+	gamma := make([]*big.Int, len(values))
+	cms := make([]*bn256.G2, len(values))
+	for j, x := range values {
+		v[j] = new(big.Int).SetInt64(x)
+		gamma[j], _ = rand.Int(rand.Reader, bn256.Order)
+		cms[j] = commit(v[j], gamma[j], p.H)
+	}
+	return v, gamma, cms
+}
+
+func TestBulletproofAggregate(t *testing.T) {
+	p := setupParams(t, 8)
+	for _, values := range [][]int64{{7}, {0, 255}, {1, 2, 3}, {10, 20, 30, 40, 50}} {
+		v, gamma, cms := commitAll(p, values)
+		proof, err := ProveAggregate(v, gamma, cms, p)
+		if err != nil {
+			t.Fatalf("ProveAggregate failed for %v: %v", values, err)
+		}
+		if len(proof.IPP.L) != int(3+bits.Len(uint(len(values)-1))) {
+			t.Errorf("unexpected number of rounds %d for %d values", len(proof.IPP.L), len(values))
+		}
+		result, err := p.VerifyAggregateCommitment(proof.Marshal(), cms)
+		if result != true || err != nil {
+			t.Errorf("Assert failure for %v: expected true, actual: %t, %v", values, result, err)
+		}
+	}
+}
+
+func TestBulletproofAggregateIsSmaller(t *testing.T) {
+	p := setupParams(t, 8)
+	v, gamma, cms := commitAll(p, []int64{1, 2, 3, 4})
+	aggregate, _ := ProveAggregate(v, gamma, cms, p)
+	single, _ := Prove(v[0], gamma[0], cms[0], p)
+	if len(aggregate.Marshal()) >= 4*len(single.Marshal()) {
+		t.Errorf("aggregated proof of %d bytes is not smaller than 4 proofs of %d bytes", len(aggregate.Marshal()), len(single.Marshal()))
+	}
+}
+
+func TestBulletproofAggregateWrongCommitments(t *testing.T) {
+	p := setupParams(t, 8)
+	v, gamma, cms := commitAll(p, []int64{1, 2, 3})
+	proof, _ := ProveAggregate(v, gamma, cms, p)
+
+	// the commitments are bound in order
+	swapped := []*bn256.G2{cms[1], cms[0], cms[2]}
+	if result, _ := VerifyAggregate(proof, swapped, p); result != false {
+		t.Errorf("Assert failure: expected false for swapped commitments, actual: %t", result)
+	}
+	// a missing commitment changes the statement
+	if result, _ := VerifyAggregate(proof, cms[:2], p); result != false {
+		t.Errorf("Assert failure: expected false for missing commitment, actual: %t", result)
+	}
+	// one of the values is out of range
+	v[2] = big.NewInt(256)
+	if _, err := ProveAggregate(v, gamma, cms, p); err == nil {
+		t.Error("Assert failure: expected an error for an out of range value")
+	}
+	if _, err := ProveAggregate(v[:2], gamma, cms, p); err == nil {
+		t.Error("Assert failure: expected an error for mismatched lengths")
+	}
+	if result, err := VerifyAggregate(proof, nil, p); result != false || err == nil {
+		t.Errorf("Assert failure: expected an error without commitments, actual: %t, %v", result, err)
+	}
+}
+
+func TestSetupInvalidBitLength(t *testing.T) {
+	h := new(bn256.G2).ScalarBaseMult(big.NewInt(7))
+	for _, n := range []int64{0, 3, 128} {
+		if _, err := Setup(n, h); err == nil {
+			t.Errorf("Assert failure: expected an error for n=%d", n)
+		}
+	}
+}
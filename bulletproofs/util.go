@@ -0,0 +1,113 @@
+package bulletproofs
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+const (
+	bLG2           = 128 // length of a marshaled G2 element
+	bLG2Compressed = 65  // length of a compressed G2 element
+	bLInt          = 32  // length of a marshaled scalar
+)
+
+// g2Lengths are the lengths of the G2 elements of an encoding: compressed as written by Marshal,
+// or uncompressed as written before, which is still accepted
+var g2Lengths = []int{bLG2Compressed, bLG2}
+
+// marshalG2 encodes e in size bytes, either bLG2Compressed or bLG2
+func marshalG2(e *bn256.G2, size int) []byte {
+	if size == bLG2 {
+		return e.Marshal()
+	}
+	return e.MarshalCompressed()
+}
+
+/*
+transcript implements the Fiat-Shamir heuristic. Every challenge is the hash of
+all the messages absorbed so far, so later challenges are bound to earlier ones.
+*/
+type transcript struct {
+	state []byte
+}
+
+func newTranscript(label string) *transcript {
+	return &transcript{state: []byte(label)}
+}
+
+// challenge absorbs the given messages and returns a challenge in Z_q
+func (t *transcript) challenge(messages ...[]byte) *big.Int {
+	digest := sha256.New()
+	digest.Write(t.state)
+	for _, m := range messages {
+		digest.Write(m)
+	}
+	t.state = digest.Sum(nil)
+	return new(big.Int).Mod(new(big.Int).SetBytes(t.state), bn256.Order)
+}
+
+func scalarBytes(x *big.Int) []byte {
+	ret := make([]byte, bLInt, bLInt)
+	b := x.Bytes()
+	copy(ret[bLInt-len(b):], b)
+	return ret
+}
+
+func randomScalar() *big.Int {
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	return r
+}
+
+func randomVector(n int64) []*big.Int {
+	v := make([]*big.Int, n)
+	for i := range v {
+		v[i] = randomScalar()
+	}
+	return v
+}
+
+func mod(x *big.Int) *big.Int {
+	return new(big.Int).Mod(x, bn256.Order)
+}
+
+func inverse(x *big.Int) *big.Int {
+	return new(big.Int).ModInverse(x, bn256.Order)
+}
+
+// powers returns (1, x, x^2, ..., x^(n-1))
+func powers(x *big.Int, n int64) []*big.Int {
+	v := make([]*big.Int, n)
+	v[0] = big.NewInt(1)
+	for i := int64(1); i < n; i++ {
+		v[i] = mod(new(big.Int).Mul(v[i-1], x))
+	}
+	return v
+}
+
+func sum(v []*big.Int) *big.Int {
+	s := new(big.Int)
+	for _, e := range v {
+		s.Add(s, e)
+	}
+	return mod(s)
+}
+
+func innerProduct(a, b []*big.Int) *big.Int {
+	s := new(big.Int)
+	for i := range a {
+		s.Add(s, new(big.Int).Mul(a[i], b[i]))
+	}
+	return mod(s)
+}
+
+// multiExp returns sum(scalars[i]*points[i])
+func multiExp(points []*bn256.G2, scalars []*big.Int) *bn256.G2 {
+	ret := new(bn256.G2).SetInfinity()
+	for i := range points {
+		ret.Add(ret, new(bn256.G2).ScalarMult(points[i], scalars[i]))
+	}
+	return ret
+}
@@ -0,0 +1,47 @@
+/*
+Package commitment defines the Pedersen commitments g^x.h^r stored in the ledger, whichever
+group they are in. The account, message and settlement logic only adds, negates and compares
+commitments, so it is written against Scheme and Commitment. pedersencurve implements them on
+G2 of a pairing-friendly curve and pedersengroup in a Schnorr group, and initParams chooses
+the scheme of the ledger.
+*/
+package commitment
+
+import "math/big"
+
+/*
+Scheme is the public parameters of a commitment scheme: the group and its generators g and h.
+*/
+type Scheme interface {
+	// Name names the scheme in errors and logs
+	Name() string
+	// Commit returns g^x.h^r
+	Commit(x, r *big.Int) Commitment
+	// Unmarshal converts the output of Marshal back into a commitment of the scheme,
+	// it returns false unless m encodes an element of the group
+	Unmarshal(m []byte) (Commitment, bool)
+}
+
+/*
+Commitment is a commitment of a Scheme. Commitments are values, Add and Neg return new ones.
+Add panics when the commitments are of different schemes.
+*/
+type Commitment interface {
+	// Scheme returns the scheme of the commitment
+	Scheme() Scheme
+	// Add returns the commitment to the sum of the values and randomness of the commitments
+	Add(b Commitment) Commitment
+	// Neg returns the commitment to the opposite value and randomness
+	Neg() Commitment
+	// Equal returns true iff b is the same commitment
+	Equal(b Commitment) bool
+	// Marshal returns the encoding stored in the ledger
+	Marshal() []byte
+}
+
+/*
+Sub returns the commitment to the difference of the values committed in a and b.
+*/
+func Sub(a, b Commitment) Commitment {
+	return a.Add(b.Neg())
+}
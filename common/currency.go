@@ -0,0 +1,138 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/blockchain-research/gridlock/ledger"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/golang/protobuf/proto"
+)
+
+//every currency has its own accounts, queues, queue sums, limits and minimums, stored under the keys of its tables.
+//The tables of the default currency are the tables themselves, so that the ledgers written before currencies
+//were added are those of the default currency. The commitments of different currencies are never added together:
+//a balance, a queue or a gridlock resolution only ever holds the payments of its currency, and every currency
+//but the default one commits with its own generator, see CurrencyGenerator
+
+//currencyDomain prefixes the currency in the message hashed to its generator
+const currencyDomain = "gridlock/currency/"
+
+//InCurrency returns the table of currency, the keys are the table followed by '_', the currency and '_'.
+//A currency is made of upper case letters only, so no key of a currency is the key of another one
+func InCurrency(table string, currency string) string {
+	if currency == DefaultCurrency {
+		return table
+	}
+	return table + "_" + currency + "_"
+}
+
+//CurrencyGenerator returns the generator h of the commitments g^x.h^r of currency: the h of the params for the default
+//currency, so that the commitments stored before currencies were added keep their meaning, and a point hashed from
+//the currency for the others. Neither has a known discrete logarithm, except the legacy h of the params, see
+//CheckBindingFromLedger. The sum of commitments of different currencies then has no opening on the generator of any
+//currency, so that no range proof of the sum verifies
+func CurrencyGenerator(h pairing.G2, currency string) pairing.G2 {
+	if currency == DefaultCurrency {
+		return h
+	}
+	return h.Curve().HashToG2([]byte(currencyDomain + currency))
+}
+
+//CheckBindingFromLedger checks that the commitments of currency are binding under the stored params. The default currency
+//commits with the h of the params, which is g raised to a known exponent in the params made before h was hashed, see
+//zkrangeproof.LegacyH: those commitments open to any value, so no proof about them is accepted until migrateCurve
+//replaces the params
+func CheckBindingFromLedger(stub ledger.Ledger, currency string) error {
+	if currency != DefaultCurrency {
+		return nil
+	}
+	paramsVerifier, err := GetParamsFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	if paramsVerifier.HasLegacyH() {
+		logger.Error("The h of the params has a known discrete logarithm")
+		return errors.New("The commitments of the default currency are not binding under the legacy h of the params, invoke migrateCurve first")
+	}
+	return nil
+}
+
+//CurrencyGeneratorBN256 is CurrencyGenerator for the range proofs that only exist on BN256
+func CurrencyGeneratorBN256(h *bn256.G2, currency string) *bn256.G2 {
+	if currency == DefaultCurrency {
+		return h
+	}
+	return bn256.HashToG2([]byte(currencyDomain + currency))
+}
+
+//ValidateCurrency checks currency is the default currency or from 1 to MaxCurrencyLength upper case letters, e.g. an ISO 4217 code
+func ValidateCurrency(currency string) error {
+	if len(currency) > MaxCurrencyLength {
+		return fmt.Errorf("The currency %s is longer than %d letters", currency, MaxCurrencyLength)
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("Invalid currency %s, a currency is made of upper case letters", currency)
+		}
+	}
+	return nil
+}
+
+//GetCurrenciesFromLedger returns the default currency followed by the currencies that have accounts, in the order they were minted
+func GetCurrenciesFromLedger(stub ledger.Ledger) ([]string, error) {
+	storedBytes, err := stub.Get(CurrencyTable)
+	if err != nil {
+		logger.Error("Failed to read currency table")
+		return nil, err
+	}
+	stored := &pb.StoredCurrencies{}
+	err = proto.Unmarshal(storedBytes, stored)
+	if err != nil {
+		logger.Error("Failed to unmarshal stored currencies")
+		return nil, err
+	}
+	return append([]string{DefaultCurrency}, stored.Currencies...), nil
+}
+
+//AddCurrencyToLedger adds currency to the currencies that have accounts unless it is already one of them
+func AddCurrencyToLedger(stub ledger.Ledger, currency string) error {
+	currencies, err := GetCurrenciesFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	for _, c := range currencies {
+		if c == currency {
+			return nil
+		}
+	}
+	currenciesToStoreBytes, err := proto.Marshal(&pb.StoredCurrencies{Currencies: append(currencies[1:], currency)})
+	if err != nil {
+		logger.Errorf("Unable to marshal stored currencies to protobuf")
+		return err
+	}
+	err = stub.Put(CurrencyTable, currenciesToStoreBytes)
+	if err != nil {
+		logger.Errorf("Failed to add currency to ledger")
+		return err
+	}
+	return nil
+}
+
+//CheckCurrencyFromLedger checks currency is the default currency or one that has accounts,
+//so that no payment is queued in a currency without accounts to settle it
+func CheckCurrencyFromLedger(stub ledger.Ledger, currency string) error {
+	currencies, err := GetCurrenciesFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	for _, c := range currencies {
+		if c == currency {
+			return nil
+		}
+	}
+	logger.Infof("No accounts in currency %s", currency)
+	return fmt.Errorf("No accounts in currency %s", currency)
+}
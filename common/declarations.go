@@ -12,17 +12,36 @@ const (
 
 //table names
 const (
-	AccountTable    = "ACCOUNT"
-	MessageTable    = "PAYMENT_MESSAGE"
-	InQueueTable    = "PAYMENT_QUEUE_INCOMING"
-	OutQueueTable   = "PAYMENT_QUEUE_OUTGOING"
-	PedersenTable   = "PEDERSEN"
-	ConfigTable     = "GLR_CONFIGURATION"
-	InfeasibleTable = "GLR_INFEASIBLE"
-	ProposalTable   = "PROPOSAL"
+	AccountTable     = "ACCOUNT"
+	MessageTable     = "PAYMENT_MESSAGE"
+	InQueueTable     = "PAYMENT_QUEUE_INCOMING"
+	OutQueueTable    = "PAYMENT_QUEUE_OUTGOING"
+	InSumTable       = "PAYMENT_SUM_INCOMING"
+	OutSumTable      = "PAYMENT_SUM_OUTGOING"
+	PedersenTable    = "PEDERSEN"
+	ConfigTable      = "GLR_CONFIGURATION"
+	InfeasibleTable  = "GLR_INFEASIBLE"
+	ProposalTable    = "PROPOSAL"
+	LimitTable       = "PAYMENT_LIMIT"
+	MinimumTable     = "PAYMENT_MINIMUM"
+	CurrencyTable    = "CURRENCY"
+	CreditTable      = "CREDIT_LIMIT"
+	RepaymentTable   = "REPAYMENT"
+	CentralBankTable = "CENTRAL_BANK"
 )
 
+//currency related
 const (
-	U = 10 // range proof for (0,u^l)
-	L = 10
+	DefaultCurrency   = "" //the currency of the accounts and payments that do not name one
+	MaxCurrencyLength = 12
+)
+
+//priority related
+const (
+	UrgentPriority = -1 //the payments of this priority or below are urgent, they may draw on the reserve of the sender
+)
+
+//amount related
+const (
+	MinPaymentAmount = 1 //the smallest amount of a payment when the sender has no minimum
 )
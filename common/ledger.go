@@ -2,51 +2,113 @@ package common
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
-	"github.com/golang/protobuf/proto"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
-
+	"github.com/blockchain-research/gridlock/borromean"
+	"github.com/blockchain-research/gridlock/bulletproofs"
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/blockchain-research/gridlock/ledger"
+	"github.com/blockchain-research/gridlock/pedersencurve"
 	"github.com/blockchain-research/gridlock/pedersengroup"
 	pb "github.com/blockchain-research/gridlock/proto"
 	"github.com/blockchain-research/gridlock/zkrangeproof"
+	"github.com/golang/protobuf/proto"
 )
 
-var logger = shim.NewLogger("gridlock")
+var logger = ledger.NewLogger("gridlock")
 
-//GetPedersenGroupFromLedger returns the public pedersen params
-func GetPedersenGroupFromLedger(stub shim.ChaincodeStubInterface) (_ *pedersengroup.PedersenPublic, err error) {
+//GetPedersenGroupFromLedger returns the public pedersen params of the Schnorr group, or nil if the ledger has none
+func GetPedersenGroupFromLedger(stub ledger.Ledger) (_ *pedersengroup.PedersenPublic, err error) {
 	//get stored pedersen
-	storedPedersenBytes, err := stub.GetState(PedersenTable + "_GROUP")
+	storedPedersenBytes, err := stub.Get(PedersenTable + "_GROUP")
 	if err != nil {
 		logger.Error("Failed to read pedersen params")
 		return nil, err
 	}
-	storedPedersen := &pb.StoredPedersenGroup{}
-	err = proto.Unmarshal(storedPedersenBytes, storedPedersen)
+	if storedPedersenBytes == nil {
+		return nil, nil
+	}
+	p, err := DecodePedersenGroup(storedPedersenBytes)
 	if err != nil {
 		logger.Error("Failed to unmarshal stored pedersen")
 		return nil, err
 	}
+	return p, nil
+}
+
+//DecodePedersenGroup reads and validates the protobuf-encoded params of a Schnorr group
+func DecodePedersenGroup(m []byte) (*pedersengroup.PedersenPublic, error) {
+	storedPedersen := &pb.StoredPedersenGroup{}
+	err := proto.Unmarshal(m, storedPedersen)
+	if err != nil {
+		return nil, err
+	}
 	p := pedersengroup.GeneratePedersenFromParams(
 		new(big.Int).SetBytes(storedPedersen.P),
 		new(big.Int).SetBytes(storedPedersen.G),
 		new(big.Int).SetBytes(storedPedersen.Q),
 		new(big.Int).SetBytes(storedPedersen.H),
 	)
+	err = p.Validate()
+	if err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
+//GetCommitmentSchemeFromLedger returns the scheme of the commitments of currency in the ledger, chosen by initParams:
+//the Schnorr group when its params are stored, otherwise the curve of the UL params with the generator of the currency
+func GetCommitmentSchemeFromLedger(stub ledger.Ledger, currency string) (commitment.Scheme, error) {
+	group, err := GetPedersenGroupFromLedger(stub)
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		return group, nil
+	}
+	paramsVerifier, err := GetParamsFromLedger(stub)
+	if err != nil {
+		return nil, err
+	}
+	return pedersencurve.NewScheme(CurrencyGenerator(paramsVerifier.H, currency)), nil
+}
+
+//RangeProofPoints returns the points of G2 of the commitments, which the range proofs are about.
+//All the range proof schemes are on curves, so the commitments of other schemes are rejected
+//with ErrCurveMismatch until a range proof backend supports them.
+func RangeProofPoints(cms ...commitment.Commitment) ([]pairing.G2, error) {
+	points := make([]pairing.G2, len(cms))
+	for k, cm := range cms {
+		e, ok := pedersencurve.ToG2(cm)
+		if !ok {
+			return nil, errNoRangeProofScheme(cm.Scheme())
+		}
+		points[k] = e
+	}
+	return points, nil
+}
+
+//errNoRangeProofScheme is the error for the commitments of a scheme no range proof supports
+func errNoRangeProofScheme(scheme commitment.Scheme) error {
+	logger.Errorf("No range proof scheme supports %s commitments", scheme.Name())
+	return fmt.Errorf("%w: no range proof scheme supports %s commitments", zkrangeproof.ErrCurveMismatch, scheme.Name())
+}
+
 //GetParamsFromLedger returns the params
-func GetParamsFromLedger(stub shim.ChaincodeStubInterface) (_ *zkrangeproof.ParamsULVerifier, err error) {
+func GetParamsFromLedger(stub ledger.Ledger) (_ *zkrangeproof.ParamsULVerifier, err error) {
 	//get stored pedersen
-	storedBytes, err := stub.GetState(PedersenTable + "_CURVE")
+	storedBytes, err := stub.Get(PedersenTable + "_CURVE")
 	if err != nil {
 		logger.Error("Failed to read pedersen curve params")
 		return nil, err
 	}
-	paramsVerifier := new(zkrangeproof.ParamsULVerifier).Unmarshal(storedBytes)
+	if storedBytes == nil {
+		logger.Error("No stored params UL verifier")
+		return nil, errors.New("UL params are not initialized")
+	}
+	paramsVerifier, err := zkrangeproof.DecodeParamsUL(storedBytes)
 	if err != nil {
 		logger.Error("Failed to unmarshal stored params UL verifier")
 		return nil, err
@@ -54,14 +116,128 @@ func GetParamsFromLedger(stub shim.ChaincodeStubInterface) (_ *zkrangeproof.Para
 	return paramsVerifier, nil
 }
 
+//GetCurrencyParamsFromLedger returns the UL params for the commitments of currency, those of the ledger with the generator
+//of the currency
+func GetCurrencyParamsFromLedger(stub ledger.Ledger, currency string) (*zkrangeproof.ParamsULVerifier, error) {
+	paramsVerifier, err := GetParamsFromLedger(stub)
+	if err != nil {
+		return nil, err
+	}
+	return CurrencyParams(paramsVerifier, currency), nil
+}
+
+//CurrencyParams returns a copy of paramsVerifier with the generator of currency
+func CurrencyParams(paramsVerifier *zkrangeproof.ParamsULVerifier, currency string) *zkrangeproof.ParamsULVerifier {
+	params := *paramsVerifier
+	params.H = CurrencyGenerator(paramsVerifier.H, currency)
+	return &params
+}
+
+//GetCurveFromLedger returns the curve of the stored UL params, which every commitment in the ledger is on
+func GetCurveFromLedger(stub ledger.Ledger) (pairing.Curve, error) {
+	paramsVerifier, err := GetParamsFromLedger(stub)
+	if err != nil {
+		return nil, err
+	}
+	return paramsVerifier.Curve(), nil
+}
+
+//GetBulletproofParamsFromLedger returns the bulletproof params
+func GetBulletproofParamsFromLedger(stub ledger.Ledger) (*bulletproofs.Params, error) {
+	storedBytes, err := stub.Get(PedersenTable + "_BULLETPROOF")
+	if err != nil {
+		logger.Error("Failed to read bulletproof params")
+		return nil, err
+	}
+	if storedBytes == nil {
+		logger.Error("No stored bulletproof params")
+		return nil, errors.New("Bulletproof params are not initialized")
+	}
+	params, err := new(bulletproofs.Params).Unmarshal(storedBytes)
+	if err != nil {
+		logger.Error("Failed to unmarshal stored bulletproof params")
+		return nil, err
+	}
+	return params, nil
+}
+
+//GetBorromeanParamsFromLedger returns the borromean ring signature range proof params
+func GetBorromeanParamsFromLedger(stub ledger.Ledger) (*borromean.Params, error) {
+	storedBytes, err := stub.Get(PedersenTable + "_BORROMEAN")
+	if err != nil {
+		logger.Error("Failed to read borromean params")
+		return nil, err
+	}
+	if storedBytes == nil {
+		logger.Error("No stored borromean params")
+		return nil, errors.New("Borromean params are not initialized")
+	}
+	params, err := new(borromean.Params).Unmarshal(storedBytes)
+	if err != nil {
+		logger.Error("Failed to unmarshal stored borromean params")
+		return nil, err
+	}
+	return params, nil
+}
+
+//GetRangeProofVerifierFromLedger returns the verifier for range proofs of type proofType of the commitments of currency,
+//bulletproofs and borromean only exist on BN256 and reject commitments on other curves.
+//No range proof scheme supports the commitments of a Schnorr group yet.
+func GetRangeProofVerifierFromLedger(stub ledger.Ledger, currency string, proofType pb.RangeProofType) (zkrangeproof.RangeProofVerifier, error) {
+	group, err := GetPedersenGroupFromLedger(stub)
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		return nil, errNoRangeProofScheme(group)
+	}
+	switch proofType {
+	case pb.RangeProofType_UL:
+		return GetCurrencyParamsFromLedger(stub, currency)
+	case pb.RangeProofType_BULLETPROOF:
+		stored, err := GetBulletproofParamsFromLedger(stub)
+		if err != nil {
+			return nil, err
+		}
+		params := *stored
+		params.H = CurrencyGeneratorBN256(stored.H, currency)
+		return zkrangeproof.OnBN256(&params), nil
+	case pb.RangeProofType_BORROMEAN:
+		stored, err := GetBorromeanParamsFromLedger(stub)
+		if err != nil {
+			return nil, err
+		}
+		params := *stored
+		params.H = CurrencyGeneratorBN256(stored.H, currency)
+		return zkrangeproof.OnBN256(&params), nil
+	}
+	logger.Error("Unknown range proof type ", proofType)
+	return nil, errors.New("Unknown range proof type")
+}
+
+//GetAggregateRangeProofVerifierFromLedger returns the verifier for aggregated range proofs of type proofType
+//of the commitments of currency
+func GetAggregateRangeProofVerifierFromLedger(stub ledger.Ledger, currency string, proofType pb.RangeProofType) (zkrangeproof.AggregateRangeProofVerifier, error) {
+	verifier, err := GetRangeProofVerifierFromLedger(stub, currency, proofType)
+	if err != nil {
+		return nil, err
+	}
+	aggregateVerifier, ok := verifier.(zkrangeproof.AggregateRangeProofVerifier)
+	if !ok {
+		logger.Error("Range proof type does not support aggregation ", proofType)
+		return nil, errors.New("Range proof type does not support aggregation")
+	}
+	return aggregateVerifier, nil
+}
+
 //AddAccountToLedger adds account to the ledger
-func AddAccountToLedger(stub shim.ChaincodeStubInterface, key string, account *pb.StoredBankAccount) error {
+func AddAccountToLedger(stub ledger.Ledger, key string, account *pb.StoredBankAccount) error {
 	accountToStoreBytes, err := proto.Marshal(account)
 	if err != nil {
 		logger.Error("Failed to marshal account to store")
 		return err
 	}
-	err = stub.PutState(key, accountToStoreBytes)
+	err = stub.Put(key, accountToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add account to ledger")
 		return err
@@ -70,8 +246,8 @@ func AddAccountToLedger(stub shim.ChaincodeStubInterface, key string, account *p
 }
 
 //GetAccountFromLedger returns stored StoredBankAccount for key
-func GetAccountFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.StoredBankAccount, error) {
-	storedAccountBytes, err := stub.GetState(key)
+func GetAccountFromLedger(stub ledger.Ledger, key string) (*pb.StoredBankAccount, error) {
+	storedAccountBytes, err := stub.Get(key)
 	if err != nil {
 		logger.Error("Failed to read account table")
 		return nil, err
@@ -90,20 +266,34 @@ func GetAccountFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.Sto
 	return storedAccount, nil
 }
 
-//DecreaseAccountFromLedger updates the account cmBalance
+//GetReserveFromAccount returns the commitment to the reserve of the account, a commitment to 0 when it has none
+func GetReserveFromAccount(scheme commitment.Scheme, account *pb.StoredBankAccount) (commitment.Commitment, error) {
+	if len(account.CmReserve) == 0 {
+		return scheme.Commit(big.NewInt(0), big.NewInt(0)), nil
+	}
+	return UnmarshalCommitment(scheme, account.CmReserve)
+}
+
+//UpdateAccountFromLedger updates the account cmBalance, a commitment of scheme
 //isIncrease true, add cmAmount, isIncrease false, substract cmAmount
-func UpdateAccountFromLedger(stub shim.ChaincodeStubInterface, key string, isIncrease bool, cmAmountBytes []byte) error {
+func UpdateAccountFromLedger(stub ledger.Ledger, scheme commitment.Scheme, key string, isIncrease bool, cmAmountBytes []byte) error {
 	storedAccount, err := GetAccountFromLedger(stub, key)
 	if err != nil {
 		return err
 	}
-	cmBalance, _ := new(bn256.G2).Unmarshal(storedAccount.CmBalance)
-	cmAmount, _ := new(bn256.G2).Unmarshal(cmAmountBytes)
-	var cmPostBalance *bn256.G2
+	cmBalance, err := UnmarshalCommitment(scheme, storedAccount.CmBalance)
+	if err != nil {
+		return err
+	}
+	cmAmount, err := UnmarshalCommitment(scheme, cmAmountBytes)
+	if err != nil {
+		return err
+	}
+	var cmPostBalance commitment.Commitment
 	if isIncrease == true {
-		cmPostBalance = new(bn256.G2).Add(cmBalance, cmAmount)
+		cmPostBalance = cmBalance.Add(cmAmount)
 	} else {
-		cmPostBalance = new(bn256.G2).Add(cmBalance, new(bn256.G2).Neg(cmAmount))
+		cmPostBalance = commitment.Sub(cmBalance, cmAmount)
 	}
 
 	storedAccount.CmBalance = cmPostBalance.Marshal()
@@ -113,7 +303,7 @@ func UpdateAccountFromLedger(stub shim.ChaincodeStubInterface, key string, isInc
 		logger.Errorf("Unable to marshal stored account to protobuf")
 		return err
 	}
-	err = stub.PutState(key, accountToStoreBytes)
+	err = stub.Put(key, accountToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add stored account to ledger")
 		return err
@@ -121,9 +311,30 @@ func UpdateAccountFromLedger(stub shim.ChaincodeStubInterface, key string, isInc
 	return nil
 }
 
+//UnmarshalCommitment converts a marshaled pedersen commitment back into a commitment of scheme,
+//points of a curve may be compressed or not. It returns an error unless the bytes encode an element of the group
+func UnmarshalCommitment(scheme commitment.Scheme, cmBytes []byte) (commitment.Commitment, error) {
+	cm, ok := scheme.Unmarshal(cmBytes)
+	if !ok {
+		logger.Error("Failed to unmarshal commitment")
+		return nil, errors.New("Invalid commitment")
+	}
+	return cm, nil
+}
+
+//NormalizeCommitment returns the encoding the ledger stores of the commitment cmBytes of scheme,
+//compressed for points of a curve whichever encoding the client sent
+func NormalizeCommitment(scheme commitment.Scheme, cmBytes []byte) ([]byte, error) {
+	cm, err := UnmarshalCommitment(scheme, cmBytes)
+	if err != nil {
+		return nil, err
+	}
+	return cm.Marshal(), nil
+}
+
 //GetPaymentFromLedger returns the stored payment for paymentId
-func GetPaymentFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.StoredPaymentMessage, error) {
-	storedPaymentBytes, err := stub.GetState(key)
+func GetPaymentFromLedger(stub ledger.Ledger, key string) (*pb.StoredPaymentMessage, error) {
+	storedPaymentBytes, err := stub.Get(key)
 	if err != nil {
 		logger.Error("Failed to read payment table")
 		return nil, err
@@ -143,12 +354,12 @@ func GetPaymentFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.Sto
 }
 
 //AddPaymentToLedger adds a payment message to the ledger key
-func AddPaymentToLedger(stub shim.ChaincodeStubInterface, key string, paymentMessage *pb.StoredPaymentMessage) error {
+func AddPaymentToLedger(stub ledger.Ledger, key string, paymentMessage *pb.StoredPaymentMessage) error {
 	paymentMessageToStoreBytes, err := proto.Marshal(paymentMessage)
 	if err != nil {
 		logger.Errorf("Unable to marshal stored payment message to protobuf")
 	}
-	err = stub.PutState(key, paymentMessageToStoreBytes)
+	err = stub.Put(key, paymentMessageToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add payment message to ledger")
 		return err
@@ -157,7 +368,7 @@ func AddPaymentToLedger(stub shim.ChaincodeStubInterface, key string, paymentMes
 }
 
 //MarkPaymentFromLedger marks the stored payment status to settled
-func MarkPaymentFromLedger(stub shim.ChaincodeStubInterface, key string) error {
+func MarkPaymentFromLedger(stub ledger.Ledger, key string) error {
 	paymentMessage, err := GetPaymentFromLedger(stub, key)
 	if err != nil {
 		return err
@@ -168,7 +379,7 @@ func MarkPaymentFromLedger(stub shim.ChaincodeStubInterface, key string) error {
 		logger.Errorf("Unable to marshal stored payment message to protobuf")
 		return err
 	}
-	err = stub.PutState(key, paymentMessageToStoreBytes)
+	err = stub.Put(key, paymentMessageToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add payment message to ledger")
 		return err
@@ -176,10 +387,10 @@ func MarkPaymentFromLedger(stub shim.ChaincodeStubInterface, key string) error {
 	return nil
 }
 
-//GetQueueFromLedger returns the stored queue for bankId
-func GetQueueFromLedger(stub shim.ChaincodeStubInterface, key string) (_ *pb.StoredPaymentQueue, err error) {
+//GetQueueFromLedger returns the stored queue by key, e.g. the infeasible set of a gridlock resolution
+func GetQueueFromLedger(stub ledger.Ledger, key string) (_ *pb.StoredPaymentQueue, err error) {
 	//get stored queue
-	storedQueueBytes, err := stub.GetState(key)
+	storedQueueBytes, err := stub.Get(key)
 	if err != nil {
 		logger.Error("Failed to read queue table")
 		return nil, err
@@ -198,12 +409,12 @@ func GetQueueFromLedger(stub shim.ChaincodeStubInterface, key string) (_ *pb.Sto
 }
 
 //AddQueueToLedger adds the queue to the ledger
-func AddQueueToLedger(stub shim.ChaincodeStubInterface, key string, queue *pb.StoredPaymentQueue) error {
+func AddQueueToLedger(stub ledger.Ledger, key string, queue *pb.StoredPaymentQueue) error {
 	queueToStoreBytes, err := proto.Marshal(queue)
 	if err != nil {
 		logger.Errorf("Unable to marshal queue to protobuf")
 	}
-	err = stub.PutState(key, queueToStoreBytes)
+	err = stub.Put(key, queueToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add queue to ledger")
 		return err
@@ -211,81 +422,13 @@ func AddQueueToLedger(stub shim.ChaincodeStubInterface, key string, queue *pb.St
 	return nil
 }
 
-//AddQueueElementToLedger adds a new paymentId to the queue by key
-func AddQueueElementToLedger(stub shim.ChaincodeStubInterface, key string, paymentId int32) error {
-	storedOutQueue, err := GetQueueFromLedger(stub, key)
-	if err != nil {
-		logger.Errorf("Failed to read outgoing queue from ledger")
-		return err
-	}
-
-	storedOutQueue.PaymentIds = append(storedOutQueue.PaymentIds, paymentId)
-	queueToStoreBytes, err := proto.Marshal(storedOutQueue)
-	if err != nil {
-		logger.Errorf("Unable to marshal queue to store protobuf")
-		return err
-	}
-	err = stub.PutState(key, queueToStoreBytes)
-	if err != nil {
-		logger.Errorf("Failed to add out queue to ledger")
-		return err
-	}
-	return nil
-}
-
-func RemoveQueueElementFromLedger(stub shim.ChaincodeStubInterface, key string, paymentIds []int32) error {
-	storedQueueBytes, err := stub.GetState(key)
-	if err != nil {
-		logger.Error("Failed to read queue table")
-		return err
-	}
-	if storedQueueBytes == nil {
-		logger.Error("No stored queue with this key ", key)
-		return errors.New("No stored queue with this key")
-	}
-	storedQueue := &pb.StoredPaymentQueue{}
-	err = proto.Unmarshal(storedQueueBytes, storedQueue)
-	if err != nil {
-		logger.Error("Failed to unmarshal storedQueue")
-		return err
-	}
-
-	newPaymentIds := []int32{}
-	var toInclude bool
-	for _, paymentId := range storedQueue.PaymentIds {
-		toInclude = true
-		for _, id := range paymentIds {
-			if paymentId == id {
-				toInclude = false
-				break
-			}
-		}
-		if toInclude == true {
-			newPaymentIds = append(newPaymentIds, paymentId)
-		}
-	}
-	storedQueue.PaymentIds = newPaymentIds
-
-	queueToStoreBytes, err := proto.Marshal(storedQueue)
-	if err != nil {
-		logger.Errorf("Unable to marshal stored queue to protobuf")
-	}
-	err = stub.PutState(key, queueToStoreBytes)
-	if err != nil {
-		logger.Errorf("Failed to add queue to ledger")
-		return err
-	}
-
-	return nil
-}
-
 //AddGLRConfigurationToLedger adds a payment message to the ledger key
-func AddGLRConfigurationToLedger(stub shim.ChaincodeStubInterface, key string, config *pb.GLRConfiguration) error {
+func AddGLRConfigurationToLedger(stub ledger.Ledger, key string, config *pb.GLRConfiguration) error {
 	configToStoreBytes, err := proto.Marshal(config)
 	if err != nil {
 		logger.Errorf("Unable to marshal stored payment message to protobuf")
 	}
-	err = stub.PutState(key, configToStoreBytes)
+	err = stub.Put(key, configToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add glr config to ledger")
 		return err
@@ -294,8 +437,8 @@ func AddGLRConfigurationToLedger(stub shim.ChaincodeStubInterface, key string, c
 }
 
 //GetGLRConfigurationFromLedger returns the stored payment for paymentId
-func GetGLRConfigFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.GLRConfiguration, error) {
-	configBytes, err := stub.GetState(key)
+func GetGLRConfigFromLedger(stub ledger.Ledger, key string) (*pb.GLRConfiguration, error) {
+	configBytes, err := stub.Get(key)
 	if err != nil {
 		logger.Error("Failed to read config table")
 		return nil, err
@@ -315,12 +458,12 @@ func GetGLRConfigFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.G
 }
 
 //AddGridlockProposalToLedger adds the gridlockProposal to the ledger
-func AddGridlockProposalToLedger(stub shim.ChaincodeStubInterface, key string, proposal *pb.StoredGridlockProposal) error {
+func AddGridlockProposalToLedger(stub ledger.Ledger, key string, proposal *pb.StoredGridlockProposal) error {
 	proposalToStoreBytes, err := proto.Marshal(proposal)
 	if err != nil {
 		logger.Errorf("Unable to marshal proposal to protobuf")
 	}
-	err = stub.PutState(key, proposalToStoreBytes)
+	err = stub.Put(key, proposalToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add proposal to ledger")
 		return err
@@ -329,8 +472,8 @@ func AddGridlockProposalToLedger(stub shim.ChaincodeStubInterface, key string, p
 }
 
 //GetGridlockProposalFromLedger returns the stored gridlock proposal
-func GetGridlockProposalFromLedger(stub shim.ChaincodeStubInterface, key string) (*pb.StoredGridlockProposal, error) {
-	proposalBytes, err := stub.GetState(key)
+func GetGridlockProposalFromLedger(stub ledger.Ledger, key string) (*pb.StoredGridlockProposal, error) {
+	proposalBytes, err := stub.Get(key)
 	if err != nil {
 		logger.Error("Failed to read proposal table")
 		return nil, err
@@ -348,3 +491,151 @@ func GetGridlockProposalFromLedger(stub shim.ChaincodeStubInterface, key string)
 	}
 	return proposal, nil
 }
+
+//AddPaymentLimitToLedger adds the payment limit of a bank to the ledger
+func AddPaymentLimitToLedger(stub ledger.Ledger, key string, limit *pb.PaymentLimit) error {
+	limitToStoreBytes, err := proto.Marshal(limit)
+	if err != nil {
+		logger.Errorf("Unable to marshal payment limit to protobuf")
+		return err
+	}
+	err = stub.Put(key, limitToStoreBytes)
+	if err != nil {
+		logger.Errorf("Failed to add payment limit to ledger")
+		return err
+	}
+	return nil
+}
+
+//GetPaymentLimitFromLedger returns the payment limit of a bank, or nil if the bank has no limit
+func GetPaymentLimitFromLedger(stub ledger.Ledger, key string) (*pb.PaymentLimit, error) {
+	limitBytes, err := stub.Get(key)
+	if err != nil {
+		logger.Error("Failed to read payment limit table")
+		return nil, err
+	}
+	if limitBytes == nil {
+		return nil, nil
+	}
+
+	limit := &pb.PaymentLimit{}
+	err = proto.Unmarshal(limitBytes, limit)
+	if err != nil {
+		logger.Error("Failed to unmarshal payment limit")
+		return nil, err
+	}
+	return limit, nil
+}
+
+//AddPaymentMinimumToLedger adds the payment minimum of a bank to the ledger
+func AddPaymentMinimumToLedger(stub ledger.Ledger, key string, minimum *pb.PaymentMinimum) error {
+	minimumToStoreBytes, err := proto.Marshal(minimum)
+	if err != nil {
+		logger.Errorf("Unable to marshal payment minimum to protobuf")
+		return err
+	}
+	err = stub.Put(key, minimumToStoreBytes)
+	if err != nil {
+		logger.Errorf("Failed to add payment minimum to ledger")
+		return err
+	}
+	return nil
+}
+
+//GetPaymentMinimumFromLedger returns the smallest amount of the payments of a bank, MinPaymentAmount if the bank has no minimum
+func GetPaymentMinimumFromLedger(stub ledger.Ledger, key string) (int64, error) {
+	minimumBytes, err := stub.Get(key)
+	if err != nil {
+		logger.Error("Failed to read payment minimum table")
+		return 0, err
+	}
+	if minimumBytes == nil {
+		return MinPaymentAmount, nil
+	}
+
+	minimum := &pb.PaymentMinimum{}
+	err = proto.Unmarshal(minimumBytes, minimum)
+	if err != nil {
+		logger.Error("Failed to unmarshal payment minimum")
+		return 0, err
+	}
+	return minimum.MinAmount, nil
+}
+
+//AddCreditLimitToLedger adds the credit limit of a bank to the ledger
+func AddCreditLimitToLedger(stub ledger.Ledger, key string, limit *pb.StoredCreditLimit) error {
+	limitToStoreBytes, err := proto.Marshal(limit)
+	if err != nil {
+		logger.Errorf("Unable to marshal credit limit to protobuf")
+		return err
+	}
+	err = stub.Put(key, limitToStoreBytes)
+	if err != nil {
+		logger.Errorf("Failed to add credit limit to ledger")
+		return err
+	}
+	return nil
+}
+
+//GetCreditLimitFromLedger returns the commitment to the credit limit of a bank, a commitment to 0 when the bank has no credit line
+func GetCreditLimitFromLedger(stub ledger.Ledger, scheme commitment.Scheme, key string) (commitment.Commitment, error) {
+	limitBytes, err := stub.Get(key)
+	if err != nil {
+		logger.Error("Failed to read credit limit table")
+		return nil, err
+	}
+	if limitBytes == nil {
+		return scheme.Commit(big.NewInt(0), big.NewInt(0)), nil
+	}
+
+	limit := &pb.StoredCreditLimit{}
+	err = proto.Unmarshal(limitBytes, limit)
+	if err != nil {
+		logger.Error("Failed to unmarshal credit limit")
+		return nil, err
+	}
+	return UnmarshalCommitment(scheme, limit.CmLimit)
+}
+
+//DeleteCreditLimitFromLedger removes the credit limit of a bank, which has no credit line afterwards
+func DeleteCreditLimitFromLedger(stub ledger.Ledger, key string) error {
+	err := stub.Delete(key)
+	if err != nil {
+		logger.Errorf("Failed to delete credit limit from ledger")
+		return err
+	}
+	return nil
+}
+
+//AddCentralBankToLedger records the serialized identity of the central bank
+func AddCentralBankToLedger(stub ledger.Ledger, centralBank []byte) error {
+	err := stub.Put(CentralBankTable, centralBank)
+	if err != nil {
+		logger.Errorf("Failed to add central bank to ledger")
+		return err
+	}
+	return nil
+}
+
+//CheckCentralBankFromLedger checks the identity that submitted the transaction is the central bank
+func CheckCentralBankFromLedger(stub ledger.Ledger) error {
+	centralBank, err := stub.Get(CentralBankTable)
+	if err != nil {
+		logger.Error("Failed to read central bank table")
+		return err
+	}
+	if centralBank == nil {
+		logger.Error("No central bank on the ledger")
+		return errors.New("No central bank on the ledger, Init records it when the chaincode is instantiated")
+	}
+	creator, err := stub.Creator()
+	if err != nil {
+		logger.Error("Failed to read the creator of the transaction")
+		return err
+	}
+	if string(creator) != string(centralBank) {
+		logger.Error("The creator of the transaction is not the central bank")
+		return errors.New("Only the central bank may invoke this function")
+	}
+	return nil
+}
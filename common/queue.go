@@ -0,0 +1,367 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/blockchain-research/gridlock/ledger"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/golang/protobuf/proto"
+)
+
+//the queues of the banks are stored one entry per payment under keys ordered by (bankId, priority, paymentId),
+//so that enqueuing is a blind write of a new key and concurrent payments do not conflict on a queue key.
+//They still conflict on the sum of the queue, see AddQueueElementsToLedger.
+//The keys are plain keys rather than Fabric composite keys, on which Fabric does not allow range queries:
+//the table, the bankId, the priority and the paymentId separated by '/', the numbers in 8 hex digits
+//with the sign bit flipped so that their order is the order of the keys
+const queueKeySeparator = "/"
+
+func queueKeyNumber(n int32) string {
+	return fmt.Sprintf("%08x", uint32(n)^0x80000000)
+}
+
+//queuePrefix returns the prefix of the keys of the queue of bankId in table
+func queuePrefix(table string, bankId int32) string {
+	return table + queueKeySeparator + queueKeyNumber(bankId) + queueKeySeparator
+}
+
+//QueueKey returns the key of the entry of the payment in the queue of bankId in table
+func QueueKey(table string, bankId int32, priority int32, paymentId int32) string {
+	return queuePrefix(table, bankId) + queueKeyNumber(priority) + queueKeySeparator + queueKeyNumber(paymentId)
+}
+
+//scanQueue returns the first limit payment ids of the queue of bankId in table, all of them if limit is 0
+func scanQueue(stub ledger.Ledger, table string, bankId int32, limit int) ([]int32, error) {
+	//the legacy queue must be migrated first, or its payments would be missed
+	legacy, err := stub.Get(table + fmt.Sprint(bankId))
+	if err != nil {
+		logger.Error("Failed to read queue table")
+		return nil, err
+	}
+	if legacy != nil {
+		logger.Errorf("The queue %s%d is stored in the legacy format", table, bankId)
+		return nil, fmt.Errorf("The queue %s%d is stored in the legacy format, invoke migrateQueues first", table, bankId)
+	}
+
+	prefix := queuePrefix(table, bankId)
+	//'0' follows the separator '/', so the keys with the prefix are below the end key
+	end := strings.TrimSuffix(prefix, queueKeySeparator) + "0"
+	kvs, err := stub.RangeScan(prefix, end, limit)
+	if err != nil {
+		logger.Error("Failed to scan queue table")
+		return nil, err
+	}
+	paymentIds := make([]int32, 0, len(kvs))
+	for _, kv := range kvs {
+		id, err := strconv.ParseInt(string(kv.Value), 10, 32)
+		if err != nil {
+			logger.Errorf("Invalid queue entry %s", kv.Key)
+			return nil, err
+		}
+		paymentIds = append(paymentIds, int32(id))
+	}
+	return paymentIds, nil
+}
+
+//GetPaymentQueueFromLedger returns the payment ids of the queue of bankId in table, in the order they are settled
+func GetPaymentQueueFromLedger(stub ledger.Ledger, table string, bankId int32) ([]int32, error) {
+	return scanQueue(stub, table, bankId, 0)
+}
+
+//GetPaymentQueueHeadFromLedger returns the first n payment ids of the queue of bankId in table, or all of them
+//if there are fewer, without reading the rest of the queue
+func GetPaymentQueueHeadFromLedger(stub ledger.Ledger, table string, bankId int32, n int) ([]int32, error) {
+	if n <= 0 {
+		return []int32{}, nil
+	}
+	return scanQueue(stub, table, bankId, n)
+}
+
+//AddQueueElementsToLedger adds the payments to the queue of bankId in table and their cmAmount of scheme to the sum
+//of the queue, the entries are blind writes and the sum is written once. A payment already in the queue is
+//rejected, it would be counted twice in the sum, and so is a payment beyond MaxQueuedPayments in an outgoing queue.
+//The sum is a single key per queue that every payment of the bank reads and writes, so the transactions adding
+//payments of the same bank in one block conflict on it and all but the first fail validation. The sum is kept
+//whole rather than sharded, since proposeNettableSet would then read every shard, and a bank that sends many
+//payments at once adds them with one addMessages
+func AddQueueElementsToLedger(stub ledger.Ledger, scheme commitment.Scheme, table string, bankId int32, payments []*pb.PaymentMessage) error {
+	//the sum is read before the entries are written, the queue tells whether a missing sum is 0
+	cmSum, count, err := getQueueSum(stub, scheme, table, bankId)
+	if err != nil {
+		return err
+	}
+	err = checkQueueSpace(table, bankId, count, len(payments))
+	if err != nil {
+		return err
+	}
+	queued := map[int32]bool{}
+	for _, payment := range payments {
+		key := QueueKey(table, bankId, payment.Priority, payment.PaymentId)
+		entry, err := stub.Get(key)
+		if err != nil {
+			logger.Error("Failed to read queue table")
+			return err
+		}
+		if entry != nil || queued[payment.PaymentId] {
+			logger.Errorf("Payment %d is already in the queue %s%d", payment.PaymentId, table, bankId)
+			return fmt.Errorf("Payment %d is already in the queue", payment.PaymentId)
+		}
+		queued[payment.PaymentId] = true
+		err = stub.Put(key, []byte(fmt.Sprint(payment.PaymentId)))
+		if err != nil {
+			logger.Errorf("Failed to add queue element to ledger")
+			return err
+		}
+		cmAmount, err := UnmarshalCommitment(scheme, payment.CmAmount)
+		if err != nil {
+			return err
+		}
+		cmSum = cmSum.Add(cmAmount)
+	}
+	return addQueueSumToLedger(stub, table, bankId, cmSum, count+int32(len(payments)))
+}
+
+//CheckQueueSpaceFromLedger checks that n more payments fit in the queue of bankId in table, so that a transaction
+//rejects them before it writes anything, Fabric does not roll back the writes of a failed invoke
+func CheckQueueSpaceFromLedger(stub ledger.Ledger, table string, bankId int32, n int) error {
+	storedSumBytes, err := stub.Get(queueSumKey(table, bankId))
+	if err != nil {
+		logger.Error("Failed to read queue sum table")
+		return err
+	}
+	storedSum := &pb.StoredPaymentSum{}
+	err = proto.Unmarshal(storedSumBytes, storedSum)
+	if err != nil {
+		logger.Error("Failed to unmarshal stored queue sum")
+		return err
+	}
+	return checkQueueSpace(table, bankId, storedSum.Count, n)
+}
+
+//checkQueueSpace checks that a queue of count payments can hold n more, only the outgoing queues are bounded
+func checkQueueSpace(table string, bankId int32, count int32, n int) error {
+	if strings.HasPrefix(table, OutQueueTable) && int(count)+n > MaxQueuedPayments {
+		logger.Errorf("The queue %s%d would hold more than %d payments", table, bankId, MaxQueuedPayments)
+		return fmt.Errorf("The outgoing queue of bank %d can not hold more than %d payments", bankId, MaxQueuedPayments)
+	}
+	return nil
+}
+
+//RemoveQueueElementFromLedger removes paymentIds from the queue of bankId in table and their cmAmount of scheme from
+//the sum of the queue, the key of each entry is found from the priority of the stored payment. A payment that is not
+//in the queue is rejected, its amount is not in the sum
+func RemoveQueueElementFromLedger(stub ledger.Ledger, scheme commitment.Scheme, table string, bankId int32, paymentIds []int32) error {
+	cmSum, count, err := getQueueSum(stub, scheme, table, bankId)
+	if err != nil {
+		return err
+	}
+	//the deletes are not visible to the reads of the transaction
+	removed := map[int32]bool{}
+	for _, paymentId := range paymentIds {
+		payment, err := GetPaymentFromLedger(stub, MessageTable+fmt.Sprint(paymentId))
+		if err != nil {
+			return err
+		}
+		key := QueueKey(table, bankId, payment.Priority, paymentId)
+		entry, err := stub.Get(key)
+		if err != nil {
+			logger.Error("Failed to read queue table")
+			return err
+		}
+		if entry == nil || removed[paymentId] {
+			logger.Errorf("Payment %d is not in the queue %s%d", paymentId, table, bankId)
+			return fmt.Errorf("Payment %d is not in the queue", paymentId)
+		}
+		removed[paymentId] = true
+		err = stub.Delete(key)
+		if err != nil {
+			logger.Errorf("Failed to remove queue element from ledger")
+			return err
+		}
+		cmAmount, err := UnmarshalCommitment(scheme, payment.CmAmount)
+		if err != nil {
+			return err
+		}
+		cmSum = commitment.Sub(cmSum, cmAmount)
+	}
+	if int(count) < len(removed) {
+		logger.Errorf("The queue %s%d counts %d payments, fewer than the %d removed", table, bankId, count, len(removed))
+		return fmt.Errorf("The queue of bank %d counts fewer payments than removed", bankId)
+	}
+	return addQueueSumToLedger(stub, table, bankId, cmSum, count-int32(len(removed)))
+}
+
+//queueSumKey returns the key of the sum of the queue of bankId in table, the table of a currency
+//has the sum table of the same currency
+func queueSumKey(table string, bankId int32) string {
+	if strings.HasPrefix(table, InQueueTable) {
+		return InSumTable + strings.TrimPrefix(table, InQueueTable) + fmt.Sprint(bankId)
+	}
+	return OutSumTable + strings.TrimPrefix(table, OutQueueTable) + fmt.Sprint(bankId)
+}
+
+//GetQueueSumFromLedger returns the sum of the cmAmount of the payments in the queue of bankId in table,
+//a commitment to 0 when the queue has never held a payment
+func GetQueueSumFromLedger(stub ledger.Ledger, scheme commitment.Scheme, table string, bankId int32) (commitment.Commitment, error) {
+	cmSum, _, err := getQueueSum(stub, scheme, table, bankId)
+	return cmSum, err
+}
+
+//getQueueSum returns the sum of the queue of bankId in table and the number of its payments
+func getQueueSum(stub ledger.Ledger, scheme commitment.Scheme, table string, bankId int32) (commitment.Commitment, int32, error) {
+	storedSumBytes, err := stub.Get(queueSumKey(table, bankId))
+	if err != nil {
+		logger.Error("Failed to read queue sum table")
+		return nil, 0, err
+	}
+	if storedSumBytes == nil {
+		//the queues added before the sums were kept have no sum
+		head, err := GetPaymentQueueHeadFromLedger(stub, table, bankId, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(head) > 0 {
+			logger.Errorf("No sum of the queue %s%d", table, bankId)
+			return nil, 0, fmt.Errorf("No sum of the queue %s%d, invoke migrateQueues first", table, bankId)
+		}
+		return scheme.Commit(big.NewInt(0), big.NewInt(0)), 0, nil
+	}
+	storedSum := &pb.StoredPaymentSum{}
+	err = proto.Unmarshal(storedSumBytes, storedSum)
+	if err != nil {
+		logger.Error("Failed to unmarshal stored queue sum")
+		return nil, 0, err
+	}
+	cmSum, err := UnmarshalCommitment(scheme, storedSum.CmSum)
+	if err != nil {
+		return nil, 0, err
+	}
+	if storedSum.Count == 0 {
+		//the sums stored before the payments were counted have the count of the queue
+		paymentIds, err := scanQueue(stub, table, bankId, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+		return cmSum, int32(len(paymentIds)), nil
+	}
+	return cmSum, storedSum.Count, nil
+}
+
+func addQueueSumToLedger(stub ledger.Ledger, table string, bankId int32, cmSum commitment.Commitment, count int32) error {
+	sumToStoreBytes, err := proto.Marshal(&pb.StoredPaymentSum{CmSum: cmSum.Marshal(), Count: count})
+	if err != nil {
+		logger.Errorf("Unable to marshal stored queue sum to protobuf")
+		return err
+	}
+	err = stub.Put(queueSumKey(table, bankId), sumToStoreBytes)
+	if err != nil {
+		logger.Errorf("Failed to add queue sum to ledger")
+		return err
+	}
+	return nil
+}
+
+//DeleteQueueSumsFromLedger removes the sums of the queues of all the banks in all the currencies,
+//e.g. when the commitment scheme changes while the queues are empty
+func DeleteQueueSumsFromLedger(stub ledger.Ledger) error {
+	currencies, err := GetCurrenciesFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	for _, currency := range currencies {
+		for bankId := int32(1); bankId <= NumOfBanks; bankId++ {
+			for _, table := range []string{InQueueTable, OutQueueTable} {
+				err := stub.Delete(queueSumKey(InCurrency(table, currency), bankId))
+				if err != nil {
+					logger.Errorf("Failed to delete queue sum from ledger")
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//MigrateQueuesFromLedger moves the queues stored as one StoredPaymentQueue per bank to their entries,
+//and computes the sums of the queues that have none, it returns the number of queues migrated.
+//Both predate the currencies, so only the queues of the default currency are migrated
+func MigrateQueuesFromLedger(stub ledger.Ledger) (int, error) {
+	scheme, err := GetCommitmentSchemeFromLedger(stub, DefaultCurrency)
+	if err != nil {
+		return 0, err
+	}
+	migrated := 0
+	for bankId := int32(1); bankId <= NumOfBanks; bankId++ {
+		for _, table := range []string{InQueueTable, OutQueueTable} {
+			key := table + fmt.Sprint(bankId)
+			storedQueueBytes, err := stub.Get(key)
+			if err != nil {
+				logger.Error("Failed to read queue table")
+				return 0, err
+			}
+			storedSumBytes, err := stub.Get(queueSumKey(table, bankId))
+			if err != nil {
+				logger.Error("Failed to read queue sum table")
+				return 0, err
+			}
+			var paymentIds []int32
+			if storedQueueBytes != nil {
+				storedQueue := &pb.StoredPaymentQueue{}
+				err = proto.Unmarshal(storedQueueBytes, storedQueue)
+				if err != nil {
+					logger.Error("Failed to unmarshal stored queue")
+					return 0, err
+				}
+				paymentIds = storedQueue.PaymentIds
+			} else if storedSumBytes == nil {
+				paymentIds, err = GetPaymentQueueFromLedger(stub, table, bankId)
+				if err != nil {
+					return 0, err
+				}
+			} else {
+				continue
+			}
+
+			cmSum := scheme.Commit(big.NewInt(0), big.NewInt(0))
+			for _, paymentId := range paymentIds {
+				payment, err := GetPaymentFromLedger(stub, MessageTable+fmt.Sprint(paymentId))
+				if err != nil {
+					return 0, err
+				}
+				if payment.Status == pb.StatusType_SETTLED {
+					return 0, errors.New("Settled payment in queue " + key)
+				}
+				if storedQueueBytes != nil {
+					err = stub.Put(QueueKey(table, bankId, payment.Priority, paymentId), []byte(fmt.Sprint(paymentId)))
+					if err != nil {
+						logger.Errorf("Failed to add queue element to ledger")
+						return 0, err
+					}
+				}
+				cmAmount, err := UnmarshalCommitment(scheme, payment.CmAmount)
+				if err != nil {
+					return 0, err
+				}
+				cmSum = cmSum.Add(cmAmount)
+			}
+			if storedQueueBytes != nil {
+				err = stub.Delete(key)
+				if err != nil {
+					logger.Errorf("Failed to delete queue from ledger")
+					return 0, err
+				}
+			}
+			err = addQueueSumToLedger(stub, table, bankId, cmSum, int32(len(paymentIds)))
+			if err != nil {
+				return 0, err
+			}
+			migrated++
+		}
+	}
+	return migrated, nil
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/zkrangeproof"
+)
+
+//the proposals prove that the balance, the credit limit and the incoming queue of a bank less its outgoing
+//payments are within [0,u^l), so their sum must stay below u^l or the proofs of honest banks fail.
+//The range is split in two halves: the total supply is below the first and the payments queued to a bank
+//are below the second. The payments are at most MaxPaymentAmount and every outgoing queue holds at most
+//MaxQueuedPayments of them, so the incoming queue of a bank sums to at most NumOfBanks*MaxQueuedPayments*MaxPaymentAmount
+const MaxQueuedPayments = 1000
+
+//MaxSupply returns the largest total supply of a currency, the sum of the balances of all the banks
+func MaxSupply(params *zkrangeproof.ParamsULVerifier) int64 {
+	half := new(big.Int).Rsh(params.UpperBound(), 1)
+	return clampInt64(half.Sub(half, big.NewInt(1)))
+}
+
+//MaxPaymentAmount returns the largest amount of a payment, the payment limits and minimums are at most this amount.
+//It is 0 when u^l is too small to queue MaxQueuedPayments payments of every bank
+func MaxPaymentAmount(params *zkrangeproof.ParamsULVerifier) int64 {
+	half := new(big.Int).Rsh(params.UpperBound(), 1)
+	return clampInt64(half.Div(half, big.NewInt(NumOfBanks*MaxQueuedPayments)))
+}
+
+func clampInt64(n *big.Int) int64 {
+	if !n.IsInt64() {
+		return math.MaxInt64
+	}
+	return n.Int64()
+}
@@ -0,0 +1,502 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bls12381 implements the BLS12-381 pairing-friendly curve with the
+// same API as package bn256. Its groups offer about 128 bits of security, where
+// the BN curve of package bn256 offers about 100 since the advances in the
+// number field sieve. Base field elements are kept in Montgomery form as six
+// 64-bit words.
+//
+// Points of G₁ and G₂ are encoded in the uncompressed form of the Zcash
+// serialization by Marshal: the big-endian affine coordinates, the imaginary
+// part of an element of GF(p²) first, with the point at infinity flagged by
+// 0x40 in its first byte. MarshalCompressed uses the compressed form, half the
+// size, and compresses elements of GT likewise. Unmarshal accepts both forms
+// and checks that a point is in its group.
+//
+// The pairing is the optimal ate pairing, raised to the third power to speed
+// up the final exponentiation.
+//
+// As in package bn256, ScalarBaseMultCT uses precomputed tables, NewG1Table
+// and NewG2Table precompute the multiples of other fixed elements, and
+// MultiScalarMult sums multiples of elements by public scalars with the bucket
+// method of Pippenger.
+package bls12381
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+)
+
+const (
+	// flagCompressed is set in the first byte of a compressed element.
+	flagCompressed = 0x80
+	// flagInfinity is set in the first byte of the point at infinity.
+	flagInfinity = 0x40
+	// flagSort is set in the first byte of a compressed point with the
+	// larger y-coordinate.
+	flagSort = 0x20
+	// flagMask covers the flags in the first byte of an encoded point.
+	flagMask = flagCompressed | flagInfinity | flagSort
+)
+
+// unmarshalFlags strips the flags from the encoded point m and returns its
+// coordinates, whether it is the point at infinity and whether the encoding is
+// valid.
+func unmarshalFlags(m []byte) ([]byte, bool, bool) {
+	flags := m[0] & flagMask
+	if flags&(flagCompressed|flagSort) != 0 {
+		return nil, false, false
+	}
+	coordinates := append([]byte{m[0] &^ flagMask}, m[1:]...)
+	if flags == flagInfinity {
+		// the point at infinity has no coordinates
+		for _, b := range coordinates {
+			if b != 0 {
+				return nil, false, false
+			}
+		}
+		return coordinates, true, true
+	}
+	return coordinates, false, true
+}
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 struct {
+	p *curvePoint
+}
+
+// RandomG1 returns x and g₁ˣ where x is a random, non-zero number read from r.
+func RandomG1(r io.Reader) (*big.Int, *G1, error) {
+	k, err := randomK(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, new(G1).ScalarBaseMult(k), nil
+}
+
+func randomK(r io.Reader) (*big.Int, error) {
+	for {
+		k, err := rand.Int(r, Order)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+// reduceScalar returns k mod Order, which leaves k untouched.
+func reduceScalar(k *big.Int) *big.Int {
+	if k.Sign() < 0 || k.Cmp(Order) >= 0 {
+		return new(big.Int).Mod(k, Order)
+	}
+	return k
+}
+
+func (e *G1) String() string {
+	return "bls12381.G1" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e. A negative k is reduced modulo Order.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	return e.ScalarMult(&G1{curveGen}, k)
+}
+
+// ScalarMult sets e to a*k and then returns e. A negative k is reduced modulo
+// Order.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Mul(a.p, reduceScalar(k))
+	return e
+}
+
+// ScalarBaseMultCT sets e to g*k where g is the generator of the group and
+// then returns e. It runs in constant time for secret scalars, adding entries
+// of a table of the multiples of g that is built on first use.
+func (e *G1) ScalarBaseMultCT(k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	curveGenMul(e.p, k)
+	return e
+}
+
+// ScalarMultCT sets e to a*k and then returns e. It runs in constant time for
+// secret scalars; only reducing a k outside of [0, Order) is not.
+func (e *G1) ScalarMultCT(a *G1, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.MulCT(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Negative(a.p)
+	return e
+}
+
+// Marshal converts n to a byte slice.
+func (e *G1) Marshal() []byte {
+	ret := make([]byte, numBytes*2)
+	if e.p.IsInfinity() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine()
+	e.p.x.Marshal(ret[0*numBytes:])
+	e.p.y.Marshal(ret[1*numBytes:])
+	return ret
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal or
+// MarshalCompressed back into a group element and then returns e.
+func (e *G1) Unmarshal(m []byte) (*G1, bool) {
+	if len(m) == numBytes {
+		return e.unmarshalCompressed(m)
+	}
+	if len(m) != 2*numBytes {
+		return nil, false
+	}
+	m, infinity, ok := unmarshalFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	if !e.p.x.Unmarshal(m[0*numBytes:1*numBytes]) ||
+		!e.p.y.Unmarshal(m[1*numBytes:2*numBytes]) {
+		return nil, false
+	}
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	if !e.p.IsOnCurve() {
+		return nil, false
+	}
+	// the curve has points outside of G₁, whose order is not Order
+	if !new(curvePoint).Mul(e.p, Order).IsInfinity() {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// SetInfinity sets e to the identity element of the group and returns e.
+func (e *G1) SetInfinity() *G1 {
+	e.p = &curvePoint{}
+	e.p.SetInfinity()
+	return e
+}
+
+// IsZero returns true iff e is the identity element of the group.
+func (e *G1) IsZero() bool {
+	return e.p.IsInfinity()
+}
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 struct {
+	p *twistPoint
+}
+
+// RandomG2 returns x and g₂ˣ where x is a random, non-zero number read from r.
+func RandomG2(r io.Reader) (*big.Int, *G2, error) {
+	k, err := randomK(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, new(G2).ScalarBaseMult(k), nil
+}
+
+func (e *G2) String() string {
+	return "bls12381.G2" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns out. A negative k is reduced modulo Order.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	return e.ScalarMult(&G2{twistGen}, k)
+}
+
+// ScalarMult sets e to a*k and then returns e. A negative k is reduced modulo
+// Order.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Mul(a.p, reduceScalar(k))
+	return e
+}
+
+// ScalarBaseMultCT sets e to g*k where g is the generator of the group and
+// then returns e. It runs in constant time for secret scalars, adding entries
+// of a table of the multiples of g that is built on first use.
+func (e *G2) ScalarBaseMultCT(k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	twistGenMul(e.p, k)
+	return e
+}
+
+// ScalarMultCT sets e to a*k and then returns e. It runs in constant time for
+// secret scalars; only reducing a k outside of [0, Order) is not.
+func (e *G2) ScalarMultCT(a *G2, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.MulCT(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Negative(a.p)
+	return e
+}
+
+// Marshal converts n into a byte slice.
+func (n *G2) Marshal() []byte {
+	ret := make([]byte, numBytes*4)
+	if n.p.IsInfinity() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	n.p.MakeAffine()
+	n.p.x.x.Marshal(ret[0*numBytes:])
+	n.p.x.y.Marshal(ret[1*numBytes:])
+	n.p.y.x.Marshal(ret[2*numBytes:])
+	n.p.y.y.Marshal(ret[3*numBytes:])
+	return ret
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal or
+// MarshalCompressed back into a group element and then returns e.
+func (e *G2) Unmarshal(m []byte) (*G2, bool) {
+	if len(m) == 2*numBytes {
+		return e.unmarshalCompressed(m)
+	}
+	if len(m) != 4*numBytes {
+		return nil, false
+	}
+	m, infinity, ok := unmarshalFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	if !e.p.x.x.Unmarshal(m[0*numBytes:1*numBytes]) ||
+		!e.p.x.y.Unmarshal(m[1*numBytes:2*numBytes]) ||
+		!e.p.y.x.Unmarshal(m[2*numBytes:3*numBytes]) ||
+		!e.p.y.y.Unmarshal(m[3*numBytes:4*numBytes]) {
+		return nil, false
+	}
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	if !e.p.IsOnCurve() {
+		return nil, false
+	}
+	// the twist has points outside of G₂, whose order is not Order
+	if !new(twistPoint).Mul(e.p, Order).IsInfinity() {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// SetInfinity sets e to the identity element of the group and returns e.
+func (e *G2) SetInfinity() *G2 {
+	e.p = &twistPoint{}
+	e.p.SetInfinity()
+	return e
+}
+
+// IsZero returns true iff e is the identity element of the group.
+func (e *G2) IsZero() bool {
+	return e.p.IsInfinity()
+}
+
+// GT is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type GT struct {
+	p *gfP12
+}
+
+func (g *GT) String() string {
+	return "bls12381.GT" + g.p.String()
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *GT) ScalarMult(a *GT, k *big.Int) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Exp(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *GT) Add(a, b *GT) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Mul(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *GT) Neg(a *GT) *GT {
+	return e.Invert(a)
+}
+
+// Invert sets e to -a and then returns e.
+func (e *GT) Invert(a *GT) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Invert(a.p)
+	return e
+}
+
+// IsZero returns true iff e is the zero element of the field GT is embedded in.
+func (e *GT) IsZero() bool {
+	return e.p.IsZero()
+}
+
+// IsOne returns true iff e is the identity element of the group.
+func (e *GT) IsOne() bool {
+	return e.p.IsOne()
+}
+
+// coefficients returns the base field coefficients of e in the order of Marshal.
+func (e *GT) coefficients() []*gfP {
+	return []*gfP{
+		&e.p.x.x.x, &e.p.x.x.y, &e.p.x.y.x, &e.p.x.y.y, &e.p.x.z.x, &e.p.x.z.y,
+		&e.p.y.x.x, &e.p.y.x.y, &e.p.y.y.x, &e.p.y.y.y, &e.p.y.z.x, &e.p.y.z.y,
+	}
+}
+
+// Marshal converts n into a byte slice.
+func (n *GT) Marshal() []byte {
+	ret := make([]byte, numBytes*12)
+	for i, c := range n.coefficients() {
+		c.Marshal(ret[i*numBytes:])
+	}
+	return ret
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal or
+// MarshalCompressed back into a group element and then returns e.
+func (e *GT) Unmarshal(m []byte) (*GT, bool) {
+	if len(m) == 6*numBytes {
+		return e.unmarshalCompressed(m)
+	}
+	if len(m) != 12*numBytes {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+
+	// elements of GT are only compared with computed pairings, so a canonical
+	// element outside of GT can not be mistaken for a valid one; only those
+	// that MarshalCompressed can not encode are rejected
+	for i, c := range e.coefficients() {
+		if !c.Unmarshal(m[i*numBytes : (i+1)*numBytes]) {
+			return nil, false
+		}
+	}
+	if !isUnitary(e.p) {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// Pair calculates an Optimal Ate pairing.
+func Pair(g1 *G1, g2 *G2) *GT {
+	return &GT{optimalAte(g2.p, g1.p)}
+}
+
+// PairingProduct returns the product of the pairings of a[i] and b[i]. The
+// Miller loops of the pairs share their squarings and a single final
+// exponentiation, which makes it much faster than multiplying the results of
+// Pair. It panics unless a and b have the same length.
+func PairingProduct(a []*G1, b []*G2) *GT {
+	qs, ps := pairPoints(a, b)
+	return &GT{pairingProduct(qs, ps)}
+}
+
+// PairingCheck returns true iff the product of the pairings of a[i] and b[i]
+// is one. It panics unless a and b have the same length.
+func PairingCheck(a []*G1, b []*G2) bool {
+	return PairingProduct(a, b).IsOne()
+}
+
+// pairPoints returns the points of the pairs (a[i], b[i]) in the argument
+// order of optimalAte.
+func pairPoints(a []*G1, b []*G2) ([]*twistPoint, []*curvePoint) {
+	if len(a) != len(b) {
+		panic("bls12381: pairing product with a different number of G1 and G2 elements")
+	}
+	ps := make([]*curvePoint, len(a))
+	qs := make([]*twistPoint, len(b))
+	for i := range a {
+		ps[i], qs[i] = a[i].p, b[i].p
+	}
+	return qs, ps
+}
@@ -0,0 +1,374 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestGFpArithmetic(t *testing.T) {
+	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
+	samples := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), pMinus1}
+	for i := 0; i < 20; i++ {
+		a, _ := rand.Int(rand.Reader, p)
+		samples = append(samples, a)
+	}
+
+	for _, a := range samples {
+		for _, b := range samples {
+			ea, eb := newGFpFromBig(a), newGFpFromBig(b)
+			var c gfP
+			gfpAdd(&c, &ea, &eb)
+			if want := new(big.Int).Mod(new(big.Int).Add(a, b), p); c.Big().Cmp(want) != 0 {
+				t.Fatalf("%s+%s: got %s, want %s", a, b, &c, want)
+			}
+			gfpSub(&c, &ea, &eb)
+			if want := new(big.Int).Mod(new(big.Int).Sub(a, b), p); c.Big().Cmp(want) != 0 {
+				t.Fatalf("%s-%s: got %s, want %s", a, b, &c, want)
+			}
+			gfpMul(&c, &ea, &eb)
+			if want := new(big.Int).Mod(new(big.Int).Mul(a, b), p); c.Big().Cmp(want) != 0 {
+				t.Fatalf("%s*%s: got %s, want %s", a, b, &c, want)
+			}
+		}
+		ea := newGFpFromBig(a)
+		inv := (&gfP{}).Invert(&ea)
+		if a.Sign() != 0 && inv.Big().Cmp(new(big.Int).ModInverse(a, p)) != 0 {
+			t.Fatalf("bad inverse of %s: %s", a, inv)
+		}
+	}
+}
+
+func TestGFpUnmarshal(t *testing.T) {
+	var buf [numBytes]byte
+	p.FillBytes(buf[:])
+	if (&gfP{}).Unmarshal(buf[:]) {
+		t.Error("unmarshaled p")
+	}
+	new(big.Int).Sub(p, big.NewInt(1)).FillBytes(buf[:])
+	var e gfP
+	if !e.Unmarshal(buf[:]) {
+		t.Fatal("failed to unmarshal p-1")
+	}
+	var out [numBytes]byte
+	e.Marshal(out[:])
+	if out != buf {
+		t.Error("p-1 does not round trip")
+	}
+}
+
+func randomGFp12(t *testing.T) *gfP12 {
+	a := &gfP12{}
+	for _, c := range []*gfP{
+		&a.x.x.x, &a.x.x.y, &a.x.y.x, &a.x.y.y, &a.x.z.x, &a.x.z.y,
+		&a.y.x.x, &a.y.x.y, &a.y.y.x, &a.y.y.y, &a.y.z.x, &a.y.z.y,
+	} {
+		k, err := rand.Int(rand.Reader, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*c = newGFpFromBig(k)
+	}
+	return a
+}
+
+func TestGFp12Invert(t *testing.T) {
+	a := randomGFp12(t)
+	inv := (&gfP12{}).Invert(a)
+
+	b := (&gfP12{}).Mul(inv, a)
+	if !b.IsOne() {
+		t.Fatalf("bad result for a^-1*a: %s", b)
+	}
+}
+
+// The Frobenius endomorphism must agree with raising to the p-th power.
+func TestGFp12Frobenius(t *testing.T) {
+	a := randomGFp12(t)
+	got := (&gfP12{}).Frobenius(a)
+	want := (&gfP12{}).Exp(a, p)
+	if *got != *want {
+		t.Fatalf("bad Frobenius: got %s, want %s", got, want)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	g1 := &curvePoint{}
+	g1.Set(curveGen)
+	if !g1.IsOnCurve() {
+		t.Error("generator of G1 is not on the curve")
+	}
+	g2 := &twistPoint{}
+	g2.Set(twistGen)
+	if !g2.IsOnCurve() {
+		t.Error("generator of G2 is not on the twist")
+	}
+}
+
+func TestOrderG1(t *testing.T) {
+	g := new(G1).SetInfinity()
+	g.p.Mul(curveGen, Order)
+	if !g.p.IsInfinity() {
+		t.Error("G1 has incorrect order")
+	}
+
+	one := new(G1).ScalarBaseMult(new(big.Int).SetInt64(1))
+	g.Add(g, one)
+	g.p.MakeAffine()
+	one.p.MakeAffine()
+	if g.p.x != one.p.x || g.p.y != one.p.y {
+		t.Errorf("1+0 != 1 in G1")
+	}
+}
+
+func TestOrderG2(t *testing.T) {
+	g := new(G2).SetInfinity()
+	g.p.Mul(twistGen, Order)
+	if !g.p.IsInfinity() {
+		t.Error("G2 has incorrect order")
+	}
+
+	one := new(G2).ScalarBaseMult(new(big.Int).SetInt64(1))
+	g.Add(g, one)
+	g.p.MakeAffine()
+	one.p.MakeAffine()
+	if g.p.x != one.p.x || g.p.y != one.p.y {
+		t.Errorf("1+0 != 1 in G2")
+	}
+}
+
+func TestOrderGT(t *testing.T) {
+	gt := Pair(&G1{curveGen}, &G2{twistGen})
+	if gt.IsOne() {
+		t.Fatal("the pairing of the generators is degenerate")
+	}
+	g := new(GT).ScalarMult(gt, Order)
+	if !g.p.IsOne() {
+		t.Error("GT has incorrect order")
+	}
+}
+
+// The hard part of the final exponentiation must match raising to
+// 3(p⁴-p²+1)/r directly.
+func TestFinalExponentiation(t *testing.T) {
+	a := randomGFp12(t)
+
+	p2 := new(big.Int).Mul(p, p)
+	p4 := new(big.Int).Mul(p2, p2)
+	p6 := new(big.Int).Mul(p4, p2)
+	easy := new(big.Int).Sub(p6, big.NewInt(1))
+	easy.Mul(easy, new(big.Int).Add(p2, big.NewInt(1)))
+	hard := new(big.Int).Sub(p4, p2)
+	hard.Add(hard, big.NewInt(1))
+	hard.Div(hard, Order)
+	hard.Mul(hard, big.NewInt(3))
+
+	want := (&gfP12{}).Exp(a, easy)
+	want.Exp(want, hard)
+	if got := finalExponentiation(a); *got != *want {
+		t.Fatalf("bad final exponentiation: got %s, want %s", got, want)
+	}
+}
+
+func TestBilinearity(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		a, p1, _ := RandomG1(rand.Reader)
+		b, p2, _ := RandomG2(rand.Reader)
+		e1 := Pair(p1, p2)
+
+		e2 := Pair(&G1{curveGen}, &G2{twistGen})
+		e2.ScalarMult(e2, a)
+		e2.ScalarMult(e2, b)
+
+		minusE2 := new(GT).Neg(e2)
+		e1.Add(e1, minusE2)
+
+		if !e1.p.IsOne() {
+			t.Fatalf("bad pairing result: %s", e1)
+		}
+	}
+}
+
+func TestPairingProduct(t *testing.T) {
+	_, p1, _ := RandomG1(rand.Reader)
+	_, p2, _ := RandomG2(rand.Reader)
+	_, q1, _ := RandomG1(rand.Reader)
+	_, q2, _ := RandomG2(rand.Reader)
+	inf1, inf2 := new(G1).SetInfinity(), new(G2).SetInfinity()
+
+	want := Pair(p1, p2)
+	want.Add(want, Pair(q1, q2))
+	got := PairingProduct([]*G1{p1, inf1, q1, p1}, []*G2{p2, q2, q2, inf2})
+	if !bytes.Equal(got.Marshal(), want.Marshal()) {
+		t.Error("PairingProduct differs from the product of the pairings")
+	}
+	if !bytes.Equal(PairingProduct([]*G1{p1}, []*G2{p2}).Marshal(), Pair(p1, p2).Marshal()) {
+		t.Error("PairingProduct of one pair differs from Pair")
+	}
+
+	// e(p1, p2)·e(-p1, p2) = 1
+	minusP1 := new(G1).Neg(p1)
+	if !PairingCheck([]*G1{p1, minusP1}, []*G2{p2, p2}) {
+		t.Error("e(p1, p2)·e(-p1, p2) != 1")
+	}
+	if PairingCheck([]*G1{p1, minusP1}, []*G2{p2, q2}) {
+		t.Error("e(p1, p2)·e(-p1, q2) == 1")
+	}
+	if !PairingCheck(nil, nil) || !PairingCheck([]*G1{inf1}, []*G2{p2}) {
+		t.Error("empty product != 1")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("PairingCheck with more G1 than G2 elements did not panic")
+		}
+	}()
+	PairingCheck([]*G1{p1, q1}, []*G2{p2})
+}
+
+func TestPairInfinity(t *testing.T) {
+	_, p1, _ := RandomG1(rand.Reader)
+	_, p2, _ := RandomG2(rand.Reader)
+	if !Pair(new(G1).SetInfinity(), p2).IsOne() || !Pair(p1, new(G2).SetInfinity()).IsOne() {
+		t.Error("pairing with ∞ is not 1")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	_, a, _ := RandomG1(rand.Reader)
+	_, b, _ := RandomG2(rand.Reader)
+	c := Pair(a, b)
+
+	for _, g := range []*G1{a, new(G1).SetInfinity()} {
+		m := g.Marshal()
+		g2, ok := new(G1).Unmarshal(m)
+		if !ok || !bytes.Equal(g2.Marshal(), m) {
+			t.Errorf("G1 %x does not round trip", m)
+		}
+	}
+	for _, g := range []*G2{b, new(G2).SetInfinity()} {
+		m := g.Marshal()
+		g2, ok := new(G2).Unmarshal(m)
+		if !ok || !bytes.Equal(g2.Marshal(), m) {
+			t.Errorf("G2 %x does not round trip", m)
+		}
+	}
+	m := c.Marshal()
+	c2, ok := new(GT).Unmarshal(m)
+	if !ok || !bytes.Equal(c2.Marshal(), m) {
+		t.Errorf("GT does not round trip")
+	}
+}
+
+// The encoding of the generators follows the Zcash serialization.
+func TestMarshalGenerators(t *testing.T) {
+	g1 := new(G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+	if want := "17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"; !bytes.Equal(g1[:numBytes], bigFromBase16(want).FillBytes(make([]byte, numBytes))) {
+		t.Errorf("bad encoding of the generator of G1: %x", g1)
+	}
+	g2 := new(G2).ScalarBaseMult(big.NewInt(1)).Marshal()
+	if want := "13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e"; !bytes.Equal(g2[:numBytes], bigFromBase16(want).FillBytes(make([]byte, numBytes))) {
+		t.Errorf("bad encoding of the generator of G2: %x", g2)
+	}
+}
+
+func TestUnmarshalFlags(t *testing.T) {
+	_, a, _ := RandomG1(rand.Reader)
+	inf := new(G1).SetInfinity().Marshal()
+
+	for _, tc := range []struct {
+		name string
+		m    []byte
+	}{
+		{"compressed", append([]byte{a.Marshal()[0] | flagCompressed}, a.Marshal()[1:]...)},
+		{"sorted", append([]byte{a.Marshal()[0] | flagSort}, a.Marshal()[1:]...)},
+		{"infinity with coordinates", append([]byte{a.Marshal()[0] | flagInfinity}, a.Marshal()[1:]...)},
+		{"all zero", make([]byte, 2*numBytes)},
+		{"short", inf[1:]},
+	} {
+		if _, ok := new(G1).Unmarshal(tc.m); ok {
+			t.Errorf("unmarshaled %s encoding", tc.name)
+		}
+	}
+}
+
+func TestScalarMultReduces(t *testing.T) {
+	k := big.NewInt(-7)
+	want := new(G1).ScalarBaseMult(new(big.Int).Add(Order, k)).Marshal()
+	if got := new(G1).ScalarBaseMult(k).Marshal(); !bytes.Equal(got, want) {
+		t.Error("negative scalar of G1 is not reduced modulo Order")
+	}
+	want = new(G2).ScalarBaseMult(new(big.Int).Add(Order, k)).Marshal()
+	if got := new(G2).ScalarBaseMult(k).Marshal(); !bytes.Equal(got, want) {
+		t.Error("negative scalar of G2 is not reduced modulo Order")
+	}
+}
+
+func TestHashToG2(t *testing.T) {
+	g := HashToG2([]byte("gridlock"))
+	if !g.p.IsOnCurve() {
+		t.Fatal("hashed point is not on the twist")
+	}
+	if !new(twistPoint).Mul(g.p, Order).IsInfinity() {
+		t.Fatal("hashed point is not in G2")
+	}
+	if _, ok := new(G2).Unmarshal(g.Marshal()); !ok {
+		t.Error("failed to unmarshal hashed point")
+	}
+}
+
+func TestG1UnmarshalOutsideSubgroup(t *testing.T) {
+	for x := int64(1); ; x++ {
+		// y² = x³ + 4
+		yy := big.NewInt(x*x*x + 4)
+		y := new(big.Int).ModSqrt(yy, p)
+		if y == nil {
+			continue
+		}
+		pt := &G1{&curvePoint{x: newGFp(x), y: newGFpFromBig(y)}}
+		pt.p.z.SetOne()
+		pt.p.t.SetOne()
+		if !pt.p.IsOnCurve() {
+			t.Fatal("sample point is not on the curve")
+		}
+		if _, ok := new(G1).Unmarshal(pt.Marshal()); ok {
+			t.Fatal("unmarshaled a point outside of G1")
+		}
+		if _, ok := new(G1).Unmarshal(pt.MarshalCompressed()); ok {
+			t.Fatal("unmarshaled a compressed point outside of G1")
+		}
+		return
+	}
+}
+
+func TestG2UnmarshalOutsideSubgroup(t *testing.T) {
+	for ctr := uint32(0); ; ctr++ {
+		x := hashToGFp2([]byte("gridlock subgroup test"), ctr)
+		yy := (&gfP2{}).Square(x)
+		yy.Mul(yy, x)
+		yy.Add(yy, twistB)
+		y, ok := sqrtGFp2(yy)
+		if !ok {
+			continue
+		}
+		// a point of the twist without the cofactor cleared
+		pt := &G2{&twistPoint{x: *x, y: *y}}
+		pt.p.z.SetOne()
+		pt.p.t.SetOne()
+		if !pt.p.IsOnCurve() {
+			t.Fatal("sample point is not on the twist")
+		}
+		if _, ok := new(G2).Unmarshal(pt.Marshal()); ok {
+			t.Fatal("unmarshaled a point outside of G2")
+		}
+		if _, ok := new(G2).Unmarshal(pt.MarshalCompressed()); ok {
+			t.Fatal("unmarshaled a compressed point outside of G2")
+		}
+		return
+	}
+}
@@ -0,0 +1,267 @@
+package bls12381
+
+import "math/big"
+
+// Compressed encodings, in the compressed form of the Zcash serialization. A
+// point of G₁ or G₂ is encoded by its x-coordinate, in 48 or 96 bytes, with
+// flagCompressed set, flagInfinity marking the point at infinity, whose other
+// bits are zero, and flagSort the larger of the two y-coordinates that go with
+// x. An element of GT is encoded in 288 bytes by the element c of GF(p⁶) such
+// that it is (c+ω)/(c-ω), with the flags of the points.
+//
+// Decoding is strict: every element has a single compressed encoding, and
+// Unmarshal rejects the others as well as the x without a point of the group.
+
+// pMinus1Over2 is (p-1)/2, the largest of the smaller elements of GF(p).
+var pMinus1Over2 = new(big.Int).Rsh(p, 1)
+
+// isLargerGFp returns true iff a > -a, seen as integers in [0, p).
+func isLargerGFp(a *gfP) bool {
+	return a.Big().Cmp(pMinus1Over2) > 0
+}
+
+// isLargerGFp2 returns true iff a > -a in the order of their encodings, which
+// compares the imaginary parts first.
+func isLargerGFp2(a *gfP2) bool {
+	if !a.x.IsZero() {
+		return isLargerGFp(&a.x)
+	}
+	return isLargerGFp(&a.y)
+}
+
+// sqrtGFp returns a square root of a and whether one exists.
+func sqrtGFp(a *gfP) (*gfP, bool) {
+	y := new(big.Int).ModSqrt(a.Big(), p)
+	if y == nil {
+		return nil, false
+	}
+	r := newGFpFromBig(y)
+	return &r, true
+}
+
+// unmarshalCompressedFlags strips the flags from the compressed encoding m and
+// returns the rest, whether it is the identity, whether flagSort is set and
+// whether the flags are valid.
+func unmarshalCompressedFlags(m []byte) ([]byte, bool, bool, bool) {
+	flags := m[0] & flagMask
+	if flags&flagCompressed == 0 {
+		return nil, false, false, false
+	}
+	rest := append([]byte{m[0] &^ flagMask}, m[1:]...)
+	if flags&flagInfinity == 0 {
+		return rest, false, flags&flagSort != 0, true
+	}
+	if flags != flagCompressed|flagInfinity {
+		return nil, false, false, false
+	}
+	for _, b := range rest {
+		if b != 0 {
+			return nil, false, false, false
+		}
+	}
+	return rest, true, false, true
+}
+
+// MarshalCompressed converts e into 48 bytes, half the size of Marshal.
+func (e *G1) MarshalCompressed() []byte {
+	ret := make([]byte, numBytes)
+	if e.p.IsInfinity() {
+		ret[0] = flagCompressed | flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine()
+	e.p.x.Marshal(ret)
+	ret[0] |= flagCompressed
+	if isLargerGFp(&e.p.y) {
+		ret[0] |= flagSort
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the point encoded by MarshalCompressed in m.
+func (e *G1) unmarshalCompressed(m []byte) (*G1, bool) {
+	x, infinity, sort, ok := unmarshalCompressedFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	if !e.p.x.Unmarshal(x) {
+		return nil, false
+	}
+
+	// y² = x³ + b
+	yy := &gfP{}
+	gfpMul(yy, &e.p.x, &e.p.x)
+	gfpMul(yy, yy, &e.p.x)
+	gfpAdd(yy, yy, &curveB)
+	y, ok := sqrtGFp(yy)
+	if !ok {
+		return nil, false
+	}
+	if isLargerGFp(y) != sort {
+		gfpNeg(y, y)
+		// y = 0 has no larger root
+		if isLargerGFp(y) != sort {
+			return nil, false
+		}
+	}
+	e.p.y = *y
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	// the curve has points outside of G₁, whose order is not Order
+	if !new(curvePoint).Mul(e.p, Order).IsInfinity() {
+		return nil, false
+	}
+	return e, true
+}
+
+// MarshalCompressed converts e into 96 bytes, half the size of Marshal.
+func (e *G2) MarshalCompressed() []byte {
+	ret := make([]byte, numBytes*2)
+	if e.p.IsInfinity() {
+		ret[0] = flagCompressed | flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine()
+	e.p.x.x.Marshal(ret[0*numBytes:])
+	e.p.x.y.Marshal(ret[1*numBytes:])
+	ret[0] |= flagCompressed
+	if isLargerGFp2(&e.p.y) {
+		ret[0] |= flagSort
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the point encoded by MarshalCompressed in m.
+func (e *G2) unmarshalCompressed(m []byte) (*G2, bool) {
+	x, infinity, sort, ok := unmarshalCompressedFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	if !e.p.x.x.Unmarshal(x[0*numBytes:1*numBytes]) ||
+		!e.p.x.y.Unmarshal(x[1*numBytes:2*numBytes]) {
+		return nil, false
+	}
+
+	// y² = x³ + b'
+	yy := (&gfP2{}).Square(&e.p.x)
+	yy.Mul(yy, &e.p.x)
+	yy.Add(yy, twistB)
+	y, ok := sqrtGFp2(yy)
+	if !ok {
+		return nil, false
+	}
+	if isLargerGFp2(y) != sort {
+		y.Negative(y)
+		// y = 0 has no larger root
+		if isLargerGFp2(y) != sort {
+			return nil, false
+		}
+	}
+	e.p.y = *y
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	// the twist has points outside of G₂, whose order is not Order
+	if !new(twistPoint).Mul(e.p, Order).IsInfinity() {
+		return nil, false
+	}
+	return e, true
+}
+
+// tau is τ = ω², which is not a square in GF(p⁶).
+var tau = &gfP6{y: gfP2{y: newGFp(1)}}
+
+// isUnitary returns true iff a·ā = 1, where ā is the conjugate of a over
+// GF(p⁶), as for every element of GT, and a is not -1, the only such element
+// of order 2. Those are the elements that GT compression encodes.
+func isUnitary(a *gfP12) bool {
+	// (xω + y)(-xω + y) = y² - x²τ
+	n := (&gfP6{}).Square(&a.x)
+	n.MulTau(n)
+	n.Sub((&gfP6{}).Square(&a.y), n)
+	if !n.IsOne() {
+		return false
+	}
+	return !a.x.IsZero() || a.y.IsOne()
+}
+
+// MarshalCompressed converts e into 288 bytes, half the size of Marshal. The
+// element xω + y ≠ 1 of GT is encoded by c = (1+y)/x, from which it is
+// (c²+τ)/(c²-τ) + 2c/(c²-τ)·ω.
+func (e *GT) MarshalCompressed() []byte {
+	ret := make([]byte, numBytes*6)
+	if e.p.IsOne() {
+		ret[0] = flagCompressed | flagInfinity
+		return ret
+	}
+
+	c := (&gfP6{}).SetOne()
+	c.Add(c, &e.p.y)
+	c.Mul(c, (&gfP6{}).Invert(&e.p.x))
+	for i, v := range []*gfP{&c.x.x, &c.x.y, &c.y.x, &c.y.y, &c.z.x, &c.z.y} {
+		v.Marshal(ret[i*numBytes:])
+	}
+	ret[0] |= flagCompressed
+	return ret
+}
+
+// unmarshalCompressed sets e to the element encoded by MarshalCompressed in m.
+func (e *GT) unmarshalCompressed(m []byte) (*GT, bool) {
+	m, one, sort, ok := unmarshalCompressedFlags(m)
+	if !ok || sort {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+
+	if one {
+		e.p.SetOne()
+		return e, true
+	}
+
+	c := &gfP6{}
+	for i, v := range []*gfP{&c.x.x, &c.x.y, &c.y.x, &c.y.y, &c.z.x, &c.z.y} {
+		if !v.Unmarshal(m[i*numBytes : (i+1)*numBytes]) {
+			return nil, false
+		}
+	}
+	// c = 0 would encode -1, which is not in GT
+	if c.IsZero() {
+		return nil, false
+	}
+
+	// c² - τ is not zero since τ is not a square
+	cc := (&gfP6{}).Square(c)
+	d := (&gfP6{}).Sub(cc, tau)
+	d.Invert(d)
+	e.p.y.Add(cc, tau)
+	e.p.y.Mul(&e.p.y, d)
+	e.p.x.Double(c)
+	e.p.x.Mul(&e.p.x, d)
+	return e, true
+}
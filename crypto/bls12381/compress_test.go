@@ -0,0 +1,112 @@
+package bls12381
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestMarshalCompressed(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		_, a, _ := RandomG1(rand.Reader)
+		_, b, _ := RandomG2(rand.Reader)
+		c := Pair(a, b)
+		for _, g := range []*G1{a, new(G1).Neg(a), new(G1).SetInfinity()} {
+			m := g.MarshalCompressed()
+			g2, ok := new(G1).Unmarshal(m)
+			if len(m) != numBytes || !ok || !bytes.Equal(g2.Marshal(), g.Marshal()) || !bytes.Equal(g2.MarshalCompressed(), m) {
+				t.Errorf("G1 %x does not round trip", m)
+			}
+		}
+		for _, g := range []*G2{b, new(G2).Neg(b), new(G2).SetInfinity()} {
+			m := g.MarshalCompressed()
+			g2, ok := new(G2).Unmarshal(m)
+			if len(m) != 2*numBytes || !ok || !bytes.Equal(g2.Marshal(), g.Marshal()) || !bytes.Equal(g2.MarshalCompressed(), m) {
+				t.Errorf("G2 %x does not round trip", m)
+			}
+		}
+		for _, g := range []*GT{c, new(GT).Neg(c), Pair(a, new(G2).SetInfinity())} {
+			m := g.MarshalCompressed()
+			g2, ok := new(GT).Unmarshal(m)
+			if len(m) != 6*numBytes || !ok || !bytes.Equal(g2.Marshal(), g.Marshal()) || !bytes.Equal(g2.MarshalCompressed(), m) {
+				t.Errorf("GT %x does not round trip", m)
+			}
+		}
+	}
+}
+
+// The compressed encoding of the generators follows the Zcash serialization.
+func TestMarshalCompressedGenerators(t *testing.T) {
+	g1 := "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"
+	if m := new(G1).ScalarBaseMult(big.NewInt(1)).MarshalCompressed(); hex.EncodeToString(m) != g1 {
+		t.Errorf("bad compressed encoding of the generator of G1: %x", m)
+	}
+	g2 := "93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+		"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8"
+	if m := new(G2).ScalarBaseMult(big.NewInt(1)).MarshalCompressed(); hex.EncodeToString(m) != g2 {
+		t.Errorf("bad compressed encoding of the generator of G2: %x", m)
+	}
+}
+
+func TestUnmarshalCompressedStrict(t *testing.T) {
+	_, a, _ := RandomG1(rand.Reader)
+	_, b, _ := RandomG2(rand.Reader)
+	c := Pair(a, b)
+
+	with := func(m []byte, flags byte) []byte {
+		return append([]byte{m[0]&^flagMask | flags}, m[1:]...)
+	}
+	pBytes := p.FillBytes(make([]byte, numBytes))
+
+	for _, tc := range []struct {
+		name string
+		m    []byte
+	}{
+		{"uncompressed flags", with(a.MarshalCompressed(), 0)},
+		{"∞ with flagSort", with(new(G1).SetInfinity().MarshalCompressed(), flagMask)},
+		{"∞ with x", with(a.MarshalCompressed(), flagCompressed|flagInfinity)},
+		{"x = p", with(pBytes, flagCompressed)},
+	} {
+		if _, ok := new(G1).Unmarshal(tc.m); ok {
+			t.Errorf("G1 Unmarshal accepted %s", tc.name)
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		m    []byte
+	}{
+		{"uncompressed flags", with(b.MarshalCompressed(), 0)},
+		{"∞ with flagSort", with(new(G2).SetInfinity().MarshalCompressed(), flagMask)},
+		{"∞ with x", with(b.MarshalCompressed(), flagCompressed|flagInfinity)},
+		{"x.y = p", append(append([]byte{}, b.MarshalCompressed()[:numBytes]...), pBytes...)},
+	} {
+		if _, ok := new(G2).Unmarshal(tc.m); ok {
+			t.Errorf("G2 Unmarshal accepted %s", tc.name)
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		m    []byte
+	}{
+		{"uncompressed flags", with(c.MarshalCompressed(), 0)},
+		{"flagSort", with(c.MarshalCompressed(), flagCompressed|flagSort)},
+		{"c = 0", with(make([]byte, 6*numBytes), flagCompressed)},
+	} {
+		if _, ok := new(GT).Unmarshal(tc.m); ok {
+			t.Errorf("GT Unmarshal accepted %s", tc.name)
+		}
+	}
+
+	// the other root of a valid x decodes to the negated point
+	if g, ok := new(G2).Unmarshal(with(b.MarshalCompressed(), b.MarshalCompressed()[0]&flagMask^flagSort)); !ok || !bytes.Equal(g.Marshal(), new(G2).Neg(b).Marshal()) {
+		t.Error("G2 with flagSort flipped is not -b")
+	}
+
+	minusOne := &GT{(&gfP12{}).SetOne()}
+	minusOne.p.Negative(minusOne.p)
+	if _, ok := new(GT).Unmarshal(minusOne.Marshal()); ok {
+		t.Error("GT Unmarshal accepted -1")
+	}
+}
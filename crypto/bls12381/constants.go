@@ -0,0 +1,40 @@
+package bls12381
+
+import (
+	"math/big"
+)
+
+func bigFromBase16(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+func gfpFromBase16(s string) gfP {
+	return newGFpFromBig(bigFromBase16(s))
+}
+
+// z is the BLS parameter that determines the prime, -0xd201000000010000.
+var z = new(big.Int).Neg(bigFromBase16("d201000000010000"))
+
+// zAbs is |z|, the loop parameter of the Miller loop and of the exponentiations
+// of the final exponentiation.
+var zAbs = new(big.Int).Abs(z)
+
+// p is a prime over which we form a basic field: (z-1)²(z⁴-z²+1)/3+z.
+var p = bigFromBase16("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab")
+
+// Order is the number of elements in both G₁ and G₂: z⁴-z²+1.
+var Order = bigFromBase16("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+
+// xi is ξ = i+1, the non-residue of the towering.
+var xi = &gfP2{newGFp(1), newGFp(1)}
+
+// The constants of the Frobenius endomorphism, which are powers of ξ.
+var (
+	// xiToPMinus1Over6 is ξ^((p-1)/6).
+	xiToPMinus1Over6 = (&gfP2{}).Exp(xi, new(big.Int).Div(new(big.Int).Sub(p, big.NewInt(1)), big.NewInt(6)))
+	// xiToPMinus1Over3 is ξ^((p-1)/3).
+	xiToPMinus1Over3 = (&gfP2{}).Square(xiToPMinus1Over6)
+	// xiTo2PMinus2Over3 is ξ^((2p-2)/3).
+	xiTo2PMinus2Over3 = (&gfP2{}).Square(xiToPMinus1Over3)
+)
@@ -0,0 +1,302 @@
+package bls12381
+
+import (
+	"crypto/subtle"
+	"math/big"
+)
+
+// Constant-time scalar multiplication. The points are kept in homogeneous
+// projective coordinates (X:Y:Z), with x=X/Z and y=Y/Z, and added with the
+// complete formulas for a=0 of "Complete addition formulas for prime order
+// elliptic curves", Renes, Costello and Batina, algorithms 7 and 9
+// (https://eprint.iacr.org/2015/1060.pdf). They have no exceptional cases for
+// points of odd order, so the sequence of field operations does not depend on
+// the scalar. Field operations are branch free, and the table lookups read
+// every entry.
+
+// ctWindow is the number of bits of the scalar consumed per addition.
+const ctWindow = 4
+
+// curveB3 is 3b for the curve.
+var curveB3 = newGFp(12)
+
+// twistB3 is 3b for the twist.
+var twistB3 = (&gfP2{}).Add(twistB, (&gfP2{}).Double(twistB))
+
+// scalarWindows returns k mod Order as 256/ctWindow windows of ctWindow bits,
+// most significant first. Only the reduction of k out of [0, Order) is not
+// constant time.
+func scalarWindows(k *big.Int) []int {
+	if k.Sign() < 0 || k.Cmp(Order) >= 0 {
+		k = new(big.Int).Mod(k, Order)
+	}
+	var buf [32]byte
+	k.FillBytes(buf[:])
+	windows := make([]int, 0, 2*len(buf))
+	for _, b := range buf {
+		windows = append(windows, int(b>>4), int(b&0xf))
+	}
+	return windows
+}
+
+// curveProjective is a point of the curve in homogeneous projective
+// coordinates. The point at infinity is (0:1:0).
+type curveProjective struct {
+	x, y, z gfP
+}
+
+func (c *curveProjective) SetInfinity() {
+	c.x.SetZero()
+	c.y.SetOne()
+	c.z.SetZero()
+}
+
+// SetJacobian sets c to the point a, (x:y:z) ↦ (xz:y:z³).
+func (c *curveProjective) SetJacobian(a *curvePoint) {
+	var inf curveProjective
+	inf.SetInfinity()
+	var z2 gfP
+	gfpMul(&c.x, &a.x, &a.z)
+	c.y = a.y
+	gfpMul(&z2, &a.z, &a.z)
+	gfpMul(&c.z, &z2, &a.z)
+	c.Select(&inf, c, boolToInt(a.IsInfinity()))
+}
+
+// Jacobian sets out to the point c, (X:Y:Z) ↦ (XZ:YZ²:Z).
+func (c *curveProjective) Jacobian(out *curvePoint) {
+	var z2 gfP
+	gfpMul(&z2, &c.z, &c.z)
+	gfpMul(&out.x, &c.x, &c.z)
+	gfpMul(&out.y, &c.y, &z2)
+	out.z = c.z
+	out.t = z2
+}
+
+func (c *curveProjective) Select(a, b *curveProjective, cond int) {
+	gfpSelect(&c.x, &a.x, &b.x, cond)
+	gfpSelect(&c.y, &a.y, &b.y, cond)
+	gfpSelect(&c.z, &a.z, &b.z, cond)
+}
+
+// Add sets c to a+b, for any a and b.
+func (c *curveProjective) Add(a, b *curveProjective) {
+	var t0, t1, t2, t3, t4, x3, y3, z3 gfP
+	gfpMul(&t0, &a.x, &b.x)
+	gfpMul(&t1, &a.y, &b.y)
+	gfpMul(&t2, &a.z, &b.z)
+	gfpAdd(&t3, &a.x, &a.y)
+	gfpAdd(&t4, &b.x, &b.y)
+	gfpMul(&t3, &t3, &t4)
+	gfpAdd(&t4, &t0, &t1)
+	gfpSub(&t3, &t3, &t4)
+	gfpAdd(&t4, &a.y, &a.z)
+	gfpAdd(&x3, &b.y, &b.z)
+	gfpMul(&t4, &t4, &x3)
+	gfpAdd(&x3, &t1, &t2)
+	gfpSub(&t4, &t4, &x3)
+	gfpAdd(&x3, &a.x, &a.z)
+	gfpAdd(&y3, &b.x, &b.z)
+	gfpMul(&x3, &x3, &y3)
+	gfpAdd(&y3, &t0, &t2)
+	gfpSub(&y3, &x3, &y3)
+	gfpAdd(&x3, &t0, &t0)
+	gfpAdd(&t0, &x3, &t0)
+	gfpMul(&t2, &curveB3, &t2)
+	gfpAdd(&z3, &t1, &t2)
+	gfpSub(&t1, &t1, &t2)
+	gfpMul(&y3, &curveB3, &y3)
+	gfpMul(&x3, &t4, &y3)
+	gfpMul(&t2, &t3, &t1)
+	gfpSub(&x3, &t2, &x3)
+	gfpMul(&y3, &y3, &t0)
+	gfpMul(&t1, &t1, &z3)
+	gfpAdd(&y3, &t1, &y3)
+	gfpMul(&t0, &t0, &t3)
+	gfpMul(&z3, &z3, &t4)
+	gfpAdd(&z3, &z3, &t0)
+	c.x, c.y, c.z = x3, y3, z3
+}
+
+// Double sets c to 2a, for any a.
+func (c *curveProjective) Double(a *curveProjective) {
+	var t0, t1, t2, x3, y3, z3 gfP
+	gfpMul(&t0, &a.y, &a.y)
+	gfpAdd(&z3, &t0, &t0)
+	gfpAdd(&z3, &z3, &z3)
+	gfpAdd(&z3, &z3, &z3)
+	gfpMul(&t1, &a.y, &a.z)
+	gfpMul(&t2, &a.z, &a.z)
+	gfpMul(&t2, &curveB3, &t2)
+	gfpMul(&x3, &t2, &z3)
+	gfpAdd(&y3, &t0, &t2)
+	gfpMul(&z3, &t1, &z3)
+	gfpAdd(&t1, &t2, &t2)
+	gfpAdd(&t2, &t1, &t2)
+	gfpSub(&t0, &t0, &t2)
+	gfpMul(&y3, &t0, &y3)
+	gfpAdd(&y3, &x3, &y3)
+	gfpMul(&t1, &a.x, &a.y)
+	gfpMul(&x3, &t0, &t1)
+	gfpAdd(&x3, &x3, &x3)
+	c.x, c.y, c.z = x3, y3, z3
+}
+
+// MulCT sets c to (k mod Order)·a in constant time and returns c.
+func (c *curvePoint) MulCT(a *curvePoint, k *big.Int) *curvePoint {
+	var table [1 << ctWindow]curveProjective
+	table[0].SetInfinity()
+	table[1].SetJacobian(a)
+	for i := 2; i < len(table); i++ {
+		table[i].Add(&table[i-1], &table[1])
+	}
+
+	var sum, t curveProjective
+	sum.SetInfinity()
+	for _, w := range scalarWindows(k) {
+		for i := 0; i < ctWindow; i++ {
+			sum.Double(&sum)
+		}
+		for i := range table {
+			t.Select(&table[i], &t, subtle.ConstantTimeEq(int32(i), int32(w)))
+		}
+		sum.Add(&sum, &t)
+	}
+
+	sum.Jacobian(c)
+	return c
+}
+
+// twistProjective is a point of the twist in homogeneous projective
+// coordinates. The point at infinity is (0:1:0).
+type twistProjective struct {
+	x, y, z gfP2
+}
+
+func (c *twistProjective) SetInfinity() {
+	c.x.SetZero()
+	c.y.SetOne()
+	c.z.SetZero()
+}
+
+// SetJacobian sets c to the point a, (x:y:z) ↦ (xz:y:z³).
+func (c *twistProjective) SetJacobian(a *twistPoint) {
+	var inf twistProjective
+	inf.SetInfinity()
+	c.x.Mul(&a.x, &a.z)
+	c.y = a.y
+	c.z.Square(&a.z)
+	c.z.Mul(&c.z, &a.z)
+	c.Select(&inf, c, boolToInt(a.IsInfinity()))
+}
+
+// Jacobian sets out to the point c, (X:Y:Z) ↦ (XZ:YZ²:Z).
+func (c *twistProjective) Jacobian(out *twistPoint) {
+	z2 := (&gfP2{}).Square(&c.z)
+	out.x.Mul(&c.x, &c.z)
+	out.y.Mul(&c.y, z2)
+	out.z = c.z
+	out.t = *z2
+}
+
+func (c *twistProjective) Select(a, b *twistProjective, cond int) {
+	c.x.Select(&a.x, &b.x, cond)
+	c.y.Select(&a.y, &b.y, cond)
+	c.z.Select(&a.z, &b.z, cond)
+}
+
+// Add sets c to a+b, for any a and b of odd order.
+func (c *twistProjective) Add(a, b *twistProjective) {
+	var t0, t1, t2, t3, t4, x3, y3, z3 gfP2
+	t0.Mul(&a.x, &b.x)
+	t1.Mul(&a.y, &b.y)
+	t2.Mul(&a.z, &b.z)
+	t3.Add(&a.x, &a.y)
+	t4.Add(&b.x, &b.y)
+	t3.Mul(&t3, &t4)
+	t4.Add(&t0, &t1)
+	t3.Sub(&t3, &t4)
+	t4.Add(&a.y, &a.z)
+	x3.Add(&b.y, &b.z)
+	t4.Mul(&t4, &x3)
+	x3.Add(&t1, &t2)
+	t4.Sub(&t4, &x3)
+	x3.Add(&a.x, &a.z)
+	y3.Add(&b.x, &b.z)
+	x3.Mul(&x3, &y3)
+	y3.Add(&t0, &t2)
+	y3.Sub(&x3, &y3)
+	x3.Add(&t0, &t0)
+	t0.Add(&x3, &t0)
+	t2.Mul(twistB3, &t2)
+	z3.Add(&t1, &t2)
+	t1.Sub(&t1, &t2)
+	y3.Mul(twistB3, &y3)
+	x3.Mul(&t4, &y3)
+	t2.Mul(&t3, &t1)
+	x3.Sub(&t2, &x3)
+	y3.Mul(&y3, &t0)
+	t1.Mul(&t1, &z3)
+	y3.Add(&t1, &y3)
+	t0.Mul(&t0, &t3)
+	z3.Mul(&z3, &t4)
+	z3.Add(&z3, &t0)
+	c.x, c.y, c.z = x3, y3, z3
+}
+
+// Double sets c to 2a, for any a.
+func (c *twistProjective) Double(a *twistProjective) {
+	var t0, t1, t2, x3, y3, z3 gfP2
+	t0.Square(&a.y)
+	z3.Add(&t0, &t0)
+	z3.Add(&z3, &z3)
+	z3.Add(&z3, &z3)
+	t1.Mul(&a.y, &a.z)
+	t2.Square(&a.z)
+	t2.Mul(twistB3, &t2)
+	x3.Mul(&t2, &z3)
+	y3.Add(&t0, &t2)
+	z3.Mul(&t1, &z3)
+	t1.Add(&t2, &t2)
+	t2.Add(&t1, &t2)
+	t0.Sub(&t0, &t2)
+	y3.Mul(&t0, &y3)
+	y3.Add(&x3, &y3)
+	t1.Mul(&a.x, &a.y)
+	x3.Mul(&t0, &t1)
+	x3.Add(&x3, &x3)
+	c.x, c.y, c.z = x3, y3, z3
+}
+
+// MulCT sets c to (k mod Order)·a in constant time and returns c. a must be in
+// G₂.
+func (c *twistPoint) MulCT(a *twistPoint, k *big.Int) *twistPoint {
+	var table [1 << ctWindow]twistProjective
+	table[0].SetInfinity()
+	table[1].SetJacobian(a)
+	for i := 2; i < len(table); i++ {
+		table[i].Add(&table[i-1], &table[1])
+	}
+
+	var sum, t twistProjective
+	sum.SetInfinity()
+	for _, w := range scalarWindows(k) {
+		for i := 0; i < ctWindow; i++ {
+			sum.Double(&sum)
+		}
+		for i := range table {
+			t.Select(&table[i], &t, subtle.ConstantTimeEq(int32(i), int32(w)))
+		}
+		sum.Add(&sum, &t)
+	}
+
+	sum.Jacobian(c)
+	return c
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package bls12381
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func ctScalars(t *testing.T) []*big.Int {
+	scalars := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(15),
+		big.NewInt(16),
+		big.NewInt(-3),
+		new(big.Int).Set(Order),
+		new(big.Int).Sub(Order, big.NewInt(1)),
+		new(big.Int).Add(Order, big.NewInt(1)),
+	}
+	for i := 0; i < 4; i++ {
+		k, err := rand.Int(rand.Reader, Order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalars = append(scalars, k)
+	}
+	return scalars
+}
+
+func TestScalarMultCTG1(t *testing.T) {
+	_, a, _ := RandomG1(rand.Reader)
+	inf := new(G1).SetInfinity()
+	for _, k := range ctScalars(t) {
+		if got, want := new(G1).ScalarBaseMultCT(k).Marshal(), new(G1).ScalarBaseMult(k).Marshal(); !bytes.Equal(got, want) {
+			t.Errorf("ScalarBaseMultCT with k=%s: got %x, want %x", k, got, want)
+		}
+		if got, want := new(G1).ScalarMultCT(a, k).Marshal(), new(G1).ScalarMult(a, k).Marshal(); !bytes.Equal(got, want) {
+			t.Errorf("ScalarMultCT with k=%s: got %x, want %x", k, got, want)
+		}
+		if !new(G1).ScalarMultCT(inf, k).IsZero() {
+			t.Errorf("ScalarMultCT of ∞ with k=%s is not ∞", k)
+		}
+	}
+}
+
+func TestScalarMultCTG2(t *testing.T) {
+	a := HashToG2([]byte("gridlock/ct"))
+	inf := new(G2).SetInfinity()
+	for _, k := range ctScalars(t) {
+		if got, want := new(G2).ScalarBaseMultCT(k).Marshal(), new(G2).ScalarBaseMult(k).Marshal(); !bytes.Equal(got, want) {
+			t.Errorf("ScalarBaseMultCT with k=%s: got %x, want %x", k, got, want)
+		}
+		if got, want := new(G2).ScalarMultCT(a, k).Marshal(), new(G2).ScalarMult(a, k).Marshal(); !bytes.Equal(got, want) {
+			t.Errorf("ScalarMultCT with k=%s: got %x, want %x", k, got, want)
+		}
+		if !new(G2).ScalarMultCT(inf, k).IsZero() {
+			t.Errorf("ScalarMultCT of ∞ with k=%s is not ∞", k)
+		}
+	}
+}
+
+// The complete formulas need no special case for doubling, inverse points or ∞.
+func TestProjectiveAddComplete(t *testing.T) {
+	var p, q, inf, sum curveProjective
+	p.SetJacobian(new(G1).ScalarBaseMult(big.NewInt(5)).p)
+	inf.SetInfinity()
+
+	sum.Add(&p, &p)
+	q.Double(&p)
+	var a, b curvePoint
+	sum.Jacobian(&a)
+	q.Jacobian(&b)
+	if !bytes.Equal((&G1{&a}).Marshal(), new(G1).ScalarBaseMult(big.NewInt(10)).Marshal()) ||
+		!bytes.Equal((&G1{&b}).Marshal(), new(G1).ScalarBaseMult(big.NewInt(10)).Marshal()) {
+		t.Error("P+P != 2P")
+	}
+
+	q.SetJacobian(new(G1).ScalarBaseMult(big.NewInt(-5)).p)
+	sum.Add(&p, &q)
+	sum.Jacobian(&a)
+	if !a.IsInfinity() {
+		t.Error("P-P != ∞")
+	}
+
+	sum.Add(&p, &inf)
+	sum.Jacobian(&a)
+	if !bytes.Equal((&G1{&a}).Marshal(), new(G1).ScalarBaseMult(big.NewInt(5)).Marshal()) {
+		t.Error("P+∞ != P")
+	}
+	sum.Double(&inf)
+	sum.Jacobian(&a)
+	if !a.IsInfinity() {
+		t.Error("2∞ != ∞")
+	}
+}
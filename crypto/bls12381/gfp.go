@@ -0,0 +1,205 @@
+package bls12381
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// gfP is an element of the base field GF(p) in Montgomery form: the value a is
+// stored as a·R mod p with R = 2³⁸⁴, as six little-endian 64-bit words. Every
+// operation keeps its result reduced, so that equal values have equal words.
+type gfP [6]uint64
+
+// numBytes is the length of the big-endian encoding of an element of GF(p).
+const numBytes = 48
+
+var (
+	// pWords is p as little-endian words.
+	pWords = gfpWordsFromBig(p)
+	// np is -p⁻¹ mod 2⁶⁴.
+	np = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64),
+		new(big.Int).ModInverse(p, new(big.Int).Lsh(big.NewInt(1), 64))).Uint64()
+	// r2 is R² mod p, which converts a value to Montgomery form.
+	r2 = gfP(gfpWordsFromBig(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 768), p)))
+	// rOne is R mod p, the Montgomery form of 1.
+	rOne = gfP(gfpWordsFromBig(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 384), p)))
+	// pMinus2 is the exponent of the inversion by Fermat's little theorem.
+	pMinus2 = gfpWordsFromBig(new(big.Int).Sub(p, big.NewInt(2)))
+)
+
+// gfpWordsFromBig returns the words of 0 ≤ a < 2³⁸⁴.
+func gfpWordsFromBig(a *big.Int) [6]uint64 {
+	var buf [numBytes]byte
+	a.FillBytes(buf[:])
+	return gfpWordsFromBytes(buf[:])
+}
+
+// gfpWordsFromBytes reads 48 big-endian bytes.
+func gfpWordsFromBytes(m []byte) (w [6]uint64) {
+	for i := range w {
+		w[i] = binary.BigEndian.Uint64(m[(5-i)*8:])
+	}
+	return w
+}
+
+// newGFp returns the element x, which may be negative.
+func newGFp(x int64) gfP {
+	return newGFpFromBig(big.NewInt(x))
+}
+
+// newGFpFromBig returns the element a mod p.
+func newGFpFromBig(a *big.Int) gfP {
+	e := gfP(gfpWordsFromBig(new(big.Int).Mod(a, p)))
+	gfpMul(&e, &e, &r2)
+	return e
+}
+
+func (e *gfP) String() string {
+	return e.Big().String()
+}
+
+// Big returns the value of e in [0, p).
+func (e *gfP) Big() *big.Int {
+	var buf [numBytes]byte
+	e.Marshal(buf[:])
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// Marshal writes the value of e as 48 big-endian bytes to out.
+func (e *gfP) Marshal(out []byte) {
+	var a gfP
+	gfpMul(&a, e, &gfP{1})
+	for i := range a {
+		binary.BigEndian.PutUint64(out[(5-i)*8:], a[i])
+	}
+}
+
+// Unmarshal sets e to the value of 48 big-endian bytes and returns false if the
+// value is not reduced modulo p.
+func (e *gfP) Unmarshal(m []byte) bool {
+	a := gfP(gfpWordsFromBytes(m))
+	var borrow uint64
+	for i := range a {
+		_, borrow = bits.Sub64(a[i], pWords[i], borrow)
+	}
+	if borrow == 0 {
+		return false
+	}
+	gfpMul(e, &a, &r2)
+	return true
+}
+
+func (e *gfP) SetZero() *gfP {
+	*e = gfP{}
+	return e
+}
+
+func (e *gfP) SetOne() *gfP {
+	*e = rOne
+	return e
+}
+
+func (e *gfP) IsZero() bool {
+	return *e == gfP{}
+}
+
+func (e *gfP) IsOne() bool {
+	return *e == rOne
+}
+
+// Invert sets e to a⁻¹ = a^(p-2) and returns e. The inverse of 0 is 0.
+func (e *gfP) Invert(a *gfP) *gfP {
+	sum := rOne
+	for i := 383; i >= 0; i-- {
+		gfpMul(&sum, &sum, &sum)
+		if (pMinus2[i/64]>>(uint(i)%64))&1 != 0 {
+			gfpMul(&sum, &sum, a)
+		}
+	}
+	*e = sum
+	return e
+}
+
+// gfpCarry sets c to a-p if carry is set or a ≥ p, and to a otherwise.
+func gfpCarry(c *gfP, a *gfP, carry uint64) {
+	var d gfP
+	var borrow uint64
+	for i := range a {
+		d[i], borrow = bits.Sub64(a[i], pWords[i], borrow)
+	}
+	// keep a iff there was no carry and subtracting p borrowed
+	mask := -(borrow &^ carry)
+	for i := range c {
+		c[i] = (a[i] & mask) | (d[i] &^ mask)
+	}
+}
+
+func gfpAdd(c, a, b *gfP) {
+	var s gfP
+	var carry uint64
+	for i := range s {
+		s[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	gfpCarry(c, &s, carry)
+}
+
+func gfpSub(c, a, b *gfP) {
+	var d gfP
+	var borrow uint64
+	for i := range d {
+		d[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	mask := -borrow
+	var carry uint64
+	for i := range c {
+		c[i], carry = bits.Add64(d[i], pWords[i]&mask, carry)
+	}
+}
+
+func gfpNeg(c, a *gfP) {
+	gfpSub(c, &gfP{}, a)
+}
+
+// madd returns the high and low words of a·b+c+d, which can not overflow.
+func madd(a, b, c, d uint64) (hi, lo uint64) {
+	var carry uint64
+	hi, lo = bits.Mul64(a, b)
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, d, 0)
+	hi += carry
+	return hi, lo
+}
+
+// gfpMul sets c to a·b·R⁻¹ mod p with the coarsely integrated operand scanning
+// method. The intermediate result is kept in seven words and a carry above
+// them.
+func gfpMul(c, a, b *gfP) {
+	var t [8]uint64
+	for i := 0; i < 6; i++ {
+		var C uint64
+		for j := 0; j < 6; j++ {
+			C, t[j] = madd(a[j], b[i], t[j], C)
+		}
+		t[6], C = bits.Add64(t[6], C, 0)
+		t[7] = C
+
+		m := t[0] * np
+		C, _ = madd(m, pWords[0], t[0], 0)
+		for j := 1; j < 6; j++ {
+			C, t[j-1] = madd(m, pWords[j], t[j], C)
+		}
+		t[5], C = bits.Add64(t[6], C, 0)
+		t[6] = t[7] + C
+	}
+	gfpCarry(c, &gfP{t[0], t[1], t[2], t[3], t[4], t[5]}, t[6])
+}
+
+// gfpSelect sets c to a if cond is 1 and to b if cond is 0.
+func gfpSelect(c, a, b *gfP, cond int) {
+	mask := -uint64(cond)
+	for i := range c {
+		c[i] = (a[i] & mask) | (b[i] &^ mask)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bls12381
+
+// For details of the algorithms used, see "Multiplication and Squaring on
+// Pairing-Friendly Fields, Devegili et al.
+// http://eprint.iacr.org/2006/471.pdf.
+
+import (
+	"math/big"
+)
+
+// gfP2 implements a field of size p² as a quadratic extension of the base
+// field where i²=-1.
+type gfP2 struct {
+	x, y gfP // value is xi+y.
+}
+
+func (e *gfP2) String() string {
+	return "(" + e.x.String() + "," + e.y.String() + ")"
+}
+
+func (e *gfP2) Set(a *gfP2) *gfP2 {
+	*e = *a
+	return e
+}
+
+func (e *gfP2) SetZero() *gfP2 {
+	e.x.SetZero()
+	e.y.SetZero()
+	return e
+}
+
+func (e *gfP2) SetOne() *gfP2 {
+	e.x.SetZero()
+	e.y.SetOne()
+	return e
+}
+
+func (e *gfP2) IsZero() bool {
+	return e.x.IsZero() && e.y.IsZero()
+}
+
+func (e *gfP2) IsOne() bool {
+	return e.x.IsZero() && e.y.IsOne()
+}
+
+func (e *gfP2) Conjugate(a *gfP2) *gfP2 {
+	e.y = a.y
+	gfpNeg(&e.x, &a.x)
+	return e
+}
+
+func (e *gfP2) Negative(a *gfP2) *gfP2 {
+	gfpNeg(&e.x, &a.x)
+	gfpNeg(&e.y, &a.y)
+	return e
+}
+
+func (e *gfP2) Add(a, b *gfP2) *gfP2 {
+	gfpAdd(&e.x, &a.x, &b.x)
+	gfpAdd(&e.y, &a.y, &b.y)
+	return e
+}
+
+func (e *gfP2) Sub(a, b *gfP2) *gfP2 {
+	gfpSub(&e.x, &a.x, &b.x)
+	gfpSub(&e.y, &a.y, &b.y)
+	return e
+}
+
+func (e *gfP2) Double(a *gfP2) *gfP2 {
+	gfpAdd(&e.x, &a.x, &a.x)
+	gfpAdd(&e.y, &a.y, &a.y)
+	return e
+}
+
+func (c *gfP2) Exp(a *gfP2, power *big.Int) *gfP2 {
+	sum := &gfP2{}
+	sum.SetOne()
+	t := &gfP2{}
+
+	for i := power.BitLen() - 1; i >= 0; i-- {
+		t.Square(sum)
+		if power.Bit(i) != 0 {
+			sum.Mul(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+
+	c.Set(sum)
+	return c
+}
+
+// See "Multiplication and Squaring in Pairing-Friendly Fields",
+// http://eprint.iacr.org/2006/471.pdf
+func (e *gfP2) Mul(a, b *gfP2) *gfP2 {
+	tx, t := &gfP{}, &gfP{}
+	gfpMul(tx, &a.x, &b.y)
+	gfpMul(t, &b.x, &a.y)
+	gfpAdd(tx, tx, t)
+
+	ty := &gfP{}
+	gfpMul(ty, &a.y, &b.y)
+	gfpMul(t, &a.x, &b.x)
+	gfpSub(&e.y, ty, t)
+	e.x = *tx
+
+	return e
+}
+
+func (e *gfP2) MulScalar(a *gfP2, b *gfP) *gfP2 {
+	gfpMul(&e.x, &a.x, b)
+	gfpMul(&e.y, &a.y, b)
+	return e
+}
+
+// MulXi sets e=ξa where ξ=i+1 and then returns e.
+func (e *gfP2) MulXi(a *gfP2) *gfP2 {
+	// (xi+y)(i+1) = (x+y)i+(y-x)
+	tx := &gfP{}
+	gfpAdd(tx, &a.x, &a.y)
+
+	ty := &gfP{}
+	gfpSub(ty, &a.y, &a.x)
+
+	e.x = *tx
+	e.y = *ty
+
+	return e
+}
+
+func (e *gfP2) Square(a *gfP2) *gfP2 {
+	// Complex squaring algorithm:
+	// (xi+b)² = (x+y)(y-x) + 2*i*x*y
+	t1, t2, ty := &gfP{}, &gfP{}, &gfP{}
+	gfpSub(t1, &a.y, &a.x)
+	gfpAdd(t2, &a.x, &a.y)
+	gfpMul(ty, t1, t2)
+
+	gfpMul(t1, &a.x, &a.y)
+	gfpAdd(&e.x, t1, t1)
+	e.y = *ty
+
+	return e
+}
+
+func (e *gfP2) Invert(a *gfP2) *gfP2 {
+	// See "Implementing cryptographic pairings", M. Scott, section 3.2.
+	// ftp://136.206.11.249/pub/crypto/pairings.pdf
+	t, t2 := &gfP{}, &gfP{}
+	gfpMul(t, &a.y, &a.y)
+	gfpMul(t2, &a.x, &a.x)
+	gfpAdd(t, t, t2)
+
+	inv := (&gfP{}).Invert(t)
+
+	gfpNeg(&e.x, &a.x)
+	gfpMul(&e.x, &e.x, inv)
+	gfpMul(&e.y, &a.y, inv)
+
+	return e
+}
+
+func (e *gfP2) Select(a, b *gfP2, cond int) *gfP2 {
+	gfpSelect(&e.x, &a.x, &b.x, cond)
+	gfpSelect(&e.y, &a.y, &b.y, cond)
+	return e
+}
@@ -0,0 +1,110 @@
+package bls12381
+
+import (
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/internal/window"
+)
+
+// MultiMul sets c to the sum of scalars[i]·points[i] and returns c. The sum
+// runs with the buckets of package window unless there are too few points or
+// the scalars have a single bit.
+func (c *curvePoint) MultiMul(points []*curvePoint, scalars []*big.Int) *curvePoint {
+	ks, neg, maxBits := window.Scalars(scalars, Order)
+	if len(points) < window.MinPoints || maxBits <= 1 {
+		sum := &curvePoint{}
+		sum.SetInfinity()
+		for i, p := range points {
+			t := new(curvePoint).Mul(p, ks[i])
+			if neg[i] {
+				t.Negative(t)
+			}
+			sum.Add(sum, t)
+		}
+		c.Set(sum)
+		return c
+	}
+	ps := make([]curveProjective, len(points))
+	for i, p := range points {
+		if neg[i] {
+			var q curvePoint
+			q.Negative(p)
+			p = &q
+		}
+		ps[i].SetJacobian(p)
+	}
+	var sum curveProjective
+	window.MultiMul(&sum, ps, ks, maxBits)
+	sum.Jacobian(c)
+	return c
+}
+
+// MultiMul sets c to the sum of scalars[i]·points[i] and returns c. The points
+// must be in G₂.
+func (c *twistPoint) MultiMul(points []*twistPoint, scalars []*big.Int) *twistPoint {
+	ks, neg, maxBits := window.Scalars(scalars, Order)
+	if len(points) < window.MinPoints || maxBits <= 1 {
+		sum := &twistPoint{}
+		sum.SetInfinity()
+		for i, p := range points {
+			t := new(twistPoint).Mul(p, ks[i])
+			if neg[i] {
+				t.Negative(t)
+			}
+			sum.Add(sum, t)
+		}
+		c.Set(sum)
+		return c
+	}
+	ps := make([]twistProjective, len(points))
+	for i, p := range points {
+		if neg[i] {
+			var q twistPoint
+			q.Negative(p)
+			p = &q
+		}
+		ps[i].SetJacobian(p)
+	}
+	var sum twistProjective
+	window.MultiMul(&sum, ps, ks, maxBits)
+	sum.Jacobian(c)
+	return c
+}
+
+// MultiScalarMult sets e to the sum of points[i]*scalars[i] and then returns
+// e. A negative scalar multiplies by its absolute value and negates. It runs in
+// variable time and is meant for public scalars, as in verification. It panics
+// unless there are as many points as scalars.
+func (e *G1) MultiScalarMult(points []*G1, scalars []*big.Int) *G1 {
+	if len(points) != len(scalars) {
+		panic("bls12381: MultiScalarMult with a different number of points and scalars")
+	}
+	ps := make([]*curvePoint, len(points))
+	for i, p := range points {
+		ps[i] = p.p
+	}
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.MultiMul(ps, scalars)
+	return e
+}
+
+// MultiScalarMult sets e to the sum of points[i]*scalars[i] and then returns
+// e. A negative scalar multiplies by its absolute value and negates. It runs in
+// variable time and is meant for public scalars, as in verification. It panics
+// unless there are as many points as scalars.
+func (e *G2) MultiScalarMult(points []*G2, scalars []*big.Int) *G2 {
+	if len(points) != len(scalars) {
+		panic("bls12381: MultiScalarMult with a different number of points and scalars")
+	}
+	ps := make([]*twistPoint, len(points))
+	for i, p := range points {
+		ps[i] = p.p
+	}
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.MultiMul(ps, scalars)
+	return e
+}
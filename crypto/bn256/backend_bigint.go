@@ -0,0 +1,79 @@
+//go:build bn256_bigint
+
+package bn256
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256/internal/bigint"
+)
+
+// Backend names the implementation of the groups selected at build time.
+const Backend = "bigint"
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 = bigint.G1
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 = bigint.G2
+
+// GT is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type GT = bigint.GT
+
+// G1Table holds precomputed multiples of a fixed element of G₁, for
+// ScalarMultTable.
+type G1Table = bigint.G1Table
+
+// G2Table holds precomputed multiples of a fixed element of G₂, for
+// ScalarMultTable.
+type G2Table = bigint.G2Table
+
+// Order is the number of elements in both G₁ and G₂: 36u⁴+36u³+18u²+6u+1.
+var Order = bigint.Order
+
+// RandomG1 returns x and g₁ˣ where x is a random, non-zero number read from r.
+func RandomG1(r io.Reader) (*big.Int, *G1, error) {
+	return bigint.RandomG1(r)
+}
+
+// RandomG2 returns x and g₂ˣ where x is a random, non-zero number read from r.
+func RandomG2(r io.Reader) (*big.Int, *G2, error) {
+	return bigint.RandomG2(r)
+}
+
+// Pair calculates an Optimal Ate pairing.
+func Pair(g1 *G1, g2 *G2) *GT {
+	return bigint.Pair(g1, g2)
+}
+
+// PairingProduct returns the product of the pairings of a[i] and b[i], with a
+// single final exponentiation. It panics unless a and b have the same length.
+func PairingProduct(a []*G1, b []*G2) *GT {
+	return bigint.PairingProduct(a, b)
+}
+
+// PairingCheck returns true iff the product of the pairings of a[i] and b[i]
+// is one. It panics unless a and b have the same length.
+func PairingCheck(a []*G1, b []*G2) bool {
+	return bigint.PairingCheck(a, b)
+}
+
+// HashToG2 deterministically maps msg to a point of G₂ whose discrete logarithm
+// with respect to the generator is unknown.
+func HashToG2(msg []byte) *G2 {
+	return bigint.HashToG2(msg)
+}
+
+// NewG1Table returns the table of the multiples of a.
+func NewG1Table(a *G1) *G1Table {
+	return bigint.NewG1Table(a)
+}
+
+// NewG2Table returns the table of the multiples of a, which must be in G₂.
+func NewG2Table(a *G2) *G2Table {
+	return bigint.NewG2Table(a)
+}
@@ -0,0 +1,207 @@
+package bn256
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256/internal/bigint"
+	"github.com/blockchain-research/gridlock/crypto/bn256/internal/montgomery"
+)
+
+// The benchmarks run every operation on both backends regardless of the build
+// tag, as sub-benchmarks named after the backend. montgomery-ct is the constant
+// time scalar multiplication and montgomery-table the constant time one with a
+// precomputed table.
+
+func BenchmarkPairing(b *testing.B) {
+	b.Run("bigint", func(b *testing.B) {
+		_, g1, _ := bigint.RandomG1(rand.Reader)
+		_, g2, _ := bigint.RandomG2(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			bigint.Pair(g1, g2)
+		}
+	})
+	b.Run("montgomery", func(b *testing.B) {
+		_, g1, _ := montgomery.RandomG1(rand.Reader)
+		_, g2, _ := montgomery.RandomG2(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			montgomery.Pair(g1, g2)
+		}
+	})
+}
+
+// BenchmarkPairingProduct multiplies the pairings of 3 pairs, as a UL verifier
+// does, with Pair and with the shared Miller loop of PairingProduct.
+func BenchmarkPairingProduct(b *testing.B) {
+	const n = 3
+	var mg1 []*montgomery.G1
+	var mg2 []*montgomery.G2
+	var bg1 []*bigint.G1
+	var bg2 []*bigint.G2
+	for i := 0; i < n; i++ {
+		k, _ := rand.Int(rand.Reader, Order)
+		mg1 = append(mg1, new(montgomery.G1).ScalarBaseMult(k))
+		mg2 = append(mg2, new(montgomery.G2).ScalarBaseMult(k))
+		bg1 = append(bg1, new(bigint.G1).ScalarBaseMult(k))
+		bg2 = append(bg2, new(bigint.G2).ScalarBaseMult(k))
+	}
+	b.Run("bigint-pair", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			e := bigint.Pair(bg1[0], bg2[0])
+			for j := 1; j < n; j++ {
+				e.Add(e, bigint.Pair(bg1[j], bg2[j]))
+			}
+		}
+	})
+	b.Run("bigint", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bigint.PairingProduct(bg1, bg2)
+		}
+	})
+	b.Run("montgomery-pair", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			e := montgomery.Pair(mg1[0], mg2[0])
+			for j := 1; j < n; j++ {
+				e.Add(e, montgomery.Pair(mg1[j], mg2[j]))
+			}
+		}
+	})
+	b.Run("montgomery", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			montgomery.PairingProduct(mg1, mg2)
+		}
+	})
+}
+
+func BenchmarkG1ScalarMult(b *testing.B) {
+	k, _ := rand.Int(rand.Reader, Order)
+	b.Run("bigint", func(b *testing.B) {
+		_, g, _ := bigint.RandomG1(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(bigint.G1).ScalarMult(g, k)
+		}
+	})
+	b.Run("montgomery", func(b *testing.B) {
+		_, g, _ := montgomery.RandomG1(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.G1).ScalarMult(g, k)
+		}
+	})
+	b.Run("montgomery-ct", func(b *testing.B) {
+		_, g, _ := montgomery.RandomG1(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.G1).ScalarMultCT(g, k)
+		}
+	})
+	b.Run("montgomery-table", func(b *testing.B) {
+		_, g, _ := montgomery.RandomG1(rand.Reader)
+		t := montgomery.NewG1Table(g)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.G1).ScalarMultTable(t, k)
+		}
+	})
+}
+
+func BenchmarkG2ScalarMult(b *testing.B) {
+	k, _ := rand.Int(rand.Reader, Order)
+	b.Run("bigint", func(b *testing.B) {
+		_, g, _ := bigint.RandomG2(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(bigint.G2).ScalarMult(g, k)
+		}
+	})
+	b.Run("montgomery", func(b *testing.B) {
+		_, g, _ := montgomery.RandomG2(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.G2).ScalarMult(g, k)
+		}
+	})
+	b.Run("montgomery-ct", func(b *testing.B) {
+		_, g, _ := montgomery.RandomG2(rand.Reader)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.G2).ScalarMultCT(g, k)
+		}
+	})
+	b.Run("montgomery-table", func(b *testing.B) {
+		_, g, _ := montgomery.RandomG2(rand.Reader)
+		t := montgomery.NewG2Table(g)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.G2).ScalarMultTable(t, k)
+		}
+	})
+}
+
+func BenchmarkGTScalarMult(b *testing.B) {
+	k, _ := rand.Int(rand.Reader, Order)
+	b.Run("bigint", func(b *testing.B) {
+		_, g1, _ := bigint.RandomG1(rand.Reader)
+		_, g2, _ := bigint.RandomG2(rand.Reader)
+		gt := bigint.Pair(g1, g2)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(bigint.GT).ScalarMult(gt, k)
+		}
+	})
+	b.Run("montgomery", func(b *testing.B) {
+		_, g1, _ := montgomery.RandomG1(rand.Reader)
+		_, g2, _ := montgomery.RandomG2(rand.Reader)
+		gt := montgomery.Pair(g1, g2)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			new(montgomery.GT).ScalarMult(gt, k)
+		}
+	})
+}
+
+// The sums of n multiples compare one scalar multiplication and addition per
+// element with MultiScalarMult, for random scalars, and the additions and
+// negations of the sums of commitments with MultiScalarMult by ±1.
+func BenchmarkG2MultiScalarMult(b *testing.B) {
+	for _, n := range []int{4, 32, 256} {
+		points := make([]*montgomery.G2, n)
+		scalars := make([]*big.Int, n)
+		signs := make([]*big.Int, n)
+		for i := range points {
+			_, points[i], _ = montgomery.RandomG2(rand.Reader)
+			scalars[i], _ = rand.Int(rand.Reader, Order)
+			signs[i] = big.NewInt(int64(1 - 2*(i%2)))
+		}
+		for _, bench := range []struct {
+			name    string
+			scalars []*big.Int
+		}{{"random", scalars}, {"sign", signs}} {
+			b.Run(fmt.Sprintf("montgomery-naive/%s/%d", bench.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					sum := new(montgomery.G2).SetInfinity()
+					for j, p := range points {
+						switch {
+						case bench.name == "random":
+							sum.Add(sum, new(montgomery.G2).ScalarMult(p, bench.scalars[j]))
+						case bench.scalars[j].Sign() > 0:
+							sum.Add(sum, p)
+						default:
+							sum.Add(sum, new(montgomery.G2).Neg(p))
+						}
+					}
+				}
+			})
+			b.Run(fmt.Sprintf("montgomery-pippenger/%s/%d", bench.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					new(montgomery.G2).MultiScalarMult(points, bench.scalars)
+				}
+			})
+		}
+	}
+}
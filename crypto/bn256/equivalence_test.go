@@ -0,0 +1,242 @@
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256/internal/bigint"
+	"github.com/blockchain-research/gridlock/crypto/bn256/internal/montgomery"
+)
+
+// The backends are compared on their encodings, and on String for G₂ and GT
+// since the challenges of CCS08 proofs hash it. String of G₁ is not compared:
+// bigint prints affine coordinates it has not reduced modulo p.
+
+func equivalenceScalars(t *testing.T) []*big.Int {
+	scalars := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(-1),
+		big.NewInt(2),
+		big.NewInt(-176),
+		new(big.Int).Set(Order),
+		new(big.Int).Sub(Order, big.NewInt(1)),
+		new(big.Int).Neg(Order),
+		new(big.Int).Add(Order, big.NewInt(5)),
+	}
+	for i := 0; i < 4; i++ {
+		k, err := rand.Int(rand.Reader, Order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalars = append(scalars, k, new(big.Int).Neg(k))
+	}
+	return scalars
+}
+
+func assertEqualBytes(t *testing.T, what string, k *big.Int, got, want []byte) {
+	t.Helper()
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s with k=%s: montgomery %x, bigint %x", what, k, got, want)
+	}
+}
+
+func assertEqualString(t *testing.T, what string, k *big.Int, got, want string) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s with k=%s: montgomery %s, bigint %s", what, k, got, want)
+	}
+}
+
+func TestEquivalenceG1(t *testing.T) {
+	m1 := new(montgomery.G1).ScalarBaseMult(big.NewInt(7))
+	b1 := new(bigint.G1).ScalarBaseMult(big.NewInt(7))
+	for _, k := range equivalenceScalars(t) {
+		m := new(montgomery.G1).ScalarBaseMult(k)
+		b := new(bigint.G1).ScalarBaseMult(k)
+		assertEqualBytes(t, "G1 ScalarBaseMult", k, m.Marshal(), b.Marshal())
+
+		m = new(montgomery.G1).ScalarMult(m1, k)
+		b = new(bigint.G1).ScalarMult(b1, k)
+		assertEqualBytes(t, "G1 ScalarMult", k, m.Marshal(), b.Marshal())
+		assertEqualBytes(t, "G1 ScalarMultCT", k, new(montgomery.G1).ScalarMultCT(m1, k).Marshal(), b.Marshal())
+		assertEqualBytes(t, "G1 ScalarMultTable", k, new(montgomery.G1).ScalarMultTable(montgomery.NewG1Table(m1), k).Marshal(), b.Marshal())
+		if m.IsZero() != b.IsZero() {
+			t.Errorf("G1 IsZero with k=%s: montgomery %t, bigint %t", k, m.IsZero(), b.IsZero())
+		}
+
+		mSum := new(montgomery.G1).Add(m, m1)
+		bSum := new(bigint.G1).Add(b, b1)
+		assertEqualBytes(t, "G1 Add", k, mSum.Marshal(), bSum.Marshal())
+		mSum.Add(mSum, new(montgomery.G1).Neg(m1))
+		bSum.Add(bSum, new(bigint.G1).Neg(b1))
+		assertEqualBytes(t, "G1 Add Neg", k, mSum.Marshal(), bSum.Marshal())
+
+		mm, mOk := new(montgomery.G1).Unmarshal(b.Marshal())
+		bb, bOk := new(bigint.G1).Unmarshal(m.Marshal())
+		if !mOk || !bOk {
+			t.Fatalf("G1 Unmarshal with k=%s: montgomery %t, bigint %t", k, mOk, bOk)
+		}
+		assertEqualBytes(t, "G1 Unmarshal", k, mm.Marshal(), bb.Marshal())
+
+		assertEqualBytes(t, "G1 MarshalCompressed", k, m.MarshalCompressed(), b.MarshalCompressed())
+		mm, mOk = new(montgomery.G1).Unmarshal(b.MarshalCompressed())
+		bb, bOk = new(bigint.G1).Unmarshal(m.MarshalCompressed())
+		if !mOk || !bOk {
+			t.Fatalf("G1 Unmarshal compressed with k=%s: montgomery %t, bigint %t", k, mOk, bOk)
+		}
+		assertEqualBytes(t, "G1 Unmarshal compressed", k, mm.Marshal(), bb.Marshal())
+	}
+	m := new(montgomery.G1).SetInfinity()
+	b := new(bigint.G1).SetInfinity()
+	assertEqualBytes(t, "G1 SetInfinity", nil, m.Marshal(), b.Marshal())
+}
+
+func TestEquivalenceG2(t *testing.T) {
+	m1 := montgomery.HashToG2([]byte("gridlock/equivalence"))
+	b1 := bigint.HashToG2([]byte("gridlock/equivalence"))
+	assertEqualBytes(t, "HashToG2", nil, m1.Marshal(), b1.Marshal())
+	for _, k := range equivalenceScalars(t) {
+		m := new(montgomery.G2).ScalarBaseMult(k)
+		b := new(bigint.G2).ScalarBaseMult(k)
+		assertEqualString(t, "G2 ScalarBaseMult", k, m.String(), b.String())
+		assertEqualBytes(t, "G2 ScalarBaseMult", k, m.Marshal(), b.Marshal())
+
+		m = new(montgomery.G2).ScalarMult(m1, k)
+		b = new(bigint.G2).ScalarMult(b1, k)
+		assertEqualString(t, "G2 ScalarMult", k, m.String(), b.String())
+		assertEqualBytes(t, "G2 ScalarMult", k, m.Marshal(), b.Marshal())
+		assertEqualBytes(t, "G2 ScalarMultCT", k, new(montgomery.G2).ScalarMultCT(m1, k).Marshal(), b.Marshal())
+		assertEqualBytes(t, "G2 ScalarMultTable", k, new(montgomery.G2).ScalarMultTable(montgomery.NewG2Table(m1), k).Marshal(), b.Marshal())
+
+		mSum := new(montgomery.G2).Add(m, m1)
+		bSum := new(bigint.G2).Add(b, b1)
+		mSum.Add(mSum, new(montgomery.G2).Neg(m))
+		bSum.Add(bSum, new(bigint.G2).Neg(b))
+		assertEqualString(t, "G2 Add Neg", k, mSum.String(), bSum.String())
+		assertEqualBytes(t, "G2 Add Neg", k, mSum.Marshal(), bSum.Marshal())
+
+		mm, mOk := new(montgomery.G2).Unmarshal(b.Marshal())
+		bb, bOk := new(bigint.G2).Unmarshal(m.Marshal())
+		if !mOk || !bOk {
+			t.Fatalf("G2 Unmarshal with k=%s: montgomery %t, bigint %t", k, mOk, bOk)
+		}
+		assertEqualBytes(t, "G2 Unmarshal", k, mm.Marshal(), bb.Marshal())
+
+		assertEqualBytes(t, "G2 MarshalCompressed", k, m.MarshalCompressed(), b.MarshalCompressed())
+		mm, mOk = new(montgomery.G2).Unmarshal(b.MarshalCompressed())
+		bb, bOk = new(bigint.G2).Unmarshal(m.MarshalCompressed())
+		if !mOk || !bOk {
+			t.Fatalf("G2 Unmarshal compressed with k=%s: montgomery %t, bigint %t", k, mOk, bOk)
+		}
+		assertEqualBytes(t, "G2 Unmarshal compressed", k, mm.Marshal(), bb.Marshal())
+	}
+	m := new(montgomery.G2).SetInfinity()
+	b := new(bigint.G2).SetInfinity()
+	assertEqualBytes(t, "G2 SetInfinity", nil, m.Marshal(), b.Marshal())
+}
+
+func TestEquivalenceGT(t *testing.T) {
+	a, _ := rand.Int(rand.Reader, Order)
+	mp := montgomery.Pair(new(montgomery.G1).ScalarBaseMult(a), montgomery.HashToG2([]byte("gridlock")))
+	bp := bigint.Pair(new(bigint.G1).ScalarBaseMult(a), bigint.HashToG2([]byte("gridlock")))
+	assertEqualString(t, "Pair", a, mp.String(), bp.String())
+	assertEqualBytes(t, "Pair", a, mp.Marshal(), bp.Marshal())
+
+	mInf := montgomery.Pair(new(montgomery.G1).SetInfinity(), new(montgomery.G2).ScalarBaseMult(a))
+	bInf := bigint.Pair(new(bigint.G1).SetInfinity(), new(bigint.G2).ScalarBaseMult(a))
+	assertEqualBytes(t, "Pair with ∞", a, mInf.Marshal(), bInf.Marshal())
+	if !mInf.IsOne() || !bInf.IsOne() {
+		t.Errorf("Pair with ∞: montgomery %t, bigint %t", mInf.IsOne(), bInf.IsOne())
+	}
+
+	mProd := montgomery.PairingProduct(
+		[]*montgomery.G1{new(montgomery.G1).ScalarBaseMult(a), new(montgomery.G1).SetInfinity(), new(montgomery.G1).ScalarBaseMult(big.NewInt(5))},
+		[]*montgomery.G2{montgomery.HashToG2([]byte("gridlock")), new(montgomery.G2).ScalarBaseMult(a), new(montgomery.G2).ScalarBaseMult(a)})
+	bProd := bigint.PairingProduct(
+		[]*bigint.G1{new(bigint.G1).ScalarBaseMult(a), new(bigint.G1).SetInfinity(), new(bigint.G1).ScalarBaseMult(big.NewInt(5))},
+		[]*bigint.G2{bigint.HashToG2([]byte("gridlock")), new(bigint.G2).ScalarBaseMult(a), new(bigint.G2).ScalarBaseMult(a)})
+	assertEqualBytes(t, "PairingProduct", a, mProd.Marshal(), bProd.Marshal())
+
+	for _, k := range equivalenceScalars(t)[:9] {
+		m := new(montgomery.GT).ScalarMult(mp, new(big.Int).Abs(k))
+		b := new(bigint.GT).ScalarMult(bp, new(big.Int).Abs(k))
+		assertEqualString(t, "GT ScalarMult", k, m.String(), b.String())
+
+		m.Add(m, mp)
+		b.Add(b, bp)
+		assertEqualBytes(t, "GT Add", k, m.Marshal(), b.Marshal())
+
+		m.Neg(m)
+		b.Neg(b)
+		assertEqualBytes(t, "GT Neg", k, m.Marshal(), b.Marshal())
+
+		// Exp works in place on its argument
+		m.Exp(m, k)
+		b.Exp(b, k)
+		assertEqualBytes(t, "GT Exp", k, m.Marshal(), b.Marshal())
+		if m.IsOne() != b.IsOne() {
+			t.Errorf("GT IsOne with k=%s: montgomery %t, bigint %t", k, m.IsOne(), b.IsOne())
+		}
+
+		mm, mOk := new(montgomery.GT).Unmarshal(b.Marshal())
+		bb, bOk := new(bigint.GT).Unmarshal(m.Marshal())
+		if !mOk || !bOk {
+			t.Fatalf("GT Unmarshal with k=%s: montgomery %t, bigint %t", k, mOk, bOk)
+		}
+		assertEqualBytes(t, "GT Unmarshal", k, mm.Marshal(), bb.Marshal())
+
+		assertEqualBytes(t, "GT MarshalCompressed", k, m.MarshalCompressed(), b.MarshalCompressed())
+		mm, mOk = new(montgomery.GT).Unmarshal(b.MarshalCompressed())
+		bb, bOk = new(bigint.GT).Unmarshal(m.MarshalCompressed())
+		if !mOk || !bOk {
+			t.Fatalf("GT Unmarshal compressed with k=%s: montgomery %t, bigint %t", k, mOk, bOk)
+		}
+		assertEqualBytes(t, "GT Unmarshal compressed", k, mm.Marshal(), bb.Marshal())
+	}
+}
+
+func TestEquivalenceUnmarshalInvalid(t *testing.T) {
+	g1 := new(bigint.G1).ScalarBaseMult(big.NewInt(3)).Marshal()
+	g2 := new(bigint.G2).ScalarBaseMult(big.NewInt(3)).Marshal()
+	testEquivalenceUnmarshalInvalid(t, g1, g2)
+	g1 = new(bigint.G1).ScalarBaseMult(big.NewInt(3)).MarshalCompressed()
+	g2 = new(bigint.G2).ScalarBaseMult(big.NewInt(3)).MarshalCompressed()
+	testEquivalenceUnmarshalInvalid(t, g1, g2)
+
+	gt := bigint.Pair(new(bigint.G1).ScalarBaseMult(big.NewInt(3)), bigint.HashToG2([]byte("gridlock")))
+	for _, m := range [][]byte{gt.Marshal(), gt.MarshalCompressed()} {
+		for i := 0; i < len(m); i += 11 {
+			m := append([]byte{}, m...)
+			m[i] ^= 0x40
+			_, mOk := new(montgomery.GT).Unmarshal(m)
+			_, bOk := new(bigint.GT).Unmarshal(m)
+			if mOk != bOk {
+				t.Errorf("GT Unmarshal of %d bytes with a flipped byte %d: montgomery %t, bigint %t", len(m), i, mOk, bOk)
+			}
+		}
+	}
+}
+
+func testEquivalenceUnmarshalInvalid(t *testing.T, g1, g2 []byte) {
+	for i := 0; i < len(g2); i += 7 {
+		m := append([]byte{}, g2...)
+		m[i] ^= 0x40
+		_, mOk := new(montgomery.G2).Unmarshal(m)
+		_, bOk := new(bigint.G2).Unmarshal(m)
+		if mOk != bOk {
+			t.Errorf("G2 Unmarshal of %d bytes with a flipped byte %d: montgomery %t, bigint %t", len(m), i, mOk, bOk)
+		}
+		if i < len(g1) {
+			m = append([]byte{}, g1...)
+			m[i] ^= 0x40
+			_, mOk = new(montgomery.G1).Unmarshal(m)
+			_, bOk = new(bigint.G1).Unmarshal(m)
+			if mOk != bOk {
+				t.Errorf("G1 Unmarshal of %d bytes with a flipped byte %d: montgomery %t, bigint %t", len(m), i, mOk, bOk)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package bn256
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// The seed corpus of every decoder holds valid encodings, uncompressed and
+// compressed, the identity, truncated and extended encodings and encodings with
+// flipped bits. A decoded element must have been encoded in one of the forms.
+func fuzzSeeds(f *testing.F, valid ...[]byte) {
+	for _, m := range valid {
+		f.Add(m)
+		f.Add(m[:len(m)-1])
+		f.Add(append(append([]byte{}, m...), 0))
+		flipped := append([]byte{}, m...)
+		flipped[len(m)/2] ^= 1
+		f.Add(flipped)
+	}
+	f.Add([]byte{})
+}
+
+func FuzzG1Unmarshal(f *testing.F) {
+	fuzzSeeds(f,
+		new(G1).ScalarBaseMult(big.NewInt(1)).Marshal(),
+		new(G1).ScalarBaseMult(big.NewInt(987654321)).Marshal(),
+		make([]byte, 64),
+		new(G1).ScalarBaseMult(big.NewInt(1)).MarshalCompressed(),
+		new(G1).ScalarBaseMult(big.NewInt(987654321)).MarshalCompressed(),
+		new(G1).SetInfinity().MarshalCompressed(),
+	)
+	f.Fuzz(func(t *testing.T, m []byte) {
+		e, ok := new(G1).Unmarshal(m)
+		if !ok {
+			return
+		}
+		if !bytes.Equal(e.Marshal(), m) && !bytes.Equal(e.MarshalCompressed(), m) {
+			t.Fatal("a decoded G1 element does not have a unique encoding")
+		}
+	})
+}
+
+func FuzzG2Unmarshal(f *testing.F) {
+	fuzzSeeds(f,
+		new(G2).ScalarBaseMult(big.NewInt(1)).Marshal(),
+		new(G2).ScalarBaseMult(big.NewInt(987654321)).Marshal(),
+		make([]byte, 128),
+		new(G2).ScalarBaseMult(big.NewInt(1)).MarshalCompressed(),
+		new(G2).ScalarBaseMult(big.NewInt(987654321)).MarshalCompressed(),
+		new(G2).SetInfinity().MarshalCompressed(),
+	)
+	f.Fuzz(func(t *testing.T, m []byte) {
+		e, ok := new(G2).Unmarshal(m)
+		if !ok {
+			return
+		}
+		if !bytes.Equal(e.Marshal(), m) && !bytes.Equal(e.MarshalCompressed(), m) {
+			t.Fatal("a decoded G2 element does not have a unique encoding")
+		}
+		if !new(G2).ScalarMult(e, Order).IsZero() {
+			t.Fatal("a decoded G2 element is not in the subgroup")
+		}
+	})
+}
+
+func FuzzGTUnmarshal(f *testing.F) {
+	fuzzSeeds(f,
+		Pair(new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))).Marshal(),
+		Pair(new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))).MarshalCompressed(),
+		Pair(new(G1).SetInfinity(), new(G2).ScalarBaseMult(big.NewInt(1))).MarshalCompressed(),
+	)
+	f.Fuzz(func(t *testing.T, m []byte) {
+		e, ok := new(GT).Unmarshal(m)
+		if !ok {
+			return
+		}
+		if !bytes.Equal(e.Marshal(), m) && !bytes.Equal(e.MarshalCompressed(), m) {
+			t.Fatal("a decoded GT element does not have a unique encoding")
+		}
+	})
+}
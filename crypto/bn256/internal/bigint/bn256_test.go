@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package bn256
+package bigint
 
 import (
 	"bytes"
@@ -268,6 +268,25 @@ func TestG2Identity(t *testing.T) {
 	}
 }
 
+func TestHashToG2(t *testing.T) {
+	g := HashToG2([]byte("gridlock"))
+	if !g.p.IsOnCurve() {
+		t.Fatal("hashed point is not on the twist")
+	}
+	if !new(G2).ScalarMult(g, Order).p.IsInfinity() {
+		t.Fatal("hashed point is not in G2")
+	}
+	if !bytes.Equal(g.Marshal(), HashToG2([]byte("gridlock")).Marshal()) {
+		t.Error("hashing is not deterministic")
+	}
+	if bytes.Equal(g.Marshal(), HashToG2([]byte("gridlock2")).Marshal()) {
+		t.Error("different messages hashed to the same point")
+	}
+	if _, ok := new(G2).Unmarshal(g.Marshal()); !ok {
+		t.Error("failed to unmarshal hashed point")
+	}
+}
+
 func TestTripartiteDiffieHellman(t *testing.T) {
 	a, _ := rand.Int(rand.Reader, Order)
 	b, _ := rand.Int(rand.Reader, Order)
@@ -302,3 +321,61 @@ func BenchmarkPairing(b *testing.B) {
 		Pair(&G1{curveGen}, &G2{twistGen})
 	}
 }
+
+func TestG2UnmarshalOutsideSubgroup(t *testing.T) {
+	pool := new(bnPool)
+	for ctr := uint32(0); ; ctr++ {
+		x := hashToGFp2([]byte("gridlock subgroup test"), ctr)
+		yy := newGFp2(pool).Square(x, pool)
+		yy.Mul(yy, x, pool)
+		yy.Add(yy, twistB)
+		yy.Minimal()
+		y, ok := sqrtGFp2(yy, pool)
+		if !ok {
+			continue
+		}
+		// a point of the twist without the cofactor cleared
+		pt := &G2{&twistPoint{x, y, newGFp2(nil).SetOne(), newGFp2(nil).SetOne()}}
+		if !pt.p.IsOnCurve() {
+			t.Fatal("sample point is not on the twist")
+		}
+		if _, ok := new(G2).Unmarshal(pt.Marshal()); ok {
+			t.Fatal("unmarshaled a point outside of G2")
+		}
+		return
+	}
+}
+
+func TestUnmarshalNonCanonical(t *testing.T) {
+	const numBytes = 256 / 8
+	addP := func(m []byte, i int) []byte {
+		ret := append([]byte{}, m...)
+		c := new(big.Int).SetBytes(m[i*numBytes : (i+1)*numBytes])
+		c.Add(c, p)
+		if c.BitLen() > 256 {
+			return nil
+		}
+		b := c.Bytes()
+		copy(ret[(i+1)*numBytes-len(b):(i+1)*numBytes], b)
+		return ret
+	}
+
+	g1 := new(G1).ScalarBaseMult(big.NewInt(1)).Marshal()
+	if m := addP(g1, 0); m != nil {
+		if _, ok := new(G1).Unmarshal(m); ok {
+			t.Error("unmarshaled a G1 coordinate larger than p")
+		}
+	}
+	g2 := new(G2).ScalarBaseMult(big.NewInt(1)).Marshal()
+	if m := addP(g2, 1); m != nil {
+		if _, ok := new(G2).Unmarshal(m); ok {
+			t.Error("unmarshaled a G2 coordinate larger than p")
+		}
+	}
+	gt := Pair(new(G1).ScalarBaseMult(big.NewInt(1)), new(G2).ScalarBaseMult(big.NewInt(1))).Marshal()
+	if m := addP(gt, 5); m != nil {
+		if _, ok := new(GT).Unmarshal(m); ok {
+			t.Error("unmarshaled a GT coordinate larger than p")
+		}
+	}
+}
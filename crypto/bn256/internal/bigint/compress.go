@@ -0,0 +1,293 @@
+package bigint
+
+import "math/big"
+
+// Compressed encodings, the same as those of the default backend. A point of G₁
+// or G₂ is encoded by a byte of flags followed by its x-coordinate, in 33 or 65
+// bytes: flagInfinity marks the point at infinity, whose other bytes are zero,
+// and flagSort the larger of the two y-coordinates that go with x. An element
+// of GT is encoded in 193 bytes by the element c of GF(p⁶) such that it is
+// (c+ω)/(c-ω), with flagInfinity marking the identity.
+//
+// Decoding is strict: every element has a single compressed encoding, and
+// Unmarshal rejects the others as well as the x without a point of the group.
+
+const (
+	flagInfinity = 0x80
+	flagSort     = 0x40
+	flagMask     = flagInfinity | flagSort
+)
+
+// pMinus1Over2 is (p-1)/2, the largest of the smaller elements of GF(p).
+var pMinus1Over2 = new(big.Int).Rsh(p, 1)
+
+// isLargerGFp returns true iff a > -a, where a must be minimal.
+func isLargerGFp(a *big.Int) bool {
+	return a.Cmp(pMinus1Over2) > 0
+}
+
+// isLargerGFp2 returns true iff a > -a in the order of their encodings, which
+// compares the imaginary parts first. a must be minimal.
+func isLargerGFp2(a *gfP2) bool {
+	if a.x.Sign() != 0 {
+		return isLargerGFp(a.x)
+	}
+	return isLargerGFp(a.y)
+}
+
+// unmarshalCompressedFlags splits the compressed encoding m into its flags and
+// the rest, and returns the rest, whether it is the identity, whether flagSort
+// is set and whether the flags are valid.
+func unmarshalCompressedFlags(m []byte) ([]byte, bool, bool, bool) {
+	flags, rest := m[0], m[1:]
+	if flags&^flagMask != 0 {
+		return nil, false, false, false
+	}
+	if flags&flagInfinity == 0 {
+		return rest, false, flags&flagSort != 0, true
+	}
+	if flags != flagInfinity {
+		return nil, false, false, false
+	}
+	for _, b := range rest {
+		if b != 0 {
+			return nil, false, false, false
+		}
+	}
+	return rest, true, false, true
+}
+
+// MarshalCompressed converts e into 33 bytes, about half the size of Marshal.
+func (e *G1) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, 1+numBytes)
+	if e.p.IsInfinity() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine(nil)
+	new(big.Int).Mod(e.p.x, p).FillBytes(ret[1:])
+	if isLargerGFp(new(big.Int).Mod(e.p.y, p)) {
+		ret[0] |= flagSort
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the point encoded by MarshalCompressed in m.
+func (e *G1) unmarshalCompressed(m []byte) (*G1, bool) {
+	x, infinity, sort, ok := unmarshalCompressedFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = newCurvePoint(nil)
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	e.p.x.SetBytes(x)
+	if !canonical(e.p.x) {
+		return nil, false
+	}
+
+	// y² = x³ + b
+	yy := new(big.Int).Exp(e.p.x, big.NewInt(3), p)
+	yy.Add(yy, curveB)
+	yy.Mod(yy, p)
+	y := new(big.Int).ModSqrt(yy, p)
+	if y == nil {
+		return nil, false
+	}
+	if isLargerGFp(y) != sort {
+		y.Sub(p, y).Mod(y, p)
+		// y = 0 has no larger root
+		if isLargerGFp(y) != sort {
+			return nil, false
+		}
+	}
+	e.p.y.Set(y)
+	e.p.z.SetInt64(1)
+	e.p.t.SetInt64(1)
+	return e, true
+}
+
+// MarshalCompressed converts e into 65 bytes, about half the size of Marshal.
+func (e *G2) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, 1+numBytes*2)
+	if e.p.IsInfinity() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine(nil)
+	e.p.x.Minimal()
+	e.p.y.Minimal()
+	e.p.x.x.FillBytes(ret[1+0*numBytes : 1+1*numBytes])
+	e.p.x.y.FillBytes(ret[1+1*numBytes : 1+2*numBytes])
+	if isLargerGFp2(e.p.y) {
+		ret[0] |= flagSort
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the point encoded by MarshalCompressed in m.
+func (e *G2) unmarshalCompressed(m []byte) (*G2, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	x, infinity, sort, ok := unmarshalCompressedFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = newTwistPoint(nil)
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	e.p.x.x.SetBytes(x[0*numBytes : 1*numBytes])
+	e.p.x.y.SetBytes(x[1*numBytes : 2*numBytes])
+	if !canonical(e.p.x.x, e.p.x.y) {
+		return nil, false
+	}
+
+	// y² = x³ + b'
+	pool := new(bnPool)
+	yy := newGFp2(nil).Square(e.p.x, pool)
+	yy.Mul(yy, e.p.x, pool)
+	yy.Add(yy, twistB)
+	yy.Minimal()
+	y, ok := sqrtGFp2(yy, pool)
+	if !ok {
+		return nil, false
+	}
+	if isLargerGFp2(y) != sort {
+		y.Negative(y)
+		y.Minimal()
+		// y = 0 has no larger root
+		if isLargerGFp2(y) != sort {
+			return nil, false
+		}
+	}
+	e.p.y.Set(y)
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	// the twist has points outside of G₂, whose order is not Order
+	if !newTwistPoint(nil).Mul(e.p, Order, pool).IsInfinity() {
+		return nil, false
+	}
+	return e, true
+}
+
+// tau is τ = ω², which is not a square in GF(p⁶).
+var tau = &gfP6{
+	x: newGFp2(nil).SetZero(),
+	y: newGFp2(nil).SetOne(),
+	z: newGFp2(nil).SetZero(),
+}
+
+// isUnitary returns true iff a·ā = 1, where ā is the conjugate of a over
+// GF(p⁶), as for every element of GT, and a is not -1, the only such element
+// of order 2. Those are the elements that GT compression encodes.
+func isUnitary(a *gfP12, pool *bnPool) bool {
+	// (xω + y)(-xω + y) = y² - x²τ
+	n := newGFp6(pool).Square(a.x, pool)
+	n.MulTau(n, pool)
+	n.Sub(newGFp6(pool).Square(a.y, pool), n)
+	n.Minimal()
+	if !n.IsOne() {
+		return false
+	}
+	a.Minimal()
+	return !a.x.IsZero() || a.y.IsOne()
+}
+
+// gfP6Coefficients returns the base field coefficients of e in the order of
+// the encodings.
+func gfP6Coefficients(e *gfP6) []*big.Int {
+	return []*big.Int{e.x.x, e.x.y, e.y.x, e.y.y, e.z.x, e.z.y}
+}
+
+// MarshalCompressed converts e into 193 bytes, about half the size of Marshal.
+// The element xω + y ≠ 1 of GT is encoded by c = (1+y)/x, from which it is
+// (c²+τ)/(c²-τ) + 2c/(c²-τ)·ω.
+func (e *GT) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, 1+numBytes*6)
+	if e.p.IsOne() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	pool := new(bnPool)
+	c := newGFp6(nil).SetOne()
+	c.Add(c, e.p.y)
+	c.Mul(c, newGFp6(nil).Invert(e.p.x, pool), pool)
+	c.Minimal()
+	for i, v := range gfP6Coefficients(c) {
+		v.FillBytes(ret[1+i*numBytes : 1+(i+1)*numBytes])
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the element encoded by MarshalCompressed in m.
+func (e *GT) unmarshalCompressed(m []byte) (*GT, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	m, one, sort, ok := unmarshalCompressedFlags(m)
+	if !ok || sort {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = newGFp12(nil)
+	}
+
+	if one {
+		e.p.SetOne()
+		return e, true
+	}
+
+	c := newGFp6(nil)
+	coefficients := gfP6Coefficients(c)
+	for i, v := range coefficients {
+		v.SetBytes(m[i*numBytes : (i+1)*numBytes])
+	}
+	if !canonical(coefficients...) {
+		return nil, false
+	}
+	// c = 0 would encode -1, which is not in GT
+	if c.IsZero() {
+		return nil, false
+	}
+
+	// c² - τ is not zero since τ is not a square
+	pool := new(bnPool)
+	cc := newGFp6(nil).Square(c, pool)
+	d := newGFp6(nil).Sub(cc, tau)
+	d.Invert(d, pool)
+	e.p.y.Add(cc, tau)
+	e.p.y.Mul(e.p.y, d, pool)
+	e.p.x.Double(c)
+	e.p.x.Mul(e.p.x, d, pool)
+	e.p.Minimal()
+	return e, true
+}
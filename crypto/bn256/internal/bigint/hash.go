@@ -0,0 +1,96 @@
+package bigint
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+// twistCofactor is the cofactor of G₂ in the group of points of the twist: 2p-n.
+var twistCofactor = new(big.Int).Sub(new(big.Int).Lsh(p, 1), Order)
+
+// HashToG2 deterministically maps msg to a point of G₂ whose discrete logarithm
+// with respect to the generator is unknown. It uses try-and-increment on the
+// x-coordinate of the twist followed by clearing the cofactor.
+func HashToG2(msg []byte) *G2 {
+	pool := new(bnPool)
+	for ctr := uint32(0); ; ctr++ {
+		x := hashToGFp2(msg, ctr)
+
+		// y² = x³ + b'
+		yy := newGFp2(pool).Square(x, pool)
+		yy.Mul(yy, x, pool)
+		yy.Add(yy, twistB)
+		yy.Minimal()
+
+		y, ok := sqrtGFp2(yy, pool)
+		if !ok {
+			continue
+		}
+		pt := &twistPoint{x, y, newGFp2(nil).SetOne(), newGFp2(nil).SetOne()}
+
+		e := &G2{newTwistPoint(nil)}
+		e.p.Mul(pt, twistCofactor, pool)
+		if e.p.IsInfinity() {
+			continue
+		}
+		e.p.MakeAffine(pool)
+		return e
+	}
+}
+
+// hashToGFp2 derives an element of GF(p²) from msg and a counter.
+func hashToGFp2(msg []byte, ctr uint32) *gfP2 {
+	var buf [5]byte
+	binary.BigEndian.PutUint32(buf[:4], ctr)
+
+	e := newGFp2(nil)
+	for i, c := range []*big.Int{e.x, e.y} {
+		buf[4] = byte(i)
+		digest := sha256.New()
+		digest.Write(msg)
+		digest.Write(buf[:])
+		c.SetBytes(digest.Sum(nil))
+		c.Mod(c, p)
+	}
+	return e
+}
+
+// sqrtGFp2 returns a square root of a, which must be minimal, and whether one
+// exists. Since p ≡ 3 mod 4 it follows Algorithm 9 of "Square root computation
+// over even extension fields", Adj and Rodríguez-Henríquez.
+func sqrtGFp2(a *gfP2, pool *bnPool) (*gfP2, bool) {
+	if a.IsZero() {
+		return newGFp2(nil).SetZero(), true
+	}
+	minusOne := &gfP2{big.NewInt(0), new(big.Int).Sub(p, big.NewInt(1))}
+
+	// a1 = a^((p-3)/4), alpha = a^((p-1)/2)
+	a1 := newGFp2(pool).Exp(a, new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(3)), 2), pool)
+	alpha := newGFp2(pool).Square(a1, pool)
+	alpha.Mul(alpha, a, pool)
+	alpha.Minimal()
+
+	x0 := newGFp2(nil).Mul(a1, a, pool)
+
+	x := newGFp2(nil)
+	if alpha.x.Cmp(minusOne.x) == 0 && alpha.y.Cmp(minusOne.y) == 0 {
+		// x = i·x0
+		x.x.Set(x0.y)
+		x.y.Neg(x0.x)
+	} else {
+		b := newGFp2(pool).SetOne()
+		b.Add(b, alpha)
+		b.Exp(b, new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(1)), 1), pool)
+		x.Mul(b, x0, pool)
+	}
+	x.Minimal()
+
+	// a is a quadratic non-residue if x² ≠ a
+	check := newGFp2(pool).Square(x, pool)
+	check.Minimal()
+	if check.x.Cmp(a.x) != 0 || check.y.Cmp(a.y) != 0 {
+		return nil, false
+	}
+	return x, true
+}
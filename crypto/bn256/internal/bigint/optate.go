@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package bn256
+package bigint
 
 func lineFunctionAdd(r, p *twistPoint, q *curvePoint, r2 *gfP2, pool *bnPool) (a, b, c *gfP2, rOut *twistPoint) {
 	// See the mixed addition algorithm from "Faster Computation of the
@@ -382,6 +382,26 @@ func finalExponentiation(in *gfP12, pool *bnPool) *gfP12 {
 	return t0
 }
 
+// pairingProduct returns the product of the optimal ate pairings of the pairs
+// (a[i], b[i]). The pairs with ∞, whose pairing is one, are left out, and the
+// product of the Miller loops of the others shares a single final
+// exponentiation.
+func pairingProduct(a []*twistPoint, b []*curvePoint, pool *bnPool) *gfP12 {
+	acc := newGFp12(pool)
+	acc.SetOne()
+	for i := range a {
+		if a[i].IsInfinity() || b[i].IsInfinity() {
+			continue
+		}
+		e := miller(a[i], b[i], pool)
+		acc.Mul(acc, e, pool)
+		e.Put(pool)
+	}
+	ret := finalExponentiation(acc, pool)
+	acc.Put(pool)
+	return ret
+}
+
 func optimalAte(a *twistPoint, b *curvePoint, pool *bnPool) *gfP12 {
 	e := miller(a, b, pool)
 	ret := finalExponentiation(e, pool)
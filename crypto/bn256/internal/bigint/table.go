@@ -0,0 +1,72 @@
+package bigint
+
+import "math/big"
+
+// The precomputed tables and the multi-scalar multiplication of the default
+// backend have no counterpart here: the math/big arithmetic of this backend is
+// kept as it was, so they fall back to ScalarMult and Add with the same
+// results.
+
+// G1Table holds a fixed element of G₁. This backend precomputes nothing.
+type G1Table struct {
+	a *G1
+}
+
+// NewG1Table returns the table of the multiples of a.
+func NewG1Table(a *G1) *G1Table {
+	return &G1Table{new(G1).ScalarMult(a, big.NewInt(1))}
+}
+
+// ScalarMultTable is ScalarMult by the element of t. The math/big arithmetic
+// of this backend is not constant time, use the default backend for secret
+// scalars.
+func (e *G1) ScalarMultTable(t *G1Table, k *big.Int) *G1 {
+	return e.ScalarMult(t.a, k)
+}
+
+// G2Table holds a fixed element of G₂. This backend precomputes nothing.
+type G2Table struct {
+	a *G2
+}
+
+// NewG2Table returns the table of the multiples of a.
+func NewG2Table(a *G2) *G2Table {
+	return &G2Table{new(G2).ScalarMult(a, big.NewInt(1))}
+}
+
+// ScalarMultTable is ScalarMult by the element of t. The math/big arithmetic
+// of this backend is not constant time, use the default backend for secret
+// scalars.
+func (e *G2) ScalarMultTable(t *G2Table, k *big.Int) *G2 {
+	return e.ScalarMult(t.a, k)
+}
+
+// MultiScalarMult sets e to the sum of points[i]*scalars[i] and then returns
+// e, one scalar multiplication at a time. It panics unless there are as many
+// points as scalars.
+func (e *G1) MultiScalarMult(points []*G1, scalars []*big.Int) *G1 {
+	if len(points) != len(scalars) {
+		panic("bn256: MultiScalarMult with a different number of points and scalars")
+	}
+	sum := new(G1).SetInfinity()
+	for i, p := range points {
+		sum.Add(sum, new(G1).ScalarMult(p, scalars[i]))
+	}
+	e.p = sum.p
+	return e
+}
+
+// MultiScalarMult sets e to the sum of points[i]*scalars[i] and then returns
+// e, one scalar multiplication at a time. It panics unless there are as many
+// points as scalars.
+func (e *G2) MultiScalarMult(points []*G2, scalars []*big.Int) *G2 {
+	if len(points) != len(scalars) {
+		panic("bn256: MultiScalarMult with a different number of points and scalars")
+	}
+	sum := new(G2).SetInfinity()
+	for i, p := range points {
+		sum.Add(sum, new(G2).ScalarMult(p, scalars[i]))
+	}
+	e.p = sum.p
+	return e
+}
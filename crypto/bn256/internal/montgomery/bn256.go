@@ -0,0 +1,488 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package montgomery implements the bilinear groups of package bn256 with base
+// field elements kept in Montgomery form as four 64-bit words. It uses the same
+// formulas as package bigint, so both produce the same encodings and the same
+// Jacobian coordinates, but it avoids the allocations and the divisions of
+// math/big.
+package montgomery
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+)
+
+// G1 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G1 struct {
+	p *curvePoint
+}
+
+// RandomG1 returns x and g₁ˣ where x is a random, non-zero number read from r.
+func RandomG1(r io.Reader) (*big.Int, *G1, error) {
+	k, err := randomK(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, new(G1).ScalarBaseMult(k), nil
+}
+
+func randomK(r io.Reader) (*big.Int, error) {
+	for {
+		k, err := rand.Int(r, Order)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+func (e *G1) String() string {
+	return "bn256.G1" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns e. A negative k multiplies by |k| and negates.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	return e.ScalarMult(&G1{curveGen}, k)
+}
+
+// ScalarMult sets e to a*k and then returns e. A negative k multiplies by |k|
+// and negates.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	switch k.Sign() {
+	case 0:
+		e.p.SetInfinity()
+	case 1:
+		e.p.Mul(a.p, k)
+	case -1:
+		e.p.Negative(e.p.Mul(a.p, new(big.Int).Abs(k)))
+	}
+	return e
+}
+
+// ScalarBaseMultCT sets e to g*k where g is the generator of the group and
+// then returns e. It runs in constant time for secret scalars, adding entries
+// of a table of the multiples of g that is built on first use.
+func (e *G1) ScalarBaseMultCT(k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	curveGenMul(e.p, k)
+	return e
+}
+
+// ScalarMultCT sets e to a*k and then returns e. It runs in constant time for
+// secret scalars; only reducing a k outside of [0, Order) is not.
+func (e *G1) ScalarMultCT(a *G1, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.MulCT(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	e.p.Negative(a.p)
+	return e
+}
+
+// Marshal converts n to a byte slice.
+func (e *G1) Marshal() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, numBytes*2)
+	if e.p.IsInfinity() {
+		return ret
+	}
+
+	e.p.MakeAffine()
+	e.p.x.Marshal(ret[0*numBytes:])
+	e.p.y.Marshal(ret[1*numBytes:])
+	return ret
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal or
+// MarshalCompressed back into a group element and then returns e.
+func (e *G1) Unmarshal(m []byte) (*G1, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	if len(m) == 1+numBytes {
+		return e.unmarshalCompressed(m)
+	}
+	if len(m) != 2*numBytes {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+
+	if !e.p.x.Unmarshal(m[0*numBytes:1*numBytes]) ||
+		!e.p.y.Unmarshal(m[1*numBytes:2*numBytes]) {
+		return nil, false
+	}
+
+	if e.p.x.IsZero() && e.p.y.IsZero() {
+		// This is the point at infinity.
+		e.p.y.SetOne()
+		e.p.z.SetZero()
+		e.p.t.SetZero()
+	} else {
+		e.p.z.SetOne()
+		e.p.t.SetOne()
+
+		if !e.p.IsOnCurve() {
+			return nil, false
+		}
+	}
+
+	return e, true
+}
+
+// SetInfinity sets e to the identity element of the group and returns e.
+func (e *G1) SetInfinity() *G1 {
+	e.p = &curvePoint{}
+	e.p.SetInfinity()
+	return e
+}
+
+// SetZero sets e to the identity element of the group.
+func (e *G1) SetZero() {
+	e.p.SetInfinity()
+}
+
+// IsZero returns true iff e is the identity element of the group.
+func (e *G1) IsZero() bool {
+	return e.p.IsInfinity()
+}
+
+// G2 is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type G2 struct {
+	p *twistPoint
+}
+
+// RandomG2 returns x and g₂ˣ where x is a random, non-zero number read from r.
+func RandomG2(r io.Reader) (*big.Int, *G2, error) {
+	k, err := randomK(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, new(G2).ScalarBaseMult(k), nil
+}
+
+func (e *G2) String() string {
+	return "bn256.G2" + e.p.String()
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and
+// then returns out. A negative k is reduced modulo Order.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	return e.ScalarMult(&G2{twistGen}, k)
+}
+
+// ScalarMult sets e to a*k and then returns e. A negative k is reduced modulo
+// Order.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+
+	if k.Sign() < 0 {
+		k = new(big.Int).Add(Order, k)
+		k = new(big.Int).Mod(k, Order)
+	}
+	e.p.Mul(a.p, k)
+
+	return e
+}
+
+// ScalarBaseMultCT sets e to g*k where g is the generator of the group and
+// then returns e. It runs in constant time for secret scalars, adding entries
+// of a table of the multiples of g that is built on first use.
+func (e *G2) ScalarBaseMultCT(k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	twistGenMul(e.p, k)
+	return e
+}
+
+// ScalarMultCT sets e to a*k and then returns e. It runs in constant time for
+// secret scalars; only reducing a k outside of [0, Order) is not.
+func (e *G2) ScalarMultCT(a *G2, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.MulCT(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Add(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	e.p.Negative(a.p)
+	return e
+}
+
+// Marshal converts n into a byte slice.
+func (n *G2) Marshal() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, numBytes*4)
+	if n.p.IsInfinity() {
+		return ret
+	}
+
+	n.p.MakeAffine()
+	n.p.x.x.Marshal(ret[0*numBytes:])
+	n.p.x.y.Marshal(ret[1*numBytes:])
+	n.p.y.x.Marshal(ret[2*numBytes:])
+	n.p.y.y.Marshal(ret[3*numBytes:])
+	return ret
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal or
+// MarshalCompressed back into a group element and then returns e.
+func (e *G2) Unmarshal(m []byte) (*G2, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	if len(m) == 1+2*numBytes {
+		return e.unmarshalCompressed(m)
+	}
+	if len(m) != 4*numBytes {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+
+	if !e.p.x.x.Unmarshal(m[0*numBytes:1*numBytes]) ||
+		!e.p.x.y.Unmarshal(m[1*numBytes:2*numBytes]) ||
+		!e.p.y.x.Unmarshal(m[2*numBytes:3*numBytes]) ||
+		!e.p.y.y.Unmarshal(m[3*numBytes:4*numBytes]) {
+		return nil, false
+	}
+
+	if e.p.x.IsZero() && e.p.y.IsZero() {
+		// This is the point at infinity.
+		e.p.y.SetOne()
+		e.p.z.SetZero()
+		e.p.t.SetZero()
+	} else {
+		e.p.z.SetOne()
+		e.p.t.SetOne()
+
+		if !e.p.IsOnCurve() {
+			return nil, false
+		}
+		// the twist has points outside of G₂, whose order is not Order
+		if !new(twistPoint).Mul(e.p, Order).IsInfinity() {
+			return nil, false
+		}
+	}
+
+	return e, true
+}
+
+// SetInfinity sets e to the identity element of the group and returns e.
+func (e *G2) SetInfinity() *G2 {
+	e.p = &twistPoint{}
+	e.p.SetInfinity()
+	return e
+}
+
+// IsZero returns true iff e is the identity element of the group.
+func (e *G2) IsZero() bool {
+	return e.p.IsInfinity()
+}
+
+// CurvePoints returns the Jacobian coordinates x, y, z and t=z² of e.
+func (e *G2) CurvePoints() (*gfP2, *gfP2, *gfP2, *gfP2) {
+	return &e.p.x, &e.p.y, &e.p.z, &e.p.t
+}
+
+// GT is an abstract cyclic group. The zero value is suitable for use as the
+// output of an operation, but cannot be used as an input.
+type GT struct {
+	p *gfP12
+}
+
+func (g *GT) String() string {
+	return "bn256.GT" + g.p.String()
+}
+
+// ScalarMult sets e to a*k and then returns e.
+func (e *GT) ScalarMult(a *GT, k *big.Int) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Exp(a.p, k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *GT) Add(a, b *GT) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Mul(a.p, b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *GT) Neg(a *GT) *GT {
+	return e.Invert(a)
+}
+
+// Invert sets e to -a and then returns e.
+func (e *GT) Invert(a *GT) *GT {
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+	e.p.Invert(a.p)
+	return e
+}
+
+// Exp sets a to a*k, where k may be negative, and returns a. e is left
+// untouched.
+func (e *GT) Exp(a *GT, k *big.Int) *GT {
+	if k.Sign() >= 0 {
+		return a.ScalarMult(a, k)
+	}
+	return a.Invert(a.ScalarMult(a, new(big.Int).Abs(k)))
+}
+
+// IsZero returns true iff e is the zero element of the field GT is embedded in.
+func (e *GT) IsZero() bool {
+	return e.p.IsZero()
+}
+
+// IsOne returns true iff e is the identity element of the group.
+func (e *GT) IsOne() bool {
+	return e.p.IsOne()
+}
+
+// coefficients returns the base field coefficients of e in the order of Marshal.
+func (e *GT) coefficients() []*gfP {
+	return []*gfP{
+		&e.p.x.x.x, &e.p.x.x.y, &e.p.x.y.x, &e.p.x.y.y, &e.p.x.z.x, &e.p.x.z.y,
+		&e.p.y.x.x, &e.p.y.x.y, &e.p.y.y.x, &e.p.y.y.y, &e.p.y.z.x, &e.p.y.z.y,
+	}
+}
+
+// Marshal converts n into a byte slice.
+func (n *GT) Marshal() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, numBytes*12)
+	for i, c := range n.coefficients() {
+		c.Marshal(ret[i*numBytes:])
+	}
+	return ret
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal or
+// MarshalCompressed back into a group element and then returns e.
+func (e *GT) Unmarshal(m []byte) (*GT, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	if len(m) == 1+6*numBytes {
+		return e.unmarshalCompressed(m)
+	}
+	if len(m) != 12*numBytes {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+
+	// elements of GT are only compared with computed pairings, so a canonical
+	// element outside of GT can not be mistaken for a valid one; only those
+	// that MarshalCompressed can not encode are rejected
+	for i, c := range e.coefficients() {
+		if !c.Unmarshal(m[i*numBytes : (i+1)*numBytes]) {
+			return nil, false
+		}
+	}
+	if !isUnitary(e.p) {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// Pair calculates an Optimal Ate pairing.
+func Pair(g1 *G1, g2 *G2) *GT {
+	return &GT{optimalAte(g2.p, g1.p)}
+}
+
+// PairingProduct returns the product of the pairings of a[i] and b[i]. The
+// Miller loops of the pairs share their squarings and a single final
+// exponentiation, which makes it much faster than multiplying the results of
+// Pair. It panics unless a and b have the same length.
+func PairingProduct(a []*G1, b []*G2) *GT {
+	qs, ps := pairPoints(a, b)
+	return &GT{pairingProduct(qs, ps)}
+}
+
+// PairingCheck returns true iff the product of the pairings of a[i] and b[i]
+// is one. It panics unless a and b have the same length.
+func PairingCheck(a []*G1, b []*G2) bool {
+	return PairingProduct(a, b).IsOne()
+}
+
+// pairPoints returns the points of the pairs (a[i], b[i]) in the argument
+// order of optimalAte.
+func pairPoints(a []*G1, b []*G2) ([]*twistPoint, []*curvePoint) {
+	if len(a) != len(b) {
+		panic("bn256: pairing product with a different number of G1 and G2 elements")
+	}
+	ps := make([]*curvePoint, len(a))
+	qs := make([]*twistPoint, len(b))
+	for i := range a {
+		ps[i], qs[i] = a[i].p, b[i].p
+	}
+	return qs, ps
+}
@@ -0,0 +1,268 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package montgomery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestGFpArithmetic(t *testing.T) {
+	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
+	samples := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), pMinus1}
+	for i := 0; i < 20; i++ {
+		a, _ := rand.Int(rand.Reader, p)
+		samples = append(samples, a)
+	}
+
+	for _, a := range samples {
+		for _, b := range samples {
+			ea, eb := newGFpFromBig(a), newGFpFromBig(b)
+			var c gfP
+			gfpAdd(&c, &ea, &eb)
+			if want := new(big.Int).Mod(new(big.Int).Add(a, b), p); c.Big().Cmp(want) != 0 {
+				t.Fatalf("%s+%s: got %s, want %s", a, b, &c, want)
+			}
+			gfpSub(&c, &ea, &eb)
+			if want := new(big.Int).Mod(new(big.Int).Sub(a, b), p); c.Big().Cmp(want) != 0 {
+				t.Fatalf("%s-%s: got %s, want %s", a, b, &c, want)
+			}
+			gfpMul(&c, &ea, &eb)
+			if want := new(big.Int).Mod(new(big.Int).Mul(a, b), p); c.Big().Cmp(want) != 0 {
+				t.Fatalf("%s*%s: got %s, want %s", a, b, &c, want)
+			}
+		}
+		ea := newGFpFromBig(a)
+		inv := (&gfP{}).Invert(&ea)
+		if a.Sign() != 0 && inv.Big().Cmp(new(big.Int).ModInverse(a, p)) != 0 {
+			t.Fatalf("bad inverse of %s: %s", a, inv)
+		}
+	}
+}
+
+func TestGFpUnmarshal(t *testing.T) {
+	var buf [32]byte
+	p.FillBytes(buf[:])
+	if (&gfP{}).Unmarshal(buf[:]) {
+		t.Error("unmarshaled p")
+	}
+	new(big.Int).Sub(p, big.NewInt(1)).FillBytes(buf[:])
+	var e gfP
+	if !e.Unmarshal(buf[:]) {
+		t.Fatal("failed to unmarshal p-1")
+	}
+	var out [32]byte
+	e.Marshal(out[:])
+	if out != buf {
+		t.Error("p-1 does not round trip")
+	}
+}
+
+func TestGFp2Invert(t *testing.T) {
+	a := &gfP2{
+		newGFpFromBig(bigFromBase10("23423492374")),
+		newGFpFromBig(bigFromBase10("12934872398472394827398470")),
+	}
+
+	inv := (&gfP2{}).Invert(a)
+
+	b := (&gfP2{}).Mul(inv, a)
+	if !b.IsOne() {
+		t.Fatalf("bad result for a^-1*a: %s", b)
+	}
+}
+
+func TestGFp6Invert(t *testing.T) {
+	a := &gfP6{}
+	a.x.x = gfpFromBase10("239487238491")
+	a.x.y = gfpFromBase10("2356249827341")
+	a.y.x = gfpFromBase10("082659782")
+	a.y.y = gfpFromBase10("182703523765")
+	a.z.x = gfpFromBase10("978236549263")
+	a.z.y = gfpFromBase10("64893242")
+
+	inv := (&gfP6{}).Invert(a)
+
+	b := (&gfP6{}).Mul(inv, a)
+	if !b.IsOne() {
+		t.Fatalf("bad result for a^-1*a: %s", b)
+	}
+}
+
+func TestGFp12Invert(t *testing.T) {
+	a := &gfP12{}
+	a.x.x.x = gfpFromBase10("239846234862342323958623")
+	a.x.x.y = gfpFromBase10("2359862352529835623")
+	a.x.y.x = gfpFromBase10("928836523")
+	a.x.y.y = gfpFromBase10("9856234")
+	a.x.z.x = gfpFromBase10("235635286")
+	a.x.z.y = gfpFromBase10("5628392833")
+	a.y.x.x = gfpFromBase10("252936598265329856238956532167968")
+	a.y.x.y = gfpFromBase10("23596239865236954178968")
+	a.y.y.x = gfpFromBase10("95421692834")
+	a.y.y.y = gfpFromBase10("236548")
+	a.y.z.x = gfpFromBase10("924523")
+	a.y.z.y = gfpFromBase10("12954623")
+
+	inv := (&gfP12{}).Invert(a)
+
+	b := (&gfP12{}).Mul(inv, a)
+	if !b.IsOne() {
+		t.Fatalf("bad result for a^-1*a: %s", b)
+	}
+}
+
+func TestCurveImpl(t *testing.T) {
+	g := &curvePoint{newGFp(1), newGFp(-2), newGFp(1), newGFp(0)}
+
+	x := big.NewInt(32498273234)
+	X := new(curvePoint).Mul(g, x)
+
+	y := big.NewInt(98732423523)
+	Y := new(curvePoint).Mul(g, y)
+
+	s1 := new(curvePoint).Mul(X, y).MakeAffine()
+	s2 := new(curvePoint).Mul(Y, x).MakeAffine()
+
+	if s1.x != s2.x || s1.y != s2.y {
+		t.Errorf("DH points don't match: (%s, %s) (%s, %s)", &s1.x, &s1.y, &s2.x, &s2.y)
+	}
+}
+
+func TestOrderG1(t *testing.T) {
+	g := new(G1).ScalarBaseMult(Order)
+	if !g.p.IsInfinity() {
+		t.Error("G1 has incorrect order")
+	}
+
+	one := new(G1).ScalarBaseMult(new(big.Int).SetInt64(1))
+	g.Add(g, one)
+	g.p.MakeAffine()
+	one.p.MakeAffine()
+	if g.p.x != one.p.x || g.p.y != one.p.y {
+		t.Errorf("1+0 != 1 in G1")
+	}
+}
+
+func TestOrderG2(t *testing.T) {
+	g := new(G2).ScalarBaseMult(Order)
+	if !g.p.IsInfinity() {
+		t.Error("G2 has incorrect order")
+	}
+
+	one := new(G2).ScalarBaseMult(new(big.Int).SetInt64(1))
+	g.Add(g, one)
+	g.p.MakeAffine()
+	one.p.MakeAffine()
+	if g.p.x != one.p.x || g.p.y != one.p.y {
+		t.Errorf("1+0 != 1 in G2")
+	}
+}
+
+func TestOrderGT(t *testing.T) {
+	gt := Pair(&G1{curveGen}, &G2{twistGen})
+	g := new(GT).ScalarMult(gt, Order)
+	if !g.p.IsOne() {
+		t.Error("GT has incorrect order")
+	}
+}
+
+func TestBilinearity(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		a, p1, _ := RandomG1(rand.Reader)
+		b, p2, _ := RandomG2(rand.Reader)
+		e1 := Pair(p1, p2)
+
+		e2 := Pair(&G1{curveGen}, &G2{twistGen})
+		e2.ScalarMult(e2, a)
+		e2.ScalarMult(e2, b)
+
+		minusE2 := new(GT).Neg(e2)
+		e1.Add(e1, minusE2)
+
+		if !e1.p.IsOne() {
+			t.Fatalf("bad pairing result: %s", e1)
+		}
+	}
+}
+
+func TestPairingProduct(t *testing.T) {
+	_, p1, _ := RandomG1(rand.Reader)
+	_, p2, _ := RandomG2(rand.Reader)
+	_, q1, _ := RandomG1(rand.Reader)
+	_, q2, _ := RandomG2(rand.Reader)
+	inf1, inf2 := new(G1).SetInfinity(), new(G2).SetInfinity()
+
+	want := Pair(p1, p2)
+	want.Add(want, Pair(q1, q2))
+	got := PairingProduct([]*G1{p1, inf1, q1, p1}, []*G2{p2, q2, q2, inf2})
+	if !bytes.Equal(got.Marshal(), want.Marshal()) {
+		t.Error("PairingProduct differs from the product of the pairings")
+	}
+	if !bytes.Equal(PairingProduct([]*G1{p1}, []*G2{p2}).Marshal(), Pair(p1, p2).Marshal()) {
+		t.Error("PairingProduct of one pair differs from Pair")
+	}
+
+	// e(p1, p2)·e(-p1, p2) = 1
+	minusP1 := new(G1).Neg(p1)
+	if !PairingCheck([]*G1{p1, minusP1}, []*G2{p2, p2}) {
+		t.Error("e(p1, p2)·e(-p1, p2) != 1")
+	}
+	if PairingCheck([]*G1{p1, minusP1}, []*G2{p2, q2}) {
+		t.Error("e(p1, p2)·e(-p1, q2) == 1")
+	}
+	if !PairingCheck(nil, nil) || !PairingCheck([]*G1{inf1}, []*G2{p2}) {
+		t.Error("empty product != 1")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("PairingCheck with more G1 than G2 elements did not panic")
+		}
+	}()
+	PairingCheck([]*G1{p1, q1}, []*G2{p2})
+}
+
+func TestHashToG2(t *testing.T) {
+	g := HashToG2([]byte("gridlock"))
+	if !g.p.IsOnCurve() {
+		t.Fatal("hashed point is not on the twist")
+	}
+	if !new(G2).ScalarMult(g, Order).p.IsInfinity() {
+		t.Fatal("hashed point is not in G2")
+	}
+	if _, ok := new(G2).Unmarshal(g.Marshal()); !ok {
+		t.Error("failed to unmarshal hashed point")
+	}
+}
+
+func TestG2UnmarshalOutsideSubgroup(t *testing.T) {
+	for ctr := uint32(0); ; ctr++ {
+		x := hashToGFp2([]byte("gridlock subgroup test"), ctr)
+		yy := (&gfP2{}).Square(x)
+		yy.Mul(yy, x)
+		yy.Add(yy, twistB)
+		y, ok := sqrtGFp2(yy)
+		if !ok {
+			continue
+		}
+		// a point of the twist without the cofactor cleared
+		pt := &G2{&twistPoint{x: *x, y: *y}}
+		pt.p.z.SetOne()
+		pt.p.t.SetOne()
+		if !pt.p.IsOnCurve() {
+			t.Fatal("sample point is not on the twist")
+		}
+		if _, ok := new(G2).Unmarshal(pt.Marshal()); ok {
+			t.Fatal("unmarshaled a point outside of G2")
+		}
+		if _, ok := new(G2).Unmarshal(pt.MarshalCompressed()); ok {
+			t.Fatal("unmarshaled a compressed point outside of G2")
+		}
+		return
+	}
+}
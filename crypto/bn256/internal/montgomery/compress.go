@@ -0,0 +1,280 @@
+package montgomery
+
+import "math/big"
+
+// Compressed encodings. A point of G₁ or G₂ is encoded by a byte of flags
+// followed by its x-coordinate, in 33 or 65 bytes, as p takes all the bits of
+// the 32 bytes of an element of GF(p): flagInfinity marks the point at
+// infinity, whose other bytes are zero, and flagSort the larger of the two
+// y-coordinates that go with x. An element of GT is encoded in 193 bytes by the
+// element c of GF(p⁶) such that it is (c+ω)/(c-ω), with flagInfinity marking
+// the identity.
+//
+// Decoding is strict: every element has a single compressed encoding, and
+// Unmarshal rejects the others as well as the x without a point of the group.
+
+const (
+	flagInfinity = 0x80
+	flagSort     = 0x40
+	flagMask     = flagInfinity | flagSort
+)
+
+// pMinus1Over2 is (p-1)/2, the largest of the smaller elements of GF(p).
+var pMinus1Over2 = new(big.Int).Rsh(p, 1)
+
+// isLargerGFp returns true iff a > -a, seen as integers in [0, p).
+func isLargerGFp(a *gfP) bool {
+	return a.Big().Cmp(pMinus1Over2) > 0
+}
+
+// isLargerGFp2 returns true iff a > -a in the order of their encodings, which
+// compares the imaginary parts first.
+func isLargerGFp2(a *gfP2) bool {
+	if !a.x.IsZero() {
+		return isLargerGFp(&a.x)
+	}
+	return isLargerGFp(&a.y)
+}
+
+// sqrtGFp returns a square root of a and whether one exists.
+func sqrtGFp(a *gfP) (*gfP, bool) {
+	y := new(big.Int).ModSqrt(a.Big(), p)
+	if y == nil {
+		return nil, false
+	}
+	r := newGFpFromBig(y)
+	return &r, true
+}
+
+// unmarshalCompressedFlags splits the compressed encoding m into its flags and
+// the rest, and returns the rest, whether it is the identity, whether flagSort
+// is set and whether the flags are valid.
+func unmarshalCompressedFlags(m []byte) ([]byte, bool, bool, bool) {
+	flags, rest := m[0], m[1:]
+	if flags&^flagMask != 0 {
+		return nil, false, false, false
+	}
+	if flags&flagInfinity == 0 {
+		return rest, false, flags&flagSort != 0, true
+	}
+	if flags != flagInfinity {
+		return nil, false, false, false
+	}
+	for _, b := range rest {
+		if b != 0 {
+			return nil, false, false, false
+		}
+	}
+	return rest, true, false, true
+}
+
+// MarshalCompressed converts e into 33 bytes, about half the size of Marshal.
+func (e *G1) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, 1+numBytes)
+	if e.p.IsInfinity() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine()
+	e.p.x.Marshal(ret[1:])
+	if isLargerGFp(&e.p.y) {
+		ret[0] |= flagSort
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the point encoded by MarshalCompressed in m.
+func (e *G1) unmarshalCompressed(m []byte) (*G1, bool) {
+	x, infinity, sort, ok := unmarshalCompressedFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	if !e.p.x.Unmarshal(x) {
+		return nil, false
+	}
+
+	// y² = x³ + b
+	yy := &gfP{}
+	gfpMul(yy, &e.p.x, &e.p.x)
+	gfpMul(yy, yy, &e.p.x)
+	gfpAdd(yy, yy, &curveB)
+	y, ok := sqrtGFp(yy)
+	if !ok {
+		return nil, false
+	}
+	if isLargerGFp(y) != sort {
+		gfpNeg(y, y)
+		// y = 0 has no larger root
+		if isLargerGFp(y) != sort {
+			return nil, false
+		}
+	}
+	e.p.y = *y
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+	return e, true
+}
+
+// MarshalCompressed converts e into 65 bytes, about half the size of Marshal.
+func (e *G2) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, 1+numBytes*2)
+	if e.p.IsInfinity() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	e.p.MakeAffine()
+	e.p.x.x.Marshal(ret[1+0*numBytes:])
+	e.p.x.y.Marshal(ret[1+1*numBytes:])
+	if isLargerGFp2(&e.p.y) {
+		ret[0] |= flagSort
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the point encoded by MarshalCompressed in m.
+func (e *G2) unmarshalCompressed(m []byte) (*G2, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	x, infinity, sort, ok := unmarshalCompressedFlags(m)
+	if !ok {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+
+	if infinity {
+		e.p.SetInfinity()
+		return e, true
+	}
+
+	if !e.p.x.x.Unmarshal(x[0*numBytes:1*numBytes]) ||
+		!e.p.x.y.Unmarshal(x[1*numBytes:2*numBytes]) {
+		return nil, false
+	}
+
+	// y² = x³ + b'
+	yy := (&gfP2{}).Square(&e.p.x)
+	yy.Mul(yy, &e.p.x)
+	yy.Add(yy, twistB)
+	y, ok := sqrtGFp2(yy)
+	if !ok {
+		return nil, false
+	}
+	if isLargerGFp2(y) != sort {
+		y.Negative(y)
+		// y = 0 has no larger root
+		if isLargerGFp2(y) != sort {
+			return nil, false
+		}
+	}
+	e.p.y = *y
+	e.p.z.SetOne()
+	e.p.t.SetOne()
+
+	// the twist has points outside of G₂, whose order is not Order
+	if !new(twistPoint).Mul(e.p, Order).IsInfinity() {
+		return nil, false
+	}
+	return e, true
+}
+
+// tau is τ = ω², which is not a square in GF(p⁶).
+var tau = &gfP6{y: gfP2{y: newGFp(1)}}
+
+// isUnitary returns true iff a·ā = 1, where ā is the conjugate of a over
+// GF(p⁶), as for every element of GT, and a is not -1, the only such element
+// of order 2. Those are the elements that GT compression encodes.
+func isUnitary(a *gfP12) bool {
+	// (xω + y)(-xω + y) = y² - x²τ
+	n := (&gfP6{}).Square(&a.x)
+	n.MulTau(n)
+	n.Sub((&gfP6{}).Square(&a.y), n)
+	if !n.IsOne() {
+		return false
+	}
+	return !a.x.IsZero() || a.y.IsOne()
+}
+
+// MarshalCompressed converts e into 193 bytes, about half the size of Marshal.
+// The element xω + y ≠ 1 of GT is encoded by c = (1+y)/x, from which it is
+// (c²+τ)/(c²-τ) + 2c/(c²-τ)·ω.
+func (e *GT) MarshalCompressed() []byte {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	ret := make([]byte, 1+numBytes*6)
+	if e.p.IsOne() {
+		ret[0] = flagInfinity
+		return ret
+	}
+
+	c := (&gfP6{}).SetOne()
+	c.Add(c, &e.p.y)
+	c.Mul(c, (&gfP6{}).Invert(&e.p.x))
+	for i, v := range []*gfP{&c.x.x, &c.x.y, &c.y.x, &c.y.y, &c.z.x, &c.z.y} {
+		v.Marshal(ret[1+i*numBytes:])
+	}
+	return ret
+}
+
+// unmarshalCompressed sets e to the element encoded by MarshalCompressed in m.
+func (e *GT) unmarshalCompressed(m []byte) (*GT, bool) {
+	// Each value is a 256-bit number.
+	const numBytes = 256 / 8
+
+	m, one, sort, ok := unmarshalCompressedFlags(m)
+	if !ok || sort {
+		return nil, false
+	}
+
+	if e.p == nil {
+		e.p = &gfP12{}
+	}
+
+	if one {
+		e.p.SetOne()
+		return e, true
+	}
+
+	c := &gfP6{}
+	for i, v := range []*gfP{&c.x.x, &c.x.y, &c.y.x, &c.y.y, &c.z.x, &c.z.y} {
+		if !v.Unmarshal(m[i*numBytes : (i+1)*numBytes]) {
+			return nil, false
+		}
+	}
+	// c = 0 would encode -1, which is not in GT
+	if c.IsZero() {
+		return nil, false
+	}
+
+	// c² - τ is not zero since τ is not a square
+	cc := (&gfP6{}).Square(c)
+	d := (&gfP6{}).Sub(cc, tau)
+	d.Invert(d)
+	e.p.y.Add(cc, tau)
+	e.p.y.Mul(&e.p.y, d)
+	e.p.x.Double(c)
+	e.p.x.Mul(&e.p.x, d)
+	return e, true
+}
@@ -0,0 +1,122 @@
+package montgomery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestMarshalCompressed(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		_, a, _ := RandomG1(rand.Reader)
+		_, b, _ := RandomG2(rand.Reader)
+		c := Pair(a, b)
+		for _, g := range []*G1{a, new(G1).Neg(a), new(G1).SetInfinity()} {
+			m := g.MarshalCompressed()
+			if len(m) != 33 {
+				t.Fatalf("G1 compressed to %d bytes", len(m))
+			}
+			g2, ok := new(G1).Unmarshal(m)
+			if !ok || !bytes.Equal(g2.Marshal(), g.Marshal()) || !bytes.Equal(g2.MarshalCompressed(), m) {
+				t.Errorf("G1 %x does not round trip", m)
+			}
+		}
+		for _, g := range []*G2{b, new(G2).Neg(b), new(G2).SetInfinity()} {
+			m := g.MarshalCompressed()
+			if len(m) != 65 {
+				t.Fatalf("G2 compressed to %d bytes", len(m))
+			}
+			g2, ok := new(G2).Unmarshal(m)
+			if !ok || !bytes.Equal(g2.Marshal(), g.Marshal()) || !bytes.Equal(g2.MarshalCompressed(), m) {
+				t.Errorf("G2 %x does not round trip", m)
+			}
+		}
+		one := Pair(a, new(G2).SetInfinity())
+		for _, g := range []*GT{c, new(GT).Neg(c), one} {
+			m := g.MarshalCompressed()
+			if len(m) != 193 {
+				t.Fatalf("GT compressed to %d bytes", len(m))
+			}
+			g2, ok := new(GT).Unmarshal(m)
+			if !ok || !bytes.Equal(g2.Marshal(), g.Marshal()) || !bytes.Equal(g2.MarshalCompressed(), m) {
+				t.Errorf("GT %x does not round trip", m)
+			}
+		}
+	}
+}
+
+func TestUnmarshalCompressedStrict(t *testing.T) {
+	_, a, _ := RandomG1(rand.Reader)
+	_, b, _ := RandomG2(rand.Reader)
+	c := Pair(a, b)
+
+	flipped := func(m []byte, mask byte) []byte {
+		m = append([]byte{}, m...)
+		m[0] ^= mask
+		return m
+	}
+	pBytes := make([]byte, 32)
+	p.FillBytes(pBytes)
+
+	g1Cases := map[string][]byte{
+		"∞ with flagSort":   flipped(new(G1).SetInfinity().MarshalCompressed(), flagSort),
+		"∞ with x":          append([]byte{flagInfinity}, a.MarshalCompressed()[1:]...),
+		"x = p":             append([]byte{0}, pBytes...),
+		"unknown flag":      flipped(a.MarshalCompressed(), 0x01),
+		"x without a point": nil,
+	}
+	for x := int64(1); ; x++ {
+		// x³ + 3 is not a square
+		yy := new(big.Int).Add(new(big.Int).Exp(big.NewInt(x), big.NewInt(3), nil), big.NewInt(3))
+		if big.Jacobi(yy, p) == -1 {
+			g1Cases["x without a point"] = new(big.Int).SetInt64(x).FillBytes(make([]byte, 33))
+			break
+		}
+	}
+	for name, m := range g1Cases {
+		if _, ok := new(G1).Unmarshal(m); ok {
+			t.Errorf("G1 Unmarshal accepted %s", name)
+		}
+	}
+
+	g2Cases := map[string][]byte{
+		"∞ with flagSort": flipped(new(G2).SetInfinity().MarshalCompressed(), flagSort),
+		"∞ with x":        append([]byte{flagInfinity}, b.MarshalCompressed()[1:]...),
+		"x.x = p":         append(append([]byte{0}, pBytes...), b.MarshalCompressed()[33:]...),
+		"x.y = p":         append(append([]byte{}, b.MarshalCompressed()[:33]...), pBytes...),
+	}
+	for name, m := range g2Cases {
+		if _, ok := new(G2).Unmarshal(m); ok {
+			t.Errorf("G2 Unmarshal accepted %s", name)
+		}
+	}
+
+	// the other root of a valid x decodes to the negated point
+	if g, ok := new(G1).Unmarshal(flipped(a.MarshalCompressed(), flagSort)); !ok || !bytes.Equal(g.Marshal(), new(G1).Neg(a).Marshal()) {
+		t.Error("G1 with flagSort flipped is not -a")
+	}
+
+	gtCases := map[string][]byte{
+		"1 with flagSort": flipped(Pair(a, new(G2).SetInfinity()).MarshalCompressed(), flagSort),
+		"flagSort":        flipped(c.MarshalCompressed(), flagSort),
+		"c = 0":           make([]byte, 193),
+		"c.x.x = p":       append(append([]byte{0}, pBytes...), c.MarshalCompressed()[33:]...),
+	}
+	for name, m := range gtCases {
+		if _, ok := new(GT).Unmarshal(m); ok {
+			t.Errorf("GT Unmarshal accepted %s", name)
+		}
+	}
+
+	// an element of GF(p¹²) that is not unitary, such as 2, or -1
+	two := &GT{(&gfP12{}).SetOne()}
+	two.p.Add(two.p, two.p)
+	minusOne := &GT{(&gfP12{}).SetOne()}
+	minusOne.p.Negative(minusOne.p)
+	for name, g := range map[string]*GT{"2": two, "-1": minusOne} {
+		if _, ok := new(GT).Unmarshal(g.Marshal()); ok {
+			t.Errorf("GT Unmarshal accepted %s", name)
+		}
+	}
+}
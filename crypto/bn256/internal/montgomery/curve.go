@@ -0,0 +1,212 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package montgomery
+
+import (
+	"math/big"
+)
+
+// curvePoint implements the elliptic curve y²=x³+3. Points are kept in
+// Jacobian form and t=z² when valid. G₁ is the set of points of this curve on
+// GF(p).
+type curvePoint struct {
+	x, y, z, t gfP
+}
+
+var curveB = newGFp(3)
+
+// curveGen is the generator of G₁.
+var curveGen = &curvePoint{
+	newGFp(1),
+	newGFp(-2),
+	newGFp(1),
+	newGFp(1),
+}
+
+func (c *curvePoint) String() string {
+	c.MakeAffine()
+	return "(" + c.x.String() + ", " + c.y.String() + ")"
+}
+
+func (c *curvePoint) Set(a *curvePoint) {
+	*c = *a
+}
+
+// IsOnCurve returns true iff c is on the curve where c must be in affine form.
+func (c *curvePoint) IsOnCurve() bool {
+	yy, xxx := &gfP{}, &gfP{}
+	gfpMul(yy, &c.y, &c.y)
+	gfpMul(xxx, &c.x, &c.x)
+	gfpMul(xxx, xxx, &c.x)
+	gfpSub(yy, yy, xxx)
+	gfpSub(yy, yy, &curveB)
+	return yy.IsZero()
+}
+
+func (c *curvePoint) SetInfinity() {
+	c.z.SetZero()
+}
+
+func (c *curvePoint) IsInfinity() bool {
+	return c.z.IsZero()
+}
+
+func (c *curvePoint) Add(a, b *curvePoint) {
+	if a.IsInfinity() {
+		c.Set(b)
+		return
+	}
+	if b.IsInfinity() {
+		c.Set(a)
+		return
+	}
+
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/addition/add-2007-bl.op3
+
+	// Normalize the points by replacing a = [x1:y1:z1] and b = [x2:y2:z2]
+	// by [u1:s1:z1·z2] and [u2:s2:z1·z2]
+	// where u1 = x1·z2², s1 = y1·z2³ and u1 = x2·z1², s2 = y2·z1³
+	var z1z1, z2z2, u1, u2, t, s1, s2 gfP
+	gfpMul(&z1z1, &a.z, &a.z)
+	gfpMul(&z2z2, &b.z, &b.z)
+	gfpMul(&u1, &a.x, &z2z2)
+	gfpMul(&u2, &b.x, &z1z1)
+
+	gfpMul(&t, &b.z, &z2z2)
+	gfpMul(&s1, &a.y, &t)
+
+	gfpMul(&t, &a.z, &z1z1)
+	gfpMul(&s2, &b.y, &t)
+
+	// Compute x = (2h)²(s²-u1-u2)
+	// where s = (s2-s1)/(u2-u1) is the slope of the line through
+	// (u1,s1) and (u2,s2). The extra factor 2h = 2(u2-u1) comes from the value of z below.
+	// This is also:
+	// 4(s2-s1)² - 4h²(u1+u2) = 4(s2-s1)² - 4h³ - 4h²(2u1)
+	//                        = r² - j - 2v
+	// with the notations below.
+	var h, i, j, r, v, t4, t6 gfP
+	gfpSub(&h, &u2, &u1)
+	xEqual := h.IsZero()
+
+	gfpAdd(&t, &h, &h)
+	// i = 4h²
+	gfpMul(&i, &t, &t)
+	// j = 4h³
+	gfpMul(&j, &h, &i)
+
+	gfpSub(&t, &s2, &s1)
+	yEqual := t.IsZero()
+	if xEqual && yEqual {
+		c.Double(a)
+		return
+	}
+	gfpAdd(&r, &t, &t)
+
+	gfpMul(&v, &u1, &i)
+
+	// t4 = 4(s2-s1)²
+	gfpMul(&t4, &r, &r)
+	gfpAdd(&t, &v, &v)
+	gfpSub(&t6, &t4, &j)
+	gfpSub(&c.x, &t6, &t)
+
+	// Set y = -(2h)³(s1 + s*(x/4h²-u1))
+	// This is also
+	// y = - 2·s1·j - (s2-s1)(2x - 2i·u1) = r(v-x) - 2·s1·j
+	gfpSub(&t, &v, &c.x)  // t7
+	gfpMul(&t4, &s1, &j)  // t8
+	gfpAdd(&t6, &t4, &t4) // t9
+	gfpMul(&t4, &r, &t)   // t10
+	gfpSub(&c.y, &t4, &t6)
+
+	// Set z = 2(u2-u1)·z1·z2 = 2h·z1·z2
+	gfpAdd(&t, &a.z, &b.z) // t11
+	gfpMul(&t4, &t, &t)    // t12
+	gfpSub(&t, &t4, &z1z1) // t13
+	gfpSub(&t4, &t, &z2z2) // t14
+	gfpMul(&c.z, &t4, &h)
+}
+
+func (c *curvePoint) Double(a *curvePoint) {
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/doubling/dbl-2009-l.op3
+	var A, B, C, t, t2, d, e, f gfP
+	gfpMul(&A, &a.x, &a.x)
+	gfpMul(&B, &a.y, &a.y)
+	gfpMul(&C, &B, &B)
+
+	gfpAdd(&t, &a.x, &B)
+	gfpMul(&t2, &t, &t)
+	gfpSub(&t, &t2, &A)
+	gfpSub(&t2, &t, &C)
+	gfpAdd(&d, &t2, &t2)
+	gfpAdd(&t, &A, &A)
+	gfpAdd(&e, &t, &A)
+	gfpMul(&f, &e, &e)
+
+	gfpAdd(&t, &d, &d)
+	gfpSub(&c.x, &f, &t)
+
+	gfpAdd(&t, &C, &C)
+	gfpAdd(&t2, &t, &t)
+	gfpAdd(&t, &t2, &t2)
+	gfpSub(&c.y, &d, &c.x)
+	gfpMul(&t2, &e, &c.y)
+	gfpSub(&c.y, &t2, &t)
+
+	gfpMul(&t, &a.y, &a.z)
+	gfpAdd(&c.z, &t, &t)
+}
+
+func (c *curvePoint) Mul(a *curvePoint, scalar *big.Int) *curvePoint {
+	sum := &curvePoint{}
+	sum.SetInfinity()
+	t := &curvePoint{}
+
+	for i := scalar.BitLen(); i >= 0; i-- {
+		t.Double(sum)
+		if scalar.Bit(i) != 0 {
+			sum.Add(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+
+	c.Set(sum)
+	return c
+}
+
+// MakeAffine converts c to affine form and returns c. If c is ∞, then it sets
+// c to 0 : 1 : 0.
+func (c *curvePoint) MakeAffine() *curvePoint {
+	if c.z.IsOne() {
+		return c
+	}
+	if c.IsInfinity() {
+		c.x.SetZero()
+		c.y.SetOne()
+		c.z.SetZero()
+		c.t.SetZero()
+		return c
+	}
+
+	var zInv, t, zInv2 gfP
+	zInv.Invert(&c.z)
+	gfpMul(&t, &c.y, &zInv)
+	gfpMul(&zInv2, &zInv, &zInv)
+	gfpMul(&c.y, &t, &zInv2)
+	gfpMul(&t, &c.x, &zInv2)
+	c.x = t
+	c.z.SetOne()
+	c.t.SetOne()
+	return c
+}
+
+func (c *curvePoint) Negative(a *curvePoint) {
+	c.x = a.x
+	gfpNeg(&c.y, &a.y)
+	c.z = a.z
+	c.t.SetZero()
+}
@@ -0,0 +1,92 @@
+package montgomery
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+// twistCofactor is the cofactor of G₂ in the group of points of the twist: 2p-n.
+var twistCofactor = new(big.Int).Sub(new(big.Int).Lsh(p, 1), Order)
+
+// HashToG2 deterministically maps msg to a point of G₂ whose discrete logarithm
+// with respect to the generator is unknown. It uses try-and-increment on the
+// x-coordinate of the twist followed by clearing the cofactor.
+func HashToG2(msg []byte) *G2 {
+	for ctr := uint32(0); ; ctr++ {
+		x := hashToGFp2(msg, ctr)
+
+		// y² = x³ + b'
+		yy := (&gfP2{}).Square(x)
+		yy.Mul(yy, x)
+		yy.Add(yy, twistB)
+
+		y, ok := sqrtGFp2(yy)
+		if !ok {
+			continue
+		}
+		pt := &twistPoint{x: *x, y: *y}
+		pt.z.SetOne()
+		pt.t.SetOne()
+
+		e := &G2{&twistPoint{}}
+		e.p.Mul(pt, twistCofactor)
+		if e.p.IsInfinity() {
+			continue
+		}
+		e.p.MakeAffine()
+		return e
+	}
+}
+
+// hashToGFp2 derives an element of GF(p²) from msg and a counter.
+func hashToGFp2(msg []byte, ctr uint32) *gfP2 {
+	var buf [5]byte
+	binary.BigEndian.PutUint32(buf[:4], ctr)
+
+	e := &gfP2{}
+	for i, c := range []*gfP{&e.x, &e.y} {
+		buf[4] = byte(i)
+		digest := sha256.New()
+		digest.Write(msg)
+		digest.Write(buf[:])
+		*c = newGFpFromBig(new(big.Int).SetBytes(digest.Sum(nil)))
+	}
+	return e
+}
+
+// sqrtGFp2 returns a square root of a and whether one exists. Since p ≡ 3 mod 4
+// it follows Algorithm 9 of "Square root computation over even extension
+// fields", Adj and Rodríguez-Henríquez.
+func sqrtGFp2(a *gfP2) (*gfP2, bool) {
+	if a.IsZero() {
+		return &gfP2{}, true
+	}
+	minusOne := &gfP2{}
+	minusOne.y = newGFp(-1)
+
+	// a1 = a^((p-3)/4), alpha = a^((p-1)/2)
+	a1 := (&gfP2{}).Exp(a, new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(3)), 2))
+	alpha := (&gfP2{}).Square(a1)
+	alpha.Mul(alpha, a)
+
+	x0 := (&gfP2{}).Mul(a1, a)
+
+	x := &gfP2{}
+	if *alpha == *minusOne {
+		// x = i·x0
+		x.x = x0.y
+		gfpNeg(&x.y, &x0.x)
+	} else {
+		b := (&gfP2{}).SetOne()
+		b.Add(b, alpha)
+		b.Exp(b, new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(1)), 1))
+		x.Mul(b, x0)
+	}
+
+	// a is a quadratic non-residue if x² ≠ a
+	if check := (&gfP2{}).Square(x); *check != *a {
+		return nil, false
+	}
+	return x, true
+}
@@ -0,0 +1,355 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package montgomery
+
+func lineFunctionAdd(r, p *twistPoint, q *curvePoint, r2 *gfP2) (a, b, c *gfP2, rOut *twistPoint) {
+	// See the mixed addition algorithm from "Faster Computation of the
+	// Tate Pairing", http://arxiv.org/pdf/0904.0854v3.pdf
+
+	B := (&gfP2{}).Mul(&p.x, &r.t)
+
+	D := (&gfP2{}).Add(&p.y, &r.z)
+	D.Square(D)
+	D.Sub(D, r2)
+	D.Sub(D, &r.t)
+	D.Mul(D, &r.t)
+
+	H := (&gfP2{}).Sub(B, &r.x)
+	I := (&gfP2{}).Square(H)
+
+	E := (&gfP2{}).Add(I, I)
+	E.Add(E, E)
+
+	J := (&gfP2{}).Mul(H, E)
+
+	L1 := (&gfP2{}).Sub(D, &r.y)
+	L1.Sub(L1, &r.y)
+
+	V := (&gfP2{}).Mul(&r.x, E)
+
+	rOut = &twistPoint{}
+	rOut.x.Square(L1)
+	rOut.x.Sub(&rOut.x, J)
+	rOut.x.Sub(&rOut.x, V)
+	rOut.x.Sub(&rOut.x, V)
+
+	rOut.z.Add(&r.z, H)
+	rOut.z.Square(&rOut.z)
+	rOut.z.Sub(&rOut.z, &r.t)
+	rOut.z.Sub(&rOut.z, I)
+
+	t := (&gfP2{}).Sub(V, &rOut.x)
+	t.Mul(t, L1)
+	t2 := (&gfP2{}).Mul(&r.y, J)
+	t2.Add(t2, t2)
+	rOut.y.Sub(t, t2)
+
+	rOut.t.Square(&rOut.z)
+
+	t.Add(&p.y, &rOut.z)
+	t.Square(t)
+	t.Sub(t, r2)
+	t.Sub(t, &rOut.t)
+
+	t2.Mul(L1, &p.x)
+	t2.Add(t2, t2)
+	a = (&gfP2{}).Sub(t2, t)
+
+	c = (&gfP2{}).MulScalar(&rOut.z, &q.y)
+	c.Add(c, c)
+
+	b = (&gfP2{}).SetZero()
+	b.Sub(b, L1)
+	b.MulScalar(b, &q.x)
+	b.Add(b, b)
+
+	return
+}
+
+func lineFunctionDouble(r *twistPoint, q *curvePoint) (a, b, c *gfP2, rOut *twistPoint) {
+	// See the doubling algorithm for a=0 from "Faster Computation of the
+	// Tate Pairing", http://arxiv.org/pdf/0904.0854v3.pdf
+
+	A := (&gfP2{}).Square(&r.x)
+	B := (&gfP2{}).Square(&r.y)
+	C := (&gfP2{}).Square(B)
+
+	D := (&gfP2{}).Add(&r.x, B)
+	D.Square(D)
+	D.Sub(D, A)
+	D.Sub(D, C)
+	D.Add(D, D)
+
+	E := (&gfP2{}).Add(A, A)
+	E.Add(E, A)
+
+	G := (&gfP2{}).Square(E)
+
+	rOut = &twistPoint{}
+	rOut.x.Sub(G, D)
+	rOut.x.Sub(&rOut.x, D)
+
+	rOut.z.Add(&r.y, &r.z)
+	rOut.z.Square(&rOut.z)
+	rOut.z.Sub(&rOut.z, B)
+	rOut.z.Sub(&rOut.z, &r.t)
+
+	rOut.y.Sub(D, &rOut.x)
+	rOut.y.Mul(&rOut.y, E)
+	t := (&gfP2{}).Add(C, C)
+	t.Add(t, t)
+	t.Add(t, t)
+	rOut.y.Sub(&rOut.y, t)
+
+	rOut.t.Square(&rOut.z)
+
+	t.Mul(E, &r.t)
+	t.Add(t, t)
+	b = (&gfP2{}).SetZero()
+	b.Sub(b, t)
+	b.MulScalar(b, &q.x)
+
+	a = (&gfP2{}).Add(&r.x, E)
+	a.Square(a)
+	a.Sub(a, A)
+	a.Sub(a, G)
+	t.Add(B, B)
+	t.Add(t, t)
+	a.Sub(a, t)
+
+	c = (&gfP2{}).Mul(&rOut.z, &r.t)
+	c.Add(c, c)
+	c.MulScalar(c, &q.y)
+
+	return
+}
+
+func mulLine(ret *gfP12, a, b, c *gfP2) {
+	a2 := &gfP6{}
+	a2.x.SetZero()
+	a2.y.Set(a)
+	a2.z.Set(b)
+	a2.Mul(a2, &ret.x)
+	t3 := (&gfP6{}).MulScalar(&ret.y, c)
+
+	t := (&gfP2{}).Add(b, c)
+	t2 := &gfP6{}
+	t2.x.SetZero()
+	t2.y.Set(a)
+	t2.z.Set(t)
+	ret.x.Add(&ret.x, &ret.y)
+
+	ret.y.Set(t3)
+
+	ret.x.Mul(&ret.x, t2)
+	ret.x.Sub(&ret.x, a2)
+	ret.x.Sub(&ret.x, &ret.y)
+	a2.MulTau(a2)
+	ret.y.Add(&ret.y, a2)
+}
+
+// sixuPlus2NAF is 6u+2 in non-adjacent form.
+var sixuPlus2NAF = []int8{0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 0, -1, 0, 1, 0, 1, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, -1, 0, 1, 0, 0, 0, 1, 0, -1, 0, 0, 0, -1, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, -1, 0, -1, 0, 0, 0, 0, 1, 0, 0, 0, 1}
+
+// miller implements the Miller loop for calculating the Optimal Ate pairing.
+// See algorithm 1 from http://cryptojedi.org/papers/dclxvi-20100714.pdf
+func miller(q *twistPoint, p *curvePoint) *gfP12 {
+	return millerProduct([]*twistPoint{q}, []*curvePoint{p})
+}
+
+// millerPair is the state of the Miller loop of one pair of points.
+type millerPair struct {
+	aAffine, minusA, r *twistPoint
+	bAffine            *curvePoint
+	r2                 *gfP2
+}
+
+// millerProduct computes the product of the Miller loops of the pairs
+// (qs[i], ps[i]). The loops run side by side and share the squarings of the
+// accumulator, so that n pairs cost one loop of squarings and n of lines.
+func millerProduct(qs []*twistPoint, ps []*curvePoint) *gfP12 {
+	ret := (&gfP12{}).SetOne()
+
+	pairs := make([]millerPair, len(qs))
+	for j := range pairs {
+		m := &pairs[j]
+		m.aAffine = &twistPoint{}
+		m.aAffine.Set(qs[j])
+		m.aAffine.MakeAffine()
+
+		m.bAffine = &curvePoint{}
+		m.bAffine.Set(ps[j])
+		m.bAffine.MakeAffine()
+
+		m.minusA = &twistPoint{}
+		m.minusA.Negative(m.aAffine)
+
+		m.r = &twistPoint{}
+		m.r.Set(m.aAffine)
+
+		m.r2 = (&gfP2{}).Square(&m.aAffine.y)
+	}
+
+	for i := len(sixuPlus2NAF) - 1; i > 0; i-- {
+		if i != len(sixuPlus2NAF)-1 {
+			ret.Square(ret)
+		}
+		for j := range pairs {
+			m := &pairs[j]
+			a, b, c, newR := lineFunctionDouble(m.r, m.bAffine)
+			mulLine(ret, a, b, c)
+			m.r = newR
+
+			switch sixuPlus2NAF[i-1] {
+			case 1:
+				a, b, c, newR = lineFunctionAdd(m.r, m.aAffine, m.bAffine, m.r2)
+			case -1:
+				a, b, c, newR = lineFunctionAdd(m.r, m.minusA, m.bAffine, m.r2)
+			default:
+				continue
+			}
+
+			mulLine(ret, a, b, c)
+			m.r = newR
+		}
+	}
+
+	for j := range pairs {
+		m := &pairs[j]
+
+		// In order to calculate Q1 we have to convert q from the sextic twist
+		// to the full GF(p^12) group, apply the Frobenius there, and convert
+		// back.
+		//
+		// The twist isomorphism is (x', y') -> (xω², yω³). If we consider just
+		// x for a moment, then after applying the Frobenius, we have x̄ω^(2p)
+		// where x̄ is the conjugate of x. If we are going to apply the inverse
+		// isomorphism we need a value with a single coefficient of ω² so we
+		// rewrite this as x̄ω^(2p-2)ω². ξ⁶ = ω and, due to the construction of
+		// p, 2p-2 is a multiple of six. Therefore we can rewrite as
+		// x̄ξ^((p-1)/3)ω² and applying the inverse isomorphism eliminates the
+		// ω².
+		//
+		// A similar argument can be made for the y value.
+
+		q1 := &twistPoint{}
+		q1.x.Conjugate(&m.aAffine.x)
+		q1.x.Mul(&q1.x, xiToPMinus1Over3)
+		q1.y.Conjugate(&m.aAffine.y)
+		q1.y.Mul(&q1.y, xiToPMinus1Over2)
+		q1.z.SetOne()
+		q1.t.SetOne()
+
+		// For Q2 we are applying the p² Frobenius. The two conjugations cancel
+		// out and we are left only with the factors from the isomorphism. In
+		// the case of x, we end up with a pure number which is why
+		// xiToPSquaredMinus1Over3 is ∈ GF(p). With y we get a factor of -1. We
+		// ignore this to end up with -Q2.
+
+		minusQ2 := &twistPoint{}
+		minusQ2.x.MulScalar(&m.aAffine.x, &xiToPSquaredMinus1Over3)
+		minusQ2.y.Set(&m.aAffine.y)
+		minusQ2.z.SetOne()
+		minusQ2.t.SetOne()
+
+		m.r2.Square(&q1.y)
+		a, b, c, newR := lineFunctionAdd(m.r, q1, m.bAffine, m.r2)
+		mulLine(ret, a, b, c)
+		m.r = newR
+
+		m.r2.Square(&minusQ2.y)
+		a, b, c, _ = lineFunctionAdd(m.r, minusQ2, m.bAffine, m.r2)
+		mulLine(ret, a, b, c)
+	}
+
+	return ret
+}
+
+// finalExponentiation computes the (p¹²-1)/Order-th power of an element of
+// GF(p¹²) to obtain an element of GT (steps 13-15 of algorithm 1 from
+// http://cryptojedi.org/papers/dclxvi-20100714.pdf)
+func finalExponentiation(in *gfP12) *gfP12 {
+	t1 := &gfP12{}
+
+	// This is the p^6-Frobenius
+	t1.x.Negative(&in.x)
+	t1.y.Set(&in.y)
+
+	inv := (&gfP12{}).Invert(in)
+	t1.Mul(t1, inv)
+
+	t2 := (&gfP12{}).FrobeniusP2(t1)
+	t1.Mul(t1, t2)
+
+	fp := (&gfP12{}).Frobenius(t1)
+	fp2 := (&gfP12{}).FrobeniusP2(t1)
+	fp3 := (&gfP12{}).Frobenius(fp2)
+
+	fu := (&gfP12{}).Exp(t1, u)
+	fu2 := (&gfP12{}).Exp(fu, u)
+	fu3 := (&gfP12{}).Exp(fu2, u)
+
+	y3 := (&gfP12{}).Frobenius(fu)
+	fu2p := (&gfP12{}).Frobenius(fu2)
+	fu3p := (&gfP12{}).Frobenius(fu3)
+	y2 := (&gfP12{}).FrobeniusP2(fu2)
+
+	y0 := (&gfP12{}).Mul(fp, fp2)
+	y0.Mul(y0, fp3)
+
+	y1, y4, y5 := &gfP12{}, &gfP12{}, &gfP12{}
+	y1.Conjugate(t1)
+	y5.Conjugate(fu2)
+	y3.Conjugate(y3)
+	y4.Mul(fu, fu2p)
+	y4.Conjugate(y4)
+
+	y6 := (&gfP12{}).Mul(fu3, fu3p)
+	y6.Conjugate(y6)
+
+	t0 := (&gfP12{}).Square(y6)
+	t0.Mul(t0, y4)
+	t0.Mul(t0, y5)
+	t1.Mul(y3, y5)
+	t1.Mul(t1, t0)
+	t0.Mul(t0, y2)
+	t1.Square(t1)
+	t1.Mul(t1, t0)
+	t1.Square(t1)
+	t0.Mul(t1, y1)
+	t1.Mul(t1, y0)
+	t0.Square(t0)
+	t0.Mul(t0, t1)
+
+	return t0
+}
+
+// pairingProduct returns the product of the optimal ate pairings of the pairs
+// (a[i], b[i]). The pairs with ∞, whose pairing is one, are left out of the
+// Miller loops, which share a single final exponentiation.
+func pairingProduct(a []*twistPoint, b []*curvePoint) *gfP12 {
+	var qs []*twistPoint
+	var ps []*curvePoint
+	for i := range a {
+		if !a[i].IsInfinity() && !b[i].IsInfinity() {
+			qs, ps = append(qs, a[i]), append(ps, b[i])
+		}
+	}
+	if len(qs) == 0 {
+		return (&gfP12{}).SetOne()
+	}
+	return finalExponentiation(millerProduct(qs, ps))
+}
+
+func optimalAte(a *twistPoint, b *curvePoint) *gfP12 {
+	e := miller(a, b)
+	ret := finalExponentiation(e)
+
+	if a.IsInfinity() || b.IsInfinity() {
+		ret.SetOne()
+	}
+
+	return ret
+}
@@ -0,0 +1,107 @@
+package montgomery
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/blockchain-research/gridlock/crypto/internal/window"
+)
+
+// Fixed-base scalar multiplication with the tables of package window, which
+// run in constant time like MulCT. A table of G₁ takes 96KiB and one of G₂
+// 192KiB.
+
+type curveTable = window.Table[curveProjective, *curveProjective]
+
+func newCurveTable(a *curvePoint) *curveTable {
+	var base curveProjective
+	base.SetJacobian(a)
+	return window.NewTable[curveProjective](base)
+}
+
+// curveTableMul sets c to (k mod Order)·a, where a is the point of t, in
+// constant time and returns c.
+func curveTableMul(t *curveTable, c *curvePoint, k *big.Int) *curvePoint {
+	var sum curveProjective
+	t.Mul(&sum, scalarWindows(k))
+	sum.Jacobian(c)
+	return c
+}
+
+type twistTable = window.Table[twistProjective, *twistProjective]
+
+func newTwistTable(a *twistPoint) *twistTable {
+	var base twistProjective
+	base.SetJacobian(a)
+	return window.NewTable[twistProjective](base)
+}
+
+// twistTableMul sets c to (k mod Order)·a, where a is the point of t, in
+// constant time and returns c.
+func twistTableMul(t *twistTable, c *twistPoint, k *big.Int) *twistPoint {
+	var sum twistProjective
+	t.Mul(&sum, scalarWindows(k))
+	sum.Jacobian(c)
+	return c
+}
+
+// The tables of the generators are built on first use.
+var (
+	curveGenOnce, twistGenOnce sync.Once
+	curveGenTable              *curveTable
+	twistGenTable              *twistTable
+)
+
+func curveGenMul(c *curvePoint, k *big.Int) *curvePoint {
+	curveGenOnce.Do(func() { curveGenTable = newCurveTable(curveGen) })
+	return curveTableMul(curveGenTable, c, k)
+}
+
+func twistGenMul(c *twistPoint, k *big.Int) *twistPoint {
+	twistGenOnce.Do(func() { twistGenTable = newTwistTable(twistGen) })
+	return twistTableMul(twistGenTable, c, k)
+}
+
+// G1Table holds precomputed multiples of a fixed element of G₁, which makes
+// multiplying that element by many scalars 2 to 3 times faster.
+type G1Table struct {
+	t *curveTable
+}
+
+// NewG1Table returns the table of the multiples of a.
+func NewG1Table(a *G1) *G1Table {
+	return &G1Table{newCurveTable(a.p)}
+}
+
+// ScalarMultTable sets e to a*k, where a is the element of t, and then returns
+// e. It runs in constant time for secret scalars; only reducing a k outside of
+// [0, Order) is not.
+func (e *G1) ScalarMultTable(t *G1Table, k *big.Int) *G1 {
+	if e.p == nil {
+		e.p = &curvePoint{}
+	}
+	curveTableMul(t.t, e.p, k)
+	return e
+}
+
+// G2Table holds precomputed multiples of a fixed element of G₂, which makes
+// multiplying that element by many scalars 2 to 3 times faster.
+type G2Table struct {
+	t *twistTable
+}
+
+// NewG2Table returns the table of the multiples of a, which must be in G₂.
+func NewG2Table(a *G2) *G2Table {
+	return &G2Table{newTwistTable(a.p)}
+}
+
+// ScalarMultTable sets e to a*k, where a is the element of t, and then returns
+// e. It runs in constant time for secret scalars; only reducing a k outside of
+// [0, Order) is not.
+func (e *G2) ScalarMultTable(t *G2Table, k *big.Int) *G2 {
+	if e.p == nil {
+		e.p = &twistPoint{}
+	}
+	twistTableMul(t.t, e.p, k)
+	return e
+}
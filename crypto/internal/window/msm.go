@@ -0,0 +1,113 @@
+package window
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Multi-scalar multiplication with the bucket method of Pippenger, for public
+// scalars. The scalars are cut into windows of c bits. For every window, each
+// point is added to the bucket of its digit and the buckets are summed with
+// weights 1 to 2ᶜ-1 by running sums, so that a window costs n+2ᶜ⁺¹ additions
+// for n points instead of n scalar multiplications overall. The points are
+// added with the complete projective formulas, as buckets may hold equal
+// points. Below MinPoints points, or for scalars of a single bit as in sums
+// of commitments, the buckets cost more than they save and the callers sum
+// the multiples one by one.
+
+// MinPoints is the smallest number of points summed with buckets.
+const MinPoints = 8
+
+// Scalars returns |k| mod order of every scalar, whether it is negative and
+// the largest bit length.
+func Scalars(scalars []*big.Int, order *big.Int) ([]*big.Int, []bool, int) {
+	ks := make([]*big.Int, len(scalars))
+	neg := make([]bool, len(scalars))
+	maxBits := 0
+	for i, k := range scalars {
+		neg[i] = k.Sign() < 0
+		ks[i] = k
+		if neg[i] {
+			ks[i] = new(big.Int).Neg(k)
+		}
+		if ks[i].Cmp(order) >= 0 {
+			ks[i] = new(big.Int).Mod(ks[i], order)
+		}
+		if ks[i].BitLen() > maxBits {
+			maxBits = ks[i].BitLen()
+		}
+	}
+	return ks, neg, maxBits
+}
+
+// size returns the number of bits of a window for n points, which is about
+// log₂(n)-1 and at most the bit length of the scalars.
+func size(n, maxBits int) int {
+	c := bits.Len(uint(n)) - 2
+	if c < 1 {
+		c = 1
+	}
+	if c > 16 {
+		c = 16
+	}
+	if c > maxBits {
+		c = maxBits
+	}
+	return c
+}
+
+// digit returns the c bits of k from bit offset.
+func digit(k *big.Int, offset, c int) int {
+	d := 0
+	for i := c - 1; i >= 0; i-- {
+		d = d<<1 | int(k.Bit(offset+i))
+	}
+	return d
+}
+
+// MultiMul sets sum to the sum of ks[i]·points[i], where the scalars are not
+// negative and of at most maxBits bits, as returned by Scalars.
+func MultiMul[T any, P Point[T]](sum *T, points []T, ks []*big.Int, maxBits int) {
+	P(sum).SetInfinity()
+	if maxBits == 0 {
+		return
+	}
+	w := size(len(points), maxBits)
+	buckets := make([]T, 1<<w)
+	used := make([]bool, 1<<w)
+	for window := (maxBits+w-1)/w - 1; window >= 0; window-- {
+		for i := 0; i < w; i++ {
+			P(sum).Double(sum)
+		}
+		for d := range used {
+			used[d] = false
+		}
+		for i := range points {
+			d := digit(ks[i], window*w, w)
+			switch {
+			case d == 0:
+			case used[d]:
+				P(&buckets[d]).Add(&buckets[d], &points[i])
+			default:
+				buckets[d] = points[i]
+				used[d] = true
+			}
+		}
+
+		// acc = Σ d·bucket[d] = Σ_d Σ_{j ≥ d} bucket[j]
+		var running, acc T
+		P(&running).SetInfinity()
+		P(&acc).SetInfinity()
+		started := false
+		for d := len(buckets) - 1; d > 0; d-- {
+			if used[d] {
+				P(&running).Add(&running, &buckets[d])
+				started = true
+			}
+			if started {
+				P(&acc).Add(&acc, &running)
+			}
+		}
+		P(sum).Add(sum, &acc)
+	}
+}
@@ -0,0 +1,50 @@
+package window
+
+import "crypto/subtle"
+
+// Fixed-base scalar multiplication. A table holds j·16ⁱ·a for every window i of
+// the scalar and every digit j, so that k·a is the sum of one entry per window:
+// 64 additions and no doubling, where a constant-time double-and-add needs 256
+// doublings and 64 additions. The lookups read every entry of a window, so the
+// sum runs in constant time.
+
+// Bits is the number of bits of a window of the scalar.
+const Bits = 4
+
+// Windows is the number of windows of a scalar of 256 bits.
+const Windows = 256 / Bits
+
+// Table holds the multiples of a fixed point.
+type Table[T any, P Point[T]] [Windows][1 << Bits]T
+
+// NewTable returns the table of the multiples of a.
+func NewTable[T any, P Point[T]](a T) *Table[T, P] {
+	t := new(Table[T, P])
+	base := a
+	for i := range t {
+		P(&t[i][0]).SetInfinity()
+		t[i][1] = base
+		for j := 2; j < len(t[i]); j++ {
+			P(&t[i][j]).Add(&t[i][j-1], &base)
+		}
+		for j := 0; j < Bits; j++ {
+			P(&base).Double(&base)
+		}
+	}
+	return t
+}
+
+// Mul sets sum to k·a, where a is the point of t and k the scalar of digits,
+// at most Windows digits of Bits bits, most significant first. It runs in
+// constant time.
+func (t *Table[T, P]) Mul(sum *T, digits []int) {
+	var e T
+	P(sum).SetInfinity()
+	for i, d := range digits {
+		row := &t[len(digits)-1-i]
+		for j := range row {
+			P(&e).Select(&row[j], &e, subtle.ConstantTimeEq(int32(j), int32(d)))
+		}
+		P(sum).Add(sum, &e)
+	}
+}
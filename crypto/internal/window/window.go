@@ -0,0 +1,23 @@
+// Package window implements the scalar multiplications that bls12381 and the
+// montgomery backend of bn256 share: the fixed-base tables and the
+// multi-scalar multiplication with the bucket method of Pippenger. They only
+// add, double and select points, so they are written once over the points in
+// projective coordinates of either curve and of its twist.
+package window
+
+// Point is a pointer to a point T in projective coordinates. The points are
+// added with complete formulas, which have no exceptional case for equal
+// points or the point at infinity, and the zero value of T may be selected
+// over.
+type Point[T any] interface {
+	*T
+	// SetInfinity sets the point to the point at infinity.
+	SetInfinity()
+	// Add sets the point to a+b.
+	Add(a, b *T)
+	// Double sets the point to 2a.
+	Double(a *T)
+	// Select sets the point to a if cond is 1 and to b if cond is 0, in
+	// constant time.
+	Select(a, b *T, cond int)
+}
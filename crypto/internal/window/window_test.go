@@ -0,0 +1,117 @@
+package window
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// q is the order of the group of the tests, the integers modulo q under
+// addition, where k·a is k times a modulo q.
+const q = 1<<61 - 1
+
+type point struct {
+	x uint64
+}
+
+func (p *point) SetInfinity()    { p.x = 0 }
+func (p *point) Add(a, b *point) { p.x = (a.x + b.x) % q }
+func (p *point) Double(a *point) { p.x = 2 * a.x % q }
+func (p *point) Select(a, b *point, cond int) {
+	if cond == 1 {
+		*p = *a
+	} else {
+		*p = *b
+	}
+}
+
+// mul returns k·a.
+func mul(k *big.Int, a point) uint64 {
+	r := new(big.Int).Mul(k, new(big.Int).SetUint64(a.x))
+	return r.Mod(r, big.NewInt(q)).Uint64()
+}
+
+func testScalars(t *testing.T) []*big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), 256)
+	scalars := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(15),
+		big.NewInt(16),
+		big.NewInt(q),
+		new(big.Int).Sub(max, big.NewInt(1)),
+	}
+	for i := 0; i < 4; i++ {
+		k, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalars = append(scalars, k)
+	}
+	return scalars
+}
+
+// digits returns the Windows digits of k, most significant first.
+func digits(k *big.Int) []int {
+	ds := make([]int, Windows)
+	for i := range ds {
+		ds[Windows-1-i] = digit(k, i*Bits, Bits)
+	}
+	return ds
+}
+
+func TestTable(t *testing.T) {
+	a := point{123456789}
+	table := NewTable[point](a)
+	for _, k := range testScalars(t) {
+		var got point
+		table.Mul(&got, digits(k))
+		if want := mul(k, a); got.x != want {
+			t.Errorf("Mul with k=%s: got %d, want %d", k, got.x, want)
+		}
+	}
+	var got point
+	NewTable[point](point{}).Mul(&got, digits(big.NewInt(5)))
+	if got.x != 0 {
+		t.Error("Mul of ∞ is not ∞")
+	}
+}
+
+// The sums cover repeated points, which land in the same bucket, and scalars
+// of every size, reduced and negated by Scalars.
+func TestMultiMul(t *testing.T) {
+	order := big.NewInt(q)
+	for _, n := range []int{1, 2, 5, 40, 300} {
+		scalars := testScalars(t)
+		points := make([]point, 0, n)
+		signed := make([]*big.Int, 0, n)
+		want := uint64(0)
+		for i := 0; i < n; i++ {
+			p := point{uint64(i%3 + 1)}
+			k := scalars[i%len(scalars)]
+			if i%2 == 1 {
+				k = new(big.Int).Neg(k)
+			}
+			points, signed = append(points, p), append(signed, k)
+			want = (want + mul(new(big.Int).Mod(k, order), p)) % q
+		}
+		ks, neg, maxBits := Scalars(signed, order)
+		for i := range points {
+			if neg[i] {
+				points[i].x = (q - points[i].x) % q
+			}
+		}
+		var got point
+		MultiMul(&got, points, ks, maxBits)
+		if got.x != want {
+			t.Errorf("MultiMul of %d points: got %d, want %d", n, got.x, want)
+		}
+	}
+
+	var got point
+	MultiMul(&got, []point{{1}, {2}}, []*big.Int{big.NewInt(0), big.NewInt(0)}, 0)
+	if got.x != 0 {
+		t.Error("MultiMul with zero scalars is not ∞")
+	}
+}
@@ -0,0 +1,285 @@
+package pairing
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bls12381"
+)
+
+// bls12381Curve is the curve of package bls12381.
+type bls12381Curve struct{}
+
+func (bls12381Curve) ID() ID                 { return BLS12381 }
+func (bls12381Curve) Order() *big.Int        { return bls12381.Order }
+func (bls12381Curve) NewG1() G1              { return &bls12381G1{new(bls12381.G1)} }
+func (bls12381Curve) NewG2() G2              { return &bls12381G2{new(bls12381.G2)} }
+func (bls12381Curve) NewGT() GT              { return &bls12381GT{new(bls12381.GT)} }
+func (bls12381Curve) HashToG2(msg []byte) G2 { return &bls12381G2{bls12381.HashToG2(msg)} }
+
+func (bls12381Curve) RandomG2(r io.Reader) (*big.Int, G2, error) {
+	k, p, err := bls12381.RandomG2(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, &bls12381G2{p}, nil
+}
+
+func (bls12381Curve) NewG1Table(a G1) G1Table {
+	return &bls12381G1Table{bls12381.NewG1Table(bls12381G1Of(a))}
+}
+
+func (bls12381Curve) NewG2Table(a G2) G2Table {
+	return &bls12381G2Table{bls12381.NewG2Table(bls12381G2Of(a))}
+}
+
+func (bls12381Curve) Pair(a G1, b G2) GT {
+	return &bls12381GT{bls12381.Pair(bls12381G1Of(a), bls12381G2Of(b))}
+}
+
+func (bls12381Curve) PairingProduct(a []G1, b []G2) GT {
+	if len(a) != len(b) {
+		panic("pairing: pairing product with a different number of G1 and G2 elements")
+	}
+	ps := make([]*bls12381.G1, len(a))
+	qs := make([]*bls12381.G2, len(b))
+	for i := range a {
+		ps[i], qs[i] = bls12381G1Of(a[i]), bls12381G2Of(b[i])
+	}
+	return &bls12381GT{bls12381.PairingProduct(ps, qs)}
+}
+
+func (c bls12381Curve) PairingCheck(a []G1, b []G2) bool {
+	return c.PairingProduct(a, b).IsOne()
+}
+
+// bls12381G1 is an element of bls12381.G1.
+type bls12381G1 struct {
+	p *bls12381.G1
+}
+
+// bls12381G1Of returns the bls12381.G1 in a, which must be an element of BLS12381.
+func bls12381G1Of(a G1) *bls12381.G1 {
+	e, ok := a.(*bls12381G1)
+	if !ok {
+		panic(mixedCurves(BLS12381, a))
+	}
+	return e.p
+}
+
+func (e *bls12381G1) Curve() Curve              { return bls12381Curve{} }
+func (e *bls12381G1) IsZero() bool              { return e.p.IsZero() }
+func (e *bls12381G1) Marshal() []byte           { return e.p.Marshal() }
+func (e *bls12381G1) MarshalCompressed() []byte { return e.p.MarshalCompressed() }
+func (e *bls12381G1) String() string            { return e.p.String() }
+
+func (e *bls12381G1) ScalarBaseMult(k *big.Int) G1 {
+	e.p.ScalarBaseMult(k)
+	return e
+}
+
+func (e *bls12381G1) ScalarMult(a G1, k *big.Int) G1 {
+	e.p.ScalarMult(bls12381G1Of(a), k)
+	return e
+}
+
+func (e *bls12381G1) ScalarBaseMultCT(k *big.Int) G1 {
+	e.p.ScalarBaseMultCT(k)
+	return e
+}
+
+func (e *bls12381G1) ScalarMultCT(a G1, k *big.Int) G1 {
+	e.p.ScalarMultCT(bls12381G1Of(a), k)
+	return e
+}
+
+func (e *bls12381G1) ScalarMultTable(t G1Table, k *big.Int) G1 {
+	e.p.ScalarMultTable(bls12381G1TableOf(t), k)
+	return e
+}
+
+func (e *bls12381G1) MultiScalarMult(points []G1, scalars []*big.Int) G1 {
+	ps := make([]*bls12381.G1, len(points))
+	for i, p := range points {
+		ps[i] = bls12381G1Of(p)
+	}
+	e.p.MultiScalarMult(ps, scalars)
+	return e
+}
+
+func (e *bls12381G1) Add(a, b G1) G1 {
+	e.p.Add(bls12381G1Of(a), bls12381G1Of(b))
+	return e
+}
+
+func (e *bls12381G1) Neg(a G1) G1 {
+	e.p.Neg(bls12381G1Of(a))
+	return e
+}
+
+func (e *bls12381G1) SetInfinity() G1 {
+	e.p.SetInfinity()
+	return e
+}
+
+func (e *bls12381G1) Unmarshal(m []byte) (G1, bool) {
+	if _, ok := e.p.Unmarshal(m); !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+// bls12381G2 is an element of bls12381.G2.
+type bls12381G2 struct {
+	p *bls12381.G2
+}
+
+// bls12381G2Of returns the bls12381.G2 in a, which must be an element of BLS12381.
+func bls12381G2Of(a G2) *bls12381.G2 {
+	e, ok := a.(*bls12381G2)
+	if !ok {
+		panic(mixedCurves(BLS12381, a))
+	}
+	return e.p
+}
+
+func (e *bls12381G2) Curve() Curve              { return bls12381Curve{} }
+func (e *bls12381G2) IsZero() bool              { return e.p.IsZero() }
+func (e *bls12381G2) Marshal() []byte           { return e.p.Marshal() }
+func (e *bls12381G2) MarshalCompressed() []byte { return e.p.MarshalCompressed() }
+func (e *bls12381G2) String() string            { return e.p.String() }
+
+func (e *bls12381G2) ScalarBaseMult(k *big.Int) G2 {
+	e.p.ScalarBaseMult(k)
+	return e
+}
+
+func (e *bls12381G2) ScalarMult(a G2, k *big.Int) G2 {
+	e.p.ScalarMult(bls12381G2Of(a), k)
+	return e
+}
+
+func (e *bls12381G2) ScalarBaseMultCT(k *big.Int) G2 {
+	e.p.ScalarBaseMultCT(k)
+	return e
+}
+
+func (e *bls12381G2) ScalarMultCT(a G2, k *big.Int) G2 {
+	e.p.ScalarMultCT(bls12381G2Of(a), k)
+	return e
+}
+
+func (e *bls12381G2) ScalarMultTable(t G2Table, k *big.Int) G2 {
+	e.p.ScalarMultTable(bls12381G2TableOf(t), k)
+	return e
+}
+
+func (e *bls12381G2) MultiScalarMult(points []G2, scalars []*big.Int) G2 {
+	ps := make([]*bls12381.G2, len(points))
+	for i, p := range points {
+		ps[i] = bls12381G2Of(p)
+	}
+	e.p.MultiScalarMult(ps, scalars)
+	return e
+}
+
+func (e *bls12381G2) Add(a, b G2) G2 {
+	e.p.Add(bls12381G2Of(a), bls12381G2Of(b))
+	return e
+}
+
+func (e *bls12381G2) Neg(a G2) G2 {
+	e.p.Neg(bls12381G2Of(a))
+	return e
+}
+
+func (e *bls12381G2) SetInfinity() G2 {
+	e.p.SetInfinity()
+	return e
+}
+
+func (e *bls12381G2) Unmarshal(m []byte) (G2, bool) {
+	if _, ok := e.p.Unmarshal(m); !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+// bls12381GT is an element of bls12381.GT.
+type bls12381GT struct {
+	p *bls12381.GT
+}
+
+// bls12381GTOf returns the bls12381.GT in a, which must be an element of BLS12381.
+func bls12381GTOf(a GT) *bls12381.GT {
+	e, ok := a.(*bls12381GT)
+	if !ok {
+		panic(mixedCurves(BLS12381, a))
+	}
+	return e.p
+}
+
+func (e *bls12381GT) Curve() Curve              { return bls12381Curve{} }
+func (e *bls12381GT) IsOne() bool               { return e.p.IsOne() }
+func (e *bls12381GT) Marshal() []byte           { return e.p.Marshal() }
+func (e *bls12381GT) MarshalCompressed() []byte { return e.p.MarshalCompressed() }
+func (e *bls12381GT) String() string            { return e.p.String() }
+
+func (e *bls12381GT) ScalarMult(a GT, k *big.Int) GT {
+	e.p.ScalarMult(bls12381GTOf(a), k)
+	return e
+}
+
+func (e *bls12381GT) Add(a, b GT) GT {
+	e.p.Add(bls12381GTOf(a), bls12381GTOf(b))
+	return e
+}
+
+func (e *bls12381GT) Neg(a GT) GT {
+	e.p.Neg(bls12381GTOf(a))
+	return e
+}
+
+func (e *bls12381GT) Invert(a GT) GT {
+	e.p.Invert(bls12381GTOf(a))
+	return e
+}
+
+func (e *bls12381GT) Unmarshal(m []byte) (GT, bool) {
+	if _, ok := e.p.Unmarshal(m); !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+// bls12381G1Table is a bls12381.G1Table.
+type bls12381G1Table struct {
+	t *bls12381.G1Table
+}
+
+func (t *bls12381G1Table) Curve() Curve { return bls12381Curve{} }
+
+// bls12381G1TableOf returns the bls12381.G1Table in t, which must be a table of BLS12381.
+func bls12381G1TableOf(t G1Table) *bls12381.G1Table {
+	e, ok := t.(*bls12381G1Table)
+	if !ok {
+		panic(mixedCurves(BLS12381, t))
+	}
+	return e.t
+}
+
+// bls12381G2Table is a bls12381.G2Table.
+type bls12381G2Table struct {
+	t *bls12381.G2Table
+}
+
+func (t *bls12381G2Table) Curve() Curve { return bls12381Curve{} }
+
+// bls12381G2TableOf returns the bls12381.G2Table in t, which must be a table of BLS12381.
+func bls12381G2TableOf(t G2Table) *bls12381.G2Table {
+	e, ok := t.(*bls12381G2Table)
+	if !ok {
+		panic(mixedCurves(BLS12381, t))
+	}
+	return e.t
+}
@@ -0,0 +1,299 @@
+package pairing
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+// bn256Curve is the curve of package bn256.
+type bn256Curve struct{}
+
+func (bn256Curve) ID() ID                 { return BN256 }
+func (bn256Curve) Order() *big.Int        { return bn256.Order }
+func (bn256Curve) NewG1() G1              { return &bn256G1{new(bn256.G1)} }
+func (bn256Curve) NewG2() G2              { return &bn256G2{new(bn256.G2)} }
+func (bn256Curve) NewGT() GT              { return &bn256GT{new(bn256.GT)} }
+func (bn256Curve) HashToG2(msg []byte) G2 { return &bn256G2{bn256.HashToG2(msg)} }
+
+func (bn256Curve) RandomG2(r io.Reader) (*big.Int, G2, error) {
+	k, p, err := bn256.RandomG2(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, &bn256G2{p}, nil
+}
+
+func (bn256Curve) NewG1Table(a G1) G1Table {
+	return &bn256G1Table{bn256.NewG1Table(bn256G1Of(a))}
+}
+
+func (bn256Curve) NewG2Table(a G2) G2Table {
+	return &bn256G2Table{bn256.NewG2Table(bn256G2Of(a))}
+}
+
+func (bn256Curve) Pair(a G1, b G2) GT {
+	return &bn256GT{bn256.Pair(bn256G1Of(a), bn256G2Of(b))}
+}
+
+func (bn256Curve) PairingProduct(a []G1, b []G2) GT {
+	if len(a) != len(b) {
+		panic("pairing: pairing product with a different number of G1 and G2 elements")
+	}
+	ps := make([]*bn256.G1, len(a))
+	qs := make([]*bn256.G2, len(b))
+	for i := range a {
+		ps[i], qs[i] = bn256G1Of(a[i]), bn256G2Of(b[i])
+	}
+	return &bn256GT{bn256.PairingProduct(ps, qs)}
+}
+
+func (c bn256Curve) PairingCheck(a []G1, b []G2) bool {
+	return c.PairingProduct(a, b).IsOne()
+}
+
+// bn256G1 is an element of bn256.G1.
+type bn256G1 struct {
+	p *bn256.G1
+}
+
+// bn256G1Of returns the bn256.G1 in a, which must be an element of BN256.
+func bn256G1Of(a G1) *bn256.G1 {
+	e, ok := a.(*bn256G1)
+	if !ok {
+		panic(mixedCurves(BN256, a))
+	}
+	return e.p
+}
+
+func (e *bn256G1) Curve() Curve              { return bn256Curve{} }
+func (e *bn256G1) IsZero() bool              { return e.p.IsZero() }
+func (e *bn256G1) Marshal() []byte           { return e.p.Marshal() }
+func (e *bn256G1) MarshalCompressed() []byte { return e.p.MarshalCompressed() }
+func (e *bn256G1) String() string            { return e.p.String() }
+
+func (e *bn256G1) ScalarBaseMult(k *big.Int) G1 {
+	e.p.ScalarBaseMult(k)
+	return e
+}
+
+func (e *bn256G1) ScalarMult(a G1, k *big.Int) G1 {
+	e.p.ScalarMult(bn256G1Of(a), k)
+	return e
+}
+
+func (e *bn256G1) ScalarBaseMultCT(k *big.Int) G1 {
+	e.p.ScalarBaseMultCT(k)
+	return e
+}
+
+func (e *bn256G1) ScalarMultCT(a G1, k *big.Int) G1 {
+	e.p.ScalarMultCT(bn256G1Of(a), k)
+	return e
+}
+
+func (e *bn256G1) ScalarMultTable(t G1Table, k *big.Int) G1 {
+	e.p.ScalarMultTable(bn256G1TableOf(t), k)
+	return e
+}
+
+func (e *bn256G1) MultiScalarMult(points []G1, scalars []*big.Int) G1 {
+	ps := make([]*bn256.G1, len(points))
+	for i, p := range points {
+		ps[i] = bn256G1Of(p)
+	}
+	e.p.MultiScalarMult(ps, scalars)
+	return e
+}
+
+func (e *bn256G1) Add(a, b G1) G1 {
+	e.p.Add(bn256G1Of(a), bn256G1Of(b))
+	return e
+}
+
+func (e *bn256G1) Neg(a G1) G1 {
+	e.p.Neg(bn256G1Of(a))
+	return e
+}
+
+func (e *bn256G1) SetInfinity() G1 {
+	e.p.SetInfinity()
+	return e
+}
+
+func (e *bn256G1) Unmarshal(m []byte) (G1, bool) {
+	if _, ok := e.p.Unmarshal(m); !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+// bn256G2 is an element of bn256.G2.
+type bn256G2 struct {
+	p *bn256.G2
+}
+
+// bn256G2Of returns the bn256.G2 in a, which must be an element of BN256.
+func bn256G2Of(a G2) *bn256.G2 {
+	e, ok := a.(*bn256G2)
+	if !ok {
+		panic(mixedCurves(BN256, a))
+	}
+	return e.p
+}
+
+func (e *bn256G2) Curve() Curve              { return bn256Curve{} }
+func (e *bn256G2) IsZero() bool              { return e.p.IsZero() }
+func (e *bn256G2) Marshal() []byte           { return e.p.Marshal() }
+func (e *bn256G2) MarshalCompressed() []byte { return e.p.MarshalCompressed() }
+func (e *bn256G2) String() string            { return e.p.String() }
+
+func (e *bn256G2) ScalarBaseMult(k *big.Int) G2 {
+	e.p.ScalarBaseMult(k)
+	return e
+}
+
+func (e *bn256G2) ScalarMult(a G2, k *big.Int) G2 {
+	e.p.ScalarMult(bn256G2Of(a), k)
+	return e
+}
+
+func (e *bn256G2) ScalarBaseMultCT(k *big.Int) G2 {
+	e.p.ScalarBaseMultCT(k)
+	return e
+}
+
+func (e *bn256G2) ScalarMultCT(a G2, k *big.Int) G2 {
+	e.p.ScalarMultCT(bn256G2Of(a), k)
+	return e
+}
+
+func (e *bn256G2) ScalarMultTable(t G2Table, k *big.Int) G2 {
+	e.p.ScalarMultTable(bn256G2TableOf(t), k)
+	return e
+}
+
+func (e *bn256G2) MultiScalarMult(points []G2, scalars []*big.Int) G2 {
+	ps := make([]*bn256.G2, len(points))
+	for i, p := range points {
+		ps[i] = bn256G2Of(p)
+	}
+	e.p.MultiScalarMult(ps, scalars)
+	return e
+}
+
+func (e *bn256G2) Add(a, b G2) G2 {
+	e.p.Add(bn256G2Of(a), bn256G2Of(b))
+	return e
+}
+
+func (e *bn256G2) Neg(a G2) G2 {
+	e.p.Neg(bn256G2Of(a))
+	return e
+}
+
+func (e *bn256G2) SetInfinity() G2 {
+	e.p.SetInfinity()
+	return e
+}
+
+func (e *bn256G2) Unmarshal(m []byte) (G2, bool) {
+	if _, ok := e.p.Unmarshal(m); !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+// bn256GT is an element of bn256.GT.
+type bn256GT struct {
+	p *bn256.GT
+}
+
+// bn256GTOf returns the bn256.GT in a, which must be an element of BN256.
+func bn256GTOf(a GT) *bn256.GT {
+	e, ok := a.(*bn256GT)
+	if !ok {
+		panic(mixedCurves(BN256, a))
+	}
+	return e.p
+}
+
+func (e *bn256GT) Curve() Curve              { return bn256Curve{} }
+func (e *bn256GT) IsOne() bool               { return e.p.IsOne() }
+func (e *bn256GT) Marshal() []byte           { return e.p.Marshal() }
+func (e *bn256GT) MarshalCompressed() []byte { return e.p.MarshalCompressed() }
+func (e *bn256GT) String() string            { return e.p.String() }
+
+func (e *bn256GT) ScalarMult(a GT, k *big.Int) GT {
+	e.p.ScalarMult(bn256GTOf(a), k)
+	return e
+}
+
+func (e *bn256GT) Add(a, b GT) GT {
+	e.p.Add(bn256GTOf(a), bn256GTOf(b))
+	return e
+}
+
+func (e *bn256GT) Neg(a GT) GT {
+	e.p.Neg(bn256GTOf(a))
+	return e
+}
+
+func (e *bn256GT) Invert(a GT) GT {
+	e.p.Invert(bn256GTOf(a))
+	return e
+}
+
+func (e *bn256GT) Unmarshal(m []byte) (GT, bool) {
+	if _, ok := e.p.Unmarshal(m); !ok {
+		return nil, false
+	}
+	return e, true
+}
+
+// FromBN256G2 returns a as an element of G₂ of BN256.
+func FromBN256G2(a *bn256.G2) G2 {
+	return &bn256G2{a}
+}
+
+// ToBN256G2 returns the bn256.G2 in a and whether a is an element of BN256.
+func ToBN256G2(a G2) (*bn256.G2, bool) {
+	e, ok := a.(*bn256G2)
+	if !ok {
+		return nil, false
+	}
+	return e.p, true
+}
+
+// bn256G1Table is a bn256.G1Table.
+type bn256G1Table struct {
+	t *bn256.G1Table
+}
+
+func (t *bn256G1Table) Curve() Curve { return bn256Curve{} }
+
+// bn256G1TableOf returns the bn256.G1Table in t, which must be a table of BN256.
+func bn256G1TableOf(t G1Table) *bn256.G1Table {
+	e, ok := t.(*bn256G1Table)
+	if !ok {
+		panic(mixedCurves(BN256, t))
+	}
+	return e.t
+}
+
+// bn256G2Table is a bn256.G2Table.
+type bn256G2Table struct {
+	t *bn256.G2Table
+}
+
+func (t *bn256G2Table) Curve() Curve { return bn256Curve{} }
+
+// bn256G2TableOf returns the bn256.G2Table in t, which must be a table of BN256.
+func bn256G2TableOf(t G2Table) *bn256.G2Table {
+	e, ok := t.(*bn256G2Table)
+	if !ok {
+		panic(mixedCurves(BN256, t))
+	}
+	return e.t
+}
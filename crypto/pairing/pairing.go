@@ -0,0 +1,182 @@
+// Package pairing selects the bilinear groups that commitments and range proofs
+// are built on at run time. Every supported curve offers the API of package
+// bn256 through the G1, G2 and GT interfaces, and is named by an ID that
+// parameters stored on the ledger carry.
+//
+// Elements of different curves must not be combined: an operation on elements
+// of another curve than the receiver panics. Callers that read elements from
+// untrusted input check their curve first.
+package pairing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ID names a curve. Its values are stored in parameters, so they must not
+// change.
+type ID int32
+
+const (
+	// BN256 is the Barreto-Naehrig curve of package bn256. The number field
+	// sieve advances reduced its security to about 100 bits.
+	BN256 ID = 0
+	// BLS12381 is the curve of package bls12381, with about 128 bits of
+	// security.
+	BLS12381 ID = 1
+)
+
+func (id ID) String() string {
+	switch id {
+	case BN256:
+		return "BN256"
+	case BLS12381:
+		return "BLS12-381"
+	}
+	return fmt.Sprintf("ID(%d)", int32(id))
+}
+
+// ErrUnknownCurve is returned by Lookup for an ID without a curve.
+var ErrUnknownCurve = errors.New("Unknown curve")
+
+// Lookup returns the curve named by id.
+func Lookup(id ID) (Curve, error) {
+	switch id {
+	case BN256:
+		return bn256Curve{}, nil
+	case BLS12381:
+		return bls12381Curve{}, nil
+	}
+	return nil, ErrUnknownCurve
+}
+
+// MustLookup returns the curve named by id and panics if there is none.
+func MustLookup(id ID) Curve {
+	c, err := Lookup(id)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Curve is a pairing-friendly curve with its groups G₁, G₂ and GT of prime
+// order Order.
+type Curve interface {
+	// ID returns the name of the curve.
+	ID() ID
+	// Order returns the number of elements of the groups, which must not be
+	// modified.
+	Order() *big.Int
+	// NewG1, NewG2 and NewGT return elements suitable for use as the output
+	// of an operation.
+	NewG1() G1
+	NewG2() G2
+	NewGT() GT
+	// RandomG2 returns x and g₂ˣ where x is a random, non-zero number read
+	// from r.
+	RandomG2(r io.Reader) (*big.Int, G2, error)
+	// Pair calculates the pairing of the curve.
+	Pair(a G1, b G2) GT
+	// PairingProduct returns the product of the pairings of a[i] and b[i],
+	// which is much faster than multiplying the results of Pair, and
+	// PairingCheck whether it is one. They panic unless a and b have the
+	// same length.
+	PairingProduct(a []G1, b []G2) GT
+	PairingCheck(a []G1, b []G2) bool
+	// HashToG2 deterministically maps msg to a point of G₂ whose discrete
+	// logarithm with respect to the generator is unknown.
+	HashToG2(msg []byte) G2
+	// NewG1Table and NewG2Table precompute the multiples of a, for
+	// ScalarMultTable.
+	NewG1Table(a G1) G1Table
+	NewG2Table(a G2) G2Table
+}
+
+// G1 is an element of the group G₁ of a curve. The methods mirror those of
+// bn256.G1: they set the receiver to the result and return it.
+type G1 interface {
+	Curve() Curve
+	ScalarBaseMult(k *big.Int) G1
+	ScalarMult(a G1, k *big.Int) G1
+	ScalarBaseMultCT(k *big.Int) G1
+	ScalarMultCT(a G1, k *big.Int) G1
+	ScalarMultTable(t G1Table, k *big.Int) G1
+	// MultiScalarMult sets the receiver to the sum of points[i]*scalars[i],
+	// in variable time.
+	MultiScalarMult(points []G1, scalars []*big.Int) G1
+	Add(a, b G1) G1
+	Neg(a G1) G1
+	SetInfinity() G1
+	IsZero() bool
+	Marshal() []byte
+	// MarshalCompressed returns the compressed encoding, about half the
+	// size of Marshal.
+	MarshalCompressed() []byte
+	// Unmarshal returns false unless m encodes an element of G₁, in either
+	// encoding.
+	Unmarshal(m []byte) (G1, bool)
+	String() string
+}
+
+// G2 is an element of the group G₂ of a curve. The methods mirror those of
+// bn256.G2: they set the receiver to the result and return it.
+type G2 interface {
+	Curve() Curve
+	ScalarBaseMult(k *big.Int) G2
+	ScalarMult(a G2, k *big.Int) G2
+	ScalarBaseMultCT(k *big.Int) G2
+	ScalarMultCT(a G2, k *big.Int) G2
+	ScalarMultTable(t G2Table, k *big.Int) G2
+	// MultiScalarMult sets the receiver to the sum of points[i]*scalars[i],
+	// in variable time.
+	MultiScalarMult(points []G2, scalars []*big.Int) G2
+	Add(a, b G2) G2
+	Neg(a G2) G2
+	SetInfinity() G2
+	IsZero() bool
+	Marshal() []byte
+	// MarshalCompressed returns the compressed encoding, about half the
+	// size of Marshal.
+	MarshalCompressed() []byte
+	// Unmarshal returns false unless m encodes an element of G₂, in either
+	// encoding.
+	Unmarshal(m []byte) (G2, bool)
+	String() string
+}
+
+// G1Table holds precomputed multiples of a fixed element of G₁ of a curve.
+type G1Table interface {
+	Curve() Curve
+}
+
+// G2Table holds precomputed multiples of a fixed element of G₂ of a curve.
+type G2Table interface {
+	Curve() Curve
+}
+
+// GT is an element of the target group of the pairing of a curve. The methods
+// mirror those of bn256.GT.
+type GT interface {
+	Curve() Curve
+	ScalarMult(a GT, k *big.Int) GT
+	Add(a, b GT) GT
+	Neg(a GT) GT
+	Invert(a GT) GT
+	IsOne() bool
+	Marshal() []byte
+	MarshalCompressed() []byte
+	Unmarshal(m []byte) (GT, bool)
+	String() string
+}
+
+// SameCurve returns true iff a and b are elements of the same curve.
+func SameCurve(a, b interface{ Curve() Curve }) bool {
+	return a.Curve().ID() == b.Curve().ID()
+}
+
+// mixedCurves is the panic of an operation on elements of another curve.
+func mixedCurves(want ID, got interface{}) string {
+	return fmt.Sprintf("pairing: element of type %T used with an element of %s", got, want)
+}
@@ -0,0 +1,179 @@
+package pairing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+)
+
+var curves = []ID{BN256, BLS12381}
+
+func TestBilinearity(t *testing.T) {
+	for _, id := range curves {
+		c := MustLookup(id)
+		a, _ := rand.Int(rand.Reader, c.Order())
+		b, _ := rand.Int(rand.Reader, c.Order())
+		p1 := c.NewG1().ScalarBaseMultCT(a)
+		p2 := c.NewG2().ScalarBaseMult(b)
+
+		e1 := c.Pair(p1, p2)
+		e2 := c.Pair(c.NewG1().ScalarBaseMult(big.NewInt(1)), c.NewG2().ScalarBaseMult(big.NewInt(1)))
+		e2.ScalarMult(e2, new(big.Int).Mul(a, b))
+		if !bytes.Equal(e1.Marshal(), e2.Marshal()) {
+			t.Errorf("%s: e(g₁ᵃ, g₂ᵇ) != e(g₁, g₂)ᵃᵇ", id)
+		}
+		if !e1.Add(e1, c.NewGT().Neg(e2)).IsOne() {
+			t.Errorf("%s: e·e⁻¹ != 1", id)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, id := range curves {
+		c := MustLookup(id)
+		_, p2, err := c.RandomG2(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q2, ok := c.NewG2().Unmarshal(p2.Marshal())
+		if !ok || !bytes.Equal(q2.Marshal(), p2.Marshal()) {
+			t.Errorf("%s: G2 does not round trip", id)
+		}
+		if q2.Curve().ID() != id || !SameCurve(p2, q2) {
+			t.Errorf("%s: element of the wrong curve", id)
+		}
+		inf := c.NewG2().Add(p2, c.NewG2().Neg(p2))
+		if !inf.IsZero() {
+			t.Errorf("%s: P-P != ∞", id)
+		}
+		if _, ok := c.NewG2().Unmarshal(p2.Marshal()[1:]); ok {
+			t.Errorf("%s: unmarshaled a truncated element", id)
+		}
+	}
+	// the encodings of the curves differ in length, so neither reads the other
+	bn, bls := MustLookup(BN256), MustLookup(BLS12381)
+	if _, ok := bls.NewG2().Unmarshal(bn.NewG2().ScalarBaseMult(big.NewInt(1)).Marshal()); ok {
+		t.Error("BLS12-381 read an element of BN256")
+	}
+}
+
+func TestMixedCurvesPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("adding elements of different curves did not panic")
+		}
+	}()
+	a := MustLookup(BN256).NewG2().ScalarBaseMult(big.NewInt(1))
+	b := MustLookup(BLS12381).NewG2().ScalarBaseMult(big.NewInt(1))
+	a.Add(a, b)
+}
+
+func TestBN256Conversion(t *testing.T) {
+	p := new(bn256.G2).ScalarBaseMult(big.NewInt(5))
+	q, ok := ToBN256G2(FromBN256G2(p))
+	if !ok || q != p {
+		t.Error("BN256 element does not convert back")
+	}
+	if _, ok := ToBN256G2(MustLookup(BLS12381).NewG2().ScalarBaseMult(big.NewInt(5))); ok {
+		t.Error("converted an element of BLS12-381 to BN256")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, err := Lookup(ID(7)); err != ErrUnknownCurve {
+		t.Errorf("expected ErrUnknownCurve, got %v", err)
+	}
+	if BLS12381.String() != "BLS12-381" || ID(7).String() != "ID(7)" {
+		t.Error("bad curve names")
+	}
+}
+
+func TestTablesAndMultiScalarMult(t *testing.T) {
+	for _, id := range curves {
+		c := MustLookup(id)
+		_, h, _ := c.RandomG2(rand.Reader)
+		k, _ := rand.Int(rand.Reader, c.Order())
+		want := c.NewG2().ScalarMult(h, k)
+		if got := c.NewG2().ScalarMultTable(c.NewG2Table(h), k); !bytes.Equal(got.Marshal(), want.Marshal()) {
+			t.Errorf("%s: ScalarMultTable != ScalarMult", id)
+		}
+		if got := c.NewG2().ScalarMultTable(CachedG2Table(h), k); !bytes.Equal(got.Marshal(), want.Marshal()) {
+			t.Errorf("%s: ScalarMultTable with the cached table != ScalarMult", id)
+		}
+		if CachedG2Table(h) != CachedG2Table(h) {
+			t.Errorf("%s: the table was built again", id)
+		}
+		h1 := c.NewG1().ScalarBaseMult(big.NewInt(3))
+		if got := c.NewG1().ScalarMultTable(CachedG1Table(h1), k); !bytes.Equal(got.Marshal(), c.NewG1().ScalarMult(h1, k).Marshal()) {
+			t.Errorf("%s: G1 ScalarMultTable != ScalarMult", id)
+		}
+
+		sum := c.NewG2().MultiScalarMult([]G2{h, want}, []*big.Int{k, big.NewInt(-1)})
+		if !sum.IsZero() {
+			t.Errorf("%s: h·k - (h·k) != ∞", id)
+		}
+		if !c.NewG2().ScalarMultTable(c.NewG2Table(c.NewG2().SetInfinity()), k).IsZero() {
+			t.Errorf("%s: ScalarMultTable of ∞ != ∞", id)
+		}
+
+		// enough points for the buckets, repeated and with negative scalars
+		points1, points2, ks := []G1{}, []G2{}, []*big.Int{}
+		want1, want2 := c.NewG1().SetInfinity(), c.NewG2().SetInfinity()
+		for i := 0; i < 40; i++ {
+			p1 := c.NewG1().ScalarBaseMult(big.NewInt(int64(i%3 + 1)))
+			p2 := c.NewG2().ScalarBaseMult(big.NewInt(int64(i%3 + 1)))
+			ki := new(big.Int).Add(k, big.NewInt(int64(i)))
+			if i%2 == 1 {
+				ki.Neg(ki)
+			}
+			points1, points2, ks = append(points1, p1), append(points2, p2), append(ks, ki)
+			want1.Add(want1, c.NewG1().ScalarMult(p1, ki))
+			want2.Add(want2, c.NewG2().ScalarMult(p2, ki))
+		}
+		if got := c.NewG1().MultiScalarMult(points1, ks); !bytes.Equal(got.Marshal(), want1.Marshal()) {
+			t.Errorf("%s: G1 MultiScalarMult of 40 points != the sum of ScalarMult", id)
+		}
+		if got := c.NewG2().MultiScalarMult(points2, ks); !bytes.Equal(got.Marshal(), want2.Marshal()) {
+			t.Errorf("%s: G2 MultiScalarMult of 40 points != the sum of ScalarMult", id)
+		}
+	}
+}
+
+func TestPairingProduct(t *testing.T) {
+	for _, id := range curves {
+		c := MustLookup(id)
+		_, q, _ := c.RandomG2(rand.Reader)
+		k, _ := rand.Int(rand.Reader, c.Order())
+		p := c.NewG1().ScalarBaseMult(k)
+		g1 := c.NewG1().ScalarBaseMult(big.NewInt(1))
+		want := c.Pair(p, q)
+		want.Add(want, c.Pair(g1, q))
+		got := c.PairingProduct([]G1{p, g1}, []G2{q, q})
+		if !bytes.Equal(got.Marshal(), want.Marshal()) {
+			t.Errorf("%s: PairingProduct != product of Pair", id)
+		}
+		// e(g^k, q)·e(g^-1, q^k) = 1
+		minusG1 := c.NewG1().Neg(g1)
+		if !c.PairingCheck([]G1{p, minusG1}, []G2{q, c.NewG2().ScalarMult(q, k)}) {
+			t.Errorf("%s: e(g^k, q)·e(g^-1, q^k) != 1", id)
+		}
+		if c.PairingCheck([]G1{p, minusG1}, []G2{q, q}) {
+			t.Errorf("%s: e(g^k, q)·e(g^-1, q) == 1", id)
+		}
+	}
+}
+
+func TestTableCacheEviction(t *testing.T) {
+	c := MustLookup(BN256)
+	first := c.NewG2().ScalarBaseMult(big.NewInt(1000))
+	table := CachedG2Table(first)
+	for i := int64(0); i < tableCacheSize; i++ {
+		CachedG2Table(c.NewG2().ScalarBaseMult(big.NewInt(1001 + i)))
+	}
+	if CachedG2Table(first) == table {
+		t.Error("the cache kept more than tableCacheSize tables")
+	}
+}
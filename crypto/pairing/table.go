@@ -0,0 +1,57 @@
+package pairing
+
+import "sync"
+
+// tableCacheSize is the number of tables kept by CachedG1Table and
+// CachedG2Table. A ledger commits with a single h, a few more cover tests and
+// migrations between curves.
+const tableCacheSize = 8
+
+// tableCache keeps the tables of the last elements asked for, by curve and
+// encoding.
+type tableCache struct {
+	mu      sync.Mutex
+	tables  map[string]interface{}
+	entries []string
+}
+
+func (c *tableCache) get(key string, build func() interface{}) interface{} {
+	c.mu.Lock()
+	t, ok := c.tables[key]
+	c.mu.Unlock()
+	if ok {
+		return t
+	}
+	// the table is built unlocked, two callers may both build it
+	t = build()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil {
+		c.tables = make(map[string]interface{})
+	}
+	if _, ok := c.tables[key]; !ok {
+		if len(c.entries) == tableCacheSize {
+			delete(c.tables, c.entries[0])
+			c.entries = c.entries[1:]
+		}
+		c.tables[key] = t
+		c.entries = append(c.entries, key)
+	}
+	return t
+}
+
+var g1Tables, g2Tables tableCache
+
+// CachedG1Table returns the table of the multiples of a, which is only built
+// the first time among the last few elements asked for.
+func CachedG1Table(a G1) G1Table {
+	key := a.Curve().ID().String() + string(a.Marshal())
+	return g1Tables.get(key, func() interface{} { return a.Curve().NewG1Table(a) }).(G1Table)
+}
+
+// CachedG2Table returns the table of the multiples of a, which is only built
+// the first time among the last few elements asked for.
+func CachedG2Table(a G2) G2Table {
+	key := a.Curve().ID().String() + string(a.Marshal())
+	return g2Tables.get(key, func() interface{} { return a.Curve().NewG2Table(a) }).(G2Table)
+}
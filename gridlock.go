@@ -5,12 +5,18 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/blockchain-research/gridlock/account"
+	"github.com/blockchain-research/gridlock/borromean"
+	"github.com/blockchain-research/gridlock/bulletproofs"
+	"github.com/blockchain-research/gridlock/commitment"
 	"github.com/blockchain-research/gridlock/common"
 	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/blockchain-research/gridlock/ledger"
+	"github.com/blockchain-research/gridlock/ledger/fabric"
 	"github.com/blockchain-research/gridlock/message"
-	"github.com/blockchain-research/gridlock/pedersengroup"
 	pb "github.com/blockchain-research/gridlock/proto"
 	"github.com/blockchain-research/gridlock/settlement"
 	"github.com/blockchain-research/gridlock/zkrangeproof"
@@ -20,7 +26,6 @@ import (
 )
 
 var logger = shim.NewLogger("gridlock")
-var pedersenParams pedersengroup.PedersenParams
 
 // Gridlock struct
 type Gridlock struct {
@@ -29,12 +34,56 @@ type Gridlock struct {
 // Init function
 func (t *Gridlock) Init(stub shim.ChaincodeStubInterface) pr.Response {
 	logger.Info("Init Gridlock Protocol chaincode")
+	_, args := stub.GetFunctionAndParameters()
+	err := t.initCentralBank(fabric.New(stub), args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 	return shim.Success(nil)
 }
 
+//initCentralBank records the central bank when the chaincode is instantiated, the identity passed
+//base64-encoded as argument or else the identity that instantiates the chaincode. An upgrade without
+//argument keeps the central bank on the ledger
+func (t *Gridlock) initCentralBank(stub ledger.Ledger, args []string) error {
+	if len(args) > 1 {
+		return errors.New("Need at most one argument: [<base64-encoded-central-bank-identity>]")
+	}
+	var centralBank []byte
+	var err error
+	if len(args) == 1 && args[0] != "" {
+		centralBank, err = base64.StdEncoding.DecodeString(args[0])
+		if err != nil {
+			logger.Error("Failed to base64-decode the central bank identity")
+			return err
+		}
+	} else {
+		stored, err := stub.Get(common.CentralBankTable)
+		if err != nil {
+			logger.Error("Failed to read central bank table")
+			return err
+		}
+		if stored != nil {
+			return nil
+		}
+		centralBank, err = stub.Creator()
+		if err != nil {
+			logger.Error("Failed to read the creator of the transaction")
+			return err
+		}
+	}
+	if len(centralBank) == 0 {
+		logger.Error("No central bank identity")
+		return errors.New("No central bank identity, pass it to Init or instantiate the chaincode with it")
+	}
+	return common.AddCentralBankToLedger(stub, centralBank)
+}
+
 // Invoke function
 func (t *Gridlock) Invoke(stub shim.ChaincodeStubInterface) pr.Response {
 	function, args := stub.GetFunctionAndParameters()
+	//the functions only depend on the ledger, Fabric commits their writes when they succeed
+	l := fabric.New(stub)
 
 	var result []byte
 	var err error
@@ -42,74 +91,281 @@ func (t *Gridlock) Invoke(stub shim.ChaincodeStubInterface) pr.Response {
 	switch function {
 	case "initParams":
 		logger.Info("initParams")
-		err = t.initParams(stub, args)
+		err = t.initParams(l, args)
 	case "mintAccount":
 		logger.Info("mintAccount")
-		err = account.MintAccount(stub, args)
+		err = account.MintAccount(l, args)
+	case "setCreditLimit":
+		logger.Info("setCreditLimit")
+		err = account.SetCreditLimit(l, args)
+	case "setReserve":
+		logger.Info("setReserve")
+		err = account.SetReserve(l, args)
+	case "proveRepayment":
+		logger.Info("proveRepayment")
+		err = account.ProveRepayment(l, args)
+	case "migrateCurve":
+		logger.Info("migrateCurve")
+		err = account.MigrateCurve(l, args)
 	case "addMessage":
 		logger.Info("addMessage")
-		err = message.AddMessage(stub, args)
+		err = message.AddMessage(l, args)
+	case "addMessages":
+		logger.Info("addMessages")
+		err = message.AddMessages(l, args)
+	case "migrateQueues":
+		logger.Info("migrateQueues")
+		err = message.MigrateQueues(l, args)
 	case "grossSettlement":
 		logger.Info("grossSettlement")
-		err = settlement.GrossSettlement(stub, args)
+		err = settlement.GrossSettlement(l, args)
+	case "setPaymentLimit":
+		logger.Info("setPaymentLimit")
+		err = t.setPaymentLimit(l, args)
+	case "setPaymentMinimum":
+		logger.Info("setPaymentMinimum")
+		err = t.setPaymentMinimum(l, args)
 	case "startGLResolution":
 		logger.Info("startGLResolution")
-		err = t.startGLResolution(stub, args)
+		err = t.startGLResolution(l, args)
 	case "proposeNettableSet":
 		logger.Info("proposeNettableSet")
-		err = t.proposeNettableSet(stub, args)
+		err = t.proposeNettableSet(l, args)
 	case "tallyGridlockProposal":
 		logger.Info("tallyGridlockProposal")
-		err = t.tallyGridlockProposal(stub, args)
+		err = t.tallyGridlockProposal(l, args)
 	case "NetGLSettlement":
 		logger.Info("NetGLSettlement")
-		err = settlement.NetGLSettlement(stub, args)
+		err = settlement.NetGLSettlement(l, args)
 	default:
 		logger.Error(fmt.Sprintf("Invalid invocation function %s", function))
 		err = fmt.Errorf("Invalid invocation function %s", function)
 	}
 	if err != nil {
+		//range proof errors are prefixed with their code so that clients can switch on it
+		if code := zkrangeproof.ErrorCode(err); code != "" {
+			return shim.Error(code + ": " + err.Error())
+		}
 		return shim.Error(err.Error())
 	}
 	return shim.Success(result)
 }
 
-func (t *Gridlock) initPedersenGroup(stub shim.ChaincodeStubInterface, args []string) error {
-	if len(args) != 1 {
-		return errors.New("Need exactly one argument: <base64-encoded-pedersengroupmessage-object>")
+func (t *Gridlock) initParams(stub ledger.Ledger, args []string) error {
+	if len(args) < 1 || len(args) > 4 {
+		return errors.New("Need one to four arguments: <base64-encoded-object> [<base64-encoded-bulletproof-params>] [<base64-encoded-borromean-params>] [<base64-encoded-pedersengroup-params>]")
 	}
-	pedersenToStoreBytes, err := base64.StdEncoding.DecodeString(args[0])
+	//only the central bank recorded by Init sets up the ledger
+	err := common.CheckCentralBankFromLedger(stub)
 	if err != nil {
-		logger.Error("Failed to base64-decode protobuf-encoded pedersengroup")
 		return err
 	}
-
-	err = stub.PutState(common.PedersenTable+"_GROUP", pedersenToStoreBytes)
+	//no range proof scheme supports commitments in a Schnorr group, a ledger of them could not verify
+	//any transaction
+	if len(args) == 4 && args[3] != "" {
+		logger.Error("pedersengroup params are not supported")
+		return errors.New("pedersengroup params are not supported, no range proof scheme supports their commitments")
+	}
+	paramsToStoreBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded pedersencurve")
+		return err
+	}
+	paramsUL, err := zkrangeproof.DecodeParamsUL(paramsToStoreBytes)
+	if err != nil {
+		logger.Errorf("Invalid UL params: %s", err)
+		return err
+	}
+	//the commitments of the default currency are not binding with the legacy h, see common.CheckBindingFromLedger
+	if paramsUL.HasLegacyH() {
+		logger.Errorf("The UL params have the legacy h")
+		return errors.New("The h of the UL params must be hashed, not the legacy h")
+	}
+	//the queues of all the banks must hold payments of at least 1
+	if common.MaxPaymentAmount(paramsUL) < common.MinPaymentAmount {
+		logger.Errorf("The range of the UL params is too small")
+		return fmt.Errorf("u^l of the UL params must be at least %d", 2*common.NumOfBanks*common.MaxQueuedPayments)
+	}
+	//bulletproofs and borromean only exist on BN256
+	if paramsUL.Curve().ID() != pairing.BN256 {
+		for _, arg := range args[1:] {
+			if arg != "" {
+				logger.Errorf("Range proof params other than UL are not supported on %s", paramsUL.Curve().ID())
+				return fmt.Errorf("Only UL range proofs are supported on %s", paramsUL.Curve().ID())
+			}
+		}
+	}
+	err = stub.Put(common.PedersenTable+"_CURVE", paramsToStoreBytes)
 	if err != nil {
 		logger.Errorf("Failed to add to ledger")
 		return err
 	}
+	err = stub.Delete(common.PedersenTable + "_GROUP")
+	if err != nil {
+		logger.Errorf("Failed to delete from ledger")
+		return err
+	}
+
+	//params of the other range proof schemes are optional, an empty argument skips the scheme
+	//and without them only UL proofs are accepted
+	optionalParams := []struct {
+		name      string
+		key       string
+		unmarshal func([]byte) (*bn256.G2, int64, error)
+	}{
+		{"bulletproof", common.PedersenTable + "_BULLETPROOF", func(m []byte) (*bn256.G2, int64, error) {
+			params, err := new(bulletproofs.Params).Unmarshal(m)
+			if err != nil {
+				return nil, 0, err
+			}
+			return params.H, params.N, nil
+		}},
+		{"borromean", common.PedersenTable + "_BORROMEAN", func(m []byte) (*bn256.G2, int64, error) {
+			params, err := new(borromean.Params).Unmarshal(m)
+			if err != nil {
+				return nil, 0, err
+			}
+			return params.H, params.N, nil
+		}},
+	}
+	for i, arg := range args[1:] {
+		if arg == "" {
+			continue
+		}
+		params := optionalParams[i]
+		paramsBytes, err := base64.StdEncoding.DecodeString(arg)
+		if err != nil {
+			logger.Errorf("Failed to base64-decode %s params", params.name)
+			return err
+		}
+		h, n, err := params.unmarshal(paramsBytes)
+		if err != nil {
+			logger.Errorf("Invalid %s params", params.name)
+			return err
+		}
+		//the proofs are about the commitments of the UL params, and must not prove a value outside of
+		//[0,u^l), on which the supply and payment bounds rely
+		hUL, _ := pairing.ToBN256G2(paramsUL.H)
+		if !bytes.Equal(h.Marshal(), hUL.Marshal()) {
+			logger.Errorf("The H of the %s params is not the H of the UL params", params.name)
+			return fmt.Errorf("The %s params must have the H of the UL params", params.name)
+		}
+		if new(big.Int).Lsh(big.NewInt(1), uint(n)).Cmp(paramsUL.UpperBound()) > 0 {
+			logger.Errorf("The range of the %s params is larger than u^l", params.name)
+			return fmt.Errorf("2^N of the %s params must not be above u^l of the UL params", params.name)
+		}
+		err = stub.Put(params.key, paramsBytes)
+		if err != nil {
+			logger.Errorf("Failed to add to ledger")
+			return err
+		}
+	}
 	return nil
 }
 
-func (t *Gridlock) initParams(stub shim.ChaincodeStubInterface, args []string) error {
+func (t *Gridlock) setPaymentLimit(stub ledger.Ledger, args []string) error {
 	if len(args) != 1 {
-		return errors.New("Need exactly one argument: <base64-encoded-object>")
+		return errors.New("Need exactly one argument: <base64-encoded-paymentlimit-object>")
 	}
-	paramsToStoreBytes, err := base64.StdEncoding.DecodeString(args[0])
+	//only the central bank sets the payment limits
+	err := common.CheckCentralBankFromLedger(stub)
 	if err != nil {
-		logger.Error("Failed to base64-decode protobuf-encoded pedersencurve")
 		return err
 	}
-	err = stub.PutState(common.PedersenTable+"_CURVE", paramsToStoreBytes)
+	limitBytes, err := base64.StdEncoding.DecodeString(args[0])
 	if err != nil {
-		logger.Errorf("Failed to add to ledger")
+		logger.Error("Failed to base64-decode protobuf-encoded payment limit")
 		return err
 	}
-	return nil
+	limit := &pb.PaymentLimit{}
+	err = proto.Unmarshal(limitBytes, limit)
+	if err != nil {
+		logger.Error("Failed to unmarshal payment limit")
+		return err
+	}
+	if limit.BankId > common.NumOfBanks || limit.BankId <= 0 {
+		logger.Infof("Invalid bank Id %d", limit.BankId)
+		return errors.New("Invalid bank Id")
+	}
+	err = common.ValidateCurrency(limit.Currency)
+	if err != nil {
+		return err
+	}
+	if limit.MaxAmount < 0 {
+		return errors.New("The payment limit must not be negative")
+	}
+	//the payments of the limit must fit in the queues
+	params, err := common.GetParamsFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	if limit.MaxAmount > common.MaxPaymentAmount(params) {
+		return fmt.Errorf("The payment limit must be at most %d", common.MaxPaymentAmount(params))
+	}
+	minAmount, err := common.GetPaymentMinimumFromLedger(stub, common.InCurrency(common.MinimumTable, limit.Currency)+fmt.Sprint(limit.BankId))
+	if err != nil {
+		return err
+	}
+	if limit.MaxAmount < minAmount {
+		return errors.New("The payment limit must not be below the payment minimum")
+	}
+
+	//add payment limit to the ledger, it applies to the payments added afterwards
+	return common.AddPaymentLimitToLedger(stub, common.InCurrency(common.LimitTable, limit.Currency)+fmt.Sprint(limit.BankId), limit)
+}
+
+func (t *Gridlock) setPaymentMinimum(stub ledger.Ledger, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Need exactly one argument: <base64-encoded-paymentminimum-object>")
+	}
+	//only the central bank sets the payment minimums
+	err := common.CheckCentralBankFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	minimumBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded payment minimum")
+		return err
+	}
+	minimum := &pb.PaymentMinimum{}
+	err = proto.Unmarshal(minimumBytes, minimum)
+	if err != nil {
+		logger.Error("Failed to unmarshal payment minimum")
+		return err
+	}
+	if minimum.BankId > common.NumOfBanks || minimum.BankId <= 0 {
+		logger.Infof("Invalid bank Id %d", minimum.BankId)
+		return errors.New("Invalid bank Id")
+	}
+	err = common.ValidateCurrency(minimum.Currency)
+	if err != nil {
+		return err
+	}
+	if minimum.MinAmount < common.MinPaymentAmount {
+		return fmt.Errorf("The payment minimum must be at least %d", common.MinPaymentAmount)
+	}
+	params, err := common.GetParamsFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	if minimum.MinAmount > common.MaxPaymentAmount(params) {
+		return fmt.Errorf("The payment minimum must be at most %d", common.MaxPaymentAmount(params))
+	}
+	//a minimum above the payment limit leaves no amount to pay
+	limit, err := common.GetPaymentLimitFromLedger(stub, common.InCurrency(common.LimitTable, minimum.Currency)+fmt.Sprint(minimum.BankId))
+	if err != nil {
+		return err
+	}
+	if limit != nil && minimum.MinAmount > limit.MaxAmount {
+		return errors.New("The payment minimum must not be above the payment limit")
+	}
+
+	//add payment minimum to the ledger, it applies to the payments added afterwards
+	return common.AddPaymentMinimumToLedger(stub, common.InCurrency(common.MinimumTable, minimum.Currency)+fmt.Sprint(minimum.BankId), minimum)
 }
 
-func (t *Gridlock) startGLResolution(stub shim.ChaincodeStubInterface, args []string) error {
+func (t *Gridlock) startGLResolution(stub ledger.Ledger, args []string) error {
 	if len(args) != 1 {
 		return errors.New("Need exactly one argument: <base64-encoded-object>")
 	}
@@ -125,6 +381,38 @@ func (t *Gridlock) startGLResolution(stub shim.ChaincodeStubInterface, args []st
 		return err
 	}
 
+	//a started resolution keeps its id, restarting it would reopen its rounds or a netted settlement
+	stored, err := stub.Get(common.ConfigTable + fmt.Sprint(config.GridlockId))
+	if err != nil {
+		logger.Error("Failed to read config table")
+		return err
+	}
+	if stored != nil {
+		logger.Errorf("Gridlock resolution %d already exists", config.GridlockId)
+		return fmt.Errorf("Gridlock resolution %d already exists", config.GridlockId)
+	}
+
+	//the resolution nets the payments of a single currency, that has accounts
+	err = common.CheckCurrencyFromLedger(stub, config.Currency)
+	if err != nil {
+		return err
+	}
+
+	//every round that does not converge adds at least one payment to the infeasible set,
+	//so the resolution converges within one round more than the number of payments
+	numOfPayments := 0
+	for _, bankId := range config.BankIds {
+		outQueue, err := common.GetPaymentQueueFromLedger(stub, common.InCurrency(common.OutQueueTable, config.Currency), bankId)
+		if err != nil {
+			logger.Error("Failed to read outQueue from ledger")
+			return err
+		}
+		numOfPayments += len(outQueue)
+	}
+	config.Status = pb.GLRStatusType_START
+	config.Round = 0
+	config.MaxRounds = int32(numOfPayments) + 1
+
 	//add GLR configuration to the ledger
 	err = common.AddGLRConfigurationToLedger(stub, common.ConfigTable+fmt.Sprint(config.GridlockId), config)
 	if err != nil {
@@ -134,7 +422,7 @@ func (t *Gridlock) startGLResolution(stub shim.ChaincodeStubInterface, args []st
 	return nil
 }
 
-func (t *Gridlock) proposeNettableSet(stub shim.ChaincodeStubInterface, args []string) error {
+func (t *Gridlock) proposeNettableSet(stub ledger.Ledger, args []string) error {
 	if len(args) != 1 {
 		return errors.New("Need exactly one argument: <base64-encoded-object>")
 	}
@@ -160,21 +448,35 @@ func (t *Gridlock) proposeNettableSet(stub shim.ChaincodeStubInterface, args []s
 		return errors.New("Verification of gridlock proposal failed")
 	}
 
+	//the balance is committed in the currency of the resolution
+	config, err := common.GetGLRConfigFromLedger(stub, common.ConfigTable+fmt.Sprint(proposal.GridlockId))
+	if err != nil {
+		return err
+	}
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, config.Currency)
+	if err != nil {
+		return err
+	}
+	cmBalance, err := common.NormalizeCommitment(scheme, proposal.CmBalance)
+	if err != nil {
+		return err
+	}
 	//add the gridlock proposal to the ledger
-	err = common.AddGridlockProposalToLedger(
+	return common.AddGridlockProposalToLedger(
 		stub,
 		common.ProposalTable+fmt.Sprint(proposal.GridlockId)+fmt.Sprint(proposal.BankId),
 		&pb.StoredGridlockProposal{
 			OutgoingIds:   proposal.OutgoingIds,
 			InfeasibleIds: proposal.InfeasibleIds,
-			CmBalance:     proposal.CmBalance,
+			CmBalance:     cmBalance,
 			Zkrp1:         proposal.Zkrp1,
 			Zkrp2:         proposal.Zkrp2,
+			ZkrpType:      proposal.ZkrpType,
+			Zkrp:          proposal.Zkrp,
 		})
-	return nil
 }
 
-func (t *Gridlock) tallyGridlockProposal(stub shim.ChaincodeStubInterface, args []string) error {
+func (t *Gridlock) tallyGridlockProposal(stub ledger.Ledger, args []string) error {
 	if len(args) != 1 {
 		return errors.New("Need exactly one argument: <base64-encoded-object>")
 	}
@@ -191,6 +493,9 @@ func (t *Gridlock) tallyGridlockProposal(stub shim.ChaincodeStubInterface, args
 	}
 
 	config, err := common.GetGLRConfigFromLedger(stub, common.ConfigTable+fmt.Sprint(tally.GridlockId))
+	if err != nil {
+		return err
+	}
 	if config.Status != pb.GLRStatusType_START {
 		logger.Error("wrong state of current glr")
 		return errors.New("wrong state of current glr")
@@ -203,7 +508,12 @@ func (t *Gridlock) tallyGridlockProposal(stub shim.ChaincodeStubInterface, args
 	}
 	logger.Info(infeasibleObj)
 
-	infeasible := []int32{}
+	//the infeasible set only grows, the proposals add the infeasible ids of their bank to it
+	infeasible := append([]int32{}, infeasibleObj.PaymentIds...)
+	isInfeasible := map[int32]bool{}
+	for _, id := range infeasible {
+		isInfeasible[id] = true
+	}
 	for _, id := range config.BankIds {
 		proposal, err := common.GetGridlockProposalFromLedger(
 			stub,
@@ -212,7 +522,12 @@ func (t *Gridlock) tallyGridlockProposal(stub shim.ChaincodeStubInterface, args
 		if err != nil {
 			return err
 		}
-		infeasible = append(infeasible, proposal.InfeasibleIds...)
+		for _, pid := range proposal.InfeasibleIds {
+			if !isInfeasible[pid] {
+				isInfeasible[pid] = true
+				infeasible = append(infeasible, pid)
+			}
+		}
 	}
 	logger.Info(infeasible)
 
@@ -226,23 +541,26 @@ func (t *Gridlock) tallyGridlockProposal(stub shim.ChaincodeStubInterface, args
 		return err
 	}
 
-	//check if infeasible is unchanged, mark it as SUCCESS
+	//check if infeasible is unchanged, mark it as SUCCESS, otherwise abort after the last round
+	config.Round++
 	if len(infeasible) == len(infeasibleObj.PaymentIds) {
 		logger.Info("Converged, the gridlock resolution is successful")
 		config.Status = pb.GLRStatusType_SUCCESS
-		err = common.AddGLRConfigurationToLedger(stub, common.ConfigTable+fmt.Sprint(config.GridlockId), config)
-		if err != nil {
-			return err
-		}
+	} else if config.Round >= config.MaxRounds {
+		logger.Errorf("Not converged after %d rounds, the gridlock resolution is aborted", config.Round)
+		config.Status = pb.GLRStatusType_ABORTED
 	}
-	return nil
+	return common.AddGLRConfigurationToLedger(stub, common.ConfigTable+fmt.Sprint(config.GridlockId), config)
 }
 
-//verifyGridlockProposal verifies the zkrp1 of cmBalance-outgoing+incoming >=0
-//zkrp2 of -(cmBalance-outgoing-highestInfeasible) >=0
-func (t *Gridlock) verifyGridlockProposal(stub shim.ChaincodeStubInterface, proposal *pb.GridlockProposal) (bool, error) {
+//verifyGridlockProposal verifies the zkrp1 of cmBalance+creditLimit-outgoing+incoming >=0
+//zkrp2 of -(cmBalance+creditLimit-outgoing-highestInfeasible) >=0
+func (t *Gridlock) verifyGridlockProposal(stub ledger.Ledger, proposal *pb.GridlockProposal) (bool, error) {
 	//check whether the BankId is in the config table
 	config, err := common.GetGLRConfigFromLedger(stub, common.ConfigTable+fmt.Sprint(proposal.GridlockId))
+	if err != nil {
+		return false, err
+	}
 	if config.Status != pb.GLRStatusType_START {
 		logger.Info("wrong state of current glr")
 		return false, nil
@@ -259,8 +577,16 @@ func (t *Gridlock) verifyGridlockProposal(stub shim.ChaincodeStubInterface, prop
 		return false, nil
 	}
 
+	//verify OutgoingIds and InfeasibleIds partition the outgoing queue of the bank in the currency of the resolution
+	currency := config.Currency
+	err = t.verifyProposalSets(stub, proposal, currency)
+	if err != nil {
+		logger.Error(err)
+		return false, err
+	}
+
 	//verify the priority is reserved for OutgoingIds
-	success, err := settlement.VerifyStrictPriority(stub, proposal.BankId, proposal.OutgoingIds)
+	success, err := settlement.VerifyStrictPriority(stub, proposal.BankId, currency, proposal.OutgoingIds)
 	if err != nil {
 		return false, err
 	}
@@ -269,20 +595,36 @@ func (t *Gridlock) verifyGridlockProposal(stub shim.ChaincodeStubInterface, prop
 		return false, errors.New("Priority order is not reserved")
 	}
 
-	//get stored params
-	paramsUL, err := common.GetParamsFromLedger(stub)
+	//the proofs only bound the balance if its commitment is binding
+	err = common.CheckBindingFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+
+	//get the verifier of the scheme the proofs are encoded with
+	verifier, err := common.GetRangeProofVerifierFromLedger(stub, currency, proposal.ZkrpType)
 	if err != nil {
 		logger.Error("Failed to read parameters from ledger")
 		return false, err
 	}
 
 	//get current bank balance and check it is the same as CmBalance in the settlementSet
-	account, err := common.GetAccountFromLedger(stub, common.AccountTable+fmt.Sprint(proposal.BankId))
+	account, err := common.GetAccountFromLedger(stub, common.InCurrency(common.AccountTable, currency)+fmt.Sprint(proposal.BankId))
 	if err != nil {
 		logger.Error("Failed to read account from ledger")
 		return false, err
 	}
-	if bytes.Compare(account.CmBalance, proposal.CmBalance) != 0 {
+	//either may be compressed, so the commitments are compared
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+	cmBalance, err := common.UnmarshalCommitment(scheme, account.CmBalance)
+	if err != nil {
+		return false, err
+	}
+	cmProposal, ok := scheme.Unmarshal(proposal.CmBalance)
+	if !ok || !cmBalance.Equal(cmProposal) {
 		logger.Error("The cmBalance in account from ledger is different from the cmBalance in proposal")
 		return false, nil
 	}
@@ -294,92 +636,235 @@ func (t *Gridlock) verifyGridlockProposal(stub shim.ChaincodeStubInterface, prop
 		return false, err
 	}
 
-	cmSum, _ := new(bn256.G2).Unmarshal(account.CmBalance)
+	//the credit line of the bank adds to what it can pay, a commitment to 0 when it has none
+	cmLimit, err := common.GetCreditLimitFromLedger(stub, scheme, common.InCurrency(common.CreditTable, currency)+fmt.Sprint(proposal.BankId))
+	if err != nil {
+		return false, err
+	}
+
+	//the running sums of the queues keep the cost independent of the length of the queues
+	cmSum := cmBalance.Add(cmLimit)
 	//add all payments in the incoming queue excluding those in infeasible
-	inQueue, err := common.GetQueueFromLedger(stub, common.InQueueTable+fmt.Sprint(proposal.BankId))
+	inSum, err := common.GetQueueSumFromLedger(stub, scheme, common.InCurrency(common.InQueueTable, currency), proposal.BankId)
 	if err != nil {
-		logger.Error("Failed to read inQueue from ledger")
+		logger.Error("Failed to read the sum of inQueue from ledger")
 		return false, err
 	}
-	for _, id := range inQueue.PaymentIds {
-		isFeasible := true
-		for _, infeasibleId := range infeasible.PaymentIds {
-			if id == infeasibleId {
-				isFeasible = false
-			}
+	cmSum = cmSum.Add(inSum)
+	for _, id := range infeasible.PaymentIds {
+		payment, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(id))
+		if err != nil {
+			return false, err
 		}
-		if isFeasible == true {
-			payment, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(id))
-			if err != nil {
-				return false, err
-			}
-			cmAmount, _ := new(bn256.G2).Unmarshal(payment.CmAmount)
-			cmSum = new(bn256.G2).Add(cmSum, cmAmount)
+		//only the active payments to the bank in the currency are in its incoming queue
+		if payment.Receiver != proposal.BankId || payment.Status != pb.StatusType_ACTIVE || payment.Currency != currency {
+			continue
+		}
+		cmAmount, err := common.UnmarshalCommitment(scheme, payment.CmAmount)
+		if err != nil {
+			return false, err
 		}
+		cmSum = commitment.Sub(cmSum, cmAmount)
 	}
 
-	//subscract those outgoing payments from proposal.OutgoingIds
-	for _, id := range proposal.OutgoingIds {
+	//subscract those outgoing payments from proposal.OutgoingIds, which are the outgoing queue without proposal.InfeasibleIds
+	outSum, err := common.GetQueueSumFromLedger(stub, scheme, common.InCurrency(common.OutQueueTable, currency), proposal.BankId)
+	if err != nil {
+		logger.Error("Failed to read the sum of outQueue from ledger")
+		return false, err
+	}
+	cmSum = commitment.Sub(cmSum, outSum)
+	for _, id := range proposal.InfeasibleIds {
 		payment, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(id))
 		if err != nil {
 			return false, err
 		}
-		cmAmount, _ := new(bn256.G2).Unmarshal(payment.CmAmount)
-		cmSum = new(bn256.G2).Add(cmSum, new(bn256.G2).Neg(cmAmount))
+		cmAmount, err := common.UnmarshalCommitment(scheme, payment.CmAmount)
+		if err != nil {
+			return false, err
+		}
+		cmSum = cmSum.Add(cmAmount)
 	}
 
-	//check that cmSum's range proof: zkrp1
-	proof1 := new(zkrangeproof.ProofULVerifier).Unmarshal(proposal.Zkrp1, common.L)
-	//verify the committed value in the proof is the same as cmBalance-outgoing cmAmount
-	logger.Info("checking zkrp1")
-	if bytes.Compare(proof1.C.Marshal(), cmSum.Marshal()) != 0 {
-		logger.Error("The committed values does not match the one in the proof")
-		return false, nil
+	//the reserve of the bank is only available to its urgent payments
+	cmReserve, err := common.GetReserveFromAccount(scheme, account)
+	if err != nil {
+		return false, err
 	}
-	//verify the range proof
-	result, _ := zkrangeproof.VerifyUL(proof1, *paramsUL)
-	if result != true {
-		logger.Error("The zero knowledge range proof verification failed. The committed value in receiving amount is not within range.")
-		return false, errors.New("ZKP verification failed")
+	cms, err := t.proposalCommitments(stub, proposal, currency, cmSum, cmReserve)
+	if err != nil {
+		return false, err
 	}
-	logger.Info("The committed post balance after settling all outgoingIds is whtin range (0,u^l)")
 
-	//calculate cmSum-smallestPidFromInfeasible
+	//a single aggregated proof may replace zkrp1 and zkrp2
+	if len(proposal.Zkrp) > 0 {
+		return t.verifyAggregatedProposalProof(stub, proposal, currency, cms)
+	}
+
+	//zkrp1 proves cmSum is within range
+	zkrps := [][]byte{proposal.Zkrp1}
+	//zkrp2 proves -(cmSum-firstPidFromInfeasible) is within range
 	if len(proposal.InfeasibleIds) == 0 {
 		logger.Info("No infeasible set, no need to verify zkrp2")
-		return true, nil
+	} else {
+		zkrps = append(zkrps, proposal.Zkrp2)
 	}
-	logger.Info("checking zkrp2")
-	smallest := proposal.InfeasibleIds[0]
-	for _, id := range proposal.InfeasibleIds {
-		if smallest > id {
-			smallest = id
+
+	//both proofs are batch verified when the scheme supports it
+	logger.Info("checking zkrp1 and zkrp2")
+	points, err := common.RangeProofPoints(cms...)
+	if err != nil {
+		return false, err
+	}
+	result, bad, err := zkrangeproof.VerifyCommitments(verifier, zkrps, points)
+	if err != nil {
+		logger.Errorf("Failed to verify zkrp%d", bad+1)
+		return false, fmt.Errorf("zkrp%d: %w", bad+1, err)
+	}
+	if result != true {
+		logger.Errorf("The zero knowledge range proof verification failed. The committed value of zkrp%d is not within range.", bad+1)
+		return false, zkrangeproof.ErrVerificationFailed
+	}
+	logger.Info("The committed post balance after settling all outgoingIds is within range")
+
+	return true, nil
+}
+
+//verifyProposalSets checks that OutgoingIds and InfeasibleIds partition the outgoing queue of the bank in currency,
+//so that no payment is hidden or belongs to another bank, and that the payments of the bank in the global
+//infeasible set stay infeasible, so that the infeasible sets grow monotonically and the resolution converges
+func (t *Gridlock) verifyProposalSets(stub ledger.Ledger, proposal *pb.GridlockProposal, currency string) error {
+	outQueue, err := common.GetPaymentQueueFromLedger(stub, common.InCurrency(common.OutQueueTable, currency), proposal.BankId)
+	if err != nil {
+		logger.Error("Failed to read outQueue from ledger")
+		return err
+	}
+	inQueue := map[int32]bool{}
+	for _, id := range outQueue {
+		inQueue[id] = true
+	}
+
+	proposed := map[int32]bool{}
+	for _, ids := range []struct {
+		name string
+		ids  []int32
+	}{{"outgoing", proposal.OutgoingIds}, {"infeasible", proposal.InfeasibleIds}} {
+		for _, id := range ids.ids {
+			if proposed[id] {
+				return fmt.Errorf("Payment %d is proposed more than once", id)
+			}
+			if !inQueue[id] {
+				return fmt.Errorf("The %s payment %d is not in the outgoing queue of bank %d", ids.name, id, proposal.BankId)
+			}
+			proposed[id] = true
 		}
 	}
 
-	//subscract the amount of smallest id from cmSum
-	payment, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(smallest))
+	//the infeasible set must be a superset of the share of the bank in the previous global infeasible set
+	infeasible, err := common.GetQueueFromLedger(stub, common.InfeasibleTable+fmt.Sprint(proposal.GridlockId))
 	if err != nil {
-		return false, err
+		logger.Error("Failed to read infeasible from ledger")
+		return err
+	}
+	for _, id := range infeasible.PaymentIds {
+		if !inQueue[id] {
+			continue
+		}
+		for _, pid := range proposal.OutgoingIds {
+			if id == pid {
+				return fmt.Errorf("Payment %d is globally infeasible and can not be outgoing", id)
+			}
+		}
+		if !proposed[id] {
+			return fmt.Errorf("Payment %d is globally infeasible and must stay in the infeasible set of bank %d", id, proposal.BankId)
+		}
+	}
+
+	for _, id := range outQueue {
+		if !proposed[id] {
+			return fmt.Errorf("Payment %d of the outgoing queue of bank %d is neither outgoing nor infeasible", id, proposal.BankId)
+		}
+	}
+	return nil
+}
+
+//proposalCommitments returns the commitment proven by zkrp1, cmSum, and when there is an infeasible set the one proven
+//by zkrp2, -(cmSum-firstPidFromInfeasible). cmSum is the post-balance the reserve is available to, it is subtracted
+//from the first unless all the outgoing payments are urgent and from the second unless the first infeasible payment is.
+//The queue is ordered by priority, so the outgoing payments are all urgent when the last of them is urgent,
+//and the first infeasible payment is the one that follows them
+func (t *Gridlock) proposalCommitments(stub ledger.Ledger, proposal *pb.GridlockProposal, currency string, cmSum commitment.Commitment, cmReserve commitment.Commitment) ([]commitment.Commitment, error) {
+	head, err := common.GetPaymentQueueHeadFromLedger(stub, common.InCurrency(common.OutQueueTable, currency), proposal.BankId, len(proposal.OutgoingIds)+1)
+	if err != nil {
+		logger.Error("Failed to read outQueue from ledger")
+		return nil, err
+	}
+	if len(head) < len(proposal.OutgoingIds) {
+		return nil, errors.New("The outgoing payments are not the head of the queue")
+	}
+	//withoutReserve subtracts the reserve from cm unless the payment is urgent
+	withoutReserve := func(payment *pb.StoredPaymentMessage, cm commitment.Commitment) commitment.Commitment {
+		if payment.Priority <= common.UrgentPriority {
+			return cm
+		}
+		return commitment.Sub(cm, cmReserve)
+	}
+
+	cmSum1 := cmSum
+	if len(proposal.OutgoingIds) > 0 {
+		last, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(head[len(proposal.OutgoingIds)-1]))
+		if err != nil {
+			return nil, err
+		}
+		cmSum1 = withoutReserve(last, cmSum)
+	}
+	if len(proposal.InfeasibleIds) == 0 {
+		return []commitment.Commitment{cmSum1}, nil
 	}
-	cmAmount, _ := new(bn256.G2).Unmarshal(payment.CmAmount)
-	cmSum = new(bn256.G2).Add(cmSum, new(bn256.G2).Neg(cmAmount))
-	cmSumNeg := new(bn256.G2).Neg(cmSum)
 
-	//check new NegcmSum's range proof: zkrp2
-	proof2 := new(zkrangeproof.ProofULVerifier).Unmarshal(proposal.Zkrp2, common.L)
-	//verify the committed value in the proof is the same as cmBalance-outgoing cmAmount
-	if bytes.Compare(proof2.C.Marshal(), cmSumNeg.Marshal()) != 0 {
-		logger.Error("The committed values does not match the one in the proof")
+	if len(head) != len(proposal.OutgoingIds)+1 {
+		return nil, errors.New("No infeasible payment follows the outgoing payments in the queue")
+	}
+	//subscract the amount of the first infeasible id from cmSum
+	first, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(head[len(proposal.OutgoingIds)]))
+	if err != nil {
+		return nil, err
+	}
+	cmAmount, err := common.UnmarshalCommitment(cmSum.Scheme(), first.CmAmount)
+	if err != nil {
+		return nil, err
+	}
+	return []commitment.Commitment{cmSum1, commitment.Sub(withoutReserve(first, cmSum), cmAmount).Neg()}, nil
+}
+
+//verifyAggregatedProposalProof verifies the aggregated zkrp of the commitments of zkrp1 and, when there is
+//an infeasible set, zkrp2 in this order, in the currency of the resolution
+func (t *Gridlock) verifyAggregatedProposalProof(stub ledger.Ledger, proposal *pb.GridlockProposal, currency string, cms []commitment.Commitment) (bool, error) {
+	if len(proposal.Zkrp1) > 0 || len(proposal.Zkrp2) > 0 {
+		logger.Error("The aggregated zkrp can not be combined with zkrp1 or zkrp2")
 		return false, nil
 	}
-	//verify the range proof
-	result, _ = zkrangeproof.VerifyUL(proof2, *paramsUL)
+
+	logger.Info("checking aggregated zkrp")
+	verifier, err := common.GetAggregateRangeProofVerifierFromLedger(stub, currency, proposal.ZkrpType)
+	if err != nil {
+		logger.Error("Failed to read parameters from ledger")
+		return false, err
+	}
+	points, err := common.RangeProofPoints(cms...)
+	if err != nil {
+		return false, err
+	}
+	result, err := verifier.VerifyAggregateCommitment(proposal.Zkrp, points)
+	if err != nil {
+		logger.Error("Failed to verify the aggregated zkrp")
+		return false, err
+	}
 	if result != true {
-		logger.Error("The zero knowledge range proof verification failed. The committed value is not within range.")
-		return false, errors.New("ZKP verification failed")
+		logger.Error("The aggregated zero knowledge range proof verification failed. The committed values are not within range.")
+		return false, zkrangeproof.ErrVerificationFailed
 	}
-	logger.Info("The neg committed post balance after settling all outgoingIds+smallest infeasible id is whtin range (0,u^l)")
+	logger.Info("The committed post balances of the proposal are within range")
 
 	return true, nil
 }
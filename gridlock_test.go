@@ -1,41 +1,257 @@
 package main
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/blockchain-research/gridlock/account"
+	"github.com/blockchain-research/gridlock/borromean"
+	"github.com/blockchain-research/gridlock/bulletproofs"
 	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/blockchain-research/gridlock/ledger"
+	"github.com/blockchain-research/gridlock/ledger/fabric"
+	"github.com/blockchain-research/gridlock/message"
 	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/blockchain-research/gridlock/settlement"
 	"github.com/blockchain-research/gridlock/testutil"
+	"github.com/blockchain-research/gridlock/zkrangeproof"
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
+//newMockStub returns a mock stub of the chaincode instantiated by the central bank, which submits
+//the transactions until the creator of the returned chaincode is changed
+func newMockStub(t *testing.T) (*shim.MockStub, *testutil.Chaincode) {
+	cc := testutil.NewChaincode(new(Gridlock))
+	stub := shim.NewMockStub("gridlock", cc)
+	testutil.NewChecker(stub, t).Init("tx0", "init", []string{})
+	return stub, cc
+}
+
+//test that Init records the central bank, which alone sets up the ledger
 func TestInit(t *testing.T) {
-	target := new(Gridlock)
-	stub := shim.NewMockStub("gridlock", target)
+	cc := testutil.NewChaincode(new(Gridlock))
+	stub := shim.NewMockStub("gridlock", cc)
 	checker := testutil.NewChecker(stub, t)
 	checker.Init("tx1", "init", []string{})
+	checker.State(testutil.CentralBank, common.CentralBankTable)
+
+	//an upgrade keeps the central bank unless it passes another one
+	cc.Creator = []byte("admin")
+	checker.Init("tx2", "init", []string{})
+	checker.State(testutil.CentralBank, common.CentralBankTable)
+	checker.InvokeFailWithMessage("tx3", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())},
+		"Only the central bank")
+	checker.Init("tx4", "init", []string{base64.StdEncoding.EncodeToString([]byte("admin"))})
+	checker.State([]byte("admin"), common.CentralBankTable)
+	checker.Invoke("tx5", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+
+	//without an identity there is no central bank, and the ledger can not be set up
+	stub = shim.NewMockStub("gridlock", new(Gridlock))
+	checker = testutil.NewChecker(stub, t)
+	if response := stub.MockInit("tx1", [][]byte{[]byte("init")}); response.Status == shim.OK {
+		t.Error("Init without a central bank identity succeeded")
+	}
+	checker.InvokeFailWithMessage("tx2", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())},
+		"No central bank on the ledger")
 }
 
 //test mintAccount, addMessage, grossSettlement flow
 func TestMintAddMessageGrossSettlement(t *testing.T) {
-	target := new(Gridlock)
-	stub := shim.NewMockStub("gridlock", target)
+	testMintAddMessageGrossSettlement(t, pb.RangeProofType_UL)
+}
+
+//test mintAccount, addMessage, grossSettlement flow with bulletproofs
+func TestMintAddMessageGrossSettlementBulletproof(t *testing.T) {
+	testMintAddMessageGrossSettlement(t, pb.RangeProofType_BULLETPROOF)
+}
+
+//test mintAccount, addMessage, grossSettlement flow with borromean ring signature range proofs
+func TestMintAddMessageGrossSettlementBorromean(t *testing.T) {
+	testMintAddMessageGrossSettlement(t, pb.RangeProofType_BORROMEAN)
+}
+
+//test mintAccount, addMessage, grossSettlement flow with commitments and UL proofs on BLS12-381
+func TestMintAddMessageGrossSettlementBLS12381(t *testing.T) {
+	defer testutil.UseCurve(pairing.BLS12381)()
+
+	//bulletproofs and borromean only exist on BN256
+	stub, _ := newMockStub(t)
 	checker := testutil.NewChecker(stub, t)
+	checker.InvokeFail("tx1", "initParams",
+		[]string{
+			base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+			base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()),
+		})
 
-	//Get sample pedersen and call initPedersen
-	p := testutil.SampleParamsUL()
-	checker.Invoke("tx2", "initParams",
+	testMintAddMessageGrossSettlement(t, pb.RangeProofType_UL)
+}
+
+//test the mintAccount, addMessage, grossSettlement flow outside Fabric, on a store on disk
+//with one transaction per operation as a standalone service would run it
+func TestMintAddMessageGrossSettlementOnDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+	store, err := ledger.OpenDisk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetCreator(testutil.CentralBank)
+	gridlock := new(Gridlock)
+	run := func(f func(ledger.Ledger, []string) error, request []byte) error {
+		tx := ledger.Begin(store)
+		err := f(tx, []string{base64.StdEncoding.EncodeToString(request)})
+		if err != nil {
+			tx.Discard()
+			return err
+		}
+		return tx.Commit()
+	}
+
+	if err = run(gridlock.initCentralBank, testutil.CentralBank); err != nil {
+		t.Fatal(err)
+	}
+	if err = run(gridlock.initParams, testutil.SampleParamsUL()); err != nil {
+		t.Fatal(err)
+	}
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	if err = run(account.MintAccount, request); err != nil {
+		t.Fatal(err)
+	}
+	spm, randomnessPayment := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	if err = run(message.AddMessage, request); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	//the operations that fail leave the store unchanged
+	store, err = ledger.OpenDisk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	before, _ := store.RangeScan("", "", 0)
+	sss := testutil.SampleGrossSettlementSet(1, spm, sma.Accounts[0].CmBalance,
+		big.NewInt(90), new(big.Int).Sub(randomnessInit[1], randomnessPayment[1]), pb.RangeProofType_UL)
+	zkrp := sss.Zkrp
+	sss.Zkrp = zkrp[:len(zkrp)-1]
+	request, _ = proto.Marshal(sss)
+	if run(settlement.GrossSettlement, request) == nil {
+		t.Fatal("grossSettlement with a truncated proof succeeded")
+	}
+	after, _ := store.RangeScan("", "", 0)
+	if !reflect.DeepEqual(before, after) {
+		t.Error("failed grossSettlement changed the store")
+	}
+
+	sss.Zkrp = zkrp
+	request, _ = proto.Marshal(sss)
+	if err = run(settlement.GrossSettlement, request); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := store.Get(common.AccountTable + "1")
+	if !bytes.Equal(stored, testutil.GetStoredBankAccountFromValue(big.NewInt(90), new(big.Int).Sub(randomnessInit[1], randomnessPayment[1]))) {
+		t.Error("the account of the sender was not updated")
+	}
+}
+
+//test that an empty argument of initParams skips the params of that scheme
+func TestInitParamsSkipsEmptyArguments(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	pBR := testutil.SampleBorromeanParams()
+	checker.Invoke("tx1", "initParams",
 		[]string{
-			base64.StdEncoding.EncodeToString(p),
+			base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+			"",
+			base64.StdEncoding.EncodeToString(pBR),
+		})
+	checker.State(pBR, common.PedersenTable+"_BORROMEAN")
+	if stub.State[common.PedersenTable+"_BULLETPROOF"] != nil {
+		t.Error("bulletproof params should not be stored")
+	}
+
+	//invalid params are rejected
+	checker.InvokeFail("tx2", "initParams",
+		[]string{
+			base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+			"",
+			base64.StdEncoding.EncodeToString(pBR[1:]),
 		})
+	pUL := testutil.SampleParamsUL()
+	checker.InvokeFail("tx3", "initParams",
+		[]string{base64.StdEncoding.EncodeToString(pUL[:len(pUL)-1])})
+}
+
+//test that initParams rejects bulletproof and borromean params that do not prove the commitments of the UL params
+//or prove a range larger than [0,u^l)
+func TestInitParamsChecksRangeProofParams(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	pUL := base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())
+	paramsUL, _ := zkrangeproof.DecodeParamsUL(testutil.SampleParamsUL())
+	h, _ := pairing.ToBN256G2(paramsUL.H)
+	otherH := new(bn256.G2).ScalarBaseMult(big.NewInt(7))
+
+	for _, args := range []struct {
+		bp, br *bn256.G2
+		n      int64
+		reason string
+	}{
+		{bp: otherH, n: 32, reason: "The bulletproof params must have the H of the UL params"},
+		{br: otherH, n: 32, reason: "The borromean params must have the H of the UL params"},
+		{bp: h, n: 64, reason: "2^N of the bulletproof params must not be above u^l of the UL params"},
+		{br: h, n: 64, reason: "2^N of the borromean params must not be above u^l of the UL params"},
+	} {
+		bp, br := "", ""
+		if args.bp != nil {
+			params, _ := bulletproofs.Setup(args.n, args.bp)
+			bp = base64.StdEncoding.EncodeToString(params.Marshal())
+		}
+		if args.br != nil {
+			params, _ := borromean.Setup(args.n, args.br)
+			br = base64.StdEncoding.EncodeToString(params.Marshal())
+		}
+		checker.InvokeFailWithMessage("tx1", "initParams", []string{pUL, bp, br}, args.reason)
+	}
+	if stub.State[common.PedersenTable+"_BULLETPROOF"] != nil || stub.State[common.PedersenTable+"_BORROMEAN"] != nil {
+		t.Error("rejected params should not be stored")
+	}
+
+	checker.Invoke("tx2", "initParams",
+		[]string{pUL, base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()), base64.StdEncoding.EncodeToString(testutil.SampleBorromeanParams())})
+}
+
+func testMintAddMessageGrossSettlement(t *testing.T, proofType pb.RangeProofType) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	//Get sample pedersen and call initPedersen, the params of the other schemes are only needed by their proofs
+	params := []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())}
+	if proofType != pb.RangeProofType_UL {
+		params = append(params,
+			base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()),
+			base64.StdEncoding.EncodeToString(testutil.SampleBorromeanParams()),
+		)
+	}
+	checker.Invoke("tx2", "initParams", params)
 
 	//Get sample MintAccount
 	sma, randomnessInit := testutil.SampleMintAccount(
 		map[int32]*big.Int{1: new(big.Int).SetInt64(100), 2: new(big.Int).SetInt64(100)},
+		proofType,
 	)
 	logger.Info(randomnessInit)
 
@@ -61,6 +277,7 @@ func TestMintAddMessageGrossSettlement(t *testing.T) {
 		1, //payerId
 		2, //payeeId
 		new(big.Int).SetInt64(10), //payment amount
+		proofType,
 	)
 	logger.Info(randomnessPayment)
 	request, err = proto.Marshal(spm)
@@ -78,19 +295,11 @@ func TestMintAddMessageGrossSettlement(t *testing.T) {
 	checker.State([]byte(paymentMessageBytes), common.MessageTable+fmt.Sprint(spm.PaymentId))
 	logger.Info("Message is on the ledger")
 
-	queue := &pb.StoredPaymentQueue{
-		PaymentIds: []int32{spm.PaymentId},
-	}
-	storedQueueBytes, err := proto.Marshal(queue)
-	if err != nil {
-		t.Logf("Failed to proto marshal 'StoredPaymentQueue' object - %s", err)
-		t.FailNow()
-	}
 	//check outgoing queue is stored correctly
-	checker.State([]byte(storedQueueBytes), common.OutQueueTable+fmt.Sprint(spm.Sender))
+	checker.Queue([]int32{spm.PaymentId}, common.OutQueueTable, spm.Sender)
 	logger.Info("Outqueue is on the ledger")
 	//check incoming queue is stored correctly
-	checker.State([]byte(storedQueueBytes), common.InQueueTable+fmt.Sprint(spm.Receiver))
+	checker.Queue([]int32{spm.PaymentId}, common.InQueueTable, spm.Receiver)
 	logger.Info("Inqueue is on the ledger")
 
 	//Get sample grosssettlement set
@@ -100,6 +309,7 @@ func TestMintAddMessageGrossSettlement(t *testing.T) {
 		sma.Accounts[0].CmBalance, //current account balance cm
 		new(big.Int).SetInt64(90),
 		new(big.Int).Sub(randomnessInit[1], randomnessPayment[1]), //randomness in account + message
+		proofType,
 	)
 	request, err = proto.Marshal(sss)
 	if err != nil {
@@ -132,22 +342,314 @@ func TestMintAddMessageGrossSettlement(t *testing.T) {
 	checker.State([]byte(accountBytes), common.AccountTable+fmt.Sprint(spm.Receiver))
 	logger.Info("Account of receiver is updated correctly on the ledger")
 
-	queue = &pb.StoredPaymentQueue{
-		PaymentIds: []int32{},
-	}
-	storedQueueBytes, err = proto.Marshal(queue)
-	if err != nil {
-		t.Logf("Failed to proto marshal 'StoredPaymentQueue' object - %s", err)
-		t.FailNow()
-	}
 	//check the outgoing queue of sender is updated
-	checker.State([]byte(storedQueueBytes), common.OutQueueTable+fmt.Sprint(spm.Sender))
+	checker.Queue([]int32{}, common.OutQueueTable, spm.Sender)
 	logger.Info("Outqueue is updated on the ledger")
 	//check incoming queue of receiver is updated
-	checker.State([]byte(storedQueueBytes), common.InQueueTable+fmt.Sprint(spm.Receiver))
+	checker.Queue([]int32{}, common.InQueueTable, spm.Receiver)
 	logger.Info("Inqueue is updated on the ledger")
 }
 
+//uncompressed returns the uncompressed encoding of the BN256 commitment cm, as clients wrote it before compression
+func uncompressed(t *testing.T, cm []byte) []byte {
+	e, ok := pairing.MustLookup(pairing.BN256).NewG2().Unmarshal(cm)
+	if !ok {
+		t.Fatal("invalid commitment")
+	}
+	return e.Marshal()
+}
+
+//test that uncompressed commitments are still accepted, and stored compressed,
+//and that uncompressed balances stored before compression are still settled
+func TestUncompressedCommitments(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: new(big.Int).SetInt64(100), 2: new(big.Int).SetInt64(100)},
+		pb.RangeProofType_UL,
+	)
+	compressed := map[int32][]byte{}
+	for _, account := range sma.Accounts {
+		compressed[account.BankId] = account.CmBalance
+		account.CmBalance = uncompressed(t, account.CmBalance)
+	}
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	for _, account := range sma.Accounts {
+		checker.State(testutil.GetStoredBankAccount(&pb.BankAccount{CmBalance: compressed[account.BankId]}),
+			common.AccountTable+fmt.Sprint(account.BankId))
+	}
+
+	spm, randomnessPayment := testutil.SamplePaymentMessage(1, 1, 2, new(big.Int).SetInt64(10), pb.RangeProofType_UL)
+	stored := testutil.GetStoredPaymentMessage(spm)
+	spm.CmAmount = uncompressed(t, spm.CmAmount)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(stored, common.MessageTable+fmt.Sprint(spm.PaymentId))
+
+	//an account stored uncompressed is settled with a compressed cmBalance in the settlement set
+	stub.State[common.AccountTable+"1"] = testutil.GetStoredBankAccount(&pb.BankAccount{CmBalance: uncompressed(t, compressed[1])})
+	sss := testutil.SampleGrossSettlementSet(1, spm, compressed[1], big.NewInt(90),
+		new(big.Int).Sub(randomnessInit[1], randomnessPayment[1]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.Invoke("tx4", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredBankAccountFromValue(big.NewInt(90), new(big.Int).Sub(randomnessInit[1], randomnessPayment[1])),
+		common.AccountTable+fmt.Sprint(spm.Sender))
+	checker.State(testutil.GetStoredBankAccountFromValue(big.NewInt(110), new(big.Int).Add(randomnessInit[2], randomnessPayment[2])),
+		common.AccountTable+fmt.Sprint(spm.Receiver))
+}
+
+//test mintAccount and addMessages with aggregated range proofs
+func TestAggregatedMintAddMessages(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	//aggregated proofs need the bulletproof params
+	p := testutil.SampleParamsUL()
+	pBP := testutil.SampleBulletproofParams()
+	pBR := testutil.SampleBorromeanParams()
+	checker.Invoke("tx2", "initParams",
+		[]string{
+			base64.StdEncoding.EncodeToString(p),
+			base64.StdEncoding.EncodeToString(pBP),
+			base64.StdEncoding.EncodeToString(pBR),
+		})
+
+	//Get sample MintAccount with one proof for both accounts
+	sma, _ := testutil.SampleAggregatedMintAccount(
+		map[int32]*big.Int{1: new(big.Int).SetInt64(100), 2: new(big.Int).SetInt64(100)},
+	)
+	request, err := proto.Marshal(sma)
+	if err != nil {
+		t.Logf("Failed to proto marshal 'MintAccount' object - %s", err)
+		t.FailNow()
+	}
+	//an aggregated proof can not be verified as UL
+	sma.ZkrpType = pb.RangeProofType_UL
+	requestUL, _ := proto.Marshal(sma)
+	checker.InvokeFail("tx2", "mintAccount",
+		[]string{
+			base64.StdEncoding.EncodeToString(requestUL),
+		})
+	checker.Invoke("tx2", "mintAccount",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+	for i := range sma.Accounts {
+		accountBytes := testutil.GetStoredBankAccount(sma.Accounts[i])
+		checker.State([]byte(accountBytes), common.AccountTable+fmt.Sprint(sma.Accounts[i].BankId))
+	}
+
+	//Get sample batch of two payments from the same sender with one proof
+	batch, _ := testutil.SamplePaymentMessageBatch(map[int32]*testutil.GLMessage{
+		1: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: new(big.Int).SetInt64(10)},
+		2: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: new(big.Int).SetInt64(5)},
+	})
+	request, err = proto.Marshal(batch)
+	if err != nil {
+		t.Logf("Failed to proto marshal 'PaymentMessageBatch' object - %s", err)
+		t.FailNow()
+	}
+	//the proof is bound to the order of the payments
+	swapped := &pb.PaymentMessageBatch{
+		Payments: []*pb.PaymentMessage{batch.Payments[1], batch.Payments[0]},
+		Zkrp:     batch.Zkrp,
+		ZkrpType: batch.ZkrpType,
+	}
+	requestSwapped, _ := proto.Marshal(swapped)
+	checker.InvokeFail("tx2", "addMessages",
+		[]string{
+			base64.StdEncoding.EncodeToString(requestSwapped),
+		})
+	checker.Invoke("tx2", "addMessages",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+
+	//check payment messages are stored correctly
+	for _, spm := range batch.Payments {
+		paymentMessageBytes := testutil.GetStoredPaymentMessage(spm)
+		checker.State([]byte(paymentMessageBytes), common.MessageTable+fmt.Sprint(spm.PaymentId))
+	}
+	checker.Queue([]int32{1, 2}, common.OutQueueTable, 1)
+	checker.Queue([]int32{1, 2}, common.InQueueTable, 2)
+
+	//the ids of a batch differ from each other and from the payments on the ledger
+	batch, _ = testutil.SamplePaymentMessageBatch(map[int32]*testutil.GLMessage{
+		3: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: new(big.Int).SetInt64(10)},
+		4: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: new(big.Int).SetInt64(5)},
+	})
+	batch.Payments[1].PaymentId = 3
+	request, _ = proto.Marshal(batch)
+	checker.InvokeFailWithMessage("tx3", "addMessages", []string{base64.StdEncoding.EncodeToString(request)}, "Payment 3 appears twice")
+	batch.Payments[1].PaymentId = 1
+	request, _ = proto.Marshal(batch)
+	checker.InvokeFailWithMessage("tx3", "addMessages", []string{base64.StdEncoding.EncodeToString(request)}, "Payment 1 already exists")
+	batch.Payments[1].PaymentId = 4
+	request, _ = proto.Marshal(batch)
+	checker.Invoke("tx3", "addMessages", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.Queue([]int32{1, 2, 3, 4}, common.OutQueueTable, 1)
+}
+
+//test that payments of a bank with a payment limit must prove their amount is within the limit
+func TestPaymentLimit(t *testing.T) {
+	stub, cc := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	p := testutil.SampleParamsUL()
+	pBP := testutil.SampleBulletproofParams()
+	checker.Invoke("tx1", "initParams",
+		[]string{
+			base64.StdEncoding.EncodeToString(p),
+			base64.StdEncoding.EncodeToString(pBP),
+		})
+
+	//bank 1 can not send more than 50 in a single payment, a limit only the central bank sets
+	limit := testutil.SamplePaymentLimit(1, 50)
+	request, err := proto.Marshal(limit)
+	if err != nil {
+		t.Logf("Failed to proto marshal 'PaymentLimit' object - %s", err)
+		t.FailNow()
+	}
+	cc.Creator = []byte("bank 1")
+	checker.InvokeFailWithMessage("tx2", "setPaymentLimit",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		}, "Only the central bank")
+	cc.Creator = testutil.CentralBank
+	checker.Invoke("tx2", "setPaymentLimit",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+	checker.State(request, common.LimitTable+fmt.Sprint(1))
+	requestInvalid, _ := proto.Marshal(testutil.SamplePaymentLimit(common.NumOfBanks+1, 50))
+	checker.InvokeFail("tx2", "setPaymentLimit",
+		[]string{
+			base64.StdEncoding.EncodeToString(requestInvalid),
+		})
+
+	//a payment within the limit is rejected with the proof of the default limit and accepted with the proof of the limit
+	amount := new(big.Int).SetInt64(40)
+	spm, randomness := testutil.SamplePaymentMessage(1, 1, 2, amount, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFail("tx3", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+	err = testutil.AddPaymentLimitProof(spm, amount, randomness[1], 50)
+	if err != nil {
+		t.Logf("Failed to prove the payment limit - %s", err)
+		t.FailNow()
+	}
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx3", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+	checker.State(testutil.GetStoredPaymentMessage(spm), common.MessageTable+fmt.Sprint(1))
+
+	//a payment above the limit can not be proven, and a proof for a larger limit is rejected
+	amount = new(big.Int).SetInt64(60)
+	spm, randomness = testutil.SamplePaymentMessage(2, 1, 2, amount, pb.RangeProofType_UL)
+	if err := testutil.AddPaymentLimitProof(spm, amount, randomness[1], 50); err == nil {
+		t.Error("Assert failure: expected an error proving an amount above the limit")
+	}
+	testutil.AddPaymentLimitProof(spm, amount, randomness[1], 100)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFailWithCode("tx4", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		}, "ZKRP_COMMITMENT_MISMATCH")
+
+	//bank 2 has no limit
+	spm, _ = testutil.SamplePaymentMessage(3, 2, 1, amount, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx5", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+
+	//the limit also applies to the payments of a batch
+	batch, _ := testutil.SamplePaymentMessageBatch(map[int32]*testutil.GLMessage{
+		4: &testutil.GLMessage{SenderId: 1, ReceiverId: 3, Amount: new(big.Int).SetInt64(10)},
+	})
+	request, _ = proto.Marshal(batch)
+	checker.InvokeFail("tx6", "addMessages",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+}
+
+//test that a rejected range proof reaches the client with the code of the reason
+func TestAddMessageErrorCodes(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	checker.Invoke("tx1", "initParams",
+		[]string{
+			base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+		})
+	spm, _ := testutil.SamplePaymentMessage(1, 1, 2, new(big.Int).SetInt64(10), pb.RangeProofType_UL)
+	other, _ := testutil.SamplePaymentMessage(2, 1, 2, new(big.Int).SetInt64(10), pb.RangeProofType_UL)
+
+	//a proof of another commitment
+	zkrp := spm.Zkrp
+	spm.Zkrp = other.Zkrp
+	request, _ := proto.Marshal(spm)
+	checker.InvokeFailWithCode("tx2", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		}, "ZKRP_COMMITMENT_MISMATCH")
+
+	//a truncated proof
+	spm.Zkrp = zkrp[:len(zkrp)-1]
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFailWithCode("tx3", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		}, "ZKRP_MALFORMED_PROOF")
+
+	spm.Zkrp = zkrp
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx4", "addMessage",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+}
+
+//test that a payment id is added once, a payment added twice would be queued and summed twice
+func TestAddMessageDuplicateId(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+	sma, _ := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	spm, _ := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	outSum := stub.State[common.OutSumTable+"1"]
+	inSum := stub.State[common.InSumTable+"2"]
+
+	//neither the same payment nor another one with its id
+	checker.InvokeFailWithMessage("tx4", "addMessage", []string{base64.StdEncoding.EncodeToString(request)}, "Payment 1 already exists")
+	other, _ := testutil.SamplePaymentMessage(1, 2, 1, big.NewInt(20), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(other)
+	checker.InvokeFailWithMessage("tx5", "addMessage", []string{base64.StdEncoding.EncodeToString(request)}, "Payment 1 already exists")
+
+	checker.State(testutil.GetStoredPaymentMessage(spm), common.MessageTable+"1")
+	checker.Queue([]int32{1}, common.OutQueueTable, 1)
+	checker.Queue([]int32{1}, common.InQueueTable, 2)
+	checker.Queue([]int32{}, common.OutQueueTable, 2)
+	if !bytes.Equal(stub.State[common.OutSumTable+"1"], outSum) || !bytes.Equal(stub.State[common.InSumTable+"2"], inSum) {
+		t.Error("the queue sums should count the payment once")
+	}
+}
+
 //test basic gridlock resolution and netSettlement flow
 //Banks				1001	1002	1003	1004	1005
 //AccountBalance	3		4		5		4		3
@@ -162,42 +664,58 @@ func TestMintAddMessageGrossSettlement(t *testing.T) {
 //T9						+100					-100
 //T10				+5						-5
 func TestGridlockResolutionFlow(t *testing.T) {
-	var glrId int32
-	glrId = 1001
-	bankIds := []int32{1, 2, 3, 4, 5}
-	balances := map[int32]*big.Int{
-		1: new(big.Int).SetInt64(3),
-		2: new(big.Int).SetInt64(4),
-		3: new(big.Int).SetInt64(5),
-		4: new(big.Int).SetInt64(4),
-		5: new(big.Int).SetInt64(3),
-	}
-	messages := map[int32]*testutil.GLMessage{
-		1:  &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: new(big.Int).SetInt64(5)},
-		2:  &testutil.GLMessage{SenderId: 2, ReceiverId: 3, Amount: new(big.Int).SetInt64(6)},
-		3:  &testutil.GLMessage{SenderId: 2, ReceiverId: 3, Amount: new(big.Int).SetInt64(30)},
-		4:  &testutil.GLMessage{SenderId: 3, ReceiverId: 4, Amount: new(big.Int).SetInt64(8)},
-		5:  &testutil.GLMessage{SenderId: 3, ReceiverId: 5, Amount: new(big.Int).SetInt64(80)},
-		6:  &testutil.GLMessage{SenderId: 4, ReceiverId: 5, Amount: new(big.Int).SetInt64(7)},
-		7:  &testutil.GLMessage{SenderId: 1, ReceiverId: 3, Amount: new(big.Int).SetInt64(6)},
-		8:  &testutil.GLMessage{SenderId: 5, ReceiverId: 1, Amount: new(big.Int).SetInt64(8)},
-		9:  &testutil.GLMessage{SenderId: 5, ReceiverId: 2, Amount: new(big.Int).SetInt64(100)},
-		10: &testutil.GLMessage{SenderId: 4, ReceiverId: 1, Amount: new(big.Int).SetInt64(5)},
-	}
-
-	target := new(Gridlock)
-	stub := shim.NewMockStub("gridlock", target)
+	testGridlockResolutionFlow(t, pb.RangeProofType_UL, false)
+}
+
+//test the same gridlock resolution flow with aggregated bulletproofs in the proposals
+func TestGridlockResolutionFlowAggregated(t *testing.T) {
+	testGridlockResolutionFlow(t, pb.RangeProofType_BULLETPROOF, true)
+}
+
+//test the same gridlock resolution flow with borromean ring signature range proofs
+func TestGridlockResolutionFlowBorromean(t *testing.T) {
+	testGridlockResolutionFlow(t, pb.RangeProofType_BORROMEAN, false)
+}
+
+//balances and payments of the gridlock resolution flow above
+var gridlockBalances = map[int32]*big.Int{
+	1: new(big.Int).SetInt64(3),
+	2: new(big.Int).SetInt64(4),
+	3: new(big.Int).SetInt64(5),
+	4: new(big.Int).SetInt64(4),
+	5: new(big.Int).SetInt64(3),
+}
+
+var gridlockMessages = map[int32]*testutil.GLMessage{
+	1:  &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: new(big.Int).SetInt64(5)},
+	2:  &testutil.GLMessage{SenderId: 2, ReceiverId: 3, Amount: new(big.Int).SetInt64(6)},
+	3:  &testutil.GLMessage{SenderId: 2, ReceiverId: 3, Amount: new(big.Int).SetInt64(30)},
+	4:  &testutil.GLMessage{SenderId: 3, ReceiverId: 4, Amount: new(big.Int).SetInt64(8)},
+	5:  &testutil.GLMessage{SenderId: 3, ReceiverId: 5, Amount: new(big.Int).SetInt64(80)},
+	6:  &testutil.GLMessage{SenderId: 4, ReceiverId: 5, Amount: new(big.Int).SetInt64(7)},
+	7:  &testutil.GLMessage{SenderId: 1, ReceiverId: 3, Amount: new(big.Int).SetInt64(6)},
+	8:  &testutil.GLMessage{SenderId: 5, ReceiverId: 1, Amount: new(big.Int).SetInt64(8)},
+	9:  &testutil.GLMessage{SenderId: 5, ReceiverId: 2, Amount: new(big.Int).SetInt64(100)},
+	10: &testutil.GLMessage{SenderId: 4, ReceiverId: 1, Amount: new(big.Int).SetInt64(5)},
+}
+
+//startGridlockResolution mints the accounts, adds the payments and starts the gridlock resolution glrId
+//of the gridlock resolution flow, it returns the randomness of the accounts and of the payments
+func startGridlockResolution(t *testing.T, stub *shim.MockStub, glrId int32, proofType pb.RangeProofType) (map[int32]*big.Int, map[int32]map[int32]*big.Int) {
 	checker := testutil.NewChecker(stub, t)
 
-	//Get sample pedersen and call initPedersen
-	p := testutil.SampleParamsUL()
-	checker.Invoke("tx2", "initParams",
-		[]string{
-			base64.StdEncoding.EncodeToString(p),
-		})
+	//Get sample pedersen and call initPedersen, the params of the other schemes are only needed by their proofs
+	params := []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())}
+	if proofType != pb.RangeProofType_UL {
+		params = append(params,
+			base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()),
+			base64.StdEncoding.EncodeToString(testutil.SampleBorromeanParams()),
+		)
+	}
+	checker.Invoke("tx2", "initParams", params)
 
 	//Get sample MintAccount
-	sma, randomnessInit := testutil.SampleMintAccount(balances)
+	sma, randomnessInit := testutil.SampleMintAccount(gridlockBalances, proofType)
 	request, err := proto.Marshal(sma)
 	if err != nil {
 		t.Logf("Failed to proto marshal 'MintAccount' object - %s", err)
@@ -209,14 +727,14 @@ func TestGridlockResolutionFlow(t *testing.T) {
 		})
 
 	//Get sample payment message and invoke addMessage
-	randomnessPayment, err := testutil.AddGridlockMessages(checker, messages)
+	randomnessPayment, err := testutil.AddGridlockMessages(checker, gridlockMessages, proofType)
 	if err != nil {
 		t.Logf("Failed to simulate gridlock - %s", err)
 		t.FailNow()
 	}
 
 	//start gridlock resolution: startGR
-	sc := testutil.SampleGLRConfiguration(glrId, bankIds)
+	sc := testutil.SampleGLRConfiguration(glrId, []int32{1, 2, 3, 4, 5})
 	request, err = proto.Marshal(sc)
 	if err != nil {
 		t.Logf("Failed to proto marshal 'GLRConfiguration' object - %s", err)
@@ -226,6 +744,26 @@ func TestGridlockResolutionFlow(t *testing.T) {
 		[]string{
 			base64.StdEncoding.EncodeToString(request),
 		})
+	return randomnessInit, randomnessPayment
+}
+
+func testGridlockResolutionFlow(t *testing.T, proofType pb.RangeProofType, aggregate bool) {
+	var glrId int32
+	glrId = 1001
+	balances, messages := gridlockBalances, gridlockMessages
+
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	randomnessInit, randomnessPayment := startGridlockResolution(t, stub, glrId, proofType)
+	var request []byte
+	var err error
+
+	sampleProposals := func(list map[int32]*testutil.IDList) (map[int32]*pb.GridlockProposal, map[int32][]byte) {
+		if aggregate {
+			return testutil.SampleAggregatedGridlockProposals(glrId, balances, messages, randomnessInit, randomnessPayment, list)
+		}
+		return testutil.SampleGridlockProposals(glrId, balances, messages, randomnessInit, randomnessPayment, list, proofType)
+	}
 
 	//Round1 proposal: proposeNettableSet
 	list1 := map[int32]*testutil.IDList{
@@ -236,7 +774,7 @@ func TestGridlockResolutionFlow(t *testing.T) {
 		5: &testutil.IDList{OutgoingIds: []int32{8}, IncomingIds: []int32{5, 6}, InfeasibleIds: []int32{9}},
 	}
 
-	sgp, postAccount1 := testutil.SampleGridlockProposals(glrId, balances, messages, randomnessInit, randomnessPayment, list1)
+	sgp, postAccount1 := sampleProposals(list1)
 	for k := range list1 {
 		request, err = proto.Marshal(sgp[k])
 		if err != nil {
@@ -266,7 +804,7 @@ func TestGridlockResolutionFlow(t *testing.T) {
 		2: &testutil.IDList{OutgoingIds: []int32{2}, IncomingIds: []int32{1}, InfeasibleIds: []int32{3}},
 		5: &testutil.IDList{OutgoingIds: []int32{8}, IncomingIds: []int32{6}, InfeasibleIds: []int32{9}},
 	}
-	sgp, postAccount2 := testutil.SampleGridlockProposals(glrId, balances, messages, randomnessInit, randomnessPayment, list2)
+	sgp, postAccount2 := sampleProposals(list2)
 	for k := range list2 {
 		request, err = proto.Marshal(sgp[k])
 		if err != nil {
@@ -294,7 +832,7 @@ func TestGridlockResolutionFlow(t *testing.T) {
 	list3 := map[int32]*testutil.IDList{
 		3: &testutil.IDList{OutgoingIds: []int32{4}, IncomingIds: []int32{2, 7}, InfeasibleIds: []int32{5}},
 	}
-	sgp, postAccount3 := testutil.SampleGridlockProposals(glrId, balances, messages, randomnessInit, randomnessPayment, list3)
+	sgp, postAccount3 := sampleProposals(list3)
 	for k := range list3 {
 		request, err = proto.Marshal(sgp[k])
 		if err != nil {
@@ -332,4 +870,1002 @@ func TestGridlockResolutionFlow(t *testing.T) {
 			base64.StdEncoding.EncodeToString(request),
 		})
 	testutil.CheckPostGLRAccountBalance(checker, postAccount1, postAccount2, postAccount3)
+
+	//a netted resolution can not be settled again, the balances and the sums of the queues are kept
+	config := &pb.GLRConfiguration{}
+	if err := proto.Unmarshal(stub.State[common.ConfigTable+fmt.Sprint(glrId)], config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Status != pb.GLRStatusType_NETTED {
+		t.Fatalf("The resolution is %s after the settlement, expected NETTED", config.Status)
+	}
+	outSum := stub.State[common.OutSumTable+"1"]
+	checker.InvokeFail("tx3", "NetGLSettlement",
+		[]string{
+			base64.StdEncoding.EncodeToString(request),
+		})
+	testutil.CheckPostGLRAccountBalance(checker, postAccount1, postAccount2, postAccount3)
+	if !bytes.Equal(stub.State[common.OutSumTable+"1"], outSum) {
+		t.Fatal("The replayed settlement changed the sum of the queue")
+	}
+}
+
+//test that proposals whose OutgoingIds and InfeasibleIds do not partition the outgoing queue
+//of the bank are rejected, even with valid range proofs
+func TestGridlockProposalSets(t *testing.T) {
+	var glrId int32
+	glrId = 1001
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	randomnessInit, randomnessPayment := startGridlockResolution(t, stub, glrId, pb.RangeProofType_UL)
+	propose := func(tx string, bankId int32, list *testutil.IDList, reason string) {
+		sgp, _ := testutil.SampleGridlockProposals(glrId, gridlockBalances, gridlockMessages, randomnessInit, randomnessPayment,
+			map[int32]*testutil.IDList{bankId: list}, pb.RangeProofType_UL)
+		request, _ := proto.Marshal(sgp[bankId])
+		if reason == "" {
+			checker.Invoke(tx, "proposeNettableSet", []string{base64.StdEncoding.EncodeToString(request)})
+			return
+		}
+		checker.InvokeFailWithMessage(tx, "proposeNettableSet", []string{base64.StdEncoding.EncodeToString(request)}, reason)
+	}
+
+	//bank 1 hides payment 7, the proofs of the remaining payments are valid
+	propose("tx3", 1, &testutil.IDList{OutgoingIds: []int32{1}, IncomingIds: []int32{8, 10}, InfeasibleIds: []int32{}},
+		"Payment 7 of the outgoing queue of bank 1 is neither outgoing nor infeasible")
+	//bank 3 marks payment 9 of bank 5 infeasible
+	propose("tx4", 3, &testutil.IDList{OutgoingIds: []int32{4}, IncomingIds: []int32{2, 3, 7}, InfeasibleIds: []int32{5, 9}},
+		"The infeasible payment 9 is not in the outgoing queue of bank 3")
+	//bank 1 pays payment 8 of bank 5
+	propose("tx5", 1, &testutil.IDList{OutgoingIds: []int32{1, 7, 8}, IncomingIds: []int32{10}, InfeasibleIds: []int32{}},
+		"The outgoing payment 8 is not in the outgoing queue of bank 1")
+	//payment 5 is both outgoing and infeasible, or infeasible twice
+	propose("tx6", 3, &testutil.IDList{OutgoingIds: []int32{4, 5}, IncomingIds: []int32{2, 3, 7}, InfeasibleIds: []int32{5}},
+		"Payment 5 is proposed more than once")
+	propose("tx7", 3, &testutil.IDList{OutgoingIds: []int32{4}, IncomingIds: []int32{2, 3, 7}, InfeasibleIds: []int32{5, 5}},
+		"Payment 5 is proposed more than once")
+
+	//the honest proposals of the first round
+	propose("tx8", 1, &testutil.IDList{OutgoingIds: []int32{1, 7}, IncomingIds: []int32{8, 10}, InfeasibleIds: []int32{}}, "")
+	propose("tx8", 2, &testutil.IDList{OutgoingIds: []int32{2, 3}, IncomingIds: []int32{1, 9}, InfeasibleIds: []int32{}}, "")
+	propose("tx8", 3, &testutil.IDList{OutgoingIds: []int32{4}, IncomingIds: []int32{2, 3, 7}, InfeasibleIds: []int32{5}}, "")
+	propose("tx8", 4, &testutil.IDList{OutgoingIds: []int32{6, 10}, IncomingIds: []int32{4}, InfeasibleIds: []int32{}}, "")
+	propose("tx8", 5, &testutil.IDList{OutgoingIds: []int32{8}, IncomingIds: []int32{5, 6}, InfeasibleIds: []int32{9}}, "")
+	tally, _ := proto.Marshal(&pb.TallyGridlockProposal{GridlockId: glrId})
+	checker.Invoke("tx9", "tallyGridlockProposal", []string{base64.StdEncoding.EncodeToString(tally)})
+
+	//payment 9 is globally infeasible after the tally
+	propose("tx10", 5, &testutil.IDList{OutgoingIds: []int32{8, 9}, IncomingIds: []int32{6}, InfeasibleIds: []int32{}},
+		"Payment 9 is globally infeasible and can not be outgoing")
+	propose("tx11", 5, &testutil.IDList{OutgoingIds: []int32{8}, IncomingIds: []int32{6}, InfeasibleIds: []int32{9}}, "")
+}
+
+//test that a bank can not shrink its share of the global infeasible set in a later round
+func TestGridlockProposalMonotonicInfeasible(t *testing.T) {
+	var glrId int32
+	glrId = 1001
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	randomnessInit, randomnessPayment := startGridlockResolution(t, stub, glrId, pb.RangeProofType_UL)
+	propose := func(tx string, list map[int32]*testutil.IDList) {
+		sgp, _ := testutil.SampleGridlockProposals(glrId, gridlockBalances, gridlockMessages, randomnessInit, randomnessPayment, list, pb.RangeProofType_UL)
+		for k := range list {
+			request, _ := proto.Marshal(sgp[k])
+			checker.Invoke(tx, "proposeNettableSet", []string{base64.StdEncoding.EncodeToString(request)})
+		}
+	}
+	propose("tx3", map[int32]*testutil.IDList{
+		1: &testutil.IDList{OutgoingIds: []int32{1, 7}, IncomingIds: []int32{8, 10}, InfeasibleIds: []int32{}},
+		2: &testutil.IDList{OutgoingIds: []int32{2, 3}, IncomingIds: []int32{1, 9}, InfeasibleIds: []int32{}},
+		3: &testutil.IDList{OutgoingIds: []int32{4}, IncomingIds: []int32{2, 3, 7}, InfeasibleIds: []int32{5}},
+		4: &testutil.IDList{OutgoingIds: []int32{6, 10}, IncomingIds: []int32{4}, InfeasibleIds: []int32{}},
+		5: &testutil.IDList{OutgoingIds: []int32{8}, IncomingIds: []int32{5, 6}, InfeasibleIds: []int32{9}},
+	})
+	tally, _ := proto.Marshal(&pb.TallyGridlockProposal{GridlockId: glrId})
+	checker.Invoke("tx4", "tallyGridlockProposal", []string{base64.StdEncoding.EncodeToString(tally)})
+
+	//bank 3 drops payment 5 from its infeasible set, with valid proofs as it has no payment left to prove infeasible
+	sgp, _ := testutil.SampleGridlockProposals(glrId, gridlockBalances, gridlockMessages, randomnessInit, randomnessPayment,
+		map[int32]*testutil.IDList{3: &testutil.IDList{OutgoingIds: []int32{4}, IncomingIds: []int32{2, 3, 7}, InfeasibleIds: []int32{}}},
+		pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sgp[3])
+	checker.InvokeFailWithMessage("tx5", "proposeNettableSet", []string{base64.StdEncoding.EncodeToString(request)},
+		"Payment 5 is globally infeasible and must stay in the infeasible set of bank 3")
+
+	//the tally keeps the previous infeasible set even when the proposals of the round miss it
+	propose("tx6", map[int32]*testutil.IDList{
+		2: &testutil.IDList{OutgoingIds: []int32{2}, IncomingIds: []int32{1}, InfeasibleIds: []int32{3}},
+	})
+	checker.Invoke("tx7", "tallyGridlockProposal", []string{base64.StdEncoding.EncodeToString(tally)})
+	infeasible, _ := proto.Marshal(&pb.StoredPaymentQueue{PaymentIds: []int32{5, 9, 3}})
+	checker.State(infeasible, common.InfeasibleTable+fmt.Sprint(glrId))
+}
+
+//test that the gridlock resolution aborts when it does not converge within the maximum number of rounds,
+//here because a payment is added after the resolution started
+func TestGridlockResolutionAbort(t *testing.T) {
+	var glrId int32
+	glrId = 2001
+	balances := map[int32]*big.Int{1: big.NewInt(1), 2: big.NewInt(1)}
+	first := map[int32]*testutil.GLMessage{1: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: big.NewInt(5)}}
+	second := map[int32]*testutil.GLMessage{2: &testutil.GLMessage{SenderId: 2, ReceiverId: 1, Amount: big.NewInt(5)}}
+	messages := map[int32]*testutil.GLMessage{1: first[1], 2: second[2]}
+
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+	sma, randomnessInit := testutil.SampleMintAccount(balances, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	randomnessPayment, err := testutil.AddGridlockMessages(checker, first, pb.RangeProofType_UL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, _ = proto.Marshal(testutil.SampleGLRConfiguration(glrId, []int32{1, 2}))
+	checker.Invoke("tx3", "startGLResolution", []string{base64.StdEncoding.EncodeToString(request)})
+	config, _ := proto.Marshal(&pb.GLRConfiguration{
+		GridlockId: glrId, BankIds: []int32{1, 2}, Status: pb.GLRStatusType_START, Round: 0, MaxRounds: 2,
+	})
+	checker.State(config, common.ConfigTable+fmt.Sprint(glrId))
+
+	propose := func(tx string, list map[int32]*testutil.IDList) {
+		sgp, _ := testutil.SampleGridlockProposals(glrId, balances, messages, randomnessInit, randomnessPayment, list, pb.RangeProofType_UL)
+		for k := range list {
+			request, _ := proto.Marshal(sgp[k])
+			checker.Invoke(tx, "proposeNettableSet", []string{base64.StdEncoding.EncodeToString(request)})
+		}
+	}
+	tally, _ := proto.Marshal(&pb.TallyGridlockProposal{GridlockId: glrId})
+	propose("tx4", map[int32]*testutil.IDList{
+		1: &testutil.IDList{OutgoingIds: []int32{}, IncomingIds: []int32{}, InfeasibleIds: []int32{1}},
+		2: &testutil.IDList{OutgoingIds: []int32{}, IncomingIds: []int32{1}, InfeasibleIds: []int32{}},
+	})
+	checker.Invoke("tx5", "tallyGridlockProposal", []string{base64.StdEncoding.EncodeToString(tally)})
+
+	//a payment added during the resolution makes the infeasible set grow once more than expected
+	more, err := testutil.AddGridlockMessages(checker, second, pb.RangeProofType_UL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range more {
+		for id, r := range v {
+			randomnessPayment[k][id] = r
+		}
+	}
+	propose("tx6", map[int32]*testutil.IDList{
+		2: &testutil.IDList{OutgoingIds: []int32{}, IncomingIds: []int32{}, InfeasibleIds: []int32{2}},
+	})
+	checker.Invoke("tx7", "tallyGridlockProposal", []string{base64.StdEncoding.EncodeToString(tally)})
+	config, _ = proto.Marshal(&pb.GLRConfiguration{
+		GridlockId: glrId, BankIds: []int32{1, 2}, Status: pb.GLRStatusType_ABORTED, Round: 2, MaxRounds: 2,
+	})
+	checker.State(config, common.ConfigTable+fmt.Sprint(glrId))
+
+	//an aborted resolution accepts neither proposals nor tallies and can not be settled
+	checker.InvokeFail("tx8", "tallyGridlockProposal", []string{base64.StdEncoding.EncodeToString(tally)})
+	net, _ := proto.Marshal(&pb.NetGridlockProposal{GridlockId: glrId})
+	checker.InvokeFail("tx9", "NetGLSettlement", []string{base64.StdEncoding.EncodeToString(net)})
+
+	//nor be restarted under the same id
+	request, _ = proto.Marshal(testutil.SampleGLRConfiguration(glrId, []int32{1, 2}))
+	checker.InvokeFailWithMessage("tx10", "startGLResolution", []string{base64.StdEncoding.EncodeToString(request)},
+		fmt.Sprintf("Gridlock resolution %d already exists", glrId))
+	checker.State(config, common.ConfigTable+fmt.Sprint(glrId))
+}
+
+//test migrateCurve moves the params and the accounts from BN256 to BLS12-381, after which payments settle on BLS12-381
+func TestMigrateCurve(t *testing.T) {
+	stub, cc := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams",
+		[]string{
+			base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+			base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()),
+		})
+	balances := map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}
+	sma, randomness := testutil.SampleMintAccount(balances, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//a pending payment blocks the migration
+	spm, randomnessPayment := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	migration, _, restore := testutil.SampleCurveMigration(pairing.BLS12381, balances, randomness)
+	restore()
+	request, _ = proto.Marshal(migration)
+	checker.InvokeFail("tx4", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	sss := testutil.SampleGrossSettlementSet(1, spm, sma.Accounts[0].CmBalance, big.NewInt(90),
+		new(big.Int).Sub(randomness[1], randomnessPayment[1]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.Invoke("tx5", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+	balances = map[int32]*big.Int{1: big.NewInt(90), 2: big.NewInt(110)}
+	randomness = map[int32]*big.Int{
+		1: new(big.Int).Sub(randomness[1], randomnessPayment[1]),
+		2: new(big.Int).Add(randomness[2], randomnessPayment[2]),
+	}
+	scl, _ := testutil.SampleCreditLimit(1, big.NewInt(50), big.NewInt(200), new(big.Int).Add(randomness[1], randomness[2]))
+	request, _ = proto.Marshal(scl)
+	checker.Invoke("tx5", "setCreditLimit", []string{base64.StdEncoding.EncodeToString(request)})
+	srb, _ := testutil.SampleReservedBalance(2, big.NewInt(10))
+	request, _ = proto.Marshal(srb)
+	checker.Invoke("tx5", "setReserve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	migration, newRandomness, restore := testutil.SampleCurveMigration(pairing.BLS12381, balances, randomness)
+	defer restore()
+	//every account must be migrated
+	partial := &pb.CurveMigration{ParamsUL: migration.ParamsUL, Accounts: migration.Accounts[:1]}
+	request, _ = proto.Marshal(partial)
+	checker.InvokeFail("tx6", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+	//the new commitments must commit to the stored balances
+	swapped := &pb.CurveMigration{ParamsUL: migration.ParamsUL, Accounts: []*pb.AccountMigration{
+		{BankId: 1, CmBalance: migration.Accounts[1].CmBalance, Zkrp: migration.Accounts[1].Zkrp, EqualityProof: migration.Accounts[0].EqualityProof},
+		migration.Accounts[1],
+	}}
+	request, _ = proto.Marshal(swapped)
+	checker.InvokeFail("tx7", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//the credit limits and the reserves block the migration until the central bank removes them
+	request, _ = proto.Marshal(migration)
+	checker.InvokeFailWithMessage("tx8", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)},
+		"The credit limits must be removed to migrate")
+	request, _ = proto.Marshal(&pb.CreditLimit{BankId: 1})
+	checker.Invoke("tx8", "setCreditLimit", []string{base64.StdEncoding.EncodeToString(request)})
+	request, _ = proto.Marshal(migration)
+	checker.InvokeFailWithMessage("tx8", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)},
+		"The reserves must be removed to migrate")
+	request, _ = proto.Marshal(&pb.ReservedBalance{BankId: 2})
+	checker.Invoke("tx8", "setReserve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//only the central bank migrates
+	request, _ = proto.Marshal(migration)
+	cc.Creator = []byte("bank 1")
+	checker.InvokeFailWithMessage("tx8", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)}, "Only the central bank")
+	cc.Creator = testutil.CentralBank
+	checker.Invoke("tx8", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(migration.ParamsUL, common.PedersenTable+"_CURVE")
+	for _, account := range migration.Accounts {
+		checker.State(testutil.GetStoredBankAccount(&pb.BankAccount{CmBalance: account.CmBalance}), common.AccountTable+fmt.Sprint(account.BankId))
+	}
+	if stub.State[common.PedersenTable+"_BULLETPROOF"] != nil {
+		t.Error("bulletproof params should be removed when leaving BN256")
+	}
+	if stub.State[common.OutSumTable+"1"] != nil || stub.State[common.InSumTable+"2"] != nil {
+		t.Error("the sums of the queues on the old curve should be removed")
+	}
+	//the params are already on BLS12-381
+	checker.InvokeFail("tx9", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//payments settle on the new curve
+	spm, randomnessPayment = testutil.SamplePaymentMessage(2, 2, 1, big.NewInt(30), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx10", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	sss = testutil.SampleGrossSettlementSet(2, spm, migration.Accounts[1].CmBalance, big.NewInt(80),
+		new(big.Int).Sub(newRandomness[2], randomnessPayment[2]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.Invoke("tx11", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredBankAccountFromValue(big.NewInt(120), new(big.Int).Add(newRandomness[1], randomnessPayment[1])),
+		common.AccountTable+"1")
+}
+
+//test that initParams rejects pedersengroup params, which no range proof scheme supports, and that
+//the commitments of a ledger that stored them are rejected
+func TestInitParamsPedersenGroup(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	group, err := testutil.SamplePedersenGroup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupBytes, _ := proto.Marshal(group)
+	pUL := base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())
+	checker.Invoke("tx1", "initParams", []string{pUL})
+
+	//the group params are rejected, with or without the curve params
+	checker.InvokeFailWithMessage("tx2", "initParams",
+		[]string{pUL, "", "", base64.StdEncoding.EncodeToString(groupBytes)}, "pedersengroup params are not supported")
+	checker.InvokeFailWithMessage("tx3", "initParams",
+		[]string{"", "", "", base64.StdEncoding.EncodeToString(groupBytes)}, "pedersengroup params are not supported")
+	checker.State(testutil.SampleParamsUL(), common.PedersenTable+"_CURVE")
+
+	//a ledger that stored them rejects its commitments
+	stub.State[common.PedersenTable+"_GROUP"] = groupBytes
+	delete(stub.State, common.PedersenTable+"_CURVE")
+
+	scheme, err := common.GetPedersenGroupFromLedger(fabric.New(stub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sma := &pb.MintAccount{Accounts: []*pb.BankAccount{{
+		BankId:    1,
+		CmBalance: scheme.Commit(big.NewInt(100), big.NewInt(7)).Marshal(),
+		Zkrp:      []byte{1},
+	}}}
+	request, _ := proto.Marshal(sma)
+	checker.InvokeFailWithCode("tx5", "mintAccount",
+		[]string{base64.StdEncoding.EncodeToString(request)}, "ZKRP_CURVE_MISMATCH")
+
+	//curve params replace the group params again
+	checker.Invoke("tx6", "initParams", []string{pUL})
+	if stub.State[common.PedersenTable+"_GROUP"] != nil {
+		t.Error("pedersengroup params should be deleted")
+	}
+}
+
+//test that the queues are ordered by priority and then by paymentId, and that payments settle in this order
+func TestPaymentPriority(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	payments := map[int32]*pb.PaymentMessage{}
+	randomnessPayment := map[int32]map[int32]*big.Int{}
+	for _, p := range []struct{ id, priority int32 }{{1, 5}, {2, 0}, {3, 5}, {4, -1}} {
+		spm, randomness := testutil.SamplePaymentMessage(p.id, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+		spm.Priority = p.priority
+		request, _ = proto.Marshal(spm)
+		checker.Invoke("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+		checker.State(testutil.GetStoredPaymentMessage(spm), common.MessageTable+fmt.Sprint(p.id))
+		payments[p.id] = spm
+		randomnessPayment[p.id] = randomness
+	}
+	checker.Queue([]int32{4, 2, 1, 3}, common.OutQueueTable, 1)
+	checker.Queue([]int32{4, 2, 1, 3}, common.InQueueTable, 2)
+
+	//the payment with the smallest id is not the head of the queue
+	settle := func(paymentId int32) []string {
+		sss := testutil.SampleGrossSettlementSet(1, payments[paymentId], sma.Accounts[0].CmBalance, big.NewInt(90),
+			new(big.Int).Sub(randomnessInit[1], randomnessPayment[paymentId][1]), pb.RangeProofType_UL)
+		request, _ := proto.Marshal(sss)
+		return []string{base64.StdEncoding.EncodeToString(request)}
+	}
+	checker.InvokeFail("tx4", "grossSettlement", settle(1))
+	checker.Invoke("tx5", "grossSettlement", settle(4))
+	checker.Queue([]int32{2, 1, 3}, common.OutQueueTable, 1)
+	checker.Queue([]int32{2, 1, 3}, common.InQueueTable, 2)
+
+	//the sums of the queues follow the payments
+	r := new(big.Int)
+	for _, id := range []int32{1, 2, 3} {
+		r.Add(r, randomnessPayment[id][1])
+	}
+	sum := testutil.GetStoredPaymentSumFromValue(big.NewInt(30), r, 3)
+	checker.State(sum, common.OutSumTable+"1")
+	checker.State(sum, common.InSumTable+"2")
+}
+
+//test that migrateQueues moves the queues stored as one StoredPaymentQueue per bank to one entry per payment,
+//and that the queues can not be read before
+func TestMigrateQueues(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	spms := []*pb.PaymentMessage{}
+	randomnessPayment := []map[int32]*big.Int{}
+	for _, id := range []int32{1, 2} {
+		spm, randomness := testutil.SamplePaymentMessage(id, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+		request, _ = proto.Marshal(spm)
+		checker.Invoke("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+		spms = append(spms, spm)
+		randomnessPayment = append(randomnessPayment, randomness)
+	}
+
+	//replace the entries by the queues of the previous format
+	for key := range stub.State {
+		if strings.HasPrefix(key, common.OutQueueTable) || strings.HasPrefix(key, common.InQueueTable) ||
+			strings.HasPrefix(key, common.OutSumTable) || strings.HasPrefix(key, common.InSumTable) {
+			delete(stub.State, key)
+		}
+	}
+	legacy, _ := proto.Marshal(&pb.StoredPaymentQueue{PaymentIds: []int32{1, 2}})
+	stub.State[common.OutQueueTable+"1"] = legacy
+	stub.State[common.InQueueTable+"2"] = legacy
+
+	sss := testutil.SampleGrossSettlementSet(1, spms[0], sma.Accounts[0].CmBalance, big.NewInt(90),
+		new(big.Int).Sub(randomnessInit[1], randomnessPayment[0][1]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.InvokeFailWithMessage("tx4", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)}, "invoke migrateQueues first")
+
+	checker.InvokeFail("tx5", "migrateQueues", []string{"1"})
+	checker.Invoke("tx6", "migrateQueues", []string{})
+	for _, key := range []string{common.OutQueueTable + "1", common.InQueueTable + "2"} {
+		if stub.State[key] != nil {
+			t.Errorf("the queue %s of the previous format was not removed", key)
+		}
+	}
+	checker.Queue([]int32{1, 2}, common.OutQueueTable, 1)
+	checker.Queue([]int32{1, 2}, common.InQueueTable, 2)
+	checker.Queue([]int32{}, common.OutQueueTable, 2)
+	sum := testutil.GetStoredPaymentSumFromValue(big.NewInt(20), new(big.Int).Add(randomnessPayment[0][1], randomnessPayment[1][1]), 2)
+	checker.State(sum, common.OutSumTable+"1")
+	checker.State(sum, common.InSumTable+"2")
+
+	checker.Invoke("tx7", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.Queue([]int32{2}, common.OutQueueTable, 1)
+	checker.State(testutil.GetStoredPaymentSumFromValue(big.NewInt(10), randomnessPayment[1][1], 1), common.OutSumTable+"1")
+}
+
+//test that mintAccount proves the total supply is within [0, MaxSupply], counting the accounts already on the ledger
+//and the credit limits, so that no balance can leave the range of the proofs
+func TestMintTotalSupply(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+
+	//every balance is within the bound but their sum is not
+	large := big.NewInt(3000000000)
+	sma, _ := testutil.SampleMintAccount(map[int32]*big.Int{1: large, 2: large}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.InvokeFail("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	balances := map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}
+	sma, randomness := testutil.SampleMintAccount(balances, pb.RangeProofType_UL)
+	supply := sma.ZkrpSupply
+	sma.ZkrpSupply = nil
+	request, _ = proto.Marshal(sma)
+	checker.InvokeFail("tx3", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	sma.Accounts = append(sma.Accounts, sma.Accounts[0])
+	sma.ZkrpSupply = supply
+	request, _ = proto.Marshal(sma)
+	checker.InvokeFail("tx4", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	sma.Accounts = sma.Accounts[:2]
+	request, _ = proto.Marshal(sma)
+	checker.Invoke("tx5", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//the supply of a later mint includes the accounts on the ledger
+	sma, randomness3 := testutil.SampleMintAccount(map[int32]*big.Int{3: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sma)
+	checker.InvokeFail("tx6", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	balances[3] = big.NewInt(100)
+	randomness[3] = randomness3[3]
+	testutil.AddSupplyProof(sma, balances, randomness)
+	request, _ = proto.Marshal(sma)
+	checker.Invoke("tx7", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredBankAccount(sma.Accounts[0]), common.AccountTable+"3")
+
+	//the credit limits are part of the supply
+	rSupply := new(big.Int)
+	for _, r := range randomness {
+		rSupply.Add(rSupply, r)
+	}
+	scl, rLimit := testutil.SampleCreditLimit(1, big.NewInt(100), big.NewInt(300), rSupply)
+	request, _ = proto.Marshal(scl)
+	checker.Invoke("tx8", "setCreditLimit", []string{base64.StdEncoding.EncodeToString(request)})
+	sma, randomness3 = testutil.SampleMintAccount(map[int32]*big.Int{3: big.NewInt(200)}, pb.RangeProofType_UL)
+	balances[3] = big.NewInt(200)
+	rSupply.Sub(rSupply, randomness[3]).Add(rSupply, randomness3[3])
+	randomness[3] = randomness3[3]
+	testutil.AddSupplyProof(sma, balances, randomness)
+	request, _ = proto.Marshal(sma)
+	checker.InvokeFail("tx9", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	sma.ZkrpSupply = testutil.SampleSupplyProof(big.NewInt(500), new(big.Int).Add(rSupply, rLimit))
+	request, _ = proto.Marshal(sma)
+	checker.Invoke("tx10", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+}
+
+//test that the payments and the outgoing queues are bounded, so that the sums of the queues stay within [0,u^l)
+func TestQueueBounds(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+
+	//u^l = 10^3 can not hold the queues of all the banks
+	checker.InvokeFailWithMessage("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsULWithRange(10, 3))},
+		"u^l of the UL params must be at least")
+	checker.Invoke("tx1", "initParams", []string{
+		base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+		base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()),
+	})
+	sma, _ := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//the limits and the minimums are at most the default limit
+	maxAmount := testutil.MaxPaymentAmount()
+	request, _ = proto.Marshal(testutil.SamplePaymentLimit(3, maxAmount+1))
+	checker.InvokeFailWithMessage("tx3", "setPaymentLimit", []string{base64.StdEncoding.EncodeToString(request)}, "The payment limit must be at most")
+	request, _ = proto.Marshal(testutil.SamplePaymentMinimum(3, maxAmount+1))
+	checker.InvokeFailWithMessage("tx3", "setPaymentMinimum", []string{base64.StdEncoding.EncodeToString(request)}, "The payment minimum must be at most")
+	request, _ = proto.Marshal(testutil.SamplePaymentLimit(3, maxAmount))
+	checker.Invoke("tx4", "setPaymentLimit", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//a payment above the default limit has no proof of it
+	amount := big.NewInt(maxAmount + 1)
+	spm, randomness := testutil.SamplePaymentMessage(1, 1, 2, amount, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFail("tx5", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	testutil.AddPaymentLimitProof(spm, amount, randomness[1], 2*maxAmount)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFail("tx5", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//the outgoing queue of bank 1 is full, the incoming queue of bank 2 is bounded by the outgoing queues
+	stub.State[common.OutSumTable+"1"] = testutil.GetStoredPaymentSumFromValue(big.NewInt(0), big.NewInt(0), common.MaxQueuedPayments)
+	spm, randomness = testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFailWithMessage("tx6", "addMessage", []string{base64.StdEncoding.EncodeToString(request)}, "can not hold more than")
+	//Fabric keeps the writes of a failed invoke, the payment is rejected before any is made
+	if stub.State[common.MessageTable+"1"] != nil {
+		t.Fatal("The rejected payment 1 is on the ledger")
+	}
+	stub.State[common.OutSumTable+"1"] = testutil.GetStoredPaymentSumFromValue(big.NewInt(0), big.NewInt(0), common.MaxQueuedPayments-1)
+	checker.Invoke("tx7", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredPaymentSumFromValue(big.NewInt(10), randomness[1], common.MaxQueuedPayments), common.OutSumTable+"1")
+	checker.State(testutil.GetStoredPaymentSumFromValue(big.NewInt(10), randomness[1], 1), common.InSumTable+"2")
+
+	//a batch is rejected as a whole when it does not fit
+	stub.State[common.OutSumTable+"1"] = testutil.GetStoredPaymentSumFromValue(big.NewInt(0), big.NewInt(0), common.MaxQueuedPayments-1)
+	batch, _ := testutil.SamplePaymentMessageBatch(map[int32]*testutil.GLMessage{
+		2: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: big.NewInt(1)},
+		3: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: big.NewInt(2)},
+	})
+	request, _ = proto.Marshal(batch)
+	checker.InvokeFailWithMessage("tx8", "addMessages", []string{base64.StdEncoding.EncodeToString(request)}, "can not hold more than")
+	for _, id := range []string{"2", "3"} {
+		if stub.State[common.MessageTable+id] != nil {
+			t.Fatalf("The rejected payment %s is on the ledger", id)
+		}
+	}
+}
+
+//test that payments prove their amount is at least 1, or the minimum of the sender when it has one
+func TestPaymentMinimum(t *testing.T) {
+	stub, cc := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+	sma, _ := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//a proof that the amount 0 is within range is not enough
+	spm, _ := testutil.SamplePaymentMessageWithMinimum(1, 1, 2, big.NewInt(0), 0, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFail("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	spm, _ = testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(1), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx4", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+
+	for _, minimum := range []*pb.PaymentMinimum{
+		testutil.SamplePaymentMinimum(1, 0),
+		testutil.SamplePaymentMinimum(1, 10000000000),
+		testutil.SamplePaymentMinimum(common.NumOfBanks+1, 5),
+	} {
+		request, _ = proto.Marshal(minimum)
+		checker.InvokeFail("tx5", "setPaymentMinimum", []string{base64.StdEncoding.EncodeToString(request)})
+	}
+	//the minimum and the payment limit of a bank leave some amount to pay
+	request, _ = proto.Marshal(testutil.SamplePaymentLimit(3, 50))
+	checker.Invoke("tx5", "setPaymentLimit", []string{base64.StdEncoding.EncodeToString(request)})
+	request, _ = proto.Marshal(testutil.SamplePaymentMinimum(3, 51))
+	checker.InvokeFailWithMessage("tx5", "setPaymentMinimum", []string{base64.StdEncoding.EncodeToString(request)},
+		"The payment minimum must not be above the payment limit")
+	request, _ = proto.Marshal(testutil.SamplePaymentMinimum(3, 50))
+	checker.Invoke("tx5", "setPaymentMinimum", []string{base64.StdEncoding.EncodeToString(request)})
+	request, _ = proto.Marshal(testutil.SamplePaymentLimit(3, 49))
+	checker.InvokeFailWithMessage("tx5", "setPaymentLimit", []string{base64.StdEncoding.EncodeToString(request)},
+		"The payment limit must not be below the payment minimum")
+
+	//only the central bank sets the minimums
+	request, _ = proto.Marshal(testutil.SamplePaymentMinimum(1, 5))
+	cc.Creator = []byte("bank 1")
+	checker.InvokeFailWithMessage("tx6", "setPaymentMinimum", []string{base64.StdEncoding.EncodeToString(request)}, "Only the central bank")
+	cc.Creator = testutil.CentralBank
+	checker.Invoke("tx6", "setPaymentMinimum", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(request, common.MinimumTable+"1")
+
+	//the payments of bank 1 must now be at least 5
+	spm, _ = testutil.SamplePaymentMessage(2, 1, 2, big.NewInt(4), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFail("tx7", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	spm, _ = testutil.SamplePaymentMessageWithMinimum(2, 1, 2, big.NewInt(5), 5, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx8", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	//the other banks keep the default minimum
+	spm, _ = testutil.SamplePaymentMessage(3, 2, 1, big.NewInt(1), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx9", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+}
+
+//test that the accounts, queues and settlements of the currencies are kept apart
+func TestMultiCurrency(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{
+		base64.StdEncoding.EncodeToString(testutil.SampleParamsUL()),
+		base64.StdEncoding.EncodeToString(testutil.SampleBulletproofParams()),
+	})
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//no payment in a currency without accounts
+	spm, _ := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	spm.Currency = "EUR"
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFailWithMessage("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)}, "No accounts in currency EUR")
+
+	//the accounts in EUR are committed with the generator of EUR
+	restore := testutil.UseCurrency("EUR")
+	smaEUR, randomnessEUR := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(10), 2: big.NewInt(0)}, pb.RangeProofType_UL)
+	restore()
+	for _, currency := range []string{"eur", "EUR1", "ABCDEFGHIJKLM"} {
+		smaEUR.Currency = currency
+		request, _ = proto.Marshal(smaEUR)
+		checker.InvokeFail("tx4", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	}
+	smaEUR.Currency = "EUR"
+	request, _ = proto.Marshal(smaEUR)
+	checker.Invoke("tx5", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredBankAccount(smaEUR.Accounts[0]), common.InCurrency(common.AccountTable, "EUR")+"1")
+	checker.State(testutil.GetStoredBankAccount(sma.Accounts[0]), common.AccountTable+"1")
+
+	//a payment in EUR committed with the default generator is rejected
+	spm, _ = testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	spm.Currency = "EUR"
+	request, _ = proto.Marshal(spm)
+	checker.InvokeFail("tx6", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+
+	//the payments are queued in their currency only
+	restore = testutil.UseCurrency("EUR")
+	spmEUR, randomnessPaymentEUR := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	restore()
+	spmEUR.Currency = "EUR"
+	request, _ = proto.Marshal(spmEUR)
+	checker.Invoke("tx6", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	spm, _ = testutil.SamplePaymentMessage(2, 1, 2, big.NewInt(50), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx7", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredPaymentMessage(spmEUR), common.MessageTable+"1")
+	checker.Queue([]int32{1}, common.InCurrency(common.OutQueueTable, "EUR"), 1)
+	checker.Queue([]int32{1}, common.InCurrency(common.InQueueTable, "EUR"), 2)
+	checker.Queue([]int32{2}, common.OutQueueTable, 1)
+	checker.Queue([]int32{2}, common.InQueueTable, 2)
+
+	//the payments of a batch are in one currency, the aggregated proof is on its generator
+	restore = testutil.UseCurrency("EUR")
+	batch, _ := testutil.SamplePaymentMessageBatch(map[int32]*testutil.GLMessage{
+		3: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: big.NewInt(1)},
+		4: &testutil.GLMessage{SenderId: 1, ReceiverId: 2, Amount: big.NewInt(2)},
+	})
+	restore()
+	batch.Payments[0].Currency = "EUR"
+	request, _ = proto.Marshal(batch)
+	checker.InvokeFail("tx7", "addMessages", []string{base64.StdEncoding.EncodeToString(request)})
+	batch.Payments[1].Currency = "EUR"
+	request, _ = proto.Marshal(batch)
+	checker.Invoke("tx7", "addMessages", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.Queue([]int32{1, 3, 4}, common.InCurrency(common.OutQueueTable, "EUR"), 1)
+
+	//the payment in EUR can not be settled against the balance of the default currency
+	sss := testutil.SampleGrossSettlementSet(1, spmEUR, sma.Accounts[0].CmBalance,
+		big.NewInt(90), new(big.Int).Sub(randomnessInit[1], randomnessPaymentEUR[1]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.InvokeFail("tx8", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+	restore = testutil.UseCurrency("EUR")
+	sss = testutil.SampleGrossSettlementSet(1, spmEUR, smaEUR.Accounts[0].CmBalance,
+		big.NewInt(0), new(big.Int).Sub(randomnessEUR[1], randomnessPaymentEUR[1]), pb.RangeProofType_UL)
+	restore()
+	request, _ = proto.Marshal(sss)
+	checker.Invoke("tx9", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+	restore = testutil.UseCurrency("EUR")
+	checker.State(testutil.GetStoredBankAccountFromValue(big.NewInt(0), new(big.Int).Sub(randomnessEUR[1], randomnessPaymentEUR[1])),
+		common.InCurrency(common.AccountTable, "EUR")+"1")
+	checker.State(testutil.GetStoredBankAccountFromValue(big.NewInt(10), new(big.Int).Add(randomnessEUR[2], randomnessPaymentEUR[2])),
+		common.InCurrency(common.AccountTable, "EUR")+"2")
+	restore()
+	checker.State(testutil.GetStoredBankAccount(sma.Accounts[0]), common.AccountTable+"1")
+	checker.Queue([]int32{3, 4}, common.InCurrency(common.OutQueueTable, "EUR"), 1)
+	checker.Queue([]int32{2}, common.OutQueueTable, 1)
+
+	//the gridlock resolution runs in a currency that has accounts
+	config := testutil.SampleGLRConfiguration(1, []int32{1, 2})
+	config.Currency = "USD"
+	request, _ = proto.Marshal(config)
+	checker.InvokeFailWithMessage("tx10", "startGLResolution", []string{base64.StdEncoding.EncodeToString(request)}, "No accounts in currency USD")
+	config.Currency = "EUR"
+	request, _ = proto.Marshal(config)
+	checker.Invoke("tx11", "startGLResolution", []string{base64.StdEncoding.EncodeToString(request)})
+}
+
+//test that migrateCurve moves the accounts of every currency
+func TestMigrateCurveCurrencies(t *testing.T) {
+	stub, _ := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+	sma, randomness := testutil.SampleMintAccount(map[int32]*big.Int{1: big.NewInt(100)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	restore := testutil.UseCurrency("EUR")
+	smaEUR, randomnessEUR := testutil.SampleMintAccount(map[int32]*big.Int{2: big.NewInt(10)}, pb.RangeProofType_UL)
+	restore()
+	smaEUR.Currency = "EUR"
+	request, _ = proto.Marshal(smaEUR)
+	checker.Invoke("tx3", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	randomness[2] = randomnessEUR[2]
+	migration, _, restore := testutil.SampleCurveMigrationInCurrencies(pairing.BLS12381,
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(10)}, randomness, map[int32]string{2: "EUR"})
+	defer restore()
+	//the account of bank 2 is in EUR
+	migration.Accounts[1].Currency = common.DefaultCurrency
+	request, _ = proto.Marshal(migration)
+	checker.InvokeFailWithMessage("tx4", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)}, "Bank 2 has no account to migrate")
+	migration.Accounts[1].Currency = "USD"
+	request, _ = proto.Marshal(migration)
+	checker.InvokeFail("tx5", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+	migration.Accounts[1].Currency = "EUR"
+	request, _ = proto.Marshal(migration)
+	checker.Invoke("tx6", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(testutil.GetStoredBankAccount(&pb.BankAccount{CmBalance: migration.Accounts[0].CmBalance}), common.AccountTable+"1")
+	checker.State(testutil.GetStoredBankAccount(&pb.BankAccount{CmBalance: migration.Accounts[1].CmBalance}),
+		common.InCurrency(common.AccountTable, "EUR")+"2")
+}
+
+//test that the commitments of the default currency are not settled under the legacy h of the params, until migrateCurve
+//replaces it on the same curve
+func TestLegacyH(t *testing.T) {
+	restoreLegacy := testutil.UseLegacyH()
+	defer restoreLegacy()
+	legacy := testutil.SampleParamsUL()
+	balances := map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(100)}
+
+	//a ledger set up before h was hashed
+	setup := func() (*shim.MockStub, *pb.MintAccount, map[int32]*big.Int) {
+		stub, _ := newMockStub(t)
+		checker := testutil.NewChecker(stub, t)
+		checker.InvokeFailWithMessage("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(legacy)},
+			"The h of the UL params must be hashed")
+		stub.State[common.PedersenTable+"_CURVE"] = legacy
+		stub.State[common.PedersenTable+"_BULLETPROOF"] = testutil.SampleBulletproofParams()
+		sma, randomness := testutil.SampleMintAccount(balances, pb.RangeProofType_UL)
+		request, _ := proto.Marshal(sma)
+		checker.Invoke("tx2", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+		return stub, sma, randomness
+	}
+
+	//the payments are queued, but not settled
+	stub, sma, randomness := setup()
+	checker := testutil.NewChecker(stub, t)
+	spm, randomnessPayment := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	request, _ := proto.Marshal(spm)
+	checker.Invoke("tx3", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	sss := testutil.SampleGrossSettlementSet(1, spm, sma.Accounts[0].CmBalance, big.NewInt(90),
+		new(big.Int).Sub(randomness[1], randomnessPayment[1]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.InvokeFailWithMessage("tx4", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)},
+		"The commitments of the default currency are not binding")
+
+	//migrateCurve replaces h on BN256, after which the payments settle
+	stub, _, randomness = setup()
+	checker = testutil.NewChecker(stub, t)
+	migration, newRandomness, restore := testutil.SampleCurveMigration(pairing.BN256, balances, randomness)
+	defer restore()
+	request, _ = proto.Marshal(migration)
+	checker.Invoke("tx3", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.State(migration.ParamsUL, common.PedersenTable+"_CURVE")
+	if stub.State[common.PedersenTable+"_BULLETPROOF"] != nil {
+		t.Error("bulletproof params with the legacy h should be removed")
+	}
+	checker.InvokeFailWithMessage("tx4", "migrateCurve", []string{base64.StdEncoding.EncodeToString(request)}, "already on BN256")
+
+	spm, randomnessPayment = testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(10), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	checker.Invoke("tx5", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+	sss = testutil.SampleGrossSettlementSet(1, spm, migration.Accounts[0].CmBalance, big.NewInt(90),
+		new(big.Int).Sub(newRandomness[1], randomnessPayment[1]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	checker.Invoke("tx6", "grossSettlement", []string{base64.StdEncoding.EncodeToString(request)})
+}
+
+//test that a bank overdraws its account up to the credit limit set by the central bank,
+//and proves it has repaid the overdraft at the end of the day
+func TestCreditLimit(t *testing.T) {
+	store := ledger.NewMemory()
+	now := time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+	gridlock := new(Gridlock)
+	run := func(creator string, f func(ledger.Ledger, []string) error, request []byte) error {
+		store.SetCreator([]byte(creator))
+		tx := ledger.Begin(store)
+		err := f(tx, []string{base64.StdEncoding.EncodeToString(request)})
+		if err != nil {
+			tx.Discard()
+			return err
+		}
+		return tx.Commit()
+	}
+	storedBalance := func(bankId int32) []byte {
+		storedBytes, _ := store.Get(common.AccountTable + fmt.Sprint(bankId))
+		stored := &pb.StoredBankAccount{}
+		proto.Unmarshal(storedBytes, stored)
+		return stored.CmBalance
+	}
+
+	if err := run("", gridlock.initCentralBank, []byte("central bank")); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("bank 1", gridlock.initParams, testutil.SampleParamsUL()); err == nil || !strings.Contains(err.Error(), "Only the central bank") {
+		t.Fatalf("initParams by a bank: %v", err)
+	}
+	if err := run("central bank", gridlock.initParams, testutil.SampleParamsUL()); err != nil {
+		t.Fatal(err)
+	}
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(10), 2: big.NewInt(0)}, pb.RangeProofType_UL)
+	request, _ := proto.Marshal(sma)
+	if err := run("central bank", account.MintAccount, request); err != nil {
+		t.Fatal(err)
+	}
+	spm, randomnessPayment := testutil.SamplePaymentMessage(1, 1, 2, big.NewInt(30), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	if err := run("bank 1", message.AddMessage, request); err != nil {
+		t.Fatal(err)
+	}
+
+	//only the central bank sets the credit limits, which are proven within range
+	rSupply := new(big.Int).Add(randomnessInit[1], randomnessInit[2])
+	scl, randomnessLimit := testutil.SampleCreditLimit(1, big.NewInt(50), big.NewInt(10), rSupply)
+	request, _ = proto.Marshal(scl)
+	if err := run("bank 1", account.SetCreditLimit, request); err == nil || !strings.Contains(err.Error(), "Only the central bank") {
+		t.Fatalf("setCreditLimit by a bank: %v", err)
+	}
+	invalid := proto.Clone(scl).(*pb.CreditLimit)
+	invalid.Zkrp = invalid.Zkrp[:len(invalid.Zkrp)-1]
+	request, _ = proto.Marshal(invalid)
+	if run("central bank", account.SetCreditLimit, request) == nil {
+		t.Fatal("setCreditLimit with an invalid proof succeeded")
+	}
+	//the limits count in the total supply, which stays within [0, MaxSupply]
+	large, _ := testutil.SampleCreditLimit(1, big.NewInt(5000000000), big.NewInt(10), rSupply)
+	request, _ = proto.Marshal(large)
+	if run("central bank", account.SetCreditLimit, request) == nil {
+		t.Fatal("setCreditLimit above the total supply bound succeeded")
+	}
+	request, _ = proto.Marshal(scl)
+	if err := run("central bank", account.SetCreditLimit, request); err != nil {
+		t.Fatal(err)
+	}
+
+	//bank 1 pays 30 out of a balance of 10 with a limit of 50
+	sss := testutil.SampleGrossSettlementSetWithCredit(1, spm, sma.Accounts[0].CmBalance, scl.CmLimit, big.NewInt(30),
+		new(big.Int).Add(new(big.Int).Sub(randomnessInit[1], randomnessPayment[1]), randomnessLimit), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	if err := run("bank 1", settlement.GrossSettlement, request); err != nil {
+		t.Fatal(err)
+	}
+	rBalance1 := new(big.Int).Sub(randomnessInit[1], randomnessPayment[1])
+	if stored, _ := store.Get(common.AccountTable + "1"); !bytes.Equal(stored, testutil.GetStoredBankAccountFromValue(big.NewInt(-20), rBalance1)) {
+		t.Error("the account of bank 1 should be overdrawn by 20")
+	}
+
+	//the overdraft is not repaid yet
+	srp := testutil.SampleRepaymentProof(1, storedBalance(1), big.NewInt(0), rBalance1, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(srp)
+	if run("bank 1", account.ProveRepayment, request) == nil {
+		t.Fatal("proveRepayment of a negative balance succeeded")
+	}
+
+	//bank 2 pays 25 back, without a credit line
+	spm, randomnessPayment2 := testutil.SamplePaymentMessage(2, 2, 1, big.NewInt(25), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(spm)
+	if err := run("bank 2", message.AddMessage, request); err != nil {
+		t.Fatal(err)
+	}
+	rBalance2 := new(big.Int).Add(randomnessInit[2], randomnessPayment[2])
+	sss = testutil.SampleGrossSettlementSet(2, spm, storedBalance(2), big.NewInt(5),
+		new(big.Int).Sub(rBalance2, randomnessPayment2[2]), pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sss)
+	if err := run("bank 2", settlement.GrossSettlement, request); err != nil {
+		t.Fatal(err)
+	}
+
+	rBalance1.Add(rBalance1, randomnessPayment2[1])
+	srp = testutil.SampleRepaymentProof(1, storedBalance(1), big.NewInt(5), rBalance1, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(srp)
+	if err := run("bank 1", account.ProveRepayment, request); err != nil {
+		t.Fatal(err)
+	}
+	storedBytes, _ := store.Get(common.RepaymentTable + "1")
+	stored := &pb.StoredRepayment{}
+	proto.Unmarshal(storedBytes, stored)
+	if stored.Timestamp != now.Unix() || !bytes.Equal(stored.CmBalance, storedBalance(1)) {
+		t.Errorf("unexpected stored repayment %v", stored)
+	}
+}
+
+//test that the reserve of a bank is left to its urgent payments, the other payments prove
+//balance - reserve - amount >= 0
+func TestReservedBalance(t *testing.T) {
+	stub, cc := newMockStub(t)
+	checker := testutil.NewChecker(stub, t)
+	checker.Invoke("tx1", "initParams", []string{base64.StdEncoding.EncodeToString(testutil.SampleParamsUL())})
+
+	//no account to reserve from yet
+	srb, randomnessReserve := testutil.SampleReservedBalance(1, big.NewInt(80))
+	request, _ := proto.Marshal(srb)
+	checker.InvokeFail("tx2", "setReserve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	sma, randomnessInit := testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(0)}, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sma)
+	checker.Invoke("tx3", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+
+	invalid := proto.Clone(srb).(*pb.ReservedBalance)
+	invalid.Zkrp = invalid.Zkrp[:len(invalid.Zkrp)-1]
+	request, _ = proto.Marshal(invalid)
+	checker.InvokeFail("tx4", "setReserve", []string{base64.StdEncoding.EncodeToString(request)})
+	//only the central bank sets the reserves
+	request, _ = proto.Marshal(srb)
+	cc.Creator = []byte("bank 2")
+	checker.InvokeFailWithMessage("tx5", "setReserve", []string{base64.StdEncoding.EncodeToString(request)}, "Only the central bank")
+	cc.Creator = testutil.CentralBank
+	checker.Invoke("tx5", "setReserve", []string{base64.StdEncoding.EncodeToString(request)})
+
+	payments := map[int32]*pb.PaymentMessage{}
+	randomnessPayment := map[int32]map[int32]*big.Int{}
+	for _, p := range []struct{ id, priority int32 }{{1, common.UrgentPriority}, {2, 0}} {
+		spm, randomness := testutil.SamplePaymentMessage(p.id, 1, 2, big.NewInt(30), pb.RangeProofType_UL)
+		spm.Priority = p.priority
+		request, _ = proto.Marshal(spm)
+		checker.Invoke("tx6", "addMessage", []string{base64.StdEncoding.EncodeToString(request)})
+		payments[p.id] = spm
+		randomnessPayment[p.id] = randomness
+	}
+	settle := func(paymentId int32, cmBalance []byte, cmReserve []byte, value *big.Int, randomness *big.Int) []string {
+		sss := testutil.SampleGrossSettlementSetWithReserve(1, payments[paymentId], cmBalance, cmReserve, value, randomness, pb.RangeProofType_UL)
+		request, _ := proto.Marshal(sss)
+		return []string{base64.StdEncoding.EncodeToString(request)}
+	}
+	storedAccount := func() *pb.StoredBankAccount {
+		stored := &pb.StoredBankAccount{}
+		proto.Unmarshal(stub.State[common.AccountTable+"1"], stored)
+		return stored
+	}
+
+	//the urgent payment draws on the reserve: 100 - 30 >= 0
+	rBalance := new(big.Int).Sub(randomnessInit[1], randomnessPayment[1][1])
+	checker.Invoke("tx7", "grossSettlement", settle(1, sma.Accounts[0].CmBalance, nil, big.NewInt(70), rBalance))
+	account := storedAccount()
+	if !bytes.Equal(account.CmReserve, srb.CmReserve) {
+		t.Error("the settlement should keep the reserve of bank 1")
+	}
+	balance := &pb.StoredBankAccount{}
+	proto.Unmarshal(testutil.GetStoredBankAccountFromValue(big.NewInt(70), rBalance), balance)
+	if !bytes.Equal(account.CmBalance, balance.CmBalance) {
+		t.Error("unexpected balance of bank 1")
+	}
+
+	//the normal payment does not: 70 - 80 - 30 < 0, a proof of 70 - 30 ignoring the reserve fails
+	rBalance.Sub(rBalance, randomnessPayment[2][1])
+	checker.InvokeFail("tx8", "grossSettlement", settle(2, account.CmBalance, nil, big.NewInt(40), rBalance))
+
+	//with a reserve of 20 it is settled: 70 - 20 - 30 >= 0
+	srb, randomnessReserve = testutil.SampleReservedBalance(1, big.NewInt(20))
+	request, _ = proto.Marshal(srb)
+	checker.Invoke("tx9", "setReserve", []string{base64.StdEncoding.EncodeToString(request)})
+	checker.Invoke("tx10", "grossSettlement", settle(2, account.CmBalance, srb.CmReserve, big.NewInt(20),
+		new(big.Int).Sub(rBalance, randomnessReserve)))
+	if !bytes.Equal(storedAccount().CmReserve, srb.CmReserve) {
+		t.Error("the settlement should keep the reserve of bank 1")
+	}
+
+	//a new mint keeps the reserve as well
+	sma, _ = testutil.SampleMintAccount(
+		map[int32]*big.Int{1: big.NewInt(100), 2: big.NewInt(0)}, pb.RangeProofType_UL)
+	request, _ = proto.Marshal(sma)
+	checker.Invoke("tx11", "mintAccount", []string{base64.StdEncoding.EncodeToString(request)})
+	if account := storedAccount(); !bytes.Equal(account.CmReserve, srb.CmReserve) || !bytes.Equal(account.CmBalance, sma.Accounts[0].CmBalance) {
+		t.Error("the mint should replace the balance and keep the reserve of bank 1")
+	}
 }
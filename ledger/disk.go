@@ -0,0 +1,168 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+/*
+Disk is a Store kept in a file, for standalone services. The state is held in memory and every
+Apply appends its writes to the file as one record, synced before it returns, so that a crash
+keeps either all the writes of a transaction or none. Reopening the file replays the records.
+
+A record is the length and the CRC-32 of its payload, as 4-byte big-endian integers, and the
+payload is the writes, each an operation byte (0 for put, 1 for delete) and the key and value
+prefixed with their uvarint length.
+*/
+type Disk struct {
+	*Memory
+	f *os.File
+}
+
+var _ Store = (*Disk)(nil)
+
+/*
+OpenDisk opens or creates the store in the file path. A record cut short by a crash is dropped.
+*/
+func OpenDisk(path string) (*Disk, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	d := &Disk{Memory: NewMemory(), f: f}
+	end, err := d.replay()
+	if err == nil {
+		err = f.Truncate(end)
+	}
+	if err == nil {
+		_, err = f.Seek(end, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// replay applies the records of the file and returns the end of the last whole one
+func (d *Disk) replay() (int64, error) {
+	r := bufio.NewReader(d.f)
+	var end int64
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return end, nil
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return end, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			return end, nil
+		}
+		writes, err := decodeWrites(payload)
+		if err != nil {
+			return 0, err
+		}
+		d.Memory.apply(writes)
+		end += int64(len(header) + len(payload))
+	}
+}
+
+// Put sets the value of key
+func (d *Disk) Put(key string, value []byte) error {
+	return d.Apply([]Write{{Key: key, Value: value}})
+}
+
+// Delete removes key
+func (d *Disk) Delete(key string) error {
+	return d.Apply([]Write{{Key: key, Delete: true}})
+}
+
+// Apply appends the writes to the file and then applies them
+func (d *Disk) Apply(writes []Write) error {
+	for _, w := range writes {
+		if w.Key == "" {
+			return errors.New("key must not be empty")
+		}
+	}
+	payload := encodeWrites(writes)
+	record := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	record = append(record, payload...)
+
+	d.Memory.mu.Lock()
+	defer d.Memory.mu.Unlock()
+	if d.f == nil {
+		return errors.New("ledger: disk store is closed")
+	}
+	if _, err := d.f.Write(record); err != nil {
+		return err
+	}
+	if err := d.f.Sync(); err != nil {
+		return err
+	}
+	d.Memory.apply(writes)
+	return nil
+}
+
+// Close closes the file, the store can not be written afterwards
+func (d *Disk) Close() error {
+	d.Memory.mu.Lock()
+	defer d.Memory.mu.Unlock()
+	if d.f == nil {
+		return nil
+	}
+	err := d.f.Close()
+	d.f = nil
+	return err
+}
+
+func encodeWrites(writes []Write) []byte {
+	m := []byte{}
+	for _, w := range writes {
+		op, value := byte(0), w.Value
+		if w.Delete {
+			op, value = 1, nil
+		}
+		m = append(m, op)
+		m = binary.AppendUvarint(m, uint64(len(w.Key)))
+		m = append(m, w.Key...)
+		m = binary.AppendUvarint(m, uint64(len(value)))
+		m = append(m, value...)
+	}
+	return m
+}
+
+func decodeWrites(m []byte) ([]Write, error) {
+	errMalformed := errors.New("ledger: malformed record")
+	writes := []Write{}
+	field := func() ([]byte, bool) {
+		n, k := binary.Uvarint(m)
+		if k <= 0 || n > uint64(len(m)-k) {
+			return nil, false
+		}
+		b := m[k : k+int(n)]
+		m = m[k+int(n):]
+		return b, true
+	}
+	for len(m) > 0 {
+		op := m[0]
+		m = m[1:]
+		key, ok := field()
+		if !ok || op > 1 {
+			return nil, errMalformed
+		}
+		value, ok := field()
+		if !ok {
+			return nil, errMalformed
+		}
+		writes = append(writes, Write{Key: string(key), Value: value, Delete: op == 1})
+	}
+	return writes, nil
+}
@@ -0,0 +1,83 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+	d, err := OpenDisk(path)
+	assert.Nil(t, err)
+	d.Put("a", []byte("1"))
+	d.Put("b", []byte("2"))
+	d.Put("e", []byte{})
+	tx := Begin(d)
+	tx.Put("c", []byte("3"))
+	tx.Delete("a")
+	assert.Nil(t, tx.Commit())
+	assert.Nil(t, d.Close())
+	assert.NotNil(t, d.Put("d", []byte("4")), "A closed store was written.")
+
+	d, err = OpenDisk(path)
+	assert.Nil(t, err)
+	kvs, _ := d.RangeScan("", "", 0)
+	assert.Equal(t, []KV{{"b", []byte("2")}, {"c", []byte("3")}, {"e", []byte{}}}, kvs)
+	d.Put("d", []byte("4"))
+	assert.Nil(t, d.Close())
+
+	d, err = OpenDisk(path)
+	assert.Nil(t, err)
+	v, _ := d.Get("d")
+	assert.Equal(t, []byte("4"), v)
+	d.Close()
+}
+
+// writeTornRecordFile writes a put of a and a transaction putting b and c, and returns the size of the file
+func writeTornRecordFile(t *testing.T, path string) int64 {
+	d, err := OpenDisk(path)
+	assert.Nil(t, err)
+	d.Put("a", []byte("1"))
+	tx := Begin(d)
+	tx.Put("b", []byte("2"))
+	tx.Put("c", []byte("3"))
+	assert.Nil(t, tx.Commit())
+	d.Close()
+	info, _ := os.Stat(path)
+	return info.Size()
+}
+
+func TestDiskTornRecord(t *testing.T) {
+	//a crash while writing the transaction loses all its writes
+	for _, cut := range []int64{1, 8, 9} {
+		path := filepath.Join(t.TempDir(), "ledger")
+		os.Truncate(path, writeTornRecordFile(t, path)-cut)
+		d, err := OpenDisk(path)
+		assert.Nil(t, err)
+		kvs, _ := d.RangeScan("", "", 0)
+		assert.Equal(t, []KV{{"a", []byte("1")}}, kvs)
+
+		//the torn record is dropped so that the next ones are read back
+		d.Put("d", []byte("4"))
+		d.Close()
+		d, _ = OpenDisk(path)
+		kvs, _ = d.RangeScan("", "", 0)
+		assert.Equal(t, []KV{{"a", []byte("1")}, {"d", []byte("4")}}, kvs)
+		d.Close()
+	}
+
+	//a corrupted record is dropped too
+	path := filepath.Join(t.TempDir(), "ledger")
+	writeTornRecordFile(t, path)
+	m, _ := os.ReadFile(path)
+	m[len(m)-1] ^= 1
+	os.WriteFile(path, m, 0600)
+	d, err := OpenDisk(path)
+	assert.Nil(t, err)
+	kvs, _ := d.RangeScan("", "", 0)
+	assert.Equal(t, []KV{{"a", []byte("1")}}, kvs)
+	d.Close()
+}
@@ -0,0 +1,79 @@
+/*
+Package fabric adapts the chaincode stub of Hyperledger Fabric to ledger.Ledger.
+*/
+package fabric
+
+import (
+	"time"
+
+	"github.com/blockchain-research/gridlock/ledger"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+/*
+Stub is the ledger of a chaincode invocation, Fabric commits its writes when the invocation succeeds.
+*/
+type Stub struct {
+	stub shim.ChaincodeStubInterface
+}
+
+var _ ledger.Ledger = Stub{}
+
+/*
+New returns the ledger of the stub.
+*/
+func New(stub shim.ChaincodeStubInterface) Stub {
+	return Stub{stub}
+}
+
+// Get returns the value of key, or nil if there is none
+func (s Stub) Get(key string) ([]byte, error) {
+	return s.stub.GetState(key)
+}
+
+// Put sets the value of key
+func (s Stub) Put(key string, value []byte) error {
+	return s.stub.PutState(key, value)
+}
+
+// Delete removes key
+func (s Stub) Delete(key string) error {
+	return s.stub.DelState(key)
+}
+
+// RangeScan returns the first limit keys in [startKey, endKey) in order, it does not see the writes of the invocation
+func (s Stub) RangeScan(startKey, endKey string, limit int) ([]ledger.KV, error) {
+	it, err := s.stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	kvs := []ledger.KV{}
+	for it.HasNext() && (limit <= 0 || len(kvs) < limit) {
+		kv, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, ledger.KV{Key: kv.Key, Value: kv.Value})
+	}
+	return kvs, nil
+}
+
+// SetEvent sets the event of the invocation, Fabric keeps the last one
+func (s Stub) SetEvent(name string, payload []byte) error {
+	return s.stub.SetEvent(name, payload)
+}
+
+// Creator returns the serialized identity that submitted the invocation
+func (s Stub) Creator() ([]byte, error) {
+	return s.stub.GetCreator()
+}
+
+// Timestamp returns the timestamp the client set in the transaction
+func (s Stub) Timestamp() (time.Time, error) {
+	ts, err := s.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
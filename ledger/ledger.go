@@ -0,0 +1,65 @@
+/*
+Package ledger defines the key-value ledger the account, message and settlement logic reads and
+writes, so that it runs the same inside Fabric, as a standalone service or in simulations. Memory
+and Disk are in-memory and on-disk stores, and the fabric package adapts the chaincode stub.
+*/
+package ledger
+
+import (
+	"time"
+)
+
+/*
+Ledger is the state of the chaincode and the context of the transaction writing it.
+*/
+type Ledger interface {
+	// Get returns the value of key, or nil if there is none
+	Get(key string) ([]byte, error)
+	// Put sets the value of key
+	Put(key string, value []byte) error
+	// Delete removes key
+	Delete(key string) error
+	// RangeScan returns the first limit keys in [startKey, endKey) in order, all of them if limit is 0,
+	// an empty endKey has no upper bound
+	RangeScan(startKey, endKey string, limit int) ([]KV, error)
+	// SetEvent emits an event with the transaction
+	SetEvent(name string, payload []byte) error
+	// Creator returns the identity that submitted the transaction
+	Creator() ([]byte, error)
+	// Timestamp returns the time of the transaction
+	Timestamp() (time.Time, error)
+}
+
+/*
+KV is a key and its value.
+*/
+type KV struct {
+	Key   string
+	Value []byte
+}
+
+/*
+Event is an event emitted by a transaction.
+*/
+type Event struct {
+	Name    string
+	Payload []byte
+}
+
+/*
+Write is a change of a key, Delete removes it and Value is ignored.
+*/
+type Write struct {
+	Key    string
+	Value  []byte
+	Delete bool
+}
+
+/*
+Store is a ledger that applies several writes at once, as Memory and Disk do.
+*/
+type Store interface {
+	Ledger
+	// Apply applies all the writes or none of them
+	Apply(writes []Write) error
+}
@@ -0,0 +1,90 @@
+package ledger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	assert.Nil(t, m.Put("b", []byte("2")))
+	assert.Nil(t, m.Put("a", []byte("1")))
+	assert.Nil(t, m.Put("c", []byte("3")))
+	assert.NotNil(t, m.Put("", []byte("0")), "An empty key was accepted.")
+
+	v, err := m.Get("a")
+	assert.True(t, err == nil && bytes.Equal(v, []byte("1")))
+	v, err = m.Get("d")
+	assert.True(t, err == nil && v == nil)
+
+	kvs, _ := m.RangeScan("a", "c", 0)
+	assert.Equal(t, []KV{{"a", []byte("1")}, {"b", []byte("2")}}, kvs)
+	kvs, _ = m.RangeScan("b", "", 0)
+	assert.Equal(t, []KV{{"b", []byte("2")}, {"c", []byte("3")}}, kvs)
+	kvs, _ = m.RangeScan("", "", 1)
+	assert.Equal(t, []KV{{"a", []byte("1")}}, kvs)
+
+	assert.Nil(t, m.Delete("b"))
+	v, _ = m.Get("b")
+	assert.Nil(t, v)
+
+	//the store keeps its own copy of the values
+	value := []byte("4")
+	m.Put("d", value)
+	value[0] = '5'
+	v, _ = m.Get("d")
+	assert.Equal(t, []byte("4"), v)
+
+	now := time.Unix(1000, 0)
+	m.SetClock(func() time.Time { return now })
+	m.SetCreator([]byte("bank1"))
+	ts, _ := m.Timestamp()
+	creator, _ := m.Creator()
+	assert.True(t, ts.Equal(now))
+	assert.Equal(t, []byte("bank1"), creator)
+}
+
+func TestTx(t *testing.T) {
+	m := NewMemory()
+	m.Put("a", []byte("1"))
+	m.Put("b", []byte("2"))
+
+	tx := Begin(m)
+	tx.Put("c", []byte("3"))
+	tx.Delete("a")
+	tx.SetEvent("settled", []byte("1"))
+	//the transaction reads its writes, the store does not
+	v, _ := tx.Get("a")
+	assert.Nil(t, v)
+	v, _ = tx.Get("c")
+	assert.Equal(t, []byte("3"), v)
+	kvs, _ := tx.RangeScan("", "", 0)
+	assert.Equal(t, []KV{{"b", []byte("2")}, {"c", []byte("3")}}, kvs)
+	kvs, _ = tx.RangeScan("", "", 1)
+	assert.Equal(t, []KV{{"b", []byte("2")}}, kvs)
+	v, _ = m.Get("a")
+	assert.Equal(t, []byte("1"), v)
+	assert.Empty(t, m.Events())
+
+	tx.Discard()
+	v, _ = tx.Get("a")
+	assert.Equal(t, []byte("1"), v)
+
+	tx.Put("c", []byte("3"))
+	tx.Delete("a")
+	tx.SetEvent("settled", []byte("1"))
+	assert.Nil(t, tx.Commit())
+	kvs, _ = m.RangeScan("", "", 0)
+	assert.Equal(t, []KV{{"b", []byte("2")}, {"c", []byte("3")}}, kvs)
+	assert.Equal(t, []Event{{"settled", []byte("1")}}, m.Events())
+
+	//a transaction with an invalid write applies none of them
+	tx.Put("d", []byte("4"))
+	tx.Put("", []byte("0"))
+	assert.NotNil(t, tx.Commit())
+	v, _ = m.Get("d")
+	assert.Nil(t, v)
+}
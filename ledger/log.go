@@ -0,0 +1,69 @@
+package ledger
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+)
+
+/*
+LogLevel is the least severe level a Logger writes.
+*/
+type LogLevel int32
+
+const (
+	LogInfo LogLevel = iota
+	LogError
+	LogNone
+)
+
+var logLevel atomic.Int32
+
+/*
+SetLogLevel sets the level of all the loggers, LogInfo by default.
+*/
+func SetLogLevel(level LogLevel) {
+	logLevel.Store(int32(level))
+}
+
+/*
+Logger writes the log of a module to the standard error, as the chaincode logger of Fabric does,
+so that the business logic does not depend on the shim.
+*/
+type Logger struct {
+	l *log.Logger
+}
+
+/*
+NewLogger returns the logger of the module name.
+*/
+func NewLogger(name string) *Logger {
+	return &Logger{log.New(os.Stderr, name+" ", log.LstdFlags|log.Lmicroseconds)}
+}
+
+func (l *Logger) output(level LogLevel, prefix, s string) {
+	if level >= LogLevel(logLevel.Load()) {
+		l.l.Output(3, prefix+s)
+	}
+}
+
+// Info logs the arguments at level LogInfo
+func (l *Logger) Info(args ...interface{}) {
+	l.output(LogInfo, "INFO ", fmt.Sprintln(args...))
+}
+
+// Infof logs the formatted arguments at level LogInfo
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.output(LogInfo, "INFO ", fmt.Sprintf(format, args...))
+}
+
+// Error logs the arguments at level LogError
+func (l *Logger) Error(args ...interface{}) {
+	l.output(LogError, "ERROR ", fmt.Sprintln(args...))
+}
+
+// Errorf logs the formatted arguments at level LogError
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.output(LogError, "ERROR ", fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Memory is a Store kept in memory, for tests and simulations. The writes are visible at once,
+Begin a Tx to discard them when an operation fails.
+*/
+type Memory struct {
+	mu      sync.RWMutex
+	state   map[string][]byte
+	events  []Event
+	creator []byte
+	clock   func() time.Time
+}
+
+var _ Store = (*Memory)(nil)
+
+/*
+NewMemory returns an empty Memory whose transactions are timestamped with the current time.
+*/
+func NewMemory() *Memory {
+	return &Memory{state: map[string][]byte{}, clock: time.Now}
+}
+
+// Get returns a copy of the value of key, or nil if there is none
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.state[key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, v...), nil
+}
+
+// Put sets the value of key
+func (m *Memory) Put(key string, value []byte) error {
+	return m.Apply([]Write{{Key: key, Value: value}})
+}
+
+// Delete removes key
+func (m *Memory) Delete(key string) error {
+	return m.Apply([]Write{{Key: key, Delete: true}})
+}
+
+// Apply applies the writes in order
+func (m *Memory) Apply(writes []Write) error {
+	for _, w := range writes {
+		if w.Key == "" {
+			return errors.New("key must not be empty")
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apply(writes)
+	return nil
+}
+
+func (m *Memory) apply(writes []Write) {
+	for _, w := range writes {
+		if w.Delete {
+			delete(m.state, w.Key)
+		} else {
+			m.state[w.Key] = append([]byte{}, w.Value...)
+		}
+	}
+}
+
+// RangeScan returns the first limit keys in [startKey, endKey) in order
+func (m *Memory) RangeScan(startKey, endKey string, limit int) ([]KV, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	kvs := []KV{}
+	for k, v := range m.state {
+		if inRange(k, startKey, endKey) {
+			kvs = append(kvs, KV{k, append([]byte{}, v...)})
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return truncate(kvs, limit), nil
+}
+
+func truncate(kvs []KV, limit int) []KV {
+	if limit > 0 && len(kvs) > limit {
+		return kvs[:limit]
+	}
+	return kvs
+}
+
+func inRange(key, startKey, endKey string) bool {
+	return key >= startKey && (endKey == "" || key < endKey)
+}
+
+// SetEvent records the event, see Events
+func (m *Memory) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name must not be empty")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, Event{name, append([]byte{}, payload...)})
+	return nil
+}
+
+// Events returns the events emitted so far in order
+func (m *Memory) Events() []Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Event{}, m.events...)
+}
+
+// Creator returns the identity set by SetCreator
+func (m *Memory) Creator() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.creator, nil
+}
+
+// SetCreator sets the identity returned by Creator
+func (m *Memory) SetCreator(creator []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.creator = creator
+}
+
+// Timestamp returns the time of the clock, the current time unless SetClock replaced it
+func (m *Memory) Timestamp() (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clock(), nil
+}
+
+// SetClock replaces the clock of Timestamp, e.g. by a simulated one
+func (m *Memory) SetClock(clock func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+)
+
+/*
+Tx buffers the writes and events of a transaction on a Store until Commit, like Fabric does for
+an invocation, so that an operation that fails half way leaves the store unchanged. Reads see
+the writes of the transaction.
+*/
+type Tx struct {
+	store  Store
+	writes map[string]Write
+	order  []string
+	events []Event
+}
+
+var _ Ledger = (*Tx)(nil)
+
+/*
+Begin starts a transaction on the store.
+*/
+func Begin(store Store) *Tx {
+	return &Tx{store: store, writes: map[string]Write{}}
+}
+
+// Get returns the value of key written by the transaction or else stored
+func (tx *Tx) Get(key string) ([]byte, error) {
+	if w, ok := tx.writes[key]; ok {
+		if w.Delete {
+			return nil, nil
+		}
+		return append([]byte{}, w.Value...), nil
+	}
+	return tx.store.Get(key)
+}
+
+// Put sets the value of key when the transaction commits
+func (tx *Tx) Put(key string, value []byte) error {
+	tx.write(Write{Key: key, Value: append([]byte{}, value...)})
+	return nil
+}
+
+// Delete removes key when the transaction commits
+func (tx *Tx) Delete(key string) error {
+	tx.write(Write{Key: key, Delete: true})
+	return nil
+}
+
+func (tx *Tx) write(w Write) {
+	if _, ok := tx.writes[w.Key]; !ok {
+		tx.order = append(tx.order, w.Key)
+	}
+	tx.writes[w.Key] = w
+}
+
+// RangeScan returns the first limit keys in [startKey, endKey) with the writes of the transaction applied
+func (tx *Tx) RangeScan(startKey, endKey string, limit int) ([]KV, error) {
+	stored, err := tx.store.RangeScan(startKey, endKey, 0)
+	if err != nil {
+		return nil, err
+	}
+	kvs := []KV{}
+	for _, kv := range stored {
+		if _, ok := tx.writes[kv.Key]; !ok {
+			kvs = append(kvs, kv)
+		}
+	}
+	for _, key := range tx.order {
+		w := tx.writes[key]
+		if !w.Delete && inRange(key, startKey, endKey) {
+			kvs = append(kvs, KV{key, append([]byte{}, w.Value...)})
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return truncate(kvs, limit), nil
+}
+
+// SetEvent emits the event when the transaction commits
+func (tx *Tx) SetEvent(name string, payload []byte) error {
+	tx.events = append(tx.events, Event{name, append([]byte{}, payload...)})
+	return nil
+}
+
+// Creator returns the creator of the store
+func (tx *Tx) Creator() ([]byte, error) {
+	return tx.store.Creator()
+}
+
+// Timestamp returns the time of the store
+func (tx *Tx) Timestamp() (time.Time, error) {
+	return tx.store.Timestamp()
+}
+
+// Commit applies the writes to the store at once and then emits the events
+func (tx *Tx) Commit() error {
+	writes := make([]Write, 0, len(tx.order))
+	for _, key := range tx.order {
+		writes = append(writes, tx.writes[key])
+	}
+	err := tx.store.Apply(writes)
+	if err != nil {
+		return err
+	}
+	for _, e := range tx.events {
+		err = tx.store.SetEvent(e.Name, e.Payload)
+		if err != nil {
+			return err
+		}
+	}
+	tx.Discard()
+	return nil
+}
+
+// Discard drops the writes and events of the transaction
+func (tx *Tx) Discard() {
+	tx.writes = map[string]Write{}
+	tx.order = nil
+	tx.events = nil
+}
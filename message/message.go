@@ -1,21 +1,22 @@
 package message
 
 import (
-	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"math/big"
 
+	"github.com/blockchain-research/gridlock/commitment"
 	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/ledger"
 	pb "github.com/blockchain-research/gridlock/proto"
 	"github.com/blockchain-research/gridlock/zkrangeproof"
 	"github.com/golang/protobuf/proto"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-var logger = shim.NewLogger("gridlock")
+var logger = ledger.NewLogger("gridlock")
 
-func AddMessage(stub shim.ChaincodeStubInterface, args []string) error {
+func AddMessage(stub ledger.Ledger, args []string) error {
 	logger.Info("add payment Message to the system")
 
 	if len(args) != 1 {
@@ -44,69 +45,389 @@ func AddMessage(stub shim.ChaincodeStubInterface, args []string) error {
 		return errors.New("Payment message is not valid")
 	}
 
+	//the payment must fit in the queues before anything is written
+	err = checkQueuesSpace(stub, []*pb.PaymentMessage{paymentMessage})
+	if err != nil {
+		return err
+	}
+
 	//add payment message to MessageTable indexed by message id
-	err = common.AddPaymentToLedger(stub,
+	err = addPaymentMessageToLedger(stub, paymentMessage)
+	if err != nil {
+		return err
+	}
+
+	//add payment message to the queues of the sender and the receiver
+	return addPaymentMessagesToQueues(stub, []*pb.PaymentMessage{paymentMessage})
+}
+
+//AddMessages adds a batch of payment messages whose amounts are covered by one aggregated range proof
+func AddMessages(stub ledger.Ledger, args []string) error {
+	logger.Info("add a batch of payment Messages to the system")
+
+	if len(args) != 1 {
+		return errors.New("Need exactly one argument: <base64-encoded-paymentmessagebatch-object>")
+	}
+
+	batchBytes, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		logger.Error("Failed to base64-decode protobuf-encoded payment message batch")
+		return err
+	}
+	batch := &pb.PaymentMessageBatch{}
+	err = proto.Unmarshal(batchBytes, batch)
+	if err != nil {
+		logger.Error("Failed to proto-unmarshal payment message batch")
+		return err
+	}
+
+	//the ids of the batch are also checked against each other, Fabric does not read back the writes
+	//of the transaction
+	err = checkNewPaymentIds(stub, batch.Payments)
+	if err != nil {
+		return err
+	}
+
+	//verify the batch
+	success, err := verifyPaymentMessageBatch(stub, batch)
+	if err != nil {
+		return err
+	}
+	if success != true {
+		logger.Error("Verification of Payment message batch failed")
+		return errors.New("Payment message batch is not valid")
+	}
+
+	//the payments must fit in the queues before anything is written
+	err = checkQueuesSpace(stub, batch.Payments)
+	if err != nil {
+		return err
+	}
+
+	for _, paymentMessage := range batch.Payments {
+		//the amount is proven by the aggregated proof of the batch
+		paymentMessage.Zkrp = nil
+		paymentMessage.ZkrpType = batch.ZkrpType
+		err = addPaymentMessageToLedger(stub, paymentMessage)
+		if err != nil {
+			return err
+		}
+	}
+	return addPaymentMessagesToQueues(stub, batch.Payments)
+}
+
+//MigrateQueues moves the queues stored as one StoredPaymentQueue per bank to one entry per payment,
+//the payments keep their priority and the queues can not be read before they are migrated.
+//It also computes the running sums of the queues added before they were kept
+func MigrateQueues(stub ledger.Ledger, args []string) error {
+	logger.Info("migrate payment queues")
+
+	if len(args) != 0 {
+		return errors.New("Need no argument")
+	}
+	migrated, err := common.MigrateQueuesFromLedger(stub)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Migrated %d payment queues", migrated)
+	return nil
+}
+
+//queue is the queue of bankId in table, of the payments in currency
+type queue struct {
+	currency string
+	table    string
+	bankId   int32
+}
+
+//groupByQueue returns the outgoing queues of the senders and the incoming queues of the receivers of the payments
+//in their currency, in the order of the payments, and the payments of every queue
+func groupByQueue(paymentMessages []*pb.PaymentMessage) ([]queue, map[queue][]*pb.PaymentMessage) {
+	queues := []queue{}
+	elements := map[queue][]*pb.PaymentMessage{}
+	appendElement := func(q queue, paymentMessage *pb.PaymentMessage) {
+		if _, ok := elements[q]; !ok {
+			queues = append(queues, q)
+		}
+		elements[q] = append(elements[q], paymentMessage)
+	}
+	for _, paymentMessage := range paymentMessages {
+		appendElement(queue{paymentMessage.Currency, common.InCurrency(common.OutQueueTable, paymentMessage.Currency), paymentMessage.Sender}, paymentMessage)
+		appendElement(queue{paymentMessage.Currency, common.InCurrency(common.InQueueTable, paymentMessage.Currency), paymentMessage.Receiver}, paymentMessage)
+	}
+	return queues, elements
+}
+
+//checkQueuesSpace checks that the payments fit in their queues, see common.CheckQueueSpaceFromLedger
+func checkQueuesSpace(stub ledger.Ledger, paymentMessages []*pb.PaymentMessage) error {
+	queues, elements := groupByQueue(paymentMessages)
+	for _, q := range queues {
+		err := common.CheckQueueSpaceFromLedger(stub, q.table, q.bankId, len(elements[q]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//addPaymentMessagesToQueues adds the payments to the outgoing queues of the senders and the incoming queues
+//of the receivers in their currency, ordered by priority and then by paymentId. Writes are not visible to reads of the same
+//transaction, so the payments are grouped by queue and the sum of every queue is written once
+func addPaymentMessagesToQueues(stub ledger.Ledger, paymentMessages []*pb.PaymentMessage) error {
+	queues, elements := groupByQueue(paymentMessages)
+	for _, q := range queues {
+		scheme, err := common.GetCommitmentSchemeFromLedger(stub, q.currency)
+		if err != nil {
+			return err
+		}
+		err = common.AddQueueElementsToLedger(stub, scheme, q.table, q.bankId, elements[q])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//addPaymentMessageToLedger stores a verified payment message as ACTIVE
+func addPaymentMessageToLedger(stub ledger.Ledger, paymentMessage *pb.PaymentMessage) error {
+	err := checkNewPaymentIds(stub, []*pb.PaymentMessage{paymentMessage})
+	if err != nil {
+		return err
+	}
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, paymentMessage.Currency)
+	if err != nil {
+		return err
+	}
+	cmAmount, err := common.NormalizeCommitment(scheme, paymentMessage.CmAmount)
+	if err != nil {
+		return err
+	}
+	return common.AddPaymentToLedger(stub,
 		common.MessageTable+fmt.Sprint(paymentMessage.PaymentId),
 		&pb.StoredPaymentMessage{
 			Sender:   paymentMessage.Sender,
 			Receiver: paymentMessage.Receiver,
-			CmAmount: paymentMessage.CmAmount,
+			CmAmount: cmAmount,
 			Zkrp:     paymentMessage.Zkrp,
 			Status:   pb.StatusType_ACTIVE,
+			ZkrpType: paymentMessage.ZkrpType,
+			Priority: paymentMessage.Priority,
+			Currency: paymentMessage.Currency,
 		},
 	)
+}
 
-	//add payment message to OutQueueTable indexed by Sender, FIFO model
-	err = common.AddQueueElementToLedger(stub, common.OutQueueTable+fmt.Sprint(paymentMessage.Sender), paymentMessage.PaymentId)
-	if err != nil {
-		return err
-	}
-
-	//add payment message to InQueueTable indexed by Receiver
-	err = common.AddQueueElementToLedger(stub, common.InQueueTable+fmt.Sprint(paymentMessage.Receiver), paymentMessage.PaymentId)
-	if err != nil {
-		return err
+//checkNewPaymentIds checks that the payment ids differ from each other and from the payments on the ledger,
+//a payment added twice would be queued twice and counted twice in the queue sums
+func checkNewPaymentIds(stub ledger.Ledger, paymentMessages []*pb.PaymentMessage) error {
+	added := map[int32]bool{}
+	for _, paymentMessage := range paymentMessages {
+		if added[paymentMessage.PaymentId] {
+			logger.Errorf("Payment %d appears twice", paymentMessage.PaymentId)
+			return fmt.Errorf("Payment %d appears twice", paymentMessage.PaymentId)
+		}
+		added[paymentMessage.PaymentId] = true
+		stored, err := stub.Get(common.MessageTable + fmt.Sprint(paymentMessage.PaymentId))
+		if err != nil {
+			logger.Error("Failed to read message table")
+			return err
+		}
+		if stored != nil {
+			logger.Errorf("Payment %d already exists", paymentMessage.PaymentId)
+			return fmt.Errorf("Payment %d already exists", paymentMessage.PaymentId)
+		}
 	}
 	return nil
 }
 
 //verify payment message: sender id within range, receiver id within range, sender != receiver
-//zkp committed value in cmAmount is within [0,u^l)
-func verifyPaymentMessage(stub shim.ChaincodeStubInterface, paymentMessage *pb.PaymentMessage) (bool, error) {
-	if paymentMessage.Sender > common.NumOfBanks || paymentMessage.Sender <= 0 {
-		logger.Info("Invalid Sender %s", paymentMessage.Sender)
+//the currency has accounts, zkp committed value in cmAmount is at least the sender's minimum,
+//within the range of the proof's scheme and the sender's payment limit in the currency
+func verifyPaymentMessage(stub ledger.Ledger, paymentMessage *pb.PaymentMessage) (bool, error) {
+	if verifyPaymentParties(paymentMessage) != true {
 		return false, nil
 	}
-	if paymentMessage.Receiver > common.NumOfBanks || paymentMessage.Receiver <= 0 {
-		logger.Info("Invalid Receiver %s", paymentMessage.Receiver)
+	err := common.CheckCurrencyFromLedger(stub, paymentMessage.Currency)
+	if err != nil {
+		return false, err
+	}
+
+	//get the verifier of the scheme the proof is encoded with
+	verifier, err := common.GetRangeProofVerifierFromLedger(stub, paymentMessage.Currency, paymentMessage.ZkrpType)
+	if err != nil {
+		logger.Info("Failed to read parameters from ledger")
+		return false, err
+	}
+
+	//check that cmAmount's range proof
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, paymentMessage.Currency)
+	if err != nil {
+		return false, err
+	}
+	cmAmount, ok := scheme.Unmarshal(paymentMessage.CmAmount)
+	if !ok {
+		logger.Info("Invalid cmAmount")
 		return false, nil
 	}
-	if paymentMessage.Sender == paymentMessage.Receiver {
-		logger.Info("Duplicate bankId")
+	//the proof is of cmAmount-minAmount*g, so that the amount is at least the minimum of the sender
+	cmShifted, err := shiftedAmount(stub, paymentMessage, cmAmount)
+	if err != nil {
+		return false, err
+	}
+	points, err := common.RangeProofPoints(cmShifted)
+	if err != nil {
+		return false, err
+	}
+	result, err := verifier.VerifyCommitment(paymentMessage.Zkrp, points[0])
+	if err != nil {
+		logger.Error("Failed to verify the zero knowledge range proof")
+		return false, err
+	}
+	if result != true {
+		logger.Error("The zero knowledge range proof verification failed. The committed value in receiving amount is not within range or below the minimum.")
+		return false, zkrangeproof.ErrVerificationFailed
+	}
+	logger.Info("The committed receiving amount is within range")
+
+	return verifyPaymentLimit(stub, paymentMessage, cmAmount)
+}
+
+//shiftedAmount returns cmAmount-minAmount*g, a commitment to the amount above the minimum of the sender in the currency,
+//its range proof shows the amount is at least the minimum, so that payments of 0 can not fill the queues
+func shiftedAmount(stub ledger.Ledger, paymentMessage *pb.PaymentMessage, cmAmount commitment.Commitment) (commitment.Commitment, error) {
+	minAmount, err := common.GetPaymentMinimumFromLedger(
+		stub,
+		common.InCurrency(common.MinimumTable, paymentMessage.Currency)+fmt.Sprint(paymentMessage.Sender),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return commitment.Sub(cmAmount, cmAmount.Scheme().Commit(big.NewInt(minAmount), big.NewInt(0))), nil
+}
+
+//verifyPaymentLimit checks zkrpLimit proves the committed value in cmAmount is within [0, maxAmount] of the payment limit
+//of the sender in the currency of the payment, or within [0, MaxPaymentAmount] when the sender has none,
+//so that the sums of the queues stay within the range of the proofs
+func verifyPaymentLimit(stub ledger.Ledger, paymentMessage *pb.PaymentMessage, cmAmount commitment.Commitment) (bool, error) {
+	if len(paymentMessage.ZkrpLimit) == 0 {
+		logger.Infof("Missing the payment limit proof of payment %d", paymentMessage.PaymentId)
 		return false, nil
 	}
 
-	//get stored params
-	paramsUL, err := common.GetParamsFromLedger(stub)
+	//interval proofs are built on the UL params
+	params, err := common.GetCurrencyParamsFromLedger(stub, paymentMessage.Currency)
 	if err != nil {
 		logger.Info("Failed to read parameters from ledger")
 		return false, err
 	}
+	maxAmount := common.MaxPaymentAmount(params)
+	limit, err := common.GetPaymentLimitFromLedger(
+		stub,
+		common.InCurrency(common.LimitTable, paymentMessage.Currency)+fmt.Sprint(paymentMessage.Sender),
+	)
+	if err != nil {
+		return false, err
+	}
+	if limit != nil {
+		maxAmount = limit.MaxAmount
+	}
+	points, err := common.RangeProofPoints(cmAmount)
+	if err != nil {
+		return false, err
+	}
+	result, err := params.VerifyIntervalCommitment(paymentMessage.ZkrpLimit, points[0], 0, maxAmount)
+	if err != nil {
+		logger.Error("Failed to verify the payment limit proof")
+		return false, err
+	}
+	if result != true {
+		logger.Error("The payment limit proof verification failed. The committed value in amount is above the limit of the sender.")
+		return false, zkrangeproof.ErrVerificationFailed
+	}
+	logger.Info("The committed amount is within the payment limit")
 
-	//check that cmAmount's range proof
-	proof := new(zkrangeproof.ProofULVerifier).Unmarshal(paymentMessage.Zkrp, common.L)
+	return true, nil
+}
 
-	if bytes.Compare(proof.C.Marshal(), paymentMessage.CmAmount) != 0 {
-		logger.Info("The committed values does not match the one in the proof")
+//verify payment message batch: the parties of every payment are valid, the payments are in the same currency, which has accounts,
+//since the aggregated proof is on the generator of the currency, zkp committed values in all cmAmount are at least the minimum of their sender, within the range of the proof's scheme
+//and the payment limit of their sender
+func verifyPaymentMessageBatch(stub ledger.Ledger, batch *pb.PaymentMessageBatch) (bool, error) {
+	if len(batch.Payments) == 0 {
+		logger.Info("Empty payment message batch")
 		return false, nil
 	}
+	currency := batch.Payments[0].Currency
+	err := common.CheckCurrencyFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+	cms := []commitment.Commitment{}
+	for _, paymentMessage := range batch.Payments {
+		if verifyPaymentParties(paymentMessage) != true {
+			return false, nil
+		}
+		if paymentMessage.Currency != currency {
+			logger.Infof("The payment %d is not in the currency of the batch", paymentMessage.PaymentId)
+			return false, nil
+		}
+		cmAmount, ok := scheme.Unmarshal(paymentMessage.CmAmount)
+		if !ok {
+			logger.Info("Invalid cmAmount")
+			return false, nil
+		}
+		success, err := verifyPaymentLimit(stub, paymentMessage, cmAmount)
+		if err != nil || success != true {
+			return false, err
+		}
+		cmShifted, err := shiftedAmount(stub, paymentMessage, cmAmount)
+		if err != nil {
+			return false, err
+		}
+		cms = append(cms, cmShifted)
+	}
 
-	result, _ := zkrangeproof.VerifyUL(proof, *paramsUL)
+	//get the verifier of the scheme the proof is encoded with
+	verifier, err := common.GetAggregateRangeProofVerifierFromLedger(stub, currency, batch.ZkrpType)
+	if err != nil {
+		logger.Info("Failed to read parameters from ledger")
+		return false, err
+	}
+	points, err := common.RangeProofPoints(cms...)
+	if err != nil {
+		return false, err
+	}
+	result, err := verifier.VerifyAggregateCommitment(batch.Zkrp, points)
+	if err != nil {
+		logger.Error("Failed to verify the aggregated zero knowledge range proof")
+		return false, err
+	}
 	if result != true {
-		logger.Error("The zero knowledge range proof verification failed. The committed value in receiving amount is not within range.")
-		return false, errors.New("ZKP verification failed")
+		logger.Error("The aggregated zero knowledge range proof verification failed. Some committed amount is not within range.")
+		return false, zkrangeproof.ErrVerificationFailed
 	}
-	logger.Info("The committed receiving amount is whtin range (0,u^l)")
+	logger.Info("The committed amounts are within range")
 
 	return true, nil
 }
+
+//verifyPaymentParties checks sender id within range, receiver id within range, sender != receiver
+func verifyPaymentParties(paymentMessage *pb.PaymentMessage) bool {
+	if paymentMessage.Sender > common.NumOfBanks || paymentMessage.Sender <= 0 {
+		logger.Infof("Invalid Sender %d", paymentMessage.Sender)
+		return false
+	}
+	if paymentMessage.Receiver > common.NumOfBanks || paymentMessage.Receiver <= 0 {
+		logger.Infof("Invalid Receiver %d", paymentMessage.Receiver)
+		return false
+	}
+	if paymentMessage.Sender == paymentMessage.Receiver {
+		logger.Info("Duplicate bankId")
+		return false
+	}
+	return true
+}
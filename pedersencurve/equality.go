@@ -0,0 +1,141 @@
+package pedersencurve
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/golang/protobuf/proto"
+)
+
+// bit lengths of the committed value, of the challenge and of the statistical mask of an equality proof
+const (
+	ValueBits     = 61
+	challengeBits = 128
+	maskBits      = 62
+)
+
+/*
+EqualityProof proves that the commitments cm1 = g1^x.h1^r1 and cm2 = g2^x.h2^r2, on two possibly
+different curves, commit to the same value 0 <= x < 2^ValueBits. The response z = a + c.x is
+computed over the integers, where a masks c.x, so that it is a valid exponent on both curves.
+It is only sound together with a range proof of one of the commitments, which bounds x.
+*/
+type EqualityProof struct {
+	T1, T2    pairing.G2
+	z, z1, z2 *big.Int
+}
+
+// zBound is the exclusive upper bound of z, below the order of every supported curve
+var zBound = new(big.Int).Lsh(big.NewInt(1), ValueBits+challengeBits+maskBits)
+
+/*
+ProveEquality returns the proof that Commit(x, r1, h1) and Commit(x, r2, h2) commit to the same value.
+The scalar multiplications by secrets run in constant time.
+*/
+func ProveEquality(x, r1, r2 *big.Int, h1, h2 pairing.G2) (*EqualityProof, error) {
+	if x.Sign() < 0 || x.BitLen() > ValueBits {
+		return nil, errors.New("The committed value is out of the range of equality proofs")
+	}
+	c1, c2 := h1.Curve(), h2.Curve()
+	// a is drawn so that a + c.x stays below zBound
+	aBound := new(big.Int).Sub(zBound, new(big.Int).Lsh(big.NewInt(1), ValueBits+challengeBits))
+	a, err := rand.Int(rand.Reader, aBound)
+	if err != nil {
+		return nil, err
+	}
+	s1, err := rand.Int(rand.Reader, c1.Order())
+	if err != nil {
+		return nil, err
+	}
+	s2, err := rand.Int(rand.Reader, c2.Order())
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &EqualityProof{T1: Commit(a, s1, h1), T2: Commit(a, s2, h2)}
+	c := challengeEquality(Commit(x, r1, h1), Commit(x, r2, h2), h1, h2, proof.T1, proof.T2)
+	proof.z = new(big.Int).Add(a, new(big.Int).Mul(c, x))
+	proof.z1 = new(big.Int).Mod(new(big.Int).Add(s1, new(big.Int).Mul(c, r1)), c1.Order())
+	proof.z2 = new(big.Int).Mod(new(big.Int).Add(s2, new(big.Int).Mul(c, r2)), c2.Order())
+	return proof, nil
+}
+
+/*
+VerifyEquality checks that cm1 and cm2 commit to the same value, where cm1 is on the curve of h1
+and cm2 is on the curve of h2. It returns true iff the proof is valid.
+*/
+func VerifyEquality(proof *EqualityProof, cm1, cm2, h1, h2 pairing.G2) (bool, error) {
+	if proof == nil || proof.T1 == nil || proof.T2 == nil || proof.z == nil || proof.z1 == nil || proof.z2 == nil {
+		return false, errors.New("The equality proof is malformed")
+	}
+	c1, c2 := h1.Curve(), h2.Curve()
+	if cm1.Curve().ID() != c1.ID() || proof.T1.Curve().ID() != c1.ID() ||
+		cm2.Curve().ID() != c2.ID() || proof.T2.Curve().ID() != c2.ID() {
+		return false, errors.New("The equality proof is not on the curves of the commitments")
+	}
+	if proof.z.Sign() < 0 || proof.z.Cmp(zBound) >= 0 || proof.z1.Cmp(c1.Order()) >= 0 || proof.z2.Cmp(c2.Order()) >= 0 {
+		return false, nil
+	}
+	c := challengeEquality(cm1, cm2, h1, h2, proof.T1, proof.T2)
+
+	// g1^z.h1^z1 == T1.cm1^c and g2^z.h2^z2 == T2.cm2^c
+	lhs1 := c1.NewG2().Add(c1.NewG2().ScalarBaseMult(proof.z), c1.NewG2().ScalarMult(h1, proof.z1))
+	rhs1 := c1.NewG2().Add(proof.T1, c1.NewG2().ScalarMult(cm1, c))
+	lhs2 := c2.NewG2().Add(c2.NewG2().ScalarBaseMult(proof.z), c2.NewG2().ScalarMult(h2, proof.z2))
+	rhs2 := c2.NewG2().Add(proof.T2, c2.NewG2().ScalarMult(cm2, c))
+	return bytes.Equal(lhs1.Marshal(), rhs1.Marshal()) && bytes.Equal(lhs2.Marshal(), rhs2.Marshal()), nil
+}
+
+// challengeEquality is the Fiat-Shamir challenge of an equality proof, a challengeBits integer
+func challengeEquality(cm1, cm2, h1, h2, T1, T2 pairing.G2) *big.Int {
+	digest := sha256.New()
+	digest.Write([]byte("gridlock/equality"))
+	for _, e := range []pairing.G2{h1, h2, cm1, cm2, T1, T2} {
+		digest.Write([]byte(e.Curve().ID().String()))
+		digest.Write(e.Marshal())
+	}
+	return new(big.Int).SetBytes(digest.Sum(nil)[:challengeBits/8])
+}
+
+/*
+MarshalProto is for marshaling the EqualityProof into its protobuf encoding, with T1 and T2 compressed
+*/
+func (p *EqualityProof) MarshalProto() ([]byte, error) {
+	return proto.Marshal(&pb.EqualityProof{
+		T1: p.T1.MarshalCompressed(),
+		T2: p.T2.MarshalCompressed(),
+		Z:  p.z.Bytes(),
+		Z1: p.z1.Bytes(),
+		Z2: p.z2.Bytes(),
+	})
+}
+
+/*
+UnmarshalProto is for converting the protobuf encoding back into an EqualityProof between commitments
+on the curves c1 and c2. It returns an error if an element is not valid.
+*/
+func (p *EqualityProof) UnmarshalProto(m []byte, c1, c2 pairing.Curve) (*EqualityProof, error) {
+	msg := &pb.EqualityProof{}
+	if err := proto.Unmarshal(m, msg); err != nil {
+		return nil, err
+	}
+	var ok bool
+	if p.T1, ok = c1.NewG2().Unmarshal(msg.T1); !ok {
+		return nil, errors.New("The equality proof contains an invalid G2 element")
+	}
+	if p.T2, ok = c2.NewG2().Unmarshal(msg.T2); !ok {
+		return nil, errors.New("The equality proof contains an invalid G2 element")
+	}
+	if len(msg.Z) > 32 || len(msg.Z1) > 32 || len(msg.Z2) > 32 {
+		return nil, errors.New("The equality proof contains an invalid scalar")
+	}
+	p.z = new(big.Int).SetBytes(msg.Z)
+	p.z1 = new(big.Int).SetBytes(msg.Z1)
+	p.z2 = new(big.Int).SetBytes(msg.Z2)
+	return p, nil
+}
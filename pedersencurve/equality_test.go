@@ -0,0 +1,46 @@
+package pedersencurve
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqualityProofAcrossCurves(t *testing.T) {
+	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
+	bn, bls := pairing.MustLookup(pairing.BN256), pairing.MustLookup(pairing.BLS12381)
+	h1, h2 := bn.NewG2().ScalarBaseMult(h), bls.NewG2().ScalarBaseMult(h)
+	r1, _ := rand.Int(rand.Reader, bn.Order())
+	r2, _ := rand.Int(rand.Reader, bls.Order())
+	x := big.NewInt(1234567)
+	cm1, cm2 := Commit(x, r1, h1), Commit(x, r2, h2)
+
+	proof, err := ProveEquality(x, r1, r2, h1, h2)
+	assert.Nil(t, err)
+	m, err := proof.MarshalProto()
+	assert.Nil(t, err)
+	decoded, err := new(EqualityProof).UnmarshalProto(m, bn, bls)
+	assert.Nil(t, err)
+	result, err := VerifyEquality(decoded, cm1, cm2, h1, h2)
+	assert.True(t, result && err == nil, "Equality proof failed: %v", err)
+
+	//commitments to different values
+	other := Commit(big.NewInt(1234568), r2, h2)
+	result, _ = VerifyEquality(decoded, cm1, other, h1, h2)
+	assert.False(t, result, "Equality proof of different values passed.")
+
+	//a response above the bound
+	decoded.z = new(big.Int).Add(decoded.z, zBound)
+	result, _ = VerifyEquality(decoded, cm1, cm2, h1, h2)
+	assert.False(t, result, "Equality proof with an unbounded response passed.")
+
+	//commitments on other curves than the params
+	_, err = VerifyEquality(proof, cm2, cm1, h1, h2)
+	assert.NotNil(t, err, "Equality proof on swapped curves was accepted.")
+
+	_, err = ProveEquality(new(big.Int).Lsh(big.NewInt(1), ValueBits), r1, r2, h1, h2)
+	assert.NotNil(t, err, "Equality proof of a value out of range was made.")
+}
@@ -3,31 +3,35 @@ package pedersencurve
 import (
 	"math/big"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
 /*
 Commit method corresponds to the Pedersen commitment scheme. Namely, given input
-message x, and randomness r, it outputs g^x.h^r.
+message x, and randomness r, it outputs g^x.h^r on the curve of h. Both are secret,
+so the scalar multiplications run in constant time. The multiples of g and h are
+precomputed, the table of h on the first commitments with it.
 */
-func Commit(x, r *big.Int, h *bn256.G2) *bn256.G2 {
+func Commit(x, r *big.Int, h pairing.G2) pairing.G2 {
 	var (
-		C *bn256.G2
+		C pairing.G2
 	)
-	C = new(bn256.G2).ScalarBaseMult(x)
-	C.Add(C, new(bn256.G2).ScalarMult(h, r))
+	C = h.Curve().NewG2().ScalarBaseMultCT(x)
+	C.Add(C, h.Curve().NewG2().ScalarMultTable(pairing.CachedG2Table(h), r))
 	return C
 }
 
 /*
 CommitG1 method corresponds to the Pedersen commitment scheme. Namely, given input
-message x, and randomness r, it outputs g^x.h^r.
+message x, and randomness r, it outputs g^x.h^r on the curve of h. Both are secret,
+so the scalar multiplications run in constant time. The multiples of g and h are
+precomputed, the table of h on the first commitments with it.
 */
-func CommitG1(x, r *big.Int, h *bn256.G1) (*bn256.G1, error) {
+func CommitG1(x, r *big.Int, h pairing.G1) (pairing.G1, error) {
 	var (
-		C *bn256.G1
+		C pairing.G1
 	)
-	C = new(bn256.G1).ScalarBaseMult(x)
-	C.Add(C, new(bn256.G1).ScalarMult(h, r))
+	C = h.Curve().NewG1().ScalarBaseMultCT(x)
+	C.Add(C, h.Curve().NewG1().ScalarMultTable(pairing.CachedG1Table(h), r))
 	return C, nil
 }
@@ -7,18 +7,19 @@ import (
 	"math/big"
 	"testing"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestPedersenSum(t *testing.T) {
+	c := pairing.MustLookup(pairing.BN256)
 	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	H := new(bn256.G2).ScalarBaseMult(h)
-	r1, _ := rand.Int(rand.Reader, bn256.Order)
+	H := c.NewG2().ScalarBaseMult(h)
+	r1, _ := rand.Int(rand.Reader, c.Order())
 	x1 := new(big.Int).SetInt64(10)
 	cm1 := Commit(x1, r1, H)
 
-	r2, _ := rand.Int(rand.Reader, bn256.Order)
+	r2, _ := rand.Int(rand.Reader, c.Order())
 	x2 := new(big.Int).SetInt64(5)
 	cm2 := Commit(x2, r2, H)
 
@@ -27,7 +28,7 @@ func TestPedersenSum(t *testing.T) {
 	cmSum := Commit(xSum, rSum, H)
 	cmSumBytes := cmSum.Marshal()
 
-	cm3 := new(bn256.G2).Add(cm1, cm2)
+	cm3 := c.NewG2().Add(cm1, cm2)
 	cm3Bytes := cm3.Marshal()
 
 	//fmt.Println("cm3=v%", cmSumBytes)
@@ -37,13 +38,14 @@ func TestPedersenSum(t *testing.T) {
 }
 
 func TestPedersenSub(t *testing.T) {
+	c := pairing.MustLookup(pairing.BN256)
 	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	H := new(bn256.G2).ScalarBaseMult(h)
-	r1, _ := rand.Int(rand.Reader, bn256.Order)
+	H := c.NewG2().ScalarBaseMult(h)
+	r1, _ := rand.Int(rand.Reader, c.Order())
 	x1 := new(big.Int).SetInt64(10)
 	cm1 := Commit(x1, r1, H)
 
-	r2, _ := rand.Int(rand.Reader, bn256.Order)
+	r2, _ := rand.Int(rand.Reader, c.Order())
 	x2 := new(big.Int).SetInt64(5)
 	cm2 := Commit(x2, r2, H)
 
@@ -52,7 +54,7 @@ func TestPedersenSub(t *testing.T) {
 	cmSub := Commit(xSub, rSub, H)
 	cmSubBytes := cmSub.Marshal()
 
-	cm3 := new(bn256.G2).Add(cm1, new(bn256.G2).Neg(cm2))
+	cm3 := c.NewG2().Add(cm1, c.NewG2().Neg(cm2))
 	cm3Bytes := cm3.Marshal()
 
 	fmt.Println("cm3=v%", cmSubBytes)
@@ -62,9 +64,10 @@ func TestPedersenSub(t *testing.T) {
 }
 
 func TestPedersenNeg(t *testing.T) {
+	c := pairing.MustLookup(pairing.BN256)
 	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	H := new(bn256.G2).ScalarBaseMult(h)
-	r1, _ := rand.Int(rand.Reader, bn256.Order)
+	H := c.NewG2().ScalarBaseMult(h)
+	r1, _ := rand.Int(rand.Reader, c.Order())
 	x1 := new(big.Int).SetInt64(10)
 	cm1 := Commit(x1, r1, H)
 
@@ -72,7 +75,7 @@ func TestPedersenNeg(t *testing.T) {
 	r2 := r1.Neg(r1)
 	cm2 := Commit(x2, r2, H)
 
-	cm3 := new(bn256.G2).Add(cm1, cm2)
+	cm3 := c.NewG2().Add(cm1, cm2)
 
 	success := cm3.IsZero()
 	assert.Equal(t, true, success, "Pedersen commitment zero sum failed.")
@@ -83,3 +86,45 @@ func GetBigInt(value string) *big.Int {
 	i.SetString(value, 10)
 	return i
 }
+
+func TestCommitConstantTimeMatches(t *testing.T) {
+	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
+	for _, id := range []pairing.ID{pairing.BN256, pairing.BLS12381} {
+		c := pairing.MustLookup(id)
+		H := c.NewG2().ScalarBaseMult(h)
+		for _, x := range []int64{0, 10, -10} {
+			r, _ := rand.Int(rand.Reader, c.Order())
+			cm := Commit(big.NewInt(x), r, H)
+			expected := c.NewG2().ScalarBaseMult(big.NewInt(x))
+			expected.Add(expected, c.NewG2().ScalarMult(H, r))
+			assert.Equal(t, expected.Marshal(), cm.Marshal(), "Constant time commitment on %s differs for %d.", id, x)
+		}
+	}
+}
+
+/*
+BenchmarkCommit compares Commit, which adds entries of precomputed tables of g and h,
+with the two constant time scalar multiplications it replaces.
+*/
+func BenchmarkCommit(b *testing.B) {
+	for _, id := range []pairing.ID{pairing.BN256, pairing.BLS12381} {
+		c := pairing.MustLookup(id)
+		_, H, _ := c.RandomG2(rand.Reader)
+		x := big.NewInt(176)
+		r, _ := rand.Int(rand.Reader, c.Order())
+		g := c.NewG2().ScalarBaseMult(big.NewInt(1))
+		b.Run(fmt.Sprintf("%s/scalarmultct", id), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				C := c.NewG2().ScalarMultCT(g, x)
+				C.Add(C, c.NewG2().ScalarMultCT(H, r))
+			}
+		})
+		b.Run(fmt.Sprintf("%s/table", id), func(b *testing.B) {
+			Commit(x, r, H)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Commit(x, r, H)
+			}
+		})
+	}
+}
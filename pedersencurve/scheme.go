@@ -0,0 +1,106 @@
+package pedersencurve
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+)
+
+/*
+Scheme is the commitment scheme g^x.h^r on G2 of the curve of h.
+*/
+type Scheme struct {
+	H pairing.G2
+}
+
+var _ commitment.Scheme = (*Scheme)(nil)
+
+/*
+NewScheme returns the commitment scheme with the generator h.
+*/
+func NewScheme(h pairing.G2) *Scheme {
+	return &Scheme{H: h}
+}
+
+// Name names the scheme in errors and logs
+func (s *Scheme) Name() string {
+	return "pedersencurve on " + s.H.Curve().ID().String()
+}
+
+// Commit returns g^x.h^r, see Commit
+func (s *Scheme) Commit(x, r *big.Int) commitment.Commitment {
+	return &Commitment{s, Commit(x, r, s.H)}
+}
+
+// Unmarshal converts a marshaled point of G2, compressed or not, back into a commitment
+func (s *Scheme) Unmarshal(m []byte) (commitment.Commitment, bool) {
+	e, ok := s.H.Curve().NewG2().Unmarshal(m)
+	if !ok {
+		return nil, false
+	}
+	return &Commitment{s, e}, true
+}
+
+/*
+Commitment is a commitment of Scheme, a point of G2.
+*/
+type Commitment struct {
+	scheme *Scheme
+	e      pairing.G2
+}
+
+/*
+NewCommitment returns the point e of G2 as a commitment of the scheme s.
+*/
+func NewCommitment(s *Scheme, e pairing.G2) *Commitment {
+	return &Commitment{s, e}
+}
+
+// G2 returns the point of the commitment
+func (c *Commitment) G2() pairing.G2 {
+	return c.e
+}
+
+// Scheme returns the scheme of the commitment
+func (c *Commitment) Scheme() commitment.Scheme {
+	return c.scheme
+}
+
+// Add returns the commitment to the sum of the values, b must be on the same curve
+func (c *Commitment) Add(b commitment.Commitment) commitment.Commitment {
+	e, ok := ToG2(b)
+	if !ok || e.Curve().ID() != c.e.Curve().ID() {
+		panic("pedersencurve: adding commitments of different schemes")
+	}
+	return &Commitment{c.scheme, c.e.Curve().NewG2().Add(c.e, e)}
+}
+
+// Neg returns the commitment to the opposite value
+func (c *Commitment) Neg() commitment.Commitment {
+	return &Commitment{c.scheme, c.e.Curve().NewG2().Neg(c.e)}
+}
+
+// Equal returns true iff b is the same point of the same curve
+func (c *Commitment) Equal(b commitment.Commitment) bool {
+	e, ok := ToG2(b)
+	return ok && e.Curve().ID() == c.e.Curve().ID() && bytes.Equal(e.Marshal(), c.e.Marshal())
+}
+
+// Marshal returns the compressed point
+func (c *Commitment) Marshal() []byte {
+	return c.e.MarshalCompressed()
+}
+
+/*
+ToG2 returns the point of a commitment of pedersencurve, the range proofs on curves prove
+values committed in it. It returns false for the commitments of other schemes.
+*/
+func ToG2(c commitment.Commitment) (pairing.G2, bool) {
+	e, ok := c.(*Commitment)
+	if !ok {
+		return nil, false
+	}
+	return e.e, true
+}
@@ -0,0 +1,39 @@
+package pedersencurve
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemeHomomorphism(t *testing.T) {
+	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
+	for _, id := range []pairing.ID{pairing.BN256, pairing.BLS12381} {
+		var scheme commitment.Scheme = NewScheme(pairing.MustLookup(id).NewG2().ScalarBaseMult(h))
+		c1 := scheme.Commit(big.NewInt(50), big.NewInt(7))
+		c2 := scheme.Commit(big.NewInt(20), big.NewInt(3))
+		assert.True(t, c1.Add(c2).Equal(scheme.Commit(big.NewInt(70), big.NewInt(10))), "Sum of commitments failed on %s.", id)
+		assert.True(t, commitment.Sub(c1, c2).Equal(scheme.Commit(big.NewInt(30), big.NewInt(4))), "Difference of commitments failed on %s.", id)
+		assert.False(t, c1.Equal(c2))
+
+		decoded, ok := scheme.Unmarshal(c1.Marshal())
+		assert.True(t, ok && decoded.Equal(c1), "Commitment did not round trip on %s.", id)
+		e, _ := ToG2(c1)
+		decoded, ok = scheme.Unmarshal(e.Marshal())
+		assert.True(t, ok && decoded.Equal(c1), "Uncompressed commitment was not accepted on %s.", id)
+		_, ok = scheme.Unmarshal(c1.Marshal()[1:])
+		assert.False(t, ok, "Truncated commitment was accepted on %s.", id)
+	}
+}
+
+func TestSchemeMismatch(t *testing.T) {
+	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
+	bn := NewScheme(pairing.MustLookup(pairing.BN256).NewG2().ScalarBaseMult(h))
+	bls := NewScheme(pairing.MustLookup(pairing.BLS12381).NewG2().ScalarBaseMult(h))
+	c1, c2 := bn.Commit(big.NewInt(1), big.NewInt(1)), bls.Commit(big.NewInt(1), big.NewInt(1))
+	assert.False(t, c1.Equal(c2))
+	assert.Panics(t, func() { c1.Add(c2) })
+}
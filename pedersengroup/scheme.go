@@ -0,0 +1,102 @@
+package pedersengroup
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/commitment"
+)
+
+// PedersenPublic is the commitment scheme g^x.h^r in the Schnorr group
+var _ commitment.Scheme = (*PedersenPublic)(nil)
+
+// Validate checks that the group has prime order Q and that G and H generate it
+func (p *PedersenPublic) Validate() error {
+	group := p.Group
+	if group == nil || group.P == nil || group.Q == nil || group.G == nil || p.H == nil {
+		return errors.New("pedersen group params are incomplete")
+	}
+	if !group.P.ProbablyPrime(20) || !group.Q.ProbablyPrime(20) {
+		return errors.New("pedersen group params have a modulus or an order that is not prime")
+	}
+	if new(big.Int).Mod(new(big.Int).Sub(group.P, big.NewInt(1)), group.Q).Sign() != 0 {
+		return errors.New("pedersen group params have an order that does not divide P-1")
+	}
+	one := big.NewInt(1)
+	for _, e := range []*big.Int{group.G, p.H} {
+		if !group.IsElementInGroup(e) || e.Cmp(one) == 0 {
+			return errors.New("pedersen group params have a generator outside of the group")
+		}
+	}
+	return nil
+}
+
+// Name names the scheme in errors and logs
+func (p *PedersenPublic) Name() string {
+	return "pedersengroup"
+}
+
+// Commit returns g^x.h^r, x and r are reduced modulo the order of the group
+func (p *PedersenPublic) Commit(x, r *big.Int) commitment.Commitment {
+	t1 := p.Group.Exp(p.Group.G, new(big.Int).Mod(x, p.Group.Q))
+	t2 := p.Group.Exp(p.H, new(big.Int).Mod(r, p.Group.Q))
+	return &Commitment{p, p.Group.Mul(t1, t2)}
+}
+
+// Unmarshal converts the output of Marshal back into a commitment, an element of the group
+func (p *PedersenPublic) Unmarshal(m []byte) (commitment.Commitment, bool) {
+	if len(m) != p.elementLength() {
+		return nil, false
+	}
+	c := new(big.Int).SetBytes(m)
+	if !p.Group.IsElementInGroup(c) {
+		return nil, false
+	}
+	return &Commitment{p, c}, true
+}
+
+// elementLength is the length of a marshaled element of the group
+func (p *PedersenPublic) elementLength() int {
+	return (p.Group.P.BitLen() + 7) / 8
+}
+
+// Commitment is a commitment of PedersenPublic, an element of the Schnorr group
+type Commitment struct {
+	params *PedersenPublic
+	c      *big.Int
+}
+
+// Int returns the element of the commitment
+func (c *Commitment) Int() *big.Int {
+	return new(big.Int).Set(c.c)
+}
+
+// Scheme returns the scheme of the commitment
+func (c *Commitment) Scheme() commitment.Scheme {
+	return c.params
+}
+
+// Add returns the commitment to the sum of the values, b must be in the same group
+func (c *Commitment) Add(b commitment.Commitment) commitment.Commitment {
+	other, ok := b.(*Commitment)
+	if !ok || other.params.Group.P.Cmp(c.params.Group.P) != 0 {
+		panic("pedersengroup: adding commitments of different schemes")
+	}
+	return &Commitment{c.params, c.params.Group.Mul(c.c, other.c)}
+}
+
+// Neg returns the commitment to the opposite value
+func (c *Commitment) Neg() commitment.Commitment {
+	return &Commitment{c.params, c.params.Group.Inv(c.c)}
+}
+
+// Equal returns true iff b is the same element of the same group
+func (c *Commitment) Equal(b commitment.Commitment) bool {
+	other, ok := b.(*Commitment)
+	return ok && other.params.Group.P.Cmp(c.params.Group.P) == 0 && other.c.Cmp(c.c) == 0
+}
+
+// Marshal returns the element in big-endian, padded to the length of P
+func (c *Commitment) Marshal() []byte {
+	return c.c.FillBytes(make([]byte, c.params.elementLength()))
+}
@@ -0,0 +1,51 @@
+package pedersengroup
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/commitment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemeHomomorphism(t *testing.T) {
+	pp, err := GeneratePedersenParams(256)
+	assert.Nil(t, err)
+	p := GeneratePedersenFromParams(pp.Group.P, pp.Group.G, pp.Group.Q, pp.H)
+	assert.Nil(t, p.Validate())
+
+	var scheme commitment.Scheme = p
+	c1 := scheme.Commit(big.NewInt(50), big.NewInt(7))
+	c2 := scheme.Commit(big.NewInt(20), big.NewInt(3))
+	assert.True(t, c1.Add(c2).Equal(scheme.Commit(big.NewInt(70), big.NewInt(10))), "Sum of commitments failed.")
+	assert.True(t, commitment.Sub(c1, c2).Equal(scheme.Commit(big.NewInt(30), big.NewInt(4))), "Difference of commitments failed.")
+	//negative values are reduced modulo the order
+	assert.True(t, c2.Neg().Equal(scheme.Commit(big.NewInt(-20), big.NewInt(-3))), "Opposite of a commitment failed.")
+	assert.False(t, c1.Equal(c2))
+
+	m := c1.Marshal()
+	assert.Equal(t, (p.Group.P.BitLen()+7)/8, len(m))
+	decoded, ok := scheme.Unmarshal(m)
+	assert.True(t, ok && decoded.Equal(c1), "Commitment did not round trip.")
+
+	_, ok = scheme.Unmarshal(m[1:])
+	assert.False(t, ok, "Truncated commitment was accepted.")
+	_, ok = scheme.Unmarshal(p.Group.P.FillBytes(make([]byte, len(m))))
+	assert.False(t, ok, "Element outside of the group was accepted.")
+}
+
+func TestValidate(t *testing.T) {
+	pp, err := GeneratePedersenParams(256)
+	assert.Nil(t, err)
+	group := pp.Group
+	one := big.NewInt(1)
+
+	for name, p := range map[string]*PedersenPublic{
+		"missing H":         GeneratePedersenFromParams(group.P, group.G, group.Q, nil),
+		"composite order":   GeneratePedersenFromParams(group.P, group.G, new(big.Int).Add(group.Q, one), pp.H),
+		"composite modulus": GeneratePedersenFromParams(new(big.Int).Add(group.P, one), group.G, group.Q, pp.H),
+		"trivial generator": GeneratePedersenFromParams(group.P, group.G, group.Q, one),
+	} {
+		assert.NotNil(t, p.Validate(), "Invalid params with %s were accepted.", name)
+	}
+}
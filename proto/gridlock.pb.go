@@ -14,6 +14,8 @@ It has these top-level messages:
 	PaymentMessage
 	StoredPaymentMessage
 	StoredPaymentQueue
+	StoredCurrencies
+	StoredPaymentSum
 	GrossSettlementSet
 	GLRConfiguration
 	GridlockProposal
@@ -21,6 +23,20 @@ It has these top-level messages:
 	TallyGridlockProposal
 	NetGridlockProposal
 	StoredPedersenGroup
+	PaymentMessageBatch
+	PaymentLimit
+	PaymentMinimum
+	ReservedBalance
+	CreditLimit
+	StoredCreditLimit
+	RepaymentProof
+	StoredRepayment
+	ProofULVerifier
+	ParamsULVerifier
+	ProofCCS08
+	EqualityProof
+	AccountMigration
+	CurveMigration
 */
 package proto
 
@@ -66,17 +82,20 @@ const (
 	GLRStatusType_START   GLRStatusType = 0
 	GLRStatusType_SUCCESS GLRStatusType = 1
 	GLRStatusType_NETTED  GLRStatusType = 2
+	GLRStatusType_ABORTED GLRStatusType = 3
 )
 
 var GLRStatusType_name = map[int32]string{
 	0: "START",
 	1: "SUCCESS",
 	2: "NETTED",
+	3: "ABORTED",
 }
 var GLRStatusType_value = map[string]int32{
 	"START":   0,
 	"SUCCESS": 1,
 	"NETTED":  2,
+	"ABORTED": 3,
 }
 
 func (x GLRStatusType) String() string {
@@ -84,11 +103,59 @@ func (x GLRStatusType) String() string {
 }
 func (GLRStatusType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
+// RangeProofType tags which range proof scheme a zkrp is encoded with
+type RangeProofType int32
+
+const (
+	RangeProofType_UL          RangeProofType = 0
+	RangeProofType_BULLETPROOF RangeProofType = 1
+	RangeProofType_BORROMEAN   RangeProofType = 2
+)
+
+var RangeProofType_name = map[int32]string{
+	0: "UL",
+	1: "BULLETPROOF",
+	2: "BORROMEAN",
+}
+var RangeProofType_value = map[string]int32{
+	"UL":          0,
+	"BULLETPROOF": 1,
+	"BORROMEAN":   2,
+}
+
+func (x RangeProofType) String() string {
+	return proto1.EnumName(RangeProofType_name, int32(x))
+}
+func (RangeProofType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+// CurveType tags the pairing-friendly curve that commitments and UL proofs are built on
+type CurveType int32
+
+const (
+	CurveType_BN256    CurveType = 0
+	CurveType_BLS12381 CurveType = 1
+)
+
+var CurveType_name = map[int32]string{
+	0: "BN256",
+	1: "BLS12381",
+}
+var CurveType_value = map[string]int32{
+	"BN256":    0,
+	"BLS12381": 1,
+}
+
+func (x CurveType) String() string {
+	return proto1.EnumName(CurveType_name, int32(x))
+}
+func (CurveType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
 // the zkrp is a zero-knowledge range proof of the current bank acount balance in the cmBalance
 type BankAccount struct {
-	BankId    int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
-	CmBalance []byte `protobuf:"bytes,2,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
-	Zkrp      []byte `protobuf:"bytes,3,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	BankId    int32          `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	CmBalance []byte         `protobuf:"bytes,2,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Zkrp      []byte         `protobuf:"bytes,3,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	ZkrpType  RangeProofType `protobuf:"varint,4,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
 }
 
 func (m *BankAccount) Reset()                    { *m = BankAccount{} }
@@ -117,9 +184,25 @@ func (m *BankAccount) GetZkrp() []byte {
 	return nil
 }
 
+func (m *BankAccount) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
 // MintAccount message will initialize bank's accounts
+// zkrp is an optional aggregated range proof of the cmBalance of all the accounts, in order
+// when it is set, the zkrp of each account is not checked
+// zkrpSupply is a CCS08 interval proof that the total supply after the mint, the sum of the cmBalance of all the accounts
+// and of the cmLimit of the credit limits, is within [0, MaxSupply], the lower half of [0,u^l)
+// the accounts are those of currency, the total supply is that of the currency
 type MintAccount struct {
-	Accounts []*BankAccount `protobuf:"bytes,1,rep,name=accounts" json:"accounts,omitempty"`
+	Accounts   []*BankAccount `protobuf:"bytes,1,rep,name=accounts" json:"accounts,omitempty"`
+	Zkrp       []byte         `protobuf:"bytes,2,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	ZkrpType   RangeProofType `protobuf:"varint,3,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+	ZkrpSupply []byte         `protobuf:"bytes,4,opt,name=zkrpSupply,proto3" json:"zkrpSupply,omitempty"`
+	Currency   string         `protobuf:"bytes,5,opt,name=currency" json:"currency,omitempty"`
 }
 
 func (m *MintAccount) Reset()                    { *m = MintAccount{} }
@@ -134,8 +217,38 @@ func (m *MintAccount) GetAccounts() []*BankAccount {
 	return nil
 }
 
+func (m *MintAccount) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
+func (m *MintAccount) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+func (m *MintAccount) GetZkrpSupply() []byte {
+	if m != nil {
+		return m.ZkrpSupply
+	}
+	return nil
+}
+
+func (m *MintAccount) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// cmReserve is the part of the balance reserved for urgent payments, none when it is empty
 type StoredBankAccount struct {
 	CmBalance []byte `protobuf:"bytes,1,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	CmReserve []byte `protobuf:"bytes,2,opt,name=cmReserve,proto3" json:"cmReserve,omitempty"`
 }
 
 func (m *StoredBankAccount) Reset()                    { *m = StoredBankAccount{} }
@@ -150,16 +263,33 @@ func (m *StoredBankAccount) GetCmBalance() []byte {
 	return nil
 }
 
+func (m *StoredBankAccount) GetCmReserve() []byte {
+	if m != nil {
+		return m.CmReserve
+	}
+	return nil
+}
+
 // Schema for payment message
 // sender and receiver are the ids of two parties involved
 // cmAmount is the committment of payment value
-// zkrp is a zero knowledge range proof attesting that committed value in cmAmount is within MAX> range >=0
+// zkrp is a zero knowledge range proof attesting that committed value in cmAmount-minAmount*g is within MAX> range >=0,
+// i.e. the amount is at least the minAmount of the sender's PaymentMinimum, 1 when the sender has none
+// zkrpLimit is a CCS08 interval proof attesting that committed value in cmAmount is within [0, maxAmount]
+// of the sender's PaymentLimit, or the default limit MaxPaymentAmount of u^l when the sender has none
+// priority orders the payment in the queues, the payments of lower priority are settled first
+// and those of the same priority in the order of paymentId
+// currency is the currency of the amount, the payment is queued and settled against the accounts of the currency
 type PaymentMessage struct {
-	PaymentId int32  `protobuf:"varint,1,opt,name=paymentId" json:"paymentId,omitempty"`
-	Sender    int32  `protobuf:"varint,2,opt,name=sender" json:"sender,omitempty"`
-	Receiver  int32  `protobuf:"varint,3,opt,name=receiver" json:"receiver,omitempty"`
-	CmAmount  []byte `protobuf:"bytes,4,opt,name=cmAmount,proto3" json:"cmAmount,omitempty"`
-	Zkrp      []byte `protobuf:"bytes,5,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	PaymentId int32          `protobuf:"varint,1,opt,name=paymentId" json:"paymentId,omitempty"`
+	Sender    int32          `protobuf:"varint,2,opt,name=sender" json:"sender,omitempty"`
+	Receiver  int32          `protobuf:"varint,3,opt,name=receiver" json:"receiver,omitempty"`
+	CmAmount  []byte         `protobuf:"bytes,4,opt,name=cmAmount,proto3" json:"cmAmount,omitempty"`
+	Zkrp      []byte         `protobuf:"bytes,5,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	ZkrpType  RangeProofType `protobuf:"varint,6,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+	ZkrpLimit []byte         `protobuf:"bytes,7,opt,name=zkrpLimit,proto3" json:"zkrpLimit,omitempty"`
+	Priority  int32          `protobuf:"varint,8,opt,name=priority" json:"priority,omitempty"`
+	Currency  string         `protobuf:"bytes,9,opt,name=currency" json:"currency,omitempty"`
 }
 
 func (m *PaymentMessage) Reset()                    { *m = PaymentMessage{} }
@@ -202,13 +332,44 @@ func (m *PaymentMessage) GetZkrp() []byte {
 	return nil
 }
 
+func (m *PaymentMessage) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+func (m *PaymentMessage) GetZkrpLimit() []byte {
+	if m != nil {
+		return m.ZkrpLimit
+	}
+	return nil
+}
+
+func (m *PaymentMessage) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *PaymentMessage) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
 // StoredPaymentMessage is stored in PAYMENT_MESSAGE table, indexed by PaymentId
 type StoredPaymentMessage struct {
-	Sender   int32      `protobuf:"varint,1,opt,name=sender" json:"sender,omitempty"`
-	Receiver int32      `protobuf:"varint,2,opt,name=receiver" json:"receiver,omitempty"`
-	CmAmount []byte     `protobuf:"bytes,4,opt,name=cmAmount,proto3" json:"cmAmount,omitempty"`
-	Zkrp     []byte     `protobuf:"bytes,5,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
-	Status   StatusType `protobuf:"varint,6,opt,name=status,enum=proto.StatusType" json:"status,omitempty"`
+	Sender   int32          `protobuf:"varint,1,opt,name=sender" json:"sender,omitempty"`
+	Receiver int32          `protobuf:"varint,2,opt,name=receiver" json:"receiver,omitempty"`
+	CmAmount []byte         `protobuf:"bytes,4,opt,name=cmAmount,proto3" json:"cmAmount,omitempty"`
+	Zkrp     []byte         `protobuf:"bytes,5,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	Status   StatusType     `protobuf:"varint,6,opt,name=status,enum=proto.StatusType" json:"status,omitempty"`
+	ZkrpType RangeProofType `protobuf:"varint,7,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+	Priority int32          `protobuf:"varint,8,opt,name=priority" json:"priority,omitempty"`
+	Currency string         `protobuf:"bytes,9,opt,name=currency" json:"currency,omitempty"`
 }
 
 func (m *StoredPaymentMessage) Reset()                    { *m = StoredPaymentMessage{} }
@@ -251,8 +412,30 @@ func (m *StoredPaymentMessage) GetStatus() StatusType {
 	return StatusType_ACTIVE
 }
 
-// StoredPaymentQueue is stored in PAYMENT_QUEUE_INCOMING/PAYMENT_QUEUE_OUTGOING table
-// indexed by bankId, stores the array of message Ids
+func (m *StoredPaymentMessage) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+func (m *StoredPaymentMessage) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *StoredPaymentMessage) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// StoredPaymentQueue is a list of payment ids, e.g. the infeasible set stored in GLR_INFEASIBLE table
+// the queues of the banks were stored as one in PAYMENT_QUEUE_INCOMING/PAYMENT_QUEUE_OUTGOING table
+// indexed by bankId before they were stored by entry, migrateQueues moves them to the entries
 type StoredPaymentQueue struct {
 	PaymentIds []int32 `protobuf:"varint,1,rep,packed,name=paymentIds" json:"paymentIds,omitempty"`
 }
@@ -269,19 +452,65 @@ func (m *StoredPaymentQueue) GetPaymentIds() []int32 {
 	return nil
 }
 
+// StoredCurrencies is the list of the currencies other than the default one that have accounts,
+// stored in CURRENCY table when their accounts are first minted
+type StoredCurrencies struct {
+	Currencies []string `protobuf:"bytes,1,rep,name=currencies" json:"currencies,omitempty"`
+}
+
+func (m *StoredCurrencies) Reset()                    { *m = StoredCurrencies{} }
+func (m *StoredCurrencies) String() string            { return proto1.CompactTextString(m) }
+func (*StoredCurrencies) ProtoMessage()               {}
+func (*StoredCurrencies) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *StoredCurrencies) GetCurrencies() []string {
+	if m != nil {
+		return m.Currencies
+	}
+	return nil
+}
+
+// the running sum of the cmAmount of the active payments in a queue of a bank,
+// updated when payments enter or leave the queue, with the number of the payments
+type StoredPaymentSum struct {
+	CmSum []byte `protobuf:"bytes,1,opt,name=cmSum,proto3" json:"cmSum,omitempty"`
+	Count int32  `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *StoredPaymentSum) Reset()                    { *m = StoredPaymentSum{} }
+func (m *StoredPaymentSum) String() string            { return proto1.CompactTextString(m) }
+func (*StoredPaymentSum) ProtoMessage()               {}
+func (*StoredPaymentSum) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *StoredPaymentSum) GetCmSum() []byte {
+	if m != nil {
+		return m.CmSum
+	}
+	return nil
+}
+
+func (m *StoredPaymentSum) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
 // grosssettlement set contains the outgoing payments ids of a single bank
-// zkrp is tha zero knowledge range proof attesting that the (balance-outgoing) is within MAX>range>=0
+// zkrp is tha zero knowledge range proof attesting that the (balance-outgoing+creditLimit) is within MAX>range>=0,
+// creditLimit is 0 when the bank has no CreditLimit, and the reserve of the bank is subtracted unless the payment is urgent
 type GrossSettlementSet struct {
-	BankId    int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
-	PaymentId int32  `protobuf:"varint,2,opt,name=paymentId" json:"paymentId,omitempty"`
-	CmBalance []byte `protobuf:"bytes,3,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
-	Zkrp      []byte `protobuf:"bytes,4,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	BankId    int32          `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	PaymentId int32          `protobuf:"varint,2,opt,name=paymentId" json:"paymentId,omitempty"`
+	CmBalance []byte         `protobuf:"bytes,3,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Zkrp      []byte         `protobuf:"bytes,4,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	ZkrpType  RangeProofType `protobuf:"varint,5,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
 }
 
 func (m *GrossSettlementSet) Reset()                    { *m = GrossSettlementSet{} }
 func (m *GrossSettlementSet) String() string            { return proto1.CompactTextString(m) }
 func (*GrossSettlementSet) ProtoMessage()               {}
-func (*GrossSettlementSet) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*GrossSettlementSet) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *GrossSettlementSet) GetBankId() int32 {
 	if m != nil {
@@ -311,16 +540,29 @@ func (m *GrossSettlementSet) GetZkrp() []byte {
 	return nil
 }
 
+func (m *GrossSettlementSet) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+// round counts the tallies of the resolution, which aborts once round reaches maxRounds without converging
+// both are set by startGLResolution, maxRounds from the number of payments in the outgoing queues of the banks
+// the resolution nets the queues and the accounts of currency only
 type GLRConfiguration struct {
 	GridlockId int32         `protobuf:"varint,1,opt,name=gridlockId" json:"gridlockId,omitempty"`
 	BankIds    []int32       `protobuf:"varint,2,rep,packed,name=bankIds" json:"bankIds,omitempty"`
 	Status     GLRStatusType `protobuf:"varint,3,opt,name=status,enum=proto.GLRStatusType" json:"status,omitempty"`
+	Round      int32         `protobuf:"varint,4,opt,name=round" json:"round,omitempty"`
+	MaxRounds  int32         `protobuf:"varint,5,opt,name=maxRounds" json:"maxRounds,omitempty"`
+	Currency   string        `protobuf:"bytes,6,opt,name=currency" json:"currency,omitempty"`
 }
 
 func (m *GLRConfiguration) Reset()                    { *m = GLRConfiguration{} }
 func (m *GLRConfiguration) String() string            { return proto1.CompactTextString(m) }
 func (*GLRConfiguration) ProtoMessage()               {}
-func (*GLRConfiguration) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*GLRConfiguration) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *GLRConfiguration) GetGridlockId() int32 {
 	if m != nil {
@@ -343,22 +585,48 @@ func (m *GLRConfiguration) GetStatus() GLRStatusType {
 	return GLRStatusType_START
 }
 
-// zkrp1 is cm of (balance - outgoing + incoming) >=0
-// zkrp2 is cm of -(balance - outgoing + incoming - firstFrominfeasibleIds) >= 0
+func (m *GLRConfiguration) GetRound() int32 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *GLRConfiguration) GetMaxRounds() int32 {
+	if m != nil {
+		return m.MaxRounds
+	}
+	return 0
+}
+
+func (m *GLRConfiguration) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// zkrp1 is cm of (balance + creditLimit - outgoing + incoming) >=0
+// zkrp2 is cm of -(balance + creditLimit - outgoing + incoming - firstFrominfeasibleIds) >= 0
+// the reserve of the bank is subtracted from the balance in zkrp1 unless all outgoing payments are urgent,
+// and in zkrp2 unless the first infeasible payment is urgent
+// zkrp optionally replaces zkrp1 and zkrp2 with one aggregated range proof of both commitments
 type GridlockProposal struct {
-	GridlockId    int32   `protobuf:"varint,1,opt,name=gridlockId" json:"gridlockId,omitempty"`
-	BankId        int32   `protobuf:"varint,2,opt,name=bankId" json:"bankId,omitempty"`
-	OutgoingIds   []int32 `protobuf:"varint,3,rep,packed,name=outgoingIds" json:"outgoingIds,omitempty"`
-	InfeasibleIds []int32 `protobuf:"varint,4,rep,packed,name=infeasibleIds" json:"infeasibleIds,omitempty"`
-	CmBalance     []byte  `protobuf:"bytes,5,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
-	Zkrp1         []byte  `protobuf:"bytes,6,opt,name=zkrp1,proto3" json:"zkrp1,omitempty"`
-	Zkrp2         []byte  `protobuf:"bytes,7,opt,name=zkrp2,proto3" json:"zkrp2,omitempty"`
+	GridlockId    int32          `protobuf:"varint,1,opt,name=gridlockId" json:"gridlockId,omitempty"`
+	BankId        int32          `protobuf:"varint,2,opt,name=bankId" json:"bankId,omitempty"`
+	OutgoingIds   []int32        `protobuf:"varint,3,rep,packed,name=outgoingIds" json:"outgoingIds,omitempty"`
+	InfeasibleIds []int32        `protobuf:"varint,4,rep,packed,name=infeasibleIds" json:"infeasibleIds,omitempty"`
+	CmBalance     []byte         `protobuf:"bytes,5,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Zkrp1         []byte         `protobuf:"bytes,6,opt,name=zkrp1,proto3" json:"zkrp1,omitempty"`
+	Zkrp2         []byte         `protobuf:"bytes,7,opt,name=zkrp2,proto3" json:"zkrp2,omitempty"`
+	ZkrpType      RangeProofType `protobuf:"varint,8,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+	Zkrp          []byte         `protobuf:"bytes,9,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
 }
 
 func (m *GridlockProposal) Reset()                    { *m = GridlockProposal{} }
 func (m *GridlockProposal) String() string            { return proto1.CompactTextString(m) }
 func (*GridlockProposal) ProtoMessage()               {}
-func (*GridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*GridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *GridlockProposal) GetGridlockId() int32 {
 	if m != nil {
@@ -409,18 +677,34 @@ func (m *GridlockProposal) GetZkrp2() []byte {
 	return nil
 }
 
+func (m *GridlockProposal) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+func (m *GridlockProposal) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
 type StoredGridlockProposal struct {
-	OutgoingIds   []int32 `protobuf:"varint,1,rep,packed,name=outgoingIds" json:"outgoingIds,omitempty"`
-	InfeasibleIds []int32 `protobuf:"varint,2,rep,packed,name=infeasibleIds" json:"infeasibleIds,omitempty"`
-	CmBalance     []byte  `protobuf:"bytes,3,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
-	Zkrp1         []byte  `protobuf:"bytes,4,opt,name=zkrp1,proto3" json:"zkrp1,omitempty"`
-	Zkrp2         []byte  `protobuf:"bytes,5,opt,name=zkrp2,proto3" json:"zkrp2,omitempty"`
+	OutgoingIds   []int32        `protobuf:"varint,1,rep,packed,name=outgoingIds" json:"outgoingIds,omitempty"`
+	InfeasibleIds []int32        `protobuf:"varint,2,rep,packed,name=infeasibleIds" json:"infeasibleIds,omitempty"`
+	CmBalance     []byte         `protobuf:"bytes,3,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Zkrp1         []byte         `protobuf:"bytes,4,opt,name=zkrp1,proto3" json:"zkrp1,omitempty"`
+	Zkrp2         []byte         `protobuf:"bytes,5,opt,name=zkrp2,proto3" json:"zkrp2,omitempty"`
+	ZkrpType      RangeProofType `protobuf:"varint,6,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+	Zkrp          []byte         `protobuf:"bytes,7,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
 }
 
 func (m *StoredGridlockProposal) Reset()                    { *m = StoredGridlockProposal{} }
 func (m *StoredGridlockProposal) String() string            { return proto1.CompactTextString(m) }
 func (*StoredGridlockProposal) ProtoMessage()               {}
-func (*StoredGridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*StoredGridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *StoredGridlockProposal) GetOutgoingIds() []int32 {
 	if m != nil {
@@ -457,6 +741,20 @@ func (m *StoredGridlockProposal) GetZkrp2() []byte {
 	return nil
 }
 
+func (m *StoredGridlockProposal) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+func (m *StoredGridlockProposal) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
 type TallyGridlockProposal struct {
 	GridlockId int32 `protobuf:"varint,1,opt,name=gridlockId" json:"gridlockId,omitempty"`
 }
@@ -464,7 +762,7 @@ type TallyGridlockProposal struct {
 func (m *TallyGridlockProposal) Reset()                    { *m = TallyGridlockProposal{} }
 func (m *TallyGridlockProposal) String() string            { return proto1.CompactTextString(m) }
 func (*TallyGridlockProposal) ProtoMessage()               {}
-func (*TallyGridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*TallyGridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 func (m *TallyGridlockProposal) GetGridlockId() int32 {
 	if m != nil {
@@ -480,7 +778,7 @@ type NetGridlockProposal struct {
 func (m *NetGridlockProposal) Reset()                    { *m = NetGridlockProposal{} }
 func (m *NetGridlockProposal) String() string            { return proto1.CompactTextString(m) }
 func (*NetGridlockProposal) ProtoMessage()               {}
-func (*NetGridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*NetGridlockProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *NetGridlockProposal) GetGridlockId() int32 {
 	if m != nil {
@@ -500,7 +798,7 @@ type StoredPedersenGroup struct {
 func (m *StoredPedersenGroup) Reset()                    { *m = StoredPedersenGroup{} }
 func (m *StoredPedersenGroup) String() string            { return proto1.CompactTextString(m) }
 func (*StoredPedersenGroup) ProtoMessage()               {}
-func (*StoredPedersenGroup) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*StoredPedersenGroup) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *StoredPedersenGroup) GetP() []byte {
 	if m != nil {
@@ -530,6 +828,628 @@ func (m *StoredPedersenGroup) GetH() []byte {
 	return nil
 }
 
+// PaymentMessageBatch adds several payment messages at once, all in the same currency
+// zkrp is an aggregated range proof of the cmAmount-minAmount*g of all the payments, in order
+type PaymentMessageBatch struct {
+	Payments []*PaymentMessage `protobuf:"bytes,1,rep,name=payments" json:"payments,omitempty"`
+	Zkrp     []byte            `protobuf:"bytes,2,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	ZkrpType RangeProofType    `protobuf:"varint,3,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+}
+
+func (m *PaymentMessageBatch) Reset()                    { *m = PaymentMessageBatch{} }
+func (m *PaymentMessageBatch) String() string            { return proto1.CompactTextString(m) }
+func (*PaymentMessageBatch) ProtoMessage()               {}
+func (*PaymentMessageBatch) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *PaymentMessageBatch) GetPayments() []*PaymentMessage {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+func (m *PaymentMessageBatch) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
+func (m *PaymentMessageBatch) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+// PaymentLimit is stored in PAYMENT_LIMIT table, indexed by currency and BankId
+// every outgoing payment of the bank in currency must prove its amount is at most maxAmount
+type PaymentLimit struct {
+	BankId    int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	MaxAmount int64  `protobuf:"varint,2,opt,name=maxAmount" json:"maxAmount,omitempty"`
+	Currency  string `protobuf:"bytes,3,opt,name=currency" json:"currency,omitempty"`
+}
+
+func (m *PaymentLimit) Reset()                    { *m = PaymentLimit{} }
+func (m *PaymentLimit) String() string            { return proto1.CompactTextString(m) }
+func (*PaymentLimit) ProtoMessage()               {}
+func (*PaymentLimit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *PaymentLimit) GetBankId() int32 {
+	if m != nil {
+		return m.BankId
+	}
+	return 0
+}
+
+func (m *PaymentLimit) GetMaxAmount() int64 {
+	if m != nil {
+		return m.MaxAmount
+	}
+	return 0
+}
+
+func (m *PaymentLimit) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// PaymentMinimum is stored in PAYMENT_MINIMUM table, indexed by currency and BankId
+// every outgoing payment of the bank in currency must prove its amount is at least minAmount, instead of 1
+type PaymentMinimum struct {
+	BankId    int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	MinAmount int64  `protobuf:"varint,2,opt,name=minAmount" json:"minAmount,omitempty"`
+	Currency  string `protobuf:"bytes,3,opt,name=currency" json:"currency,omitempty"`
+}
+
+func (m *PaymentMinimum) Reset()                    { *m = PaymentMinimum{} }
+func (m *PaymentMinimum) String() string            { return proto1.CompactTextString(m) }
+func (*PaymentMinimum) ProtoMessage()               {}
+func (*PaymentMinimum) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *PaymentMinimum) GetBankId() int32 {
+	if m != nil {
+		return m.BankId
+	}
+	return 0
+}
+
+func (m *PaymentMinimum) GetMinAmount() int64 {
+	if m != nil {
+		return m.MinAmount
+	}
+	return 0
+}
+
+func (m *PaymentMinimum) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// ReservedBalance reserves part of the balance of bankId in currency for its urgent payments
+// cmReserve commits to the reserve, zkrp is a UL proof that it is within [0,u^l)
+// the gross settlements of the other payments of the bank prove balance - reserve - amount >= 0
+type ReservedBalance struct {
+	BankId    int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	CmReserve []byte `protobuf:"bytes,2,opt,name=cmReserve,proto3" json:"cmReserve,omitempty"`
+	Zkrp      []byte `protobuf:"bytes,3,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	Currency  string `protobuf:"bytes,4,opt,name=currency" json:"currency,omitempty"`
+}
+
+func (m *ReservedBalance) Reset()                    { *m = ReservedBalance{} }
+func (m *ReservedBalance) String() string            { return proto1.CompactTextString(m) }
+func (*ReservedBalance) ProtoMessage()               {}
+func (*ReservedBalance) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *ReservedBalance) GetBankId() int32 {
+	if m != nil {
+		return m.BankId
+	}
+	return 0
+}
+
+func (m *ReservedBalance) GetCmReserve() []byte {
+	if m != nil {
+		return m.CmReserve
+	}
+	return nil
+}
+
+func (m *ReservedBalance) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
+func (m *ReservedBalance) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// CreditLimit is the intraday credit line of bankId in currency, set by the central bank
+// cmLimit commits to the limit, zkrp is a UL proof that it is within [0,u^l)
+// zkrpSupply is a CCS08 interval proof that the total supply with the limit, the sum of the cmBalance and of the cmLimit
+// of all the banks in currency, is within [0, MaxSupply]
+// the settlements of the bank prove balance - amount + limit >= 0 instead of balance - amount >= 0
+type CreditLimit struct {
+	BankId     int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	CmLimit    []byte `protobuf:"bytes,2,opt,name=cmLimit,proto3" json:"cmLimit,omitempty"`
+	Zkrp       []byte `protobuf:"bytes,3,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	Currency   string `protobuf:"bytes,4,opt,name=currency" json:"currency,omitempty"`
+	ZkrpSupply []byte `protobuf:"bytes,5,opt,name=zkrpSupply,proto3" json:"zkrpSupply,omitempty"`
+}
+
+func (m *CreditLimit) Reset()                    { *m = CreditLimit{} }
+func (m *CreditLimit) String() string            { return proto1.CompactTextString(m) }
+func (*CreditLimit) ProtoMessage()               {}
+func (*CreditLimit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *CreditLimit) GetBankId() int32 {
+	if m != nil {
+		return m.BankId
+	}
+	return 0
+}
+
+func (m *CreditLimit) GetCmLimit() []byte {
+	if m != nil {
+		return m.CmLimit
+	}
+	return nil
+}
+
+func (m *CreditLimit) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
+func (m *CreditLimit) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *CreditLimit) GetZkrpSupply() []byte {
+	if m != nil {
+		return m.ZkrpSupply
+	}
+	return nil
+}
+
+// StoredCreditLimit is stored in CREDIT_LIMIT table, indexed by currency and BankId
+type StoredCreditLimit struct {
+	CmLimit []byte `protobuf:"bytes,1,opt,name=cmLimit,proto3" json:"cmLimit,omitempty"`
+}
+
+func (m *StoredCreditLimit) Reset()                    { *m = StoredCreditLimit{} }
+func (m *StoredCreditLimit) String() string            { return proto1.CompactTextString(m) }
+func (*StoredCreditLimit) ProtoMessage()               {}
+func (*StoredCreditLimit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *StoredCreditLimit) GetCmLimit() []byte {
+	if m != nil {
+		return m.CmLimit
+	}
+	return nil
+}
+
+// RepaymentProof shows the bank has repaid its overdraft in currency
+// zkrp is a range proof that cmBalance, the current balance of the account, is within MAX> range >=0
+type RepaymentProof struct {
+	BankId    int32          `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	CmBalance []byte         `protobuf:"bytes,2,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Zkrp      []byte         `protobuf:"bytes,3,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	ZkrpType  RangeProofType `protobuf:"varint,4,opt,name=zkrpType,enum=proto.RangeProofType" json:"zkrpType,omitempty"`
+	Currency  string         `protobuf:"bytes,5,opt,name=currency" json:"currency,omitempty"`
+}
+
+func (m *RepaymentProof) Reset()                    { *m = RepaymentProof{} }
+func (m *RepaymentProof) String() string            { return proto1.CompactTextString(m) }
+func (*RepaymentProof) ProtoMessage()               {}
+func (*RepaymentProof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *RepaymentProof) GetBankId() int32 {
+	if m != nil {
+		return m.BankId
+	}
+	return 0
+}
+
+func (m *RepaymentProof) GetCmBalance() []byte {
+	if m != nil {
+		return m.CmBalance
+	}
+	return nil
+}
+
+func (m *RepaymentProof) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
+func (m *RepaymentProof) GetZkrpType() RangeProofType {
+	if m != nil {
+		return m.ZkrpType
+	}
+	return RangeProofType_UL
+}
+
+func (m *RepaymentProof) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// StoredRepayment is stored in REPAYMENT table, indexed by currency and BankId
+// it is the balance of the last RepaymentProof of the bank and the unix time of its transaction
+type StoredRepayment struct {
+	CmBalance []byte `protobuf:"bytes,1,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Timestamp int64  `protobuf:"varint,2,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *StoredRepayment) Reset()                    { *m = StoredRepayment{} }
+func (m *StoredRepayment) String() string            { return proto1.CompactTextString(m) }
+func (*StoredRepayment) ProtoMessage()               {}
+func (*StoredRepayment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *StoredRepayment) GetCmBalance() []byte {
+	if m != nil {
+		return m.CmBalance
+	}
+	return nil
+}
+
+func (m *StoredRepayment) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+// ProofULVerifier is the versioned encoding of a UL range proof on the curve of its params
+// u and l are those of the params the proof was made with, so the proof can be read without knowing them
+// scalars are big-endian, group elements are in their marshaled form
+type ProofULVerifier struct {
+	Scheme  RangeProofType `protobuf:"varint,1,opt,name=scheme,enum=proto.RangeProofType" json:"scheme,omitempty"`
+	Version int32          `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+	U       int64          `protobuf:"varint,3,opt,name=u" json:"u,omitempty"`
+	L       int64          `protobuf:"varint,4,opt,name=l" json:"l,omitempty"`
+	V       [][]byte       `protobuf:"bytes,5,rep,name=v" json:"v,omitempty"`
+	D       []byte         `protobuf:"bytes,6,opt,name=d,proto3" json:"d,omitempty"`
+	Cm      []byte         `protobuf:"bytes,7,opt,name=cm,proto3" json:"cm,omitempty"`
+	A       [][]byte       `protobuf:"bytes,8,rep,name=a" json:"a,omitempty"`
+	Zsig    [][]byte       `protobuf:"bytes,9,rep,name=zsig" json:"zsig,omitempty"`
+	Zv      [][]byte       `protobuf:"bytes,10,rep,name=zv" json:"zv,omitempty"`
+	C       []byte         `protobuf:"bytes,11,opt,name=c,proto3" json:"c,omitempty"`
+	Zr      []byte         `protobuf:"bytes,12,opt,name=zr,proto3" json:"zr,omitempty"`
+	Curve   CurveType      `protobuf:"varint,13,opt,name=curve,enum=proto.CurveType" json:"curve,omitempty"`
+}
+
+func (m *ProofULVerifier) Reset()                    { *m = ProofULVerifier{} }
+func (m *ProofULVerifier) String() string            { return proto1.CompactTextString(m) }
+func (*ProofULVerifier) ProtoMessage()               {}
+func (*ProofULVerifier) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *ProofULVerifier) GetScheme() RangeProofType {
+	if m != nil {
+		return m.Scheme
+	}
+	return RangeProofType_UL
+}
+
+func (m *ProofULVerifier) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ProofULVerifier) GetU() int64 {
+	if m != nil {
+		return m.U
+	}
+	return 0
+}
+
+func (m *ProofULVerifier) GetL() int64 {
+	if m != nil {
+		return m.L
+	}
+	return 0
+}
+
+func (m *ProofULVerifier) GetV() [][]byte {
+	if m != nil {
+		return m.V
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetD() []byte {
+	if m != nil {
+		return m.D
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetCm() []byte {
+	if m != nil {
+		return m.Cm
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetA() [][]byte {
+	if m != nil {
+		return m.A
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetZsig() [][]byte {
+	if m != nil {
+		return m.Zsig
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetZv() [][]byte {
+	if m != nil {
+		return m.Zv
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetC() []byte {
+	if m != nil {
+		return m.C
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetZr() []byte {
+	if m != nil {
+		return m.Zr
+	}
+	return nil
+}
+
+func (m *ProofULVerifier) GetCurve() CurveType {
+	if m != nil {
+		return m.Curve
+	}
+	return CurveType_BN256
+}
+
+// ParamsULVerifier is the versioned encoding of the UL verifier params stored in ledger table PEDERSEN_CURVE
+type ParamsULVerifier struct {
+	Scheme  RangeProofType `protobuf:"varint,1,opt,name=scheme,enum=proto.RangeProofType" json:"scheme,omitempty"`
+	Version int32          `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+	U       int64          `protobuf:"varint,3,opt,name=u" json:"u,omitempty"`
+	L       int64          `protobuf:"varint,4,opt,name=l" json:"l,omitempty"`
+	H       []byte         `protobuf:"bytes,5,opt,name=h,proto3" json:"h,omitempty"`
+	Pubk    []byte         `protobuf:"bytes,6,opt,name=pubk,proto3" json:"pubk,omitempty"`
+	Curve   CurveType      `protobuf:"varint,7,opt,name=curve,enum=proto.CurveType" json:"curve,omitempty"`
+}
+
+func (m *ParamsULVerifier) Reset()                    { *m = ParamsULVerifier{} }
+func (m *ParamsULVerifier) String() string            { return proto1.CompactTextString(m) }
+func (*ParamsULVerifier) ProtoMessage()               {}
+func (*ParamsULVerifier) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *ParamsULVerifier) GetScheme() RangeProofType {
+	if m != nil {
+		return m.Scheme
+	}
+	return RangeProofType_UL
+}
+
+func (m *ParamsULVerifier) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ParamsULVerifier) GetU() int64 {
+	if m != nil {
+		return m.U
+	}
+	return 0
+}
+
+func (m *ParamsULVerifier) GetL() int64 {
+	if m != nil {
+		return m.L
+	}
+	return 0
+}
+
+func (m *ParamsULVerifier) GetH() []byte {
+	if m != nil {
+		return m.H
+	}
+	return nil
+}
+
+func (m *ParamsULVerifier) GetPubk() []byte {
+	if m != nil {
+		return m.Pubk
+	}
+	return nil
+}
+
+func (m *ParamsULVerifier) GetCurve() CurveType {
+	if m != nil {
+		return m.Curve
+	}
+	return CurveType_BN256
+}
+
+// ProofCCS08 is the versioned encoding of a CCS08 interval proof, the two UL proofs of its bounds
+type ProofCCS08 struct {
+	P1 *ProofULVerifier `protobuf:"bytes,1,opt,name=p1" json:"p1,omitempty"`
+	P2 *ProofULVerifier `protobuf:"bytes,2,opt,name=p2" json:"p2,omitempty"`
+}
+
+func (m *ProofCCS08) Reset()                    { *m = ProofCCS08{} }
+func (m *ProofCCS08) String() string            { return proto1.CompactTextString(m) }
+func (*ProofCCS08) ProtoMessage()               {}
+func (*ProofCCS08) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *ProofCCS08) GetP1() *ProofULVerifier {
+	if m != nil {
+		return m.P1
+	}
+	return nil
+}
+
+func (m *ProofCCS08) GetP2() *ProofULVerifier {
+	if m != nil {
+		return m.P2
+	}
+	return nil
+}
+
+// EqualityProof proves two pedersen commitments, on the curves of their params, commit to the same value
+// z is a big-endian integer below 2^251, z1 and z2 are scalars of the two curves
+type EqualityProof struct {
+	T1 []byte `protobuf:"bytes,1,opt,name=t1,proto3" json:"t1,omitempty"`
+	T2 []byte `protobuf:"bytes,2,opt,name=t2,proto3" json:"t2,omitempty"`
+	Z  []byte `protobuf:"bytes,3,opt,name=z,proto3" json:"z,omitempty"`
+	Z1 []byte `protobuf:"bytes,4,opt,name=z1,proto3" json:"z1,omitempty"`
+	Z2 []byte `protobuf:"bytes,5,opt,name=z2,proto3" json:"z2,omitempty"`
+}
+
+func (m *EqualityProof) Reset()                    { *m = EqualityProof{} }
+func (m *EqualityProof) String() string            { return proto1.CompactTextString(m) }
+func (*EqualityProof) ProtoMessage()               {}
+func (*EqualityProof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *EqualityProof) GetT1() []byte {
+	if m != nil {
+		return m.T1
+	}
+	return nil
+}
+
+func (m *EqualityProof) GetT2() []byte {
+	if m != nil {
+		return m.T2
+	}
+	return nil
+}
+
+func (m *EqualityProof) GetZ() []byte {
+	if m != nil {
+		return m.Z
+	}
+	return nil
+}
+
+func (m *EqualityProof) GetZ1() []byte {
+	if m != nil {
+		return m.Z1
+	}
+	return nil
+}
+
+func (m *EqualityProof) GetZ2() []byte {
+	if m != nil {
+		return m.Z2
+	}
+	return nil
+}
+
+// AccountMigration is the account of bankId in currency committed on the curve of the new params of a CurveMigration
+// zkrp is a UL range proof of cmBalance with the new params
+// equalityProof is a marshaled EqualityProof that cmBalance commits to the same balance as the stored account
+type AccountMigration struct {
+	BankId        int32  `protobuf:"varint,1,opt,name=bankId" json:"bankId,omitempty"`
+	CmBalance     []byte `protobuf:"bytes,2,opt,name=cmBalance,proto3" json:"cmBalance,omitempty"`
+	Zkrp          []byte `protobuf:"bytes,3,opt,name=zkrp,proto3" json:"zkrp,omitempty"`
+	EqualityProof []byte `protobuf:"bytes,4,opt,name=equalityProof,proto3" json:"equalityProof,omitempty"`
+	Currency      string `protobuf:"bytes,5,opt,name=currency" json:"currency,omitempty"`
+}
+
+func (m *AccountMigration) Reset()                    { *m = AccountMigration{} }
+func (m *AccountMigration) String() string            { return proto1.CompactTextString(m) }
+func (*AccountMigration) ProtoMessage()               {}
+func (*AccountMigration) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *AccountMigration) GetBankId() int32 {
+	if m != nil {
+		return m.BankId
+	}
+	return 0
+}
+
+func (m *AccountMigration) GetCmBalance() []byte {
+	if m != nil {
+		return m.CmBalance
+	}
+	return nil
+}
+
+func (m *AccountMigration) GetZkrp() []byte {
+	if m != nil {
+		return m.Zkrp
+	}
+	return nil
+}
+
+func (m *AccountMigration) GetEqualityProof() []byte {
+	if m != nil {
+		return m.EqualityProof
+	}
+	return nil
+}
+
+func (m *AccountMigration) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// CurveMigration moves the UL params and every stored account to the curve of paramsUL
+type CurveMigration struct {
+	ParamsUL []byte              `protobuf:"bytes,1,opt,name=paramsUL,proto3" json:"paramsUL,omitempty"`
+	Accounts []*AccountMigration `protobuf:"bytes,2,rep,name=accounts" json:"accounts,omitempty"`
+}
+
+func (m *CurveMigration) Reset()                    { *m = CurveMigration{} }
+func (m *CurveMigration) String() string            { return proto1.CompactTextString(m) }
+func (*CurveMigration) ProtoMessage()               {}
+func (*CurveMigration) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *CurveMigration) GetParamsUL() []byte {
+	if m != nil {
+		return m.ParamsUL
+	}
+	return nil
+}
+
+func (m *CurveMigration) GetAccounts() []*AccountMigration {
+	if m != nil {
+		return m.Accounts
+	}
+	return nil
+}
+
 func init() {
 	proto1.RegisterType((*BankAccount)(nil), "proto.BankAccount")
 	proto1.RegisterType((*MintAccount)(nil), "proto.MintAccount")
@@ -537,6 +1457,8 @@ func init() {
 	proto1.RegisterType((*PaymentMessage)(nil), "proto.PaymentMessage")
 	proto1.RegisterType((*StoredPaymentMessage)(nil), "proto.StoredPaymentMessage")
 	proto1.RegisterType((*StoredPaymentQueue)(nil), "proto.StoredPaymentQueue")
+	proto1.RegisterType((*StoredCurrencies)(nil), "proto.StoredCurrencies")
+	proto1.RegisterType((*StoredPaymentSum)(nil), "proto.StoredPaymentSum")
 	proto1.RegisterType((*GrossSettlementSet)(nil), "proto.GrossSettlementSet")
 	proto1.RegisterType((*GLRConfiguration)(nil), "proto.GLRConfiguration")
 	proto1.RegisterType((*GridlockProposal)(nil), "proto.GridlockProposal")
@@ -544,51 +1466,116 @@ func init() {
 	proto1.RegisterType((*TallyGridlockProposal)(nil), "proto.TallyGridlockProposal")
 	proto1.RegisterType((*NetGridlockProposal)(nil), "proto.NetGridlockProposal")
 	proto1.RegisterType((*StoredPedersenGroup)(nil), "proto.StoredPedersenGroup")
+	proto1.RegisterType((*PaymentMessageBatch)(nil), "proto.PaymentMessageBatch")
+	proto1.RegisterType((*PaymentLimit)(nil), "proto.PaymentLimit")
+	proto1.RegisterType((*PaymentMinimum)(nil), "proto.PaymentMinimum")
+	proto1.RegisterType((*ReservedBalance)(nil), "proto.ReservedBalance")
+	proto1.RegisterType((*CreditLimit)(nil), "proto.CreditLimit")
+	proto1.RegisterType((*StoredCreditLimit)(nil), "proto.StoredCreditLimit")
+	proto1.RegisterType((*RepaymentProof)(nil), "proto.RepaymentProof")
+	proto1.RegisterType((*StoredRepayment)(nil), "proto.StoredRepayment")
+	proto1.RegisterType((*ProofULVerifier)(nil), "proto.ProofULVerifier")
+	proto1.RegisterType((*ParamsULVerifier)(nil), "proto.ParamsULVerifier")
+	proto1.RegisterType((*ProofCCS08)(nil), "proto.ProofCCS08")
+	proto1.RegisterType((*EqualityProof)(nil), "proto.EqualityProof")
+	proto1.RegisterType((*AccountMigration)(nil), "proto.AccountMigration")
+	proto1.RegisterType((*CurveMigration)(nil), "proto.CurveMigration")
 	proto1.RegisterEnum("proto.StatusType", StatusType_name, StatusType_value)
 	proto1.RegisterEnum("proto.GLRStatusType", GLRStatusType_name, GLRStatusType_value)
+	proto1.RegisterEnum("proto.RangeProofType", RangeProofType_name, RangeProofType_value)
+	proto1.RegisterEnum("proto.CurveType", CurveType_name, CurveType_value)
 }
 
 func init() { proto1.RegisterFile("gridlock.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 620 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x94, 0xdf, 0x4e, 0xdb, 0x30,
-	0x14, 0xc6, 0xe7, 0xb4, 0x29, 0x70, 0x0a, 0xa8, 0x18, 0x86, 0xa2, 0x69, 0x9a, 0xaa, 0x68, 0x93,
-	0x3a, 0x34, 0x21, 0x51, 0x36, 0xed, 0x6a, 0x17, 0xa5, 0x54, 0x15, 0x12, 0x20, 0x96, 0x64, 0xdb,
-	0x75, 0x48, 0x4d, 0x88, 0x9a, 0xc6, 0xc1, 0x76, 0x26, 0x81, 0xf6, 0x14, 0x7b, 0x87, 0xdd, 0xec,
-	0x99, 0xf6, 0x30, 0x93, 0x1d, 0x37, 0x7f, 0x3a, 0x3a, 0x86, 0x76, 0xd5, 0x9c, 0x2f, 0x27, 0x3e,
-	0xbf, 0xf3, 0x9d, 0x53, 0xc3, 0x66, 0xc8, 0xa2, 0x49, 0x4c, 0x83, 0xe9, 0x7e, 0xca, 0xa8, 0xa0,
-	0xd8, 0x54, 0x3f, 0xf6, 0x17, 0x68, 0x1f, 0xf9, 0xc9, 0x74, 0x10, 0x04, 0x34, 0x4b, 0x04, 0xde,
-	0x85, 0xd6, 0xa5, 0x9f, 0x4c, 0x4f, 0x26, 0x16, 0xea, 0xa2, 0x9e, 0xe9, 0xe8, 0x08, 0x3f, 0x87,
-	0xb5, 0x60, 0x76, 0xe4, 0xc7, 0x7e, 0x12, 0x10, 0xcb, 0xe8, 0xa2, 0xde, 0xba, 0x53, 0x0a, 0x18,
-	0x43, 0xf3, 0x6e, 0xca, 0x52, 0xab, 0xa1, 0x5e, 0xa8, 0x67, 0xfb, 0x03, 0xb4, 0xcf, 0xa2, 0x44,
-	0xcc, 0x0f, 0xde, 0x87, 0x55, 0x3f, 0x7f, 0xe4, 0x16, 0xea, 0x36, 0x7a, 0xed, 0x3e, 0xce, 0x41,
-	0xf6, 0x2b, 0xe5, 0x9d, 0x22, 0xc7, 0x3e, 0x80, 0x2d, 0x57, 0x50, 0x46, 0x26, 0x55, 0xba, 0x1a,
-	0x05, 0x5a, 0xa0, 0xb0, 0xbf, 0x23, 0xd8, 0xbc, 0xf0, 0x6f, 0x67, 0x24, 0x11, 0x67, 0x84, 0x73,
-	0x3f, 0x24, 0xf2, 0x83, 0x34, 0x57, 0x8a, 0x8e, 0x4a, 0x41, 0x36, 0xcb, 0x49, 0x32, 0x21, 0x4c,
-	0x75, 0x64, 0x3a, 0x3a, 0xc2, 0xcf, 0x60, 0x95, 0x91, 0x80, 0x44, 0x5f, 0x09, 0x53, 0x2d, 0x99,
-	0x4e, 0x11, 0xcb, 0x77, 0xc1, 0x6c, 0x30, 0x93, 0x38, 0x56, 0x53, 0x11, 0x14, 0x71, 0x61, 0x83,
-	0x59, 0xb1, 0xe1, 0x07, 0x82, 0x9d, 0xbc, 0x91, 0x05, 0xb4, 0xb2, 0x38, 0x5a, 0x5a, 0xdc, 0xf8,
-	0xbf, 0xe2, 0xf8, 0x35, 0xb4, 0xb8, 0xf0, 0x45, 0xc6, 0xad, 0x56, 0x17, 0xf5, 0x36, 0xfb, 0x5b,
-	0xda, 0x72, 0x57, 0x89, 0xde, 0x6d, 0x4a, 0x1c, 0x9d, 0x60, 0xbf, 0x05, 0x5c, 0xc3, 0xfc, 0x98,
-	0x91, 0x8c, 0xe0, 0x17, 0x00, 0x85, 0x5d, 0xf9, 0xdc, 0x4c, 0xa7, 0xa2, 0xd8, 0xdf, 0x00, 0x8f,
-	0x19, 0xe5, 0xdc, 0x25, 0x42, 0xc4, 0x44, 0xaa, 0x2e, 0xf9, 0xeb, 0x12, 0x95, 0xd3, 0x30, 0x16,
-	0xa7, 0x51, 0x1b, 0x6e, 0x63, 0xd9, 0x8a, 0x35, 0x2b, 0xde, 0xde, 0x41, 0x67, 0x7c, 0xea, 0x0c,
-	0x69, 0x72, 0x15, 0x85, 0x19, 0xf3, 0x45, 0x44, 0x13, 0x49, 0x3c, 0x5f, 0xf4, 0xa2, 0x7e, 0x45,
-	0xc1, 0x16, 0xac, 0xe4, 0x34, 0xdc, 0x32, 0x54, 0x3b, 0xf3, 0x10, 0xbf, 0x29, 0xcc, 0x6a, 0x28,
-	0xb3, 0x76, 0xb4, 0x59, 0xe3, 0x53, 0xe7, 0x1e, 0xbf, 0x7e, 0x21, 0xe8, 0x8c, 0xf5, 0xb1, 0x17,
-	0x8c, 0xa6, 0x94, 0xfb, 0xf1, 0x83, 0xc5, 0x4b, 0x63, 0x8c, 0x9a, 0x31, 0x5d, 0x68, 0xd3, 0x4c,
-	0x84, 0x34, 0x4a, 0x42, 0x09, 0xd6, 0x50, 0x60, 0x55, 0x09, 0xbf, 0x84, 0x8d, 0x28, 0xb9, 0x22,
-	0x3e, 0x8f, 0x2e, 0x63, 0x22, 0x73, 0x9a, 0x2a, 0xa7, 0x2e, 0xd6, 0x2d, 0x34, 0x17, 0x2d, 0xdc,
-	0x01, 0x53, 0xda, 0x76, 0xa0, 0x96, 0x61, 0xdd, 0xc9, 0x83, 0xb9, 0xda, 0xb7, 0x56, 0x4a, 0xb5,
-	0x6f, 0xff, 0x44, 0xb0, 0x9b, 0xef, 0xc3, 0x1f, 0x4d, 0x2e, 0xc0, 0xa2, 0x7f, 0x80, 0x35, 0x1e,
-	0x84, 0x6d, 0x2c, 0x85, 0x6d, 0xde, 0x0b, 0x6b, 0x56, 0x61, 0xdf, 0xc3, 0x53, 0xcf, 0x8f, 0xe3,
-	0xdb, 0xc7, 0xce, 0xc3, 0x7e, 0x07, 0xdb, 0xe7, 0x44, 0x3c, 0xfa, 0xb3, 0x33, 0xd8, 0xd6, 0xff,
-	0x15, 0x32, 0x21, 0x8c, 0x93, 0x64, 0xcc, 0x68, 0x96, 0xe2, 0x75, 0x40, 0xa9, 0xbe, 0x95, 0x90,
-	0x8a, 0x42, 0x7d, 0x53, 0xa2, 0x50, 0x46, 0x37, 0xba, 0x49, 0x74, 0x23, 0xa3, 0x6b, 0xdd, 0x18,
-	0xba, 0xde, 0x7b, 0x05, 0x50, 0x2e, 0x18, 0x06, 0x68, 0x0d, 0x86, 0xde, 0xc9, 0xe7, 0x51, 0xe7,
-	0x09, 0x6e, 0xc3, 0x8a, 0x3b, 0xf2, 0xbc, 0xd3, 0xd1, 0x71, 0x07, 0xed, 0x1d, 0xc2, 0x46, 0x6d,
-	0x15, 0xf1, 0x1a, 0x98, 0xae, 0x37, 0x70, 0x3c, 0x9d, 0xf8, 0x69, 0x38, 0x1c, 0xb9, 0x6e, 0x07,
-	0xc9, 0x13, 0xce, 0x47, 0x9e, 0x37, 0x3a, 0xee, 0x18, 0x97, 0x2d, 0xb5, 0xc3, 0x87, 0xbf, 0x03,
-	0x00, 0x00, 0xff, 0xff, 0xc0, 0x5a, 0x78, 0xdb, 0xfe, 0x05, 0x00, 0x00,
+	// 1394 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0xce, 0x52, 0xa6, 0x7e, 0x46, 0xb2, 0xcc, 0x6c, 0x9c, 0x94, 0x28, 0x82, 0x42, 0x20, 0xd2,
+	0xc0, 0x35, 0x9a, 0xa0, 0x52, 0x9a, 0x36, 0xa7, 0xa2, 0x96, 0xa2, 0x1a, 0x01, 0xe4, 0x9f, 0xae,
+	0xe4, 0x9c, 0x5a, 0xa0, 0x34, 0xb5, 0x96, 0x09, 0x8b, 0x3f, 0x59, 0x92, 0x6e, 0xa4, 0x47, 0xe8,
+	0x25, 0x3d, 0xb7, 0x40, 0x2f, 0x3d, 0xf5, 0xd4, 0x57, 0x68, 0xaf, 0x7d, 0x9b, 0xbe, 0x41, 0xb1,
+	0xcb, 0x25, 0x45, 0xb2, 0x96, 0x2c, 0xb7, 0x01, 0x72, 0xe2, 0xce, 0xec, 0xec, 0xce, 0x7c, 0xdf,
+	0xce, 0xce, 0x0e, 0xa1, 0x39, 0x61, 0xf6, 0x78, 0xea, 0x59, 0x17, 0x8f, 0x7d, 0xe6, 0x85, 0x1e,
+	0x56, 0xc5, 0xc7, 0xf8, 0x01, 0x41, 0xbd, 0x6b, 0xba, 0x17, 0x7b, 0x96, 0xe5, 0x45, 0x6e, 0x88,
+	0xef, 0x41, 0xf9, 0xd4, 0x74, 0x2f, 0x5e, 0x8c, 0x75, 0xd4, 0x42, 0x3b, 0x2a, 0x91, 0x12, 0xbe,
+	0x0f, 0x35, 0xcb, 0xe9, 0x9a, 0x53, 0xd3, 0xb5, 0xa8, 0xae, 0xb4, 0xd0, 0x4e, 0x83, 0x2c, 0x14,
+	0x18, 0xc3, 0xc6, 0xfc, 0x82, 0xf9, 0x7a, 0x49, 0x4c, 0x88, 0x31, 0x6e, 0x43, 0x95, 0x7f, 0x47,
+	0x33, 0x9f, 0xea, 0x1b, 0x2d, 0xb4, 0xd3, 0xec, 0xdc, 0x8d, 0x5d, 0x3f, 0x26, 0xa6, 0x3b, 0xa1,
+	0xc7, 0xcc, 0xf3, 0xce, 0xf8, 0x24, 0x49, 0xcd, 0x8c, 0x3f, 0x10, 0xd4, 0x0f, 0x6c, 0x37, 0x4c,
+	0x82, 0x79, 0x0c, 0x55, 0x33, 0x1e, 0x06, 0x3a, 0x6a, 0x95, 0x76, 0xea, 0x1d, 0x2c, 0xb7, 0xc8,
+	0x84, 0x4c, 0x52, 0x9b, 0x34, 0x0c, 0x65, 0x49, 0x18, 0xa5, 0xb5, 0xc2, 0xc0, 0x1f, 0x00, 0xf0,
+	0xf1, 0x30, 0xf2, 0xfd, 0xe9, 0x4c, 0xc4, 0xde, 0x20, 0x19, 0x0d, 0x7e, 0x1f, 0xaa, 0x56, 0xc4,
+	0x18, 0x75, 0xad, 0x99, 0xae, 0xb6, 0xd0, 0x4e, 0x8d, 0xa4, 0xb2, 0x71, 0x04, 0xb7, 0x87, 0xa1,
+	0xc7, 0xe8, 0x38, 0x4b, 0x6a, 0x8e, 0x3c, 0x54, 0x24, 0x4f, 0xcc, 0x12, 0x1a, 0x50, 0x76, 0x99,
+	0xa1, 0x56, 0x2a, 0x8c, 0x9f, 0x15, 0x68, 0x1e, 0x9b, 0x33, 0x87, 0xba, 0xe1, 0x01, 0x0d, 0x02,
+	0x73, 0x22, 0x16, 0xf8, 0xb1, 0x26, 0x3d, 0xa6, 0x85, 0x82, 0x9f, 0x60, 0x40, 0xdd, 0x31, 0x65,
+	0x62, 0x2f, 0x95, 0x48, 0x89, 0x47, 0xcd, 0xa8, 0x45, 0xed, 0x4b, 0xca, 0x04, 0x11, 0x2a, 0x49,
+	0x65, 0x81, 0xc8, 0xd9, 0x73, 0x78, 0xb0, 0x12, 0x6f, 0x2a, 0xa7, 0xa4, 0xaa, 0x4b, 0x48, 0x2d,
+	0xaf, 0x47, 0xea, 0x7d, 0xa8, 0xf1, 0xf1, 0xc0, 0x76, 0xec, 0x50, 0xaf, 0xc4, 0x28, 0x53, 0x05,
+	0x0f, 0xc0, 0x67, 0xb6, 0xc7, 0xec, 0x70, 0xa6, 0x57, 0xe3, 0xe0, 0x12, 0x39, 0x47, 0x77, 0xad,
+	0x40, 0xf7, 0x8f, 0x0a, 0x6c, 0xc7, 0x7c, 0x17, 0x38, 0x5a, 0xb0, 0x80, 0x96, 0xb2, 0xa0, 0xfc,
+	0x4f, 0x16, 0x3e, 0x82, 0x72, 0x10, 0x9a, 0x61, 0x14, 0x48, 0x0e, 0x6e, 0x4b, 0x0e, 0x86, 0x42,
+	0x29, 0xf0, 0x4b, 0x83, 0x1c, 0x61, 0x95, 0xf5, 0x08, 0xfb, 0xaf, 0x94, 0x7c, 0x0a, 0x38, 0xc7,
+	0xc8, 0xd7, 0x11, 0x8d, 0x44, 0x4e, 0xa7, 0x29, 0x12, 0x5f, 0x26, 0x95, 0x64, 0x34, 0x46, 0x07,
+	0xb4, 0x78, 0x55, 0x2f, 0xde, 0xc7, 0xa6, 0x01, 0x5f, 0x63, 0xa5, 0x92, 0x58, 0x53, 0x23, 0x19,
+	0x8d, 0xf1, 0x05, 0x68, 0x39, 0x4f, 0xc3, 0xc8, 0xc1, 0xdb, 0xa0, 0x5a, 0xce, 0x30, 0x72, 0x64,
+	0x9a, 0xc7, 0x82, 0xd0, 0x0a, 0x5a, 0x63, 0xca, 0x63, 0xc1, 0xf8, 0x1d, 0x01, 0xde, 0x67, 0x5e,
+	0x10, 0x0c, 0x69, 0x18, 0x4e, 0xa9, 0xd8, 0x82, 0xae, 0x2c, 0x41, 0x8b, 0xb4, 0x57, 0x8a, 0x69,
+	0x9f, 0xbb, 0x63, 0xa5, 0x65, 0x05, 0x6a, 0x63, 0x49, 0x12, 0xab, 0xeb, 0x15, 0xa8, 0xbf, 0x10,
+	0x68, 0xfb, 0x03, 0xd2, 0xf3, 0xdc, 0x33, 0x7b, 0x12, 0x31, 0x33, 0xb4, 0x3d, 0x97, 0xd3, 0x94,
+	0xd4, 0xd6, 0x34, 0xe6, 0x8c, 0x06, 0xeb, 0x50, 0x89, 0x11, 0x04, 0xba, 0x22, 0x78, 0x4f, 0x44,
+	0xfc, 0x71, 0x9a, 0x40, 0x71, 0x65, 0xda, 0x96, 0xfe, 0xf7, 0x07, 0xe4, 0x8a, 0x1c, 0xda, 0x06,
+	0x95, 0x79, 0x91, 0x3b, 0x16, 0x20, 0x54, 0x12, 0x0b, 0x1c, 0xb7, 0x63, 0xbe, 0x26, 0x7c, 0x1c,
+	0x08, 0x18, 0x2a, 0x59, 0x28, 0x72, 0x89, 0x52, 0x2e, 0x24, 0xca, 0xaf, 0x0a, 0x68, 0xfb, 0x32,
+	0xcc, 0x63, 0xe6, 0xf9, 0x5e, 0x60, 0x4e, 0xaf, 0x05, 0xb3, 0x38, 0x1c, 0x25, 0x77, 0x38, 0x2d,
+	0xa8, 0x7b, 0x51, 0x38, 0xf1, 0x6c, 0x77, 0xc2, 0x81, 0x96, 0x04, 0xd0, 0xac, 0x0a, 0x3f, 0x80,
+	0x4d, 0xdb, 0x3d, 0xa3, 0x66, 0x60, 0x9f, 0x4e, 0x29, 0xb7, 0xd9, 0x10, 0x36, 0x79, 0x65, 0xfe,
+	0x18, 0xd5, 0xe2, 0x31, 0x6e, 0x83, 0xca, 0xcf, 0xa2, 0x2d, 0xb0, 0x34, 0x48, 0x2c, 0x24, 0xda,
+	0x8e, 0x2c, 0x2b, 0xb1, 0x90, 0x3b, 0xde, 0xea, 0x7a, 0x57, 0x2e, 0xc9, 0x92, 0xda, 0x22, 0x4b,
+	0x8c, 0xbf, 0x11, 0xdc, 0x8b, 0xb3, 0xfc, 0x5f, 0x5c, 0x15, 0x30, 0xa3, 0x35, 0x30, 0x2b, 0xd7,
+	0x62, 0x2e, 0x2d, 0xc5, 0xbc, 0x71, 0x25, 0x66, 0x75, 0x19, 0xe6, 0xf2, 0xcd, 0x30, 0x57, 0x32,
+	0x98, 0x3f, 0x87, 0xbb, 0x23, 0x73, 0x3a, 0x9d, 0xdd, 0x34, 0x3b, 0x8c, 0xa7, 0x70, 0xe7, 0x90,
+	0x86, 0x37, 0x5e, 0x76, 0x00, 0x77, 0x64, 0x21, 0xa1, 0x63, 0xca, 0x02, 0xea, 0xee, 0x33, 0x2f,
+	0xf2, 0x71, 0x03, 0x90, 0x2f, 0xeb, 0x08, 0x12, 0xd2, 0x44, 0x3e, 0x8f, 0x68, 0xc2, 0xa5, 0x57,
+	0x92, 0x2b, 0xf4, 0x8a, 0x4b, 0xe7, 0x92, 0x1f, 0x74, 0x6e, 0xbc, 0x41, 0x70, 0x27, 0xff, 0x1c,
+	0x74, 0xcd, 0xd0, 0x3a, 0xe7, 0xec, 0xc8, 0x7a, 0x91, 0xb4, 0x13, 0x09, 0x3b, 0x79, 0x6b, 0x92,
+	0x9a, 0xbd, 0xa5, 0x8e, 0xc2, 0xf8, 0x0e, 0x1a, 0xd2, 0x45, 0xfc, 0xdc, 0xad, 0x28, 0x71, 0x8e,
+	0xf9, 0x5a, 0x3e, 0x41, 0xdc, 0x67, 0x89, 0x2c, 0x14, 0xb9, 0xcb, 0x5c, 0x2a, 0x5c, 0xe6, 0xd3,
+	0x45, 0x97, 0x60, 0xbb, 0xb6, 0x13, 0x39, 0x2b, 0x7d, 0xd8, 0x6e, 0xc1, 0x87, 0xed, 0xae, 0xe1,
+	0xe3, 0x7b, 0xd8, 0x92, 0x5d, 0xc9, 0x38, 0x49, 0xce, 0x95, 0xed, 0xe2, 0xb2, 0x9e, 0xe6, 0xca,
+	0x76, 0x31, 0xeb, 0x78, 0xa3, 0xe0, 0xf8, 0x0d, 0x82, 0x7a, 0x8f, 0xd1, 0xb1, 0x7d, 0x0d, 0x7d,
+	0x3a, 0x54, 0x2c, 0x47, 0x98, 0x48, 0x9f, 0x89, 0x78, 0x53, 0x8f, 0x85, 0x16, 0x50, 0x2d, 0xb6,
+	0x80, 0xc6, 0xa3, 0xa4, 0xcd, 0xcb, 0x86, 0x95, 0x71, 0x8f, 0x72, 0xee, 0x8d, 0xdf, 0x10, 0x34,
+	0x09, 0x95, 0x59, 0x25, 0x12, 0xe4, 0x9d, 0x36, 0xda, 0x2b, 0x3b, 0xd8, 0x03, 0xd8, 0x8a, 0xa1,
+	0xa5, 0x01, 0x5f, 0xdf, 0xbf, 0x86, 0xb6, 0x43, 0x83, 0xd0, 0x74, 0xfc, 0x24, 0xa1, 0x52, 0x85,
+	0xf1, 0x93, 0x02, 0x5b, 0x22, 0x84, 0x93, 0xc1, 0x4b, 0xca, 0xec, 0x33, 0x9b, 0x32, 0xfc, 0x08,
+	0xca, 0x81, 0x75, 0x4e, 0x9d, 0x78, 0xb3, 0xa5, 0xf1, 0x4a, 0x23, 0xce, 0xeb, 0x25, 0x65, 0x81,
+	0xed, 0xb9, 0xf2, 0xd1, 0x49, 0x44, 0x7e, 0xef, 0x23, 0xc1, 0x45, 0x89, 0xa0, 0x88, 0x4b, 0x53,
+	0xc1, 0x40, 0x89, 0xa0, 0x29, 0x97, 0x2e, 0x75, 0xb5, 0x55, 0xe2, 0x35, 0xe1, 0x92, 0x4b, 0x63,
+	0xf9, 0x6a, 0xa0, 0x31, 0x6e, 0x82, 0x62, 0x39, 0xb2, 0xe4, 0x29, 0x96, 0xc3, 0x67, 0x4d, 0xbd,
+	0x1a, 0xdb, 0x9a, 0x82, 0xe4, 0xc0, 0x9e, 0xe8, 0x35, 0xa1, 0x10, 0x63, 0xbe, 0x62, 0x7e, 0xa9,
+	0x83, 0xd0, 0x28, 0x73, 0xb1, 0x9f, 0xa5, 0xd7, 0xe3, 0xfd, 0x2c, 0x31, 0xcb, 0xf4, 0x46, 0xbc,
+	0xdf, 0x9c, 0xe1, 0x87, 0xa0, 0x5a, 0x11, 0x4f, 0xfd, 0x4d, 0x81, 0x4f, 0x93, 0xf8, 0x7a, 0x5c,
+	0x27, 0xa0, 0xc5, 0xd3, 0xc6, 0x9f, 0x08, 0xb4, 0x63, 0x93, 0x99, 0x4e, 0xf0, 0x0e, 0xd8, 0x39,
+	0x97, 0x79, 0x8d, 0xce, 0x39, 0x62, 0x3f, 0x3a, 0xbd, 0x90, 0x04, 0x89, 0xf1, 0x02, 0x43, 0x65,
+	0x35, 0x86, 0x6f, 0x00, 0x44, 0x50, 0xbd, 0xde, 0xf0, 0x93, 0x67, 0xf8, 0x21, 0x28, 0x7e, 0x5b,
+	0x04, 0x5e, 0xef, 0xdc, 0x4b, 0xaa, 0x6b, 0xfe, 0xf8, 0x89, 0xe2, 0xb7, 0x85, 0x5d, 0x47, 0x57,
+	0xae, 0xb1, 0xeb, 0x18, 0xdf, 0xc2, 0x66, 0xff, 0x55, 0x64, 0x4e, 0xed, 0x70, 0x26, 0xa6, 0x39,
+	0xd5, 0x61, 0x5b, 0x26, 0xa1, 0x12, 0xb6, 0x85, 0xdc, 0x91, 0x17, 0x45, 0x09, 0x3b, 0x1c, 0xd8,
+	0x3c, 0x79, 0x18, 0xe6, 0xe2, 0x60, 0x92, 0x97, 0x53, 0x99, 0x0b, 0xeb, 0x79, 0xf2, 0x66, 0x2a,
+	0xf3, 0x8e, 0xf1, 0x0b, 0x02, 0x4d, 0xfe, 0xa5, 0x1d, 0xd8, 0x13, 0xd9, 0xd0, 0xbd, 0xbd, 0xab,
+	0xf9, 0x00, 0x36, 0x69, 0x36, 0x7a, 0x19, 0x49, 0x5e, 0xb9, 0xf2, 0x36, 0x9a, 0xd0, 0x14, 0x8c,
+	0x2f, 0xa2, 0xe3, 0xff, 0x05, 0x32, 0x65, 0x24, 0x0d, 0xa9, 0x8c, 0x9f, 0x64, 0x7e, 0x98, 0x15,
+	0xf1, 0xc2, 0xbd, 0x27, 0xb9, 0x2d, 0x82, 0x5c, 0xfc, 0x35, 0xef, 0x7e, 0x08, 0xb0, 0xe8, 0x36,
+	0x31, 0x40, 0x79, 0xaf, 0x37, 0x7a, 0xf1, 0xb2, 0xaf, 0xdd, 0xc2, 0x75, 0xa8, 0x0c, 0xfb, 0xa3,
+	0xd1, 0xa0, 0xff, 0x5c, 0x43, 0xbb, 0x5f, 0xc2, 0x66, 0xae, 0x2f, 0xc5, 0x35, 0x50, 0x87, 0xa3,
+	0x3d, 0x32, 0x92, 0x86, 0x27, 0xbd, 0x5e, 0x7f, 0x38, 0xd4, 0x10, 0xdf, 0xe1, 0xb0, 0x3f, 0x1a,
+	0xf5, 0x9f, 0x6b, 0x0a, 0x9f, 0xd8, 0xeb, 0x1e, 0x11, 0x2e, 0x94, 0x76, 0x9f, 0x41, 0x33, 0x9f,
+	0xc3, 0xb8, 0x0c, 0xca, 0xc9, 0x40, 0xbb, 0x85, 0xb7, 0xa0, 0xde, 0x3d, 0x19, 0x0c, 0xfa, 0xa3,
+	0x63, 0x72, 0x74, 0xf4, 0x95, 0x86, 0xf0, 0x26, 0xd4, 0xba, 0x47, 0x84, 0x1c, 0x1d, 0xf4, 0xf7,
+	0x0e, 0x35, 0x65, 0xf7, 0x01, 0xd4, 0xd2, 0xbc, 0xe3, 0x7e, 0xbb, 0x87, 0x9d, 0xa7, 0x9f, 0x69,
+	0xb7, 0x70, 0x03, 0xaa, 0xdd, 0xc1, 0xb0, 0xdd, 0x79, 0xf2, 0xac, 0xad, 0xa1, 0xd3, 0xb2, 0x80,
+	0xfa, 0xe4, 0x1f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x03, 0x00, 0xf6, 0x3e, 0x67, 0xf9, 0xeb, 0x10,
+	0x00, 0x00,
 }
@@ -1,22 +1,21 @@
 package settlement
 
 import (
-	"bytes"
 	"encoding/base64"
 	"errors"
 	"fmt"
 
+	"github.com/blockchain-research/gridlock/commitment"
 	"github.com/blockchain-research/gridlock/common"
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/ledger"
 	pb "github.com/blockchain-research/gridlock/proto"
 	"github.com/blockchain-research/gridlock/zkrangeproof"
 	"github.com/golang/protobuf/proto"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-var logger = shim.NewLogger("gridlock")
+var logger = ledger.NewLogger("gridlock")
 
-func GrossSettlement(stub shim.ChaincodeStubInterface, args []string) error {
+func GrossSettlement(stub ledger.Ledger, args []string) error {
 	logger.Info("GrossSettlement of a certain bank")
 
 	if len(args) != 1 {
@@ -35,8 +34,15 @@ func GrossSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
+	//the payment is settled against the accounts and the queues of its currency
+	paymentMessage, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(settlementSet.PaymentId))
+	if err != nil {
+		return err
+	}
+	currency := paymentMessage.Currency
+
 	//Optional based on queue model: verify the strict priority
-	success, err := VerifyStrictPriority(stub, settlementSet.BankId, []int32{settlementSet.PaymentId})
+	success, err := VerifyStrictPriority(stub, settlementSet.BankId, currency, []int32{settlementSet.PaymentId})
 	if err != nil {
 		return err
 	}
@@ -49,8 +55,10 @@ func GrossSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 	success, err = verifySettlementSet(
 		stub,
 		settlementSet.BankId,
+		currency,
 		settlementSet.CmBalance,
 		settlementSet.Zkrp,
+		settlementSet.ZkrpType,
 		[]int32{settlementSet.PaymentId},
 	)
 	if err != nil {
@@ -67,14 +75,15 @@ func GrossSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	//update ledger: update account balance of sender
-	paymentMessage, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(settlementSet.PaymentId))
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, currency)
 	if err != nil {
 		return err
 	}
+	//update ledger: update account balance of sender
 	err = common.UpdateAccountFromLedger(
 		stub,
-		common.AccountTable+fmt.Sprint(paymentMessage.Sender),
+		scheme,
+		common.InCurrency(common.AccountTable, currency)+fmt.Sprint(paymentMessage.Sender),
 		false, //decrease
 		paymentMessage.CmAmount)
 	if err != nil {
@@ -83,20 +92,21 @@ func GrossSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 	//update ledger: update account balance of receiver
 	err = common.UpdateAccountFromLedger(
 		stub,
-		common.AccountTable+fmt.Sprint(paymentMessage.Receiver),
+		scheme,
+		common.InCurrency(common.AccountTable, currency)+fmt.Sprint(paymentMessage.Receiver),
 		true, //increase
 		paymentMessage.CmAmount)
 	if err != nil {
 		return err
 	}
-	//update ledger: remove paymentId from outgoing queue in sender
-	err = common.RemoveQueueElementFromLedger(stub, common.InQueueTable+fmt.Sprint(paymentMessage.Receiver), []int32{settlementSet.PaymentId})
+	//update ledger: remove paymentId from incoming queue of receiver
+	err = common.RemoveQueueElementFromLedger(stub, scheme, common.InCurrency(common.InQueueTable, currency), paymentMessage.Receiver, []int32{settlementSet.PaymentId})
 	if err != nil {
 		return err
 	}
 
-	//update ledger: remove paymentId from incoming queue of receiver
-	err = common.RemoveQueueElementFromLedger(stub, common.OutQueueTable+fmt.Sprint(paymentMessage.Sender), []int32{settlementSet.PaymentId})
+	//update ledger: remove paymentId from outgoing queue in sender
+	err = common.RemoveQueueElementFromLedger(stub, scheme, common.InCurrency(common.OutQueueTable, currency), paymentMessage.Sender, []int32{settlementSet.PaymentId})
 	if err != nil {
 		return err
 	}
@@ -104,30 +114,57 @@ func GrossSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 	return nil
 }
 
-//verify settlement set: current bank balance is the same as CmBalance in settlementSet
-//zkrp committed value in cmBalance-outgoing is within [0,u^l)
-func verifySettlementSet(stub shim.ChaincodeStubInterface, bankId int32, cmBalance []byte, zkrp []byte, paymentIds []int32) (bool, error) {
+//verify settlement set: current bank balance in currency is the same as CmBalance in settlementSet
+//zkrp committed value in cmBalance-outgoing+creditLimit is within the range of the zkrpType scheme, the outgoing payments are in currency,
+//the reserve of the bank is subtracted from cmBalance unless all the outgoing payments are urgent
+func verifySettlementSet(stub ledger.Ledger, bankId int32, currency string, cmBalance []byte, zkrp []byte, zkrpType pb.RangeProofType, paymentIds []int32) (bool, error) {
 	if bankId > common.NumOfBanks || bankId <= 0 {
 		logger.Info("Invalid bankId ", bankId)
 		return false, nil
 	}
 
-	//get stored params
-	paramsUL, err := common.GetParamsFromLedger(stub)
+	//the proof only bounds the balance if its commitment is binding
+	err := common.CheckBindingFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+
+	//get the verifier of the scheme the proof is encoded with
+	verifier, err := common.GetRangeProofVerifierFromLedger(stub, currency, zkrpType)
 	if err != nil {
 		logger.Info("Failed to read parameters from ledger")
 		return false, err
 	}
 
 	//get current bank balance and check it is the same as CmBalance in the settlementSet
-	account, err := common.GetAccountFromLedger(stub, common.AccountTable+fmt.Sprint(bankId))
-	if bytes.Compare(account.CmBalance, cmBalance) != 0 {
+	account, err := common.GetAccountFromLedger(stub, common.InCurrency(common.AccountTable, currency)+fmt.Sprint(bankId))
+	if err != nil {
+		return false, err
+	}
+	//either may be compressed, so the commitments are compared
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, currency)
+	if err != nil {
+		return false, err
+	}
+	cmSum, err := common.UnmarshalCommitment(scheme, account.CmBalance)
+	if err != nil {
+		return false, err
+	}
+	cmSettlement, ok := scheme.Unmarshal(cmBalance)
+	if !ok || !cmSum.Equal(cmSettlement) {
 		logger.Info("The cmBalance in account from ledger is different from the cmBalance in settlement set")
 		return false, nil
 	}
 
-	//calculate the post-balance commitment = cmBalance - outgoing cmAmount
-	cmSum, _ := new(bn256.G2).Unmarshal(account.CmBalance)
+	//the bank may overdraw its account up to its credit line, a commitment to 0 when it has none
+	cmLimit, err := common.GetCreditLimitFromLedger(stub, scheme, common.InCurrency(common.CreditTable, currency)+fmt.Sprint(bankId))
+	if err != nil {
+		return false, err
+	}
+	cmSum = cmSum.Add(cmLimit)
+
+	//calculate the post-balance commitment = cmBalance - outgoing cmAmount + creditLimit
+	urgent := true
 	for _, id := range paymentIds {
 		payment, err := common.GetPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(id))
 		if err != nil {
@@ -141,68 +178,69 @@ func verifySettlementSet(stub shim.ChaincodeStubInterface, bankId int32, cmBalan
 			logger.Error("The payment is already settled")
 			return false, errors.New("The payment is already settled")
 		}
-		cmAmount, _ := new(bn256.G2).Unmarshal(payment.CmAmount)
-		cmSum = new(bn256.G2).Add(cmSum, new(bn256.G2).Neg(cmAmount))
+		if payment.Currency != currency {
+			logger.Errorf("The payment %d is not in currency %s", id, currency)
+			return false, errors.New("payment's currency is not the currency of the balance")
+		}
+		cmAmount, err := common.UnmarshalCommitment(scheme, payment.CmAmount)
+		if err != nil {
+			return false, err
+		}
+		cmSum = commitment.Sub(cmSum, cmAmount)
+		if payment.Priority > common.UrgentPriority {
+			urgent = false
+		}
+	}
+	//only the urgent payments may draw on the reserve
+	if !urgent {
+		cmReserve, err := common.GetReserveFromAccount(scheme, account)
+		if err != nil {
+			return false, err
+		}
+		cmSum = commitment.Sub(cmSum, cmReserve)
 	}
 
 	//check that cmSum's range proof
-	proof := new(zkrangeproof.ProofULVerifier).Unmarshal(zkrp, common.L)
-	//verify the committed value in the proof is the same as cmBalance-outgoing cmAmount
-	if bytes.Compare(proof.C.Marshal(), cmSum.Marshal()) != 0 {
-		logger.Info("The committed values does not match the one in the proof")
-		return false, nil
+	points, err := common.RangeProofPoints(cmSum)
+	if err != nil {
+		return false, err
+	}
+	result, err := verifier.VerifyCommitment(zkrp, points[0])
+	if err != nil {
+		logger.Error("Failed to verify the zero knowledge range proof")
+		return false, err
 	}
-	//verify the range proof
-	result, _ := zkrangeproof.VerifyUL(proof, *paramsUL)
 	if result != true {
 		logger.Error("The zero knowledge range proof verification failed. The committed value in receiving amount is not within range.")
-		return false, errors.New("ZKP verification failed")
+		return false, zkrangeproof.ErrVerificationFailed
 	}
-	logger.Info("The committed post balance is whtin range (0,u^l)")
+	logger.Info("The committed post balance is within range")
 
 	return true, nil
 }
 
 //verify the settlementSet's PaymentIds are obey strict priority queue model
 //i.e., if a queue has {1,2,3,4} You can only settle based on order {1,2,3} You cannot settle{1,2,4}
-func VerifyStrictPriority(stub shim.ChaincodeStubInterface, bankId int32, paymentIds []int32) (bool, error) {
-	outQueue, err := common.GetQueueFromLedger(stub, common.OutQueueTable+fmt.Sprint(bankId))
-
+//the queue is ordered by priority and then by paymentId, so paymentIds must be the head of the queue of currency
+func VerifyStrictPriority(stub ledger.Ledger, bankId int32, currency string, paymentIds []int32) (bool, error) {
+	head, err := common.GetPaymentQueueHeadFromLedger(stub, common.InCurrency(common.OutQueueTable, currency), bankId, len(paymentIds))
 	if err != nil {
 		logger.Error("Failed to get outgoing queue from ledger")
 		return false, err
 	}
-	infeasible := []int32{}
-	for _, qid := range outQueue.PaymentIds {
-		isInfeasible := true
-		for _, pid := range paymentIds {
-			if qid == pid {
-				isInfeasible = false
-			}
-		}
-		if isInfeasible == true {
-			infeasible = append(infeasible, qid)
-		}
-	}
-	if len(infeasible) == 0 {
-		return true, nil
+	if len(head) != len(paymentIds) {
+		return false, nil
 	}
-
-	//the smallest id in infeasible should be larger than the largest id in paymentIds
-	smallestQid := infeasible[0]
-	biggestPid := paymentIds[0]
-	for _, qid := range infeasible {
-		if qid < smallestQid {
-			smallestQid = qid
-		}
+	inHead := map[int32]bool{}
+	for _, qid := range head {
+		inHead[qid] = true
 	}
+	settled := map[int32]bool{}
 	for _, pid := range paymentIds {
-		if pid > biggestPid {
-			biggestPid = pid
+		if !inHead[pid] || settled[pid] {
+			return false, nil
 		}
-	}
-	if smallestQid <= biggestPid {
-		return false, nil
+		settled[pid] = true
 	}
 
 	return true, nil
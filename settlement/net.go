@@ -5,14 +5,14 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/blockchain-research/gridlock/commitment"
 	"github.com/blockchain-research/gridlock/common"
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/ledger"
 	pb "github.com/blockchain-research/gridlock/proto"
 	"github.com/golang/protobuf/proto"
-	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-func NetGLSettlement(stub shim.ChaincodeStubInterface, args []string) error {
+func NetGLSettlement(stub ledger.Ledger, args []string) error {
 	if len(args) != 1 {
 		return errors.New("Need exactly one argument: <base64-encoded-NetGridlockProposal-object>")
 	}
@@ -35,20 +35,32 @@ func NetGLSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	//check whether gridlock configuration is in right status
+	//check whether gridlock configuration is in right status, a resolution is settled once and is then NETTED
 	if config.Status != pb.GLRStatusType_SUCCESS {
 		logger.Error("GLR in wrong status")
+		return errors.New("GLR in wrong status")
+	}
+
+	scheme, err := common.GetCommitmentSchemeFromLedger(stub, config.Currency)
+	if err != nil {
 		return err
 	}
 
 	//update bank account one by one
-	bankBalance := map[int32]*bn256.G2{}
+	bankAccount := map[int32]*pb.StoredBankAccount{}
+	bankBalance := map[int32]commitment.Commitment{}
 	outgoingIds := map[int32][]int32{}
 	incomingIds := map[int32][]int32{}
+	//the payments marked settled by this transaction still read ACTIVE
+	settled := map[int32]bool{}
 	for _, bankId := range config.BankIds {
 		//get bank account
-		account, err := common.GetAccountFromLedger(stub, common.AccountTable+fmt.Sprint(bankId))
-		bankBalance[bankId], _ = new(bn256.G2).Unmarshal(account.CmBalance)
+		account, err := common.GetAccountFromLedger(stub, common.InCurrency(common.AccountTable, config.Currency)+fmt.Sprint(bankId))
+		if err != nil {
+			return err
+		}
+		bankAccount[bankId] = account
+		bankBalance[bankId], err = common.UnmarshalCommitment(scheme, account.CmBalance)
 		if err != nil {
 			return err
 		}
@@ -68,11 +80,24 @@ func NetGLSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 			if err != nil {
 				return err
 			}
-			cmAmount, _ := new(bn256.G2).Unmarshal(paymentMessage.CmAmount)
+			if paymentMessage.Currency != config.Currency {
+				logger.Errorf("The payment %d is not in the currency of the gridlock resolution", pid)
+				return fmt.Errorf("The payment %d is not in currency %s", pid, config.Currency)
+			}
+			//a settled payment is no longer queued, its amount is not in the sums of the queues
+			if paymentMessage.Status != pb.StatusType_ACTIVE || settled[pid] {
+				logger.Errorf("The payment %d is already settled", pid)
+				return fmt.Errorf("The payment %d is already settled", pid)
+			}
+			settled[pid] = true
+			cmAmount, err := common.UnmarshalCommitment(scheme, paymentMessage.CmAmount)
+			if err != nil {
+				return err
+			}
 			//substract amount from the sender
-			bankBalance[paymentMessage.Sender] = new(bn256.G2).Add(bankBalance[paymentMessage.Sender], new(bn256.G2).Neg(cmAmount))
+			bankBalance[paymentMessage.Sender] = commitment.Sub(bankBalance[paymentMessage.Sender], cmAmount)
 			//add amount to the receiver
-			bankBalance[paymentMessage.Receiver] = new(bn256.G2).Add(bankBalance[paymentMessage.Receiver], cmAmount)
+			bankBalance[paymentMessage.Receiver] = bankBalance[paymentMessage.Receiver].Add(cmAmount)
 
 			//update ledger: mark PaymentMessage as settled
 			err = common.MarkPaymentFromLedger(stub, common.MessageTable+fmt.Sprint(pid))
@@ -88,25 +113,29 @@ func NetGLSettlement(stub shim.ChaincodeStubInterface, args []string) error {
 
 	//update each bank'a account and queue
 	for _, bankId := range config.BankIds {
-		//update account
+		//update account, the reserve is kept
+		bankAccount[bankId].CmBalance = bankBalance[bankId].Marshal()
 		err := common.AddAccountToLedger(
 			stub,
-			common.AccountTable+fmt.Sprint(bankId),
-			&pb.StoredBankAccount{CmBalance: bankBalance[bankId].Marshal()},
+			common.InCurrency(common.AccountTable, config.Currency)+fmt.Sprint(bankId),
+			bankAccount[bankId],
 		)
 		if err != nil {
 			return err
 		}
-		//update ledger: remove paymentId from outgoing queue in sender
-		err = common.RemoveQueueElementFromLedger(stub, common.InQueueTable+fmt.Sprint(bankId), incomingIds[bankId])
+		//update ledger: remove paymentId from incoming queue of receiver
+		err = common.RemoveQueueElementFromLedger(stub, scheme, common.InCurrency(common.InQueueTable, config.Currency), bankId, incomingIds[bankId])
 		if err != nil {
 			return err
 		}
-		//update ledger: remove paymentId from incoming queue of receiver
-		err = common.RemoveQueueElementFromLedger(stub, common.OutQueueTable+fmt.Sprint(bankId), outgoingIds[bankId])
+		//update ledger: remove paymentId from outgoing queue in sender
+		err = common.RemoveQueueElementFromLedger(stub, scheme, common.InCurrency(common.OutQueueTable, config.Currency), bankId, outgoingIds[bankId])
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+
+	//the resolution can not be settled again
+	config.Status = pb.GLRStatusType_NETTED
+	return common.AddGLRConfigurationToLedger(stub, common.ConfigTable+fmt.Sprint(config.GridlockId), config)
 }
@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pr "github.com/hyperledger/fabric/protos/peer"
+)
+
+//CentralBank is the identity of the central bank in the tests
+var CentralBank = []byte("central bank")
+
+//Chaincode submits the transactions of a chaincode as Creator, the mock stub has no identity
+type Chaincode struct {
+	shim.Chaincode
+	Creator []byte
+}
+
+//NewChaincode returns cc with the central bank as creator
+func NewChaincode(cc shim.Chaincode) *Chaincode {
+	return &Chaincode{Chaincode: cc, Creator: CentralBank}
+}
+
+func (c *Chaincode) Init(stub shim.ChaincodeStubInterface) pr.Response {
+	return c.Chaincode.Init(creatorStub{stub, c.Creator})
+}
+
+func (c *Chaincode) Invoke(stub shim.ChaincodeStubInterface) pr.Response {
+	return c.Chaincode.Invoke(creatorStub{stub, c.Creator})
+}
+
+//creatorStub returns creator as the identity that submitted the transaction
+type creatorStub struct {
+	shim.ChaincodeStubInterface
+	creator []byte
+}
+
+func (s creatorStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
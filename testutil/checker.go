@@ -3,8 +3,12 @@ package testutil
 import (
 	bp "bytes"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/blockchain-research/gridlock/common"
+	"github.com/blockchain-research/gridlock/ledger"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
@@ -62,3 +66,66 @@ func (c *_checker) State(value []byte, name string) {
 		c.t.FailNow()
 	}
 }
+
+//Queue checks that the queue of bankId in table holds paymentIds in this order
+func (c *_checker) Queue(paymentIds []int32, table string, bankId int32) {
+	state := ledger.NewMemory()
+	for key, value := range c.stub.State {
+		state.Put(key, value)
+	}
+	queue, err := common.GetPaymentQueueFromLedger(state, table, bankId)
+	if err != nil {
+		logger.Error("Queue", table, bankId, "failed to get value", err)
+		c.t.FailNow()
+	}
+	if len(queue) != len(paymentIds) || (len(queue) > 0 && !reflect.DeepEqual(queue, paymentIds)) {
+		logger.Error("Queue", table, bankId, "was", queue, "not", paymentIds, "as expected")
+		c.t.FailNow()
+	}
+}
+
+func (c *_checker) InvokeFail(tx string, function string, args []string) {
+	var byteArgs [][]byte
+	byteArgs = append(byteArgs, []byte(function))
+	byteArgs = append(byteArgs, stringArrayToByteMatrix(args)...)
+	response := c.stub.MockInvoke(tx, byteArgs)
+
+	if response.GetStatus() == shim.OK {
+		c.t.Log("Invoke", function, "succeeded but was expected to fail")
+		c.t.FailNow()
+	}
+}
+
+//InvokeFailWithCode checks that the invoke fails with an error message starting with code
+func (c *_checker) InvokeFailWithCode(tx string, function string, args []string, code string) {
+	var byteArgs [][]byte
+	byteArgs = append(byteArgs, []byte(function))
+	byteArgs = append(byteArgs, stringArrayToByteMatrix(args)...)
+	response := c.stub.MockInvoke(tx, byteArgs)
+
+	if response.GetStatus() == shim.OK {
+		c.t.Log("Invoke", function, "succeeded but was expected to fail")
+		c.t.FailNow()
+	}
+	if !strings.HasPrefix(response.GetMessage(), code+": ") {
+		c.t.Log("Invoke", function, "failed with", response.GetMessage(), "but was expected to fail with", code)
+		c.t.FailNow()
+	}
+}
+
+//InvokeFailWithMessage checks that the invoke fails with an error message containing reason
+func (c *_checker) InvokeFailWithMessage(tx string, function string, args []string, reason string) {
+	var byteArgs [][]byte
+	byteArgs = append(byteArgs, []byte(function))
+	byteArgs = append(byteArgs, stringArrayToByteMatrix(args)...)
+	response := c.stub.MockInvoke(tx, byteArgs)
+
+	if response.GetStatus() == shim.OK {
+		c.t.Log("Invoke", function, "succeeded but was expected to fail")
+		c.t.FailNow()
+	}
+	if !strings.Contains(response.GetMessage(), reason) {
+		c.t.Log("Invoke", function, "failed with", response.GetMessage(), "but was expected to fail with", reason)
+		c.t.FailNow()
+	}
+}
@@ -3,11 +3,16 @@ package testutil
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
+	"github.com/blockchain-research/gridlock/borromean"
+	"github.com/blockchain-research/gridlock/bulletproofs"
 	"github.com/blockchain-research/gridlock/common"
 	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 	"github.com/blockchain-research/gridlock/pedersencurve"
 	"github.com/blockchain-research/gridlock/pedersengroup"
 	pb "github.com/blockchain-research/gridlock/proto"
@@ -27,7 +32,70 @@ type IDList struct {
 	InfeasibleIds []int32
 }
 
-var pUL, _ = zkrangeproof.SetupUL(common.U, common.L)
+//the u and l of the sample UL params, amounts and balances are proven within [0,U^L)
+const (
+	U = 10
+	L = 10
+)
+
+var pUL, _ = zkrangeproof.SetupUL(U, L)
+var hBN256, _ = pairing.ToBN256G2(pUL.H)
+//the bulletproof and borromean params prove values in [0,2^32), the largest range of either within [0,U^L)
+var pBP, _ = bulletproofs.Setup(32, hBN256)
+var pBR, _ = borromean.Setup(32, hBN256)
+
+//UseCurve switches the sample UL params and commitments to the curve id until restore is called,
+//the bulletproof and borromean params stay on BN256
+func UseCurve(id pairing.ID) (restore func()) {
+	saved := pUL
+	pUL, _ = zkrangeproof.SetupULOnCurve(pairing.MustLookup(id), U, L)
+	return func() { pUL = saved }
+}
+
+//UseLegacyH switches the sample UL params to the legacy h until restore is called, as in the ledgers set up before h was hashed
+func UseLegacyH() (restore func()) {
+	saved := pUL
+	pUL.H = zkrangeproof.LegacyH(pUL.H.Curve())
+	return func() { pUL = saved }
+}
+
+//UseCurrency switches the sample commitments and range proofs to the generator of currency until restore is called,
+//the params of the ledger are sampled before, with the generator they store
+func UseCurrency(currency string) (restore func()) {
+	savedUL, savedBP, savedBR := pUL, pBP, pBR
+	pUL.H = common.CurrencyGenerator(pUL.H, currency)
+	bp, br := *pBP, *pBR
+	bp.H = common.CurrencyGeneratorBN256(pBP.H, currency)
+	br.H = common.CurrencyGeneratorBN256(pBR.H, currency)
+	pBP, pBR = &bp, &br
+	return func() { pUL, pBP, pBR = savedUL, savedBP, savedBR }
+}
+
+//toBN256 returns the commitments as points of BN256, for the schemes that only exist on it
+func toBN256(cms []pairing.G2) []*bn256.G2 {
+	bnCms := []*bn256.G2{}
+	for _, cm := range cms {
+		bnCm, _ := pairing.ToBN256G2(cm)
+		bnCms = append(bnCms, bnCm)
+	}
+	return bnCms
+}
+
+//proveRange returns the range proof of the value committed in cm, encoded with the proofType scheme
+func proveRange(value *big.Int, r *big.Int, cm pairing.G2, proofType pb.RangeProofType) []byte {
+	switch proofType {
+	case pb.RangeProofType_BULLETPROOF:
+		proof, _ := bulletproofs.Prove(value, r, toBN256([]pairing.G2{cm})[0], pBP)
+		return proof.Marshal()
+	case pb.RangeProofType_BORROMEAN:
+		proof, _ := borromean.Prove(value, r, toBN256([]pairing.G2{cm})[0], pBR)
+		return proof.Marshal()
+	}
+	proof, _ := zkrangeproof.ProveUL(value, r, cm, pUL)
+	proofOut := zkrangeproof.GenerateProofVerifier(proof)
+	proofBytes, _ := proofOut.MarshalProto()
+	return proofBytes
+}
 
 // SamplePedersenGroup returns a sample pedersengroup message
 func SamplePedersenGroup() (_ *pb.StoredPedersenGroup, err error) {
@@ -50,40 +118,147 @@ func SamplePedersenGroup() (_ *pb.StoredPedersenGroup, err error) {
 // This setup step should be done at the client side
 func SampleParamsUL() (_ []byte) {
 	paramsVerifier := zkrangeproof.GenerateParamsVefifier(&pUL)
-	paramsBytes := paramsVerifier.Marshal()
+	paramsBytes, _ := paramsVerifier.MarshalProto()
 	return paramsBytes
 }
 
-//SampleMintAccount returns a sample MintAccount message
-func SampleMintAccount(balances map[int32]*big.Int) (*pb.MintAccount, map[int32]*big.Int) {
+//SampleParamsULWithRange returns sample UL params for the interval [0,u^l), on the curve of SampleParamsUL
+func SampleParamsULWithRange(u, l int64) []byte {
+	p, _ := zkrangeproof.SetupULOnCurve(pUL.H.Curve(), u, l)
+	paramsVerifier := zkrangeproof.GenerateParamsVefifier(&p)
+	paramsBytes, _ := paramsVerifier.MarshalProto()
+	return paramsBytes
+}
+
+// SampleBulletproofParams returns the sample bulletproof params sharing H with SampleParamsUL
+func SampleBulletproofParams() []byte {
+	return pBP.Marshal()
+}
+
+// SampleBorromeanParams returns the sample borromean params sharing H with SampleParamsUL
+func SampleBorromeanParams() []byte {
+	return pBR.Marshal()
+}
+
+//SampleMintAccount returns a sample MintAccount message, the accounts are ordered by bankId
+func SampleMintAccount(balances map[int32]*big.Int, proofType pb.RangeProofType) (*pb.MintAccount, map[int32]*big.Int) {
 	ma := &pb.MintAccount{
 		Accounts: []*pb.BankAccount{},
 	}
+	bankIds := []int32{}
+	for key := range balances {
+		bankIds = append(bankIds, key)
+	}
+	sort.Slice(bankIds, func(i, j int) bool { return bankIds[i] < bankIds[j] })
 	randomness := map[int32]*big.Int{}
-	for key, val := range balances {
-		r, _ := rand.Int(rand.Reader, bn256.Order)
+	for _, key := range bankIds {
+		val := balances[key]
+		r, _ := rand.Int(rand.Reader, pUL.H.Curve().Order())
 		randomness[key] = r
 		c := pedersencurve.Commit(val, r, pUL.H)
-		proof, _ := zkrangeproof.ProveUL(val, r, c, pUL)
-		proofOut := zkrangeproof.GenerateProofVerifier(proof)
 		ba := &pb.BankAccount{
 			BankId:    key,
-			CmBalance: c.Marshal(),
-			Zkrp:      proofOut.Marshal(),
+			CmBalance: c.MarshalCompressed(),
+			Zkrp:      proveRange(val, r, c, proofType),
+			ZkrpType:  proofType,
 		}
 		ma.Accounts = append(ma.Accounts, ba)
 	}
+	AddSupplyProof(ma, balances, randomness)
 	return ma, randomness
 }
 
-// SamplePaymentMessage returns a sample payment message
+//AddSupplyProof sets the zkrpSupply of the mint to an interval proof that the sum of the balances is within [0, MaxSupply],
+//the total supply when no other account nor credit limit is on the ledger. The zkrpSupply is nil when the sum is above MaxSupply
+func AddSupplyProof(ma *pb.MintAccount, balances map[int32]*big.Int, randomness map[int32]*big.Int) {
+	supply := new(big.Int)
+	r := new(big.Int)
+	for bankId, val := range balances {
+		supply.Add(supply, val)
+		r.Add(r, randomness[bankId])
+	}
+	ma.ZkrpSupply = SampleSupplyProof(supply, r)
+}
+
+//SampleSupplyProof returns the interval proof that the total supply, committed with randomness r, is within [0, MaxSupply],
+//nil when it is not
+func SampleSupplyProof(supply *big.Int, r *big.Int) []byte {
+	r = new(big.Int).Mod(r, pUL.H.Curve().Order())
+	params := zkrangeproof.GenerateParamsVefifier(&pUL)
+	return proveLimit(supply, r, pedersencurve.Commit(supply, r, pUL.H), common.MaxSupply(&params))
+}
+
+//SampleCurveMigration returns the CurveMigration to the curve id of the accounts with balances and randomness,
+//ordered by bankId, and the randomness of the new accounts. It switches the sample params to the curve id until restore is called
+func SampleCurveMigration(id pairing.ID, balances map[int32]*big.Int, randomness map[int32]*big.Int) (*pb.CurveMigration, map[int32]*big.Int, func()) {
+	return SampleCurveMigrationInCurrencies(id, balances, randomness, nil)
+}
+
+//SampleCurveMigrationInCurrencies is SampleCurveMigration with the accounts of the banks in currencies, in the default currency
+//for the banks not in currencies
+func SampleCurveMigrationInCurrencies(id pairing.ID, balances map[int32]*big.Int, randomness map[int32]*big.Int, currencies map[int32]string) (*pb.CurveMigration, map[int32]*big.Int, func()) {
+	oldUL := pUL
+	restore := UseCurve(id)
+	migration := &pb.CurveMigration{ParamsUL: SampleParamsUL()}
+	bankIds := []int32{}
+	for key := range balances {
+		bankIds = append(bankIds, key)
+	}
+	sort.Slice(bankIds, func(i, j int) bool { return bankIds[i] < bankIds[j] })
+	newRandomness := map[int32]*big.Int{}
+	for _, key := range bankIds {
+		val := balances[key]
+		currency := currencies[key]
+		oldH := common.CurrencyGenerator(oldUL.H, currency)
+		restoreCurrency := UseCurrency(currency)
+		r, _ := rand.Int(rand.Reader, pUL.H.Curve().Order())
+		newRandomness[key] = r
+		c := pedersencurve.Commit(val, r, pUL.H)
+		equality, _ := pedersencurve.ProveEquality(val, randomness[key], r, oldH, pUL.H)
+		equalityBytes, _ := equality.MarshalProto()
+		migration.Accounts = append(migration.Accounts, &pb.AccountMigration{
+			BankId:        key,
+			Currency:      currency,
+			CmBalance:     c.MarshalCompressed(),
+			Zkrp:          proveRange(val, r, c, pb.RangeProofType_UL),
+			EqualityProof: equalityBytes,
+		})
+		restoreCurrency()
+	}
+	return migration, newRandomness, restore
+}
+
+//SampleAggregatedMintAccount returns a sample MintAccount message with one aggregated bulletproof for all the accounts
+func SampleAggregatedMintAccount(balances map[int32]*big.Int) (*pb.MintAccount, map[int32]*big.Int) {
+	ma, randomness := SampleMintAccount(balances, pb.RangeProofType_BULLETPROOF)
+	values := []*big.Int{}
+	gammas := []*big.Int{}
+	cms := []*bn256.G2{}
+	for _, account := range ma.Accounts {
+		cm, _ := new(bn256.G2).Unmarshal(account.CmBalance)
+		values = append(values, balances[account.BankId])
+		gammas = append(gammas, randomness[account.BankId])
+		cms = append(cms, cm)
+		account.Zkrp = nil
+	}
+	proof, _ := bulletproofs.ProveAggregate(values, gammas, cms, pBP)
+	ma.Zkrp = proof.Marshal()
+	ma.ZkrpType = pb.RangeProofType_BULLETPROOF
+	return ma, randomness
+}
+
+// SamplePaymentMessage returns a sample payment message, its zkrpLimit proves the default limit MaxPaymentAmount
 // The proof generation should be done at client side
-func SamplePaymentMessage(paymentId int32, sender int32, receiver int32, value *big.Int) (*pb.PaymentMessage, map[int32]*big.Int) {
-	r1, _ := rand.Int(rand.Reader, bn256.Order)
-	c1 := pedersencurve.Commit(value, r1, pUL.H)
+func SamplePaymentMessage(paymentId int32, sender int32, receiver int32, value *big.Int, proofType pb.RangeProofType) (*pb.PaymentMessage, map[int32]*big.Int) {
+	return SamplePaymentMessageWithMinimum(paymentId, sender, receiver, value, common.MinPaymentAmount, proofType)
+}
 
-	proof, _ := zkrangeproof.ProveUL(value, r1, c1, pUL)
-	proofOut := zkrangeproof.GenerateProofVerifier(proof)
+//SamplePaymentMessageWithMinimum returns a sample payment message of a sender whose payments must be at least minAmount,
+//its zkrp proves value-minAmount is within range
+func SamplePaymentMessageWithMinimum(paymentId int32, sender int32, receiver int32, value *big.Int, minAmount int64, proofType pb.RangeProofType) (*pb.PaymentMessage, map[int32]*big.Int) {
+	r1, _ := rand.Int(rand.Reader, pUL.H.Curve().Order())
+	c1 := pedersencurve.Commit(value, r1, pUL.H)
+	above, cmAbove := aboveMinimum(value, c1, minAmount)
 
 	randomness := map[int32]*big.Int{}
 	randomness[receiver] = r1
@@ -92,31 +267,186 @@ func SamplePaymentMessage(paymentId int32, sender int32, receiver int32, value *
 		PaymentId: paymentId,
 		Sender:    sender,
 		Receiver:  receiver,
-		CmAmount:  c1.Marshal(),
-		Zkrp:      proofOut.Marshal(),
+		CmAmount:  c1.MarshalCompressed(),
+		Zkrp:      proveRange(above, r1, cmAbove, proofType),
+		ZkrpType:  proofType,
+		ZkrpLimit: proveLimit(value, r1, c1, MaxPaymentAmount()),
 	}, randomness
 }
 
+//aboveMinimum returns value-minAmount and its commitment cm-minAmount*g, with the randomness of cm
+func aboveMinimum(value *big.Int, cm pairing.G2, minAmount int64) (*big.Int, pairing.G2) {
+	g := pedersencurve.Commit(big.NewInt(minAmount), big.NewInt(0), pUL.H)
+	return new(big.Int).Sub(value, big.NewInt(minAmount)), cm.Curve().NewG2().Add(cm, cm.Curve().NewG2().Neg(g))
+}
+
+//SamplePaymentMinimum returns a sample PaymentMinimum message
+func SamplePaymentMinimum(bankId int32, minAmount int64) *pb.PaymentMinimum {
+	return &pb.PaymentMinimum{
+		BankId:    bankId,
+		MinAmount: minAmount,
+	}
+}
+
+//SampleCreditLimit returns a sample CreditLimit message of the limit and the randomness of its cmLimit,
+//supply and rSupply open the total supply without the limit of the bank
+func SampleCreditLimit(bankId int32, limit *big.Int, supply *big.Int, rSupply *big.Int) (*pb.CreditLimit, *big.Int) {
+	r, _ := rand.Int(rand.Reader, pUL.H.Curve().Order())
+	c := pedersencurve.Commit(limit, r, pUL.H)
+	return &pb.CreditLimit{
+		BankId:     bankId,
+		CmLimit:    c.MarshalCompressed(),
+		Zkrp:       proveRange(limit, r, c, pb.RangeProofType_UL),
+		ZkrpSupply: SampleSupplyProof(new(big.Int).Add(supply, limit), new(big.Int).Add(rSupply, r)),
+	}, r
+}
+
+//SampleReservedBalance returns a sample ReservedBalance of the bank and the randomness of its commitment to reserve
+func SampleReservedBalance(bankId int32, reserve *big.Int) (*pb.ReservedBalance, *big.Int) {
+	r, _ := rand.Int(rand.Reader, pUL.H.Curve().Order())
+	c := pedersencurve.Commit(reserve, r, pUL.H)
+	return &pb.ReservedBalance{
+		BankId:    bankId,
+		CmReserve: c.MarshalCompressed(),
+		Zkrp:      proveRange(reserve, r, c, pb.RangeProofType_UL),
+	}, r
+}
+
+//SampleRepaymentProof returns a sample RepaymentProof of the balance cmBalance, which commits to value with randomness r
+func SampleRepaymentProof(bankId int32, cmBalance []byte, value *big.Int, r *big.Int, proofType pb.RangeProofType) *pb.RepaymentProof {
+	c, _ := pUL.H.Curve().NewG2().Unmarshal(cmBalance)
+	return &pb.RepaymentProof{
+		BankId:    bankId,
+		CmBalance: cmBalance,
+		Zkrp:      proveRange(value, r, c, proofType),
+		ZkrpType:  proofType,
+	}
+}
+
+//SamplePaymentLimit returns a sample PaymentLimit message
+func SamplePaymentLimit(bankId int32, maxAmount int64) *pb.PaymentLimit {
+	return &pb.PaymentLimit{
+		BankId:    bankId,
+		MaxAmount: maxAmount,
+	}
+}
+
+//AddPaymentLimitProof sets the zkrpLimit of the payment to an interval proof that value is within [0, maxAmount]
+func AddPaymentLimitProof(payment *pb.PaymentMessage, value *big.Int, r *big.Int, maxAmount int64) error {
+	cm, ok := pUL.H.Curve().NewG2().Unmarshal(payment.CmAmount)
+	if !ok {
+		return errors.New("Invalid cmAmount")
+	}
+	proof, err := zkrangeproof.ProveCCS08(value, r, cm, 0, maxAmount, pUL)
+	if err != nil {
+		return err
+	}
+	payment.ZkrpLimit, err = proof.MarshalProto()
+	return err
+}
+
+//MaxPaymentAmount returns the default payment limit of the sample UL params
+func MaxPaymentAmount() int64 {
+	params := zkrangeproof.GenerateParamsVefifier(&pUL)
+	return common.MaxPaymentAmount(&params)
+}
+
+//proveLimit returns the interval proof that the value committed in cm is within [0, maxAmount], nil when it is not
+func proveLimit(value *big.Int, r *big.Int, cm pairing.G2, maxAmount int64) []byte {
+	proof, err := zkrangeproof.ProveCCS08(value, r, cm, 0, maxAmount, pUL)
+	if err != nil {
+		return nil
+	}
+	proofBytes, _ := proof.MarshalProto()
+	return proofBytes
+}
+
+//SamplePaymentMessageBatch returns a sample batch of the messages, ordered by paymentId, with one aggregated bulletproof
+//of the amounts above the default minimum and the proofs of the default limit
+//and the randomness of the payments as bankId->paymentId->randomness
+func SamplePaymentMessageBatch(messages map[int32]*GLMessage) (*pb.PaymentMessageBatch, map[int32]map[int32]*big.Int) {
+	paymentIds := []int32{}
+	for key := range messages {
+		paymentIds = append(paymentIds, key)
+	}
+	sort.Slice(paymentIds, func(i, j int) bool { return paymentIds[i] < paymentIds[j] })
+
+	batch := &pb.PaymentMessageBatch{ZkrpType: pb.RangeProofType_BULLETPROOF}
+	randomnessPayment := map[int32]map[int32]*big.Int{}
+	values := []*big.Int{}
+	gammas := []*big.Int{}
+	cms := []pairing.G2{}
+	for _, id := range paymentIds {
+		val := messages[id]
+		r, _ := rand.Int(rand.Reader, pUL.H.Curve().Order())
+		c := pedersencurve.Commit(val.Amount, r, pUL.H)
+		batch.Payments = append(batch.Payments, &pb.PaymentMessage{
+			PaymentId: id,
+			Sender:    val.SenderId,
+			Receiver:  val.ReceiverId,
+			CmAmount:  c.MarshalCompressed(),
+			ZkrpType:  pb.RangeProofType_BULLETPROOF,
+			ZkrpLimit: proveLimit(val.Amount, r, c, MaxPaymentAmount()),
+		})
+		for _, bankId := range []int32{val.SenderId, val.ReceiverId} {
+			if randomnessPayment[bankId] == nil {
+				randomnessPayment[bankId] = map[int32]*big.Int{}
+			}
+			randomnessPayment[bankId][id] = r
+		}
+		above, cmAbove := aboveMinimum(val.Amount, c, common.MinPaymentAmount)
+		values = append(values, above)
+		gammas = append(gammas, r)
+		cms = append(cms, cmAbove)
+	}
+	proof, _ := bulletproofs.ProveAggregate(values, gammas, toBN256(cms), pBP)
+	batch.Zkrp = proof.Marshal()
+	return batch, randomnessPayment
+}
+
 //SampleGrossSettlementSet
-func SampleGrossSettlementSet(bankId int32, payment *pb.PaymentMessage, cmBalance []byte, value *big.Int, randomness *big.Int) *pb.GrossSettlementSet {
-	//calculate the sum commitment = cmBalance - outgoing cmAmount
-	cmSum, _ := new(bn256.G2).Unmarshal(cmBalance)
+func SampleGrossSettlementSet(bankId int32, payment *pb.PaymentMessage, cmBalance []byte, value *big.Int, randomness *big.Int, proofType pb.RangeProofType) *pb.GrossSettlementSet {
+	return SampleGrossSettlementSetWithCredit(bankId, payment, cmBalance, nil, value, randomness, proofType)
+}
 
-	cmAmount, _ := new(bn256.G2).Unmarshal(payment.CmAmount)
-	cmSum = new(bn256.G2).Add(cmSum, new(bn256.G2).Neg(cmAmount))
+//SampleGrossSettlementSetWithCredit is SampleGrossSettlementSet of a bank with the credit limit cmLimit, none when it is nil,
+//value and randomness are those of cmBalance - outgoing cmAmount + cmLimit
+func SampleGrossSettlementSetWithCredit(bankId int32, payment *pb.PaymentMessage, cmBalance []byte, cmLimit []byte, value *big.Int, randomness *big.Int, proofType pb.RangeProofType) *pb.GrossSettlementSet {
+	return sampleGrossSettlementSet(bankId, payment, cmBalance, cmLimit, nil, value, randomness, proofType)
+}
+
+//SampleGrossSettlementSetWithReserve is SampleGrossSettlementSet of a normal payment of a bank with the reserve cmReserve,
+//value and randomness are those of cmBalance - outgoing cmAmount - cmReserve
+func SampleGrossSettlementSetWithReserve(bankId int32, payment *pb.PaymentMessage, cmBalance []byte, cmReserve []byte, value *big.Int, randomness *big.Int, proofType pb.RangeProofType) *pb.GrossSettlementSet {
+	return sampleGrossSettlementSet(bankId, payment, cmBalance, nil, cmReserve, value, randomness, proofType)
+}
+
+func sampleGrossSettlementSet(bankId int32, payment *pb.PaymentMessage, cmBalance []byte, cmLimit []byte, cmReserve []byte, value *big.Int, randomness *big.Int, proofType pb.RangeProofType) *pb.GrossSettlementSet {
+	//calculate the sum commitment = cmBalance - outgoing cmAmount + cmLimit - cmReserve
+	curve := pUL.H.Curve()
+	cmSum, _ := curve.NewG2().Unmarshal(cmBalance)
+
+	cmAmount, _ := curve.NewG2().Unmarshal(payment.CmAmount)
+	cmSum = curve.NewG2().Add(cmSum, curve.NewG2().Neg(cmAmount))
+	if cmLimit != nil {
+		cmCredit, _ := curve.NewG2().Unmarshal(cmLimit)
+		cmSum = curve.NewG2().Add(cmSum, cmCredit)
+	}
+	if cmReserve != nil {
+		cmReserved, _ := curve.NewG2().Unmarshal(cmReserve)
+		cmSum = curve.NewG2().Add(cmSum, curve.NewG2().Neg(cmReserved))
+	}
 
 	//c := pedersencurve.Commit(value, randomness, pUL.H)
 	//fmt.Println(c.Marshal())
 	//fmt.Println(cmSum.Marshal())
 
-	proof, _ := zkrangeproof.ProveUL(value, randomness, cmSum, pUL)
-	proofOut := zkrangeproof.GenerateProofVerifier(proof)
-
 	settlementSet := &pb.GrossSettlementSet{
 		BankId:    bankId,
 		PaymentId: payment.PaymentId,
 		CmBalance: cmBalance,
-		Zkrp:      proofOut.Marshal(),
+		Zkrp:      proveRange(value, randomness, cmSum, proofType),
+		ZkrpType:  proofType,
 	}
 	return settlementSet
 }
@@ -132,10 +462,11 @@ func GetStoredBankAccount(account *pb.BankAccount) []byte {
 	return storedBankAccountBytes
 }
 
+//GetStoredBankAccountFromValue returns the StoredBankAccount of the balance value with randomness r, value may be negative
 func GetStoredBankAccountFromValue(value *big.Int, r *big.Int) []byte {
-	c := pedersencurve.Commit(value, r, pUL.H)
+	c := pedersencurve.Commit(new(big.Int).Mod(value, pUL.H.Curve().Order()), r, pUL.H)
 	storedBankAccount := &pb.StoredBankAccount{
-		CmBalance: c.Marshal(),
+		CmBalance: c.MarshalCompressed(),
 	}
 	storedBankAccountBytes, err := proto.Marshal(storedBankAccount)
 	if err != nil {
@@ -144,6 +475,16 @@ func GetStoredBankAccountFromValue(value *big.Int, r *big.Int) []byte {
 	return storedBankAccountBytes
 }
 
+//GetStoredPaymentSumFromValue returns the StoredPaymentSum of a queue of count payments that sum to value with randomness r
+func GetStoredPaymentSumFromValue(value *big.Int, r *big.Int, count int32) []byte {
+	c := pedersencurve.Commit(value, r, pUL.H)
+	storedPaymentSumBytes, err := proto.Marshal(&pb.StoredPaymentSum{CmSum: c.MarshalCompressed(), Count: count})
+	if err != nil {
+		return nil
+	}
+	return storedPaymentSumBytes
+}
+
 /*
 * GetStoredPaymentMessage This helper function creates a pb.StoredPaymentMessage protobuf payload from a
 * pb.StoredPaymentMessage protobuf payload
@@ -155,6 +496,9 @@ func GetStoredPaymentMessage(paymentMessage *pb.PaymentMessage) []byte {
 		CmAmount: paymentMessage.CmAmount,
 		Zkrp:     paymentMessage.Zkrp,
 		Status:   pb.StatusType_ACTIVE,
+		ZkrpType: paymentMessage.ZkrpType,
+		Priority: paymentMessage.Priority,
+		Currency: paymentMessage.Currency,
 	}
 
 	storedPaymentMessageBytes, err := proto.Marshal(storedPaymentMessage)
@@ -175,6 +519,9 @@ func GetStoredSettledPaymentMessage(paymentMessage *pb.PaymentMessage) []byte {
 		CmAmount: paymentMessage.CmAmount,
 		Zkrp:     paymentMessage.Zkrp,
 		Status:   pb.StatusType_SETTLED,
+		ZkrpType: paymentMessage.ZkrpType,
+		Priority: paymentMessage.Priority,
+		Currency: paymentMessage.Currency,
 	}
 
 	storedPaymentMessageBytes, err := proto.Marshal(storedPaymentMessage)
@@ -184,7 +531,7 @@ func GetStoredSettledPaymentMessage(paymentMessage *pb.PaymentMessage) []byte {
 	return storedPaymentMessageBytes
 }
 
-func AddGridlockMessages(checker *_checker, messages map[int32]*GLMessage) (map[int32]map[int32]*big.Int, error) {
+func AddGridlockMessages(checker *_checker, messages map[int32]*GLMessage, proofType pb.RangeProofType) (map[int32]map[int32]*big.Int, error) {
 	randomnessPayment := map[int32]map[int32]*big.Int{
 		1: {},
 		2: {},
@@ -198,10 +545,11 @@ func AddGridlockMessages(checker *_checker, messages map[int32]*GLMessage) (map[
 			val.SenderId,   //payerId
 			val.ReceiverId, //payeeId
 			val.Amount,     //payment amount
+			proofType,
 		)
 		request, err := proto.Marshal(spm)
 		if err != nil {
-			logger.Error("Failed to proto marshal 'PaymentMessage' object - %s", err)
+			logger.Errorf("Failed to proto marshal 'PaymentMessage' object - %s", err)
 			return nil, err
 		}
 		checker.Invoke("tx2", "addMessage",
@@ -235,6 +583,32 @@ func SampleGridlockProposals(
 	randomnessInit map[int32]*big.Int,
 	randomnessPayment map[int32]map[int32]*big.Int,
 	list map[int32]*IDList,
+	proofType pb.RangeProofType,
+) (map[int32]*pb.GridlockProposal, map[int32][]byte) {
+	return sampleGridlockProposals(gridlockId, balances, messages, randomnessInit, randomnessPayment, list, proofType, false)
+}
+
+//SampleAggregatedGridlockProposals is SampleGridlockProposals with one aggregated bulletproof replacing zkrp1 and zkrp2
+func SampleAggregatedGridlockProposals(
+	gridlockId int32,
+	balances map[int32]*big.Int,
+	messages map[int32]*GLMessage,
+	randomnessInit map[int32]*big.Int,
+	randomnessPayment map[int32]map[int32]*big.Int,
+	list map[int32]*IDList,
+) (map[int32]*pb.GridlockProposal, map[int32][]byte) {
+	return sampleGridlockProposals(gridlockId, balances, messages, randomnessInit, randomnessPayment, list, pb.RangeProofType_BULLETPROOF, true)
+}
+
+func sampleGridlockProposals(
+	gridlockId int32,
+	balances map[int32]*big.Int,
+	messages map[int32]*GLMessage,
+	randomnessInit map[int32]*big.Int,
+	randomnessPayment map[int32]map[int32]*big.Int,
+	list map[int32]*IDList,
+	proofType pb.RangeProofType,
+	aggregate bool,
 ) (map[int32]*pb.GridlockProposal, map[int32][]byte) {
 	//calculate the sum commitment = cmBalance - outgoing cmAmount
 	result := map[int32]*pb.GridlockProposal{
@@ -245,6 +619,7 @@ func SampleGridlockProposals(
 		5: {},
 	}
 	postAccount := map[int32][]byte{}
+	curve := pUL.H.Curve()
 
 	for k := range list {
 		postbalance := balances[k]
@@ -257,26 +632,30 @@ func SampleGridlockProposals(
 			postbalance = new(big.Int).Add(postbalance, messages[id].Amount)
 			sumRandomness = new(big.Int).Add(sumRandomness, randomnessPayment[k][id])
 			amount := pedersencurve.Commit(messages[id].Amount, randomnessPayment[k][id], pUL.H)
-			cmSum = new(bn256.G2).Add(cmSum, amount)
+			cmSum = curve.NewG2().Add(cmSum, amount)
 		}
 		//substract outgoing amounts
 		for _, id := range list[k].OutgoingIds {
 			postbalance = new(big.Int).Sub(postbalance, messages[id].Amount)
 			sumRandomness = new(big.Int).Sub(sumRandomness, randomnessPayment[k][id])
 			amount := pedersencurve.Commit(messages[id].Amount, randomnessPayment[k][id], pUL.H)
-			cmSum = new(bn256.G2).Add(cmSum, new(bn256.G2).Neg(amount))
+			cmSum = curve.NewG2().Add(cmSum, curve.NewG2().Neg(amount))
 		}
-		postAccount[k] = cmSum.Marshal()
-		proof, _ := zkrangeproof.ProveUL(postbalance, sumRandomness, cmSum, pUL)
-		proofOut := zkrangeproof.GenerateProofVerifier(proof)
-
+		postAccount[k] = cmSum.MarshalCompressed()
 		result[k] = &pb.GridlockProposal{
 			GridlockId:    gridlockId,
 			BankId:        k,
 			OutgoingIds:   list[k].OutgoingIds,
 			InfeasibleIds: list[k].InfeasibleIds,
-			CmBalance:     cmBalance.Marshal(),
-			Zkrp1:         proofOut.Marshal(),
+			CmBalance:     cmBalance.MarshalCompressed(),
+			ZkrpType:      proofType,
+		}
+		//values, randomness and commitments covered by the aggregated proof
+		values := []*big.Int{postbalance}
+		gammas := []*big.Int{sumRandomness}
+		cms := []pairing.G2{cmSum}
+		if !aggregate {
+			result[k].Zkrp1 = proveRange(postbalance, sumRandomness, cmSum, proofType)
 		}
 
 		//substract the smallest id from infeasible
@@ -292,12 +671,19 @@ func SampleGridlockProposals(
 			sumRandomness = new(big.Int).Sub(sumRandomness, randomnessPayment[k][smallest])
 			sumRandomnessNeg := new(big.Int).Sub(new(big.Int).SetInt64(0), sumRandomness)
 			amount := pedersencurve.Commit(messages[smallest].Amount, randomnessPayment[k][smallest], pUL.H)
-			cmSum = new(bn256.G2).Add(cmSum, new(bn256.G2).Neg(amount))
-			cmSumNeg := new(bn256.G2).Neg(cmSum)
-
-			proofNeg, _ := zkrangeproof.ProveUL(postbalanceNeg, sumRandomnessNeg, cmSumNeg, pUL)
-			proofOutNeg := zkrangeproof.GenerateProofVerifier(proofNeg)
-			result[k].Zkrp2 = proofOutNeg.Marshal()
+			cmSum = curve.NewG2().Add(cmSum, curve.NewG2().Neg(amount))
+			cmSumNeg := curve.NewG2().Neg(cmSum)
+
+			values = append(values, postbalanceNeg)
+			gammas = append(gammas, sumRandomnessNeg)
+			cms = append(cms, cmSumNeg)
+			if !aggregate {
+				result[k].Zkrp2 = proveRange(postbalanceNeg, sumRandomnessNeg, cmSumNeg, proofType)
+			}
+		}
+		if aggregate {
+			proof, _ := bulletproofs.ProveAggregate(values, gammas, toBN256(cms), pBP)
+			result[k].Zkrp = proof.Marshal()
 		}
 	}
 
@@ -21,28 +21,28 @@ import (
 	"errors"
 	"math/big"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
 type keypair struct {
-	pubk  *bn256.G1
+	pubk  pairing.G1
 	privk *big.Int
 }
 
 /*
-keygen is responsible for the key generation.
+keygen is responsible for the key generation on the curve c.
 */
-func keygen() (keypair, error) {
+func keygen(c pairing.Curve) (keypair, error) {
 	var (
 		kp  keypair
 		e   error
 		res bool
 	)
-	kp.privk, e = rand.Int(rand.Reader, bn256.Order)
+	kp.privk, e = rand.Int(rand.Reader, c.Order())
 	if e != nil {
 		return kp, e
 	}
-	kp.pubk, res = new(bn256.G1).Unmarshal(new(bn256.G1).ScalarBaseMult(kp.privk).Marshal())
+	kp.pubk, res = c.NewG1().Unmarshal(c.NewG1().ScalarBaseMult(kp.privk).Marshal())
 	if res {
 		return kp, errors.New("Could not compute scalar multiplication.")
 	}
@@ -50,15 +50,15 @@ func keygen() (keypair, error) {
 }
 
 /*
-sign receives as input a message and a private key and outputs a digital signature.
+sign receives as input a message and a private key of the curve c and outputs a digital signature.
 */
-func sign(m *big.Int, privk *big.Int) (*bn256.G2, error) {
+func sign(c pairing.Curve, m *big.Int, privk *big.Int) (pairing.G2, error) {
 	var (
 		res       bool
-		signature *bn256.G2
+		signature pairing.G2
 	)
-	inv := ModInverse(Mod(Add(m, privk), bn256.Order), bn256.Order)
-	signature, res = new(bn256.G2).Unmarshal(new(bn256.G2).ScalarBaseMult(inv).Marshal())
+	inv := ModInverse(Mod(Add(m, privk), c.Order()), c.Order())
+	signature, res = c.NewG2().Unmarshal(c.NewG2().ScalarBaseMult(inv).Marshal())
 	if res != false {
 		return signature, nil
 	} else {
@@ -70,26 +70,23 @@ func sign(m *big.Int, privk *big.Int) (*bn256.G2, error) {
 verify receives as input the digital signature, the message and the public key. It outputs
 true if and only if the signature is valid.
 */
-func verify(signature *bn256.G2, m *big.Int, pubk *bn256.G1) (bool, error) {
+func verify(signature pairing.G2, m *big.Int, pubk pairing.G1) (bool, error) {
 	// e(y.g^m, sig) = e(g1,g2)
 	var (
-		gm     *bn256.G1
+		gm     pairing.G1
 		e, res bool
 	)
+	c := pubk.Curve()
 	// g^m
-	gm, e = new(bn256.G1).Unmarshal(new(bn256.G1).ScalarBaseMult(m).Marshal())
+	gm, e = c.NewG1().Unmarshal(c.NewG1().ScalarBaseMult(m).Marshal())
 	// y.g^m
 	gm = gm.Add(gm, pubk)
-	// e(y.g^m, sig)
-	p1 := bn256.Pair(gm, signature)
-	// e(g1,g2)
-	g1 := new(bn256.G1).ScalarBaseMult(new(big.Int).SetInt64(1))
-	g2 := new(bn256.G2).ScalarBaseMult(new(big.Int).SetInt64(1))
-	p2 := bn256.Pair(g1, g2)
-	// p1 == p2?
-	p2 = p2.Neg(p2)
-	p1 = p1.Add(p1, p2)
-	res = p1.IsOne()
+	// e(g1,g2)^-1 = e(g1^-1,g2)
+	g1 := c.NewG1().ScalarBaseMult(new(big.Int).SetInt64(1))
+	g1.Neg(g1)
+	g2 := c.NewG2().ScalarBaseMult(new(big.Int).SetInt64(1))
+	// e(y.g^m, sig).e(g1^-1,g2) == 1?
+	res = c.PairingCheck([]pairing.G1{gm, g1}, []pairing.G2{signature, g2})
 	if e != false {
 		return res, nil
 	}
@@ -19,15 +19,19 @@ package zkrangeproof
 import (
 	"testing"
 	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
 func TestKeyGen(t *testing.T) {
-	kp, _ := keygen()
-	signature, _ := sign(big.NewInt(42), kp.privk)	
-	res, _ := verify(signature, big.NewInt(42), kp.pubk)
-	if res != true {
-		t.Errorf("Assert failure: expected true, actual: %t", res)
-		t.Fail()
+	for _, id := range []pairing.ID{pairing.BN256, pairing.BLS12381} {
+		c := pairing.MustLookup(id)
+		kp, _ := keygen(c)
+		signature, _ := sign(c, big.NewInt(42), kp.privk)
+		res, _ := verify(signature, big.NewInt(42), kp.pubk)
+		if res != true {
+			t.Errorf("Assert failure on %s: expected true, actual: %t", id, res)
+		}
 	}
 }
 
@@ -16,7 +16,9 @@ import (
 	"math"
 	"math/big"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/golang/protobuf/proto"
 )
 
 /*
@@ -24,8 +26,9 @@ paramsSet contains elements generated by the verifier, which are necessary for t
 This must be computed in a trusted setup.
 */
 type paramsSet struct {
-	signatures map[int64]*bn256.G2
-	H          *bn256.G2
+	curve      pairing.Curve
+	signatures map[int64]pairing.G2
+	H          pairing.G2
 	// TODO:must protect the private key
 	kp keypair
 	// u determines the amount of signatures we need in the public params.
@@ -40,31 +43,33 @@ type paramsSet struct {
 proofSet contains the necessary elements for the ZK Set Membership proof.
 */
 type proofSet struct {
-	V              *bn256.G2
-	D, C           *bn256.G2
-	a              *bn256.GT
+	V              pairing.G2
+	D, C           pairing.G2
+	a              pairing.GT
 	s, t, zsig, zv *big.Int
 	c, m, zr       *big.Int
 }
 
 /*
-SetupSet generates the signature for the elements in the set.
+SetupSet generates the signature for the elements in the set on BN256.
 */
 func SetupSet(s []int64) (paramsSet, error) {
 	var (
 		i int
 		p paramsSet
 	)
-	p.kp, _ = keygen()
+	c := pairing.MustLookup(pairing.BN256)
+	p.curve = c
+	p.kp, _ = keygen(c)
 
-	p.signatures = make(map[int64]*bn256.G2)
+	p.signatures = make(map[int64]pairing.G2)
 	for i = 0; i < len(s); i++ {
-		sig_i, _ := sign(new(big.Int).SetInt64(int64(s[i])), p.kp.privk)
+		sig_i, _ := sign(c, new(big.Int).SetInt64(int64(s[i])), p.kp.privk)
 		p.signatures[s[i]] = sig_i
 	}
 	//TODO: protect the 'master' key
 	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	p.H = new(bn256.G2).ScalarBaseMult(h)
+	p.H = c.NewG2().ScalarBaseMult(h)
 	return p, nil
 }
 
@@ -77,27 +82,30 @@ func ProveSet(x int64, r *big.Int, p paramsSet) (proofSet, error) {
 		proof_out proofSet
 	)
 
+	c := p.curve
+	k := constantsOf(c)
+
 	// Initialize variables
-	proof_out.D = new(bn256.G2)
+	proof_out.D = c.NewG2()
 	proof_out.D.SetInfinity()
-	proof_out.m, _ = rand.Int(rand.Reader, bn256.Order)
+	proof_out.m, _ = rand.Int(rand.Reader, c.Order())
 
-	D := new(bn256.G2)
-	v, _ = rand.Int(rand.Reader, bn256.Order)
+	D := c.NewG2()
+	v, _ = rand.Int(rand.Reader, c.Order())
 	A, ok := p.signatures[x]
 	if ok {
 		// D = g^s.H^m
-		D = new(bn256.G2).ScalarMult(p.H, proof_out.m)
-		proof_out.s, _ = rand.Int(rand.Reader, bn256.Order)
-		aux := new(bn256.G2).ScalarBaseMult(proof_out.s)
+		D = c.NewG2().ScalarMultTable(pairing.CachedG2Table(p.H), proof_out.m)
+		proof_out.s, _ = rand.Int(rand.Reader, c.Order())
+		aux := c.NewG2().ScalarBaseMultCT(proof_out.s)
 		D.Add(D, aux)
 
-		proof_out.V = new(bn256.G2).ScalarMult(A, v)
-		proof_out.t, _ = rand.Int(rand.Reader, bn256.Order)
-		proof_out.a = bn256.Pair(G1, proof_out.V)
+		proof_out.V = c.NewG2().ScalarMultCT(A, v)
+		proof_out.t, _ = rand.Int(rand.Reader, c.Order())
+		proof_out.a = c.Pair(k.G1, proof_out.V)
 		proof_out.a.ScalarMult(proof_out.a, proof_out.s)
 		proof_out.a.Invert(proof_out.a)
-		proof_out.a.Add(proof_out.a, new(bn256.GT).ScalarMult(E, proof_out.t))
+		proof_out.a.Add(proof_out.a, c.NewGT().ScalarMult(k.E, proof_out.t))
 	} else {
 		return proof_out, errors.New("Could not generate proof. Element does not belong to the interval.")
 	}
@@ -108,14 +116,14 @@ func ProveSet(x int64, r *big.Int, p paramsSet) (proofSet, error) {
 	proof_out.C, _ = Commit(new(big.Int).SetInt64(x), r, p.H)
 	// Fiat-Shamir heuristic
 	proof_out.c, _ = HashSet(proof_out.a, proof_out.D)
-	proof_out.c = Mod(proof_out.c, bn256.Order)
+	proof_out.c = Mod(proof_out.c, c.Order())
 
 	proof_out.zr = Sub(proof_out.m, Multiply(r, proof_out.c))
-	proof_out.zr = Mod(proof_out.zr, bn256.Order)
+	proof_out.zr = Mod(proof_out.zr, c.Order())
 	proof_out.zsig = Sub(proof_out.s, Multiply(new(big.Int).SetInt64(x), proof_out.c))
-	proof_out.zsig = Mod(proof_out.zsig, bn256.Order)
+	proof_out.zsig = Mod(proof_out.zsig, c.Order())
 	proof_out.zv = Sub(proof_out.t, Multiply(v, proof_out.c))
-	proof_out.zv = Mod(proof_out.zv, bn256.Order)
+	proof_out.zv = Mod(proof_out.zv, c.Order())
 	return proof_out, nil
 }
 
@@ -124,14 +132,16 @@ VerifySet is used to validate the ZK Set Membership proof. It returns true iff t
 */
 func VerifySet(proof_out *proofSet, p *paramsSet) (bool, error) {
 	var (
-		D      *bn256.G2
+		D      pairing.G2
 		r1, r2 bool
-		p1, p2 *bn256.GT
+		p1     pairing.GT
 	)
+	c := p.curve
+	k := constantsOf(c)
 	// D == C^c.h^ zr.g^zsig ?
-	D = new(bn256.G2).ScalarMult(proof_out.C, proof_out.c)
-	D.Add(D, new(bn256.G2).ScalarMult(p.H, proof_out.zr))
-	aux := new(bn256.G2).ScalarBaseMult(proof_out.zsig)
+	D = c.NewG2().ScalarMult(proof_out.C, proof_out.c)
+	D.Add(D, c.NewG2().ScalarMult(p.H, proof_out.zr))
+	aux := c.NewG2().ScalarBaseMult(proof_out.zsig)
 	D.Add(D, aux)
 
 	DBytes := D.Marshal()
@@ -139,14 +149,10 @@ func VerifySet(proof_out *proofSet, p *paramsSet) (bool, error) {
 	r1 = bytes.Equal(DBytes, pDBytes)
 
 	r2 = true
-	// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
-	p1 = bn256.Pair(p.kp.pubk, proof_out.V)
-	p1.ScalarMult(p1, proof_out.c)
-	p2 = bn256.Pair(G1, proof_out.V)
-	p2.ScalarMult(p2, proof_out.zsig)
-	p2.Invert(p2)
-	p1.Add(p1, p2)
-	p1.Add(p1, new(bn256.GT).ScalarMult(E, proof_out.zv))
+	// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv] == e(y^c.g^-zsig, V).e(g^zv, g)
+	yg := c.NewG1().MultiScalarMult([]pairing.G1{p.kp.pubk, k.G1}, []*big.Int{proof_out.c, Mod(new(big.Int).Neg(proof_out.zsig), c.Order())})
+	gzv := c.NewG1().ScalarBaseMult(Mod(proof_out.zv, c.Order()))
+	p1 = c.PairingProduct([]pairing.G1{yg, gzv}, []pairing.G2{proof_out.V, k.G2})
 
 	pBytes := p1.Marshal()
 	aBytes := proof_out.a.Marshal()
@@ -155,10 +161,13 @@ func VerifySet(proof_out *proofSet, p *paramsSet) (bool, error) {
 }
 
 /*
-proof contains the necessary elements for the ZK proof.
+ProofCCS08 contains the public output of the ZK proof that the value x committed in C belongs to [a,b].
+P1 proves x-b-1+u^l belongs to [0,u^l) and P2 proves x-a belongs to [0,u^l). Their commitments
+are derived from C by the verifier, so both proofs use the randomness of C.
+Its size is twice the size of a UL proof.
 */
-type proof struct {
-	p1, p2 proofUL
+type ProofCCS08 struct {
+	P1, P2 ProofULVerifier
 }
 
 /*
@@ -173,8 +182,9 @@ type params struct {
 type ccs08 struct {
 	p         *params
 	x, r      *big.Int
-	proof_out proof
-	pubk      *bn256.G1
+	cm        pairing.G2
+	proof_out ProofCCS08
+	pubk      pairing.G1
 }
 
 /*
@@ -223,32 +233,188 @@ func (zkrp *ccs08) Setup(a, b int64) error {
 Prove method is responsible for generating the zero knowledge proof.
 */
 func (zkrp *ccs08) Prove() error {
-	ul := new(big.Int).Exp(new(big.Int).SetInt64(zkrp.p.p.u), new(big.Int).SetInt64(zkrp.p.p.l), nil)
+	zkrp.cm, _ = Commit(zkrp.x, zkrp.r, zkrp.p.p.H)
+	proof, err := ProveCCS08(zkrp.x, zkrp.r, zkrp.cm, zkrp.p.a, zkrp.p.b, *zkrp.p.p)
+	if err != nil {
+		return err
+	}
+	zkrp.proof_out = proof
+	return nil
+}
+
+/*
+Verify is responsible for validating the proof.
+*/
+func (zkrp *ccs08) Verify() (bool, error) {
+	paramsVerifier := GenerateParamsVefifier(zkrp.p.p)
+	return VerifyCCS08(&zkrp.proof_out, zkrp.cm, zkrp.p.a, zkrp.p.b, paramsVerifier)
+}
+
+/*
+ProveCCS08 method is used to produce the ZK proof that the value x committed in cm = g^x.h^r
+belongs to the interval [a,b]. The interval must satisfy b-a < u^l.
+*/
+func ProveCCS08(x, r *big.Int, cm pairing.G2, a, b int64, p paramsUL) (ProofCCS08, error) {
+	var proof_out ProofCCS08
+	if a > b {
+		return proof_out, errors.New("a must be less than or equal to b")
+	}
+	ul := new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(p.l), nil)
+	if new(big.Int).Sub(new(big.Int).SetInt64(b), new(big.Int).SetInt64(a)).Cmp(ul) >= 0 {
+		return proof_out, errors.New("Could not generate proof. The interval is larger than u^l.")
+	}
+	if x.Cmp(new(big.Int).SetInt64(a)) < 0 || x.Cmp(new(big.Int).SetInt64(b)) > 0 {
+		return proof_out, errors.New("Could not generate proof. Element does not belong to the interval.")
+	}
 
-	// x - b + ul
-	xb := new(big.Int).Sub(zkrp.x, new(big.Int).SetInt64(zkrp.p.b))
+	// x - b - 1 + u^l
+	xb := new(big.Int).Sub(x, new(big.Int).SetInt64(b))
+	xb.Sub(xb, big.NewInt(1))
 	xb.Add(xb, ul)
-	cmfirst, _ := Commit(xb, zkrp.r, zkrp.p.p.H)
-	first, _ := ProveUL(xb, zkrp.r, cmfirst, *zkrp.p.p)
+	cmfirst := shiftCommitment(cm, new(big.Int).Sub(xb, x))
+	first, err := ProveUL(xb, r, cmfirst, p)
+	if err != nil {
+		return proof_out, err
+	}
 
 	// x - a
-	xa := new(big.Int).Sub(zkrp.x, new(big.Int).SetInt64(zkrp.p.a))
-	cmsecond, _ := Commit(xa, zkrp.r, zkrp.p.p.H)
-	second, _ := ProveUL(xa, zkrp.r, cmsecond, *zkrp.p.p)
+	xa := new(big.Int).Sub(x, new(big.Int).SetInt64(a))
+	cmsecond := shiftCommitment(cm, new(big.Int).Sub(xa, x))
+	second, err := ProveUL(xa, r, cmsecond, p)
+	if err != nil {
+		return proof_out, err
+	}
 
-	zkrp.proof_out.p1 = first
-	zkrp.proof_out.p2 = second
-	return nil
+	proof_out.P1 = GenerateProofVerifier(first)
+	proof_out.P2 = GenerateProofVerifier(second)
+	return proof_out, nil
 }
 
 /*
-Verify is responsible for validating the proof.
+VerifyCCS08 is used to validate the ZK proof that the value committed in cm belongs to [a,b].
+It returns true iff the proof is valid, otherwise the error tells which check failed.
 */
-func (zkrp *ccs08) Verify() (bool, error) {
-	proofVerifier1 := GenerateProofVerifier(zkrp.proof_out.p1)
-	proofVerifier2 := GenerateProofVerifier(zkrp.proof_out.p2)
-	paramsVerifier1 := GenerateParamsVefifier(zkrp.p.p)
-	first, _ := VerifyUL(&proofVerifier1, paramsVerifier1)
-	second, _ := VerifyUL(&proofVerifier2, paramsVerifier1)
-	return first && second, nil
+func VerifyCCS08(proof_out *ProofCCS08, cm pairing.G2, a, b int64, p ParamsULVerifier) (bool, error) {
+	if a > b {
+		return false, errors.New("a must be less than or equal to b")
+	}
+	if cm == nil || cm.Curve().ID() != p.curve.ID() {
+		return false, ErrCurveMismatch
+	}
+	ul := new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(p.l), nil)
+
+	// the commitments of the UL proofs must be derived from cm
+	shiftFirst := new(big.Int).Sub(ul, new(big.Int).SetInt64(b))
+	shiftFirst.Sub(shiftFirst, big.NewInt(1))
+	cmfirst := shiftCommitment(cm, shiftFirst)
+	cmsecond := shiftCommitment(cm, new(big.Int).Neg(new(big.Int).SetInt64(a)))
+	if proof_out.P1.C == nil || proof_out.P2.C == nil {
+		return false, ErrMalformedProof
+	}
+	if !bytes.Equal(proof_out.P1.C.Marshal(), cmfirst.Marshal()) || !bytes.Equal(proof_out.P2.C.Marshal(), cmsecond.Marshal()) {
+		return false, ErrCommitmentMismatch
+	}
+
+	result, _, err := BatchVerifyUL([]*ProofULVerifier{&proof_out.P1, &proof_out.P2}, p)
+	return result, err
+}
+
+// shiftCommitment returns cm.g^k, the commitment to x+k with the same randomness
+func shiftCommitment(cm pairing.G2, k *big.Int) pairing.G2 {
+	c := cm.Curve()
+	return c.NewG2().Add(cm, c.NewG2().ScalarBaseMult(Mod(k, c.Order())))
+}
+
+/*
+VerifyIntervalCommitment unmarshals zkrp and checks that it proves the value committed in cm is in [a,b].
+*/
+func (p *ParamsULVerifier) VerifyIntervalCommitment(zkrp []byte, cm pairing.G2, a, b int64) (bool, error) {
+	proof, err := p.decodeProofCCS08(zkrp)
+	if err != nil {
+		return false, malformed(err)
+	}
+	return VerifyCCS08(proof, cm, a, b, *p)
+}
+
+// decodeProofCCS08 reads a CCS08 proof made with the params p, in the versioned protobuf encoding
+// or, for proofs on BN256, in the unversioned encoding
+func (p *ParamsULVerifier) decodeProofCCS08(m []byte) (*ProofCCS08, error) {
+	if p.curve.ID() == pairing.BN256 && int64(len(m)) == 2*ulProofLength(p.l) {
+		return new(ProofCCS08).Unmarshal(m, p.l)
+	}
+	proof, err := new(ProofCCS08).UnmarshalProto(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkProof(&proof.P1); err != nil {
+		return nil, err
+	}
+	return proof, p.checkProof(&proof.P2)
+}
+
+/*
+MarshalProto is for marshaling the ProofCCS08 into the versioned protobuf encoding
+*/
+func (p *ProofCCS08) MarshalProto() ([]byte, error) {
+	first, err := p.P1.toProto()
+	if err != nil {
+		return nil, err
+	}
+	second, err := p.P2.toProto()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&pb.ProofCCS08{P1: first, P2: second})
+}
+
+/*
+UnmarshalProto is for converting the versioned protobuf encoding back into ProofCCS08
+*/
+func (p *ProofCCS08) UnmarshalProto(m []byte) (*ProofCCS08, error) {
+	msg := &pb.ProofCCS08{}
+	if err := proto.Unmarshal(m, msg); err != nil {
+		return nil, err
+	}
+	first, err := new(ProofULVerifier).fromProto(msg.P1)
+	if err != nil {
+		return nil, err
+	}
+	second, err := new(ProofULVerifier).fromProto(msg.P2)
+	if err != nil {
+		return nil, err
+	}
+	p.P1 = *first
+	p.P2 = *second
+	return p, nil
+}
+
+/*
+Marshal is for marshaling the ProofCCS08 into the unversioned []byte encoding, which only exists for BN256
+*/
+func (p *ProofCCS08) Marshal() []byte {
+	var ret []byte
+	ret = append(ret, p.P1.Marshal()...)
+	ret = append(ret, p.P2.Marshal()...)
+	return ret
+}
+
+/*
+Unmarshal is for converting the unversioned []byte encoding back into ProofCCS08 on BN256
+*/
+func (p *ProofCCS08) Unmarshal(m []byte, L int64) (*ProofCCS08, error) {
+	if L < 1 || L > maxL || int64(len(m)) != 2*ulProofLength(L) {
+		return nil, errors.New("CCS08 proof has the wrong length")
+	}
+	length := ulProofLength(L)
+	first, err := new(ProofULVerifier).Unmarshal(m[:length], L)
+	if err != nil {
+		return nil, err
+	}
+	second, err := new(ProofULVerifier).Unmarshal(m[length:], L)
+	if err != nil {
+		return nil, err
+	}
+	p.P1 = *first
+	p.P2 = *second
+	return p, nil
 }
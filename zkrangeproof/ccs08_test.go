@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
 /*
@@ -145,3 +146,95 @@ func TestZKRP(t *testing.T) {
 		t.Errorf("Assert failure: expected true, actual: %t", result)
 	}
 }
+
+/*
+Tests the interval proof at the bounds of [a,b] and outside of it.
+*/
+func TestZKRPCCS08Bounds(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	for _, x := range []int64{50, 51, 120, 200} {
+		r, _ := rand.Int(rand.Reader, bn256.Order)
+		cm, _ := Commit(new(big.Int).SetInt64(x), r, p.H)
+		proof, err := ProveCCS08(new(big.Int).SetInt64(x), r, cm, 50, 200, p)
+		if err != nil {
+			t.Fatalf("ProveCCS08 failed for %d: %v", x, err)
+		}
+		result, err := VerifyCCS08(&proof, cm, 50, 200, paramsVerifier)
+		if result != true || err != nil {
+			t.Errorf("Assert failure for %d: expected true, actual: %t, %v", x, result, err)
+		}
+	}
+	for _, x := range []int64{49, 201} {
+		r, _ := rand.Int(rand.Reader, bn256.Order)
+		cm, _ := Commit(new(big.Int).SetInt64(x), r, p.H)
+		if _, err := ProveCCS08(new(big.Int).SetInt64(x), r, cm, 50, 200, p); err == nil {
+			t.Errorf("Assert failure: expected an error for %d", x)
+		}
+	}
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	cm, _ := Commit(new(big.Int).SetInt64(0), r, p.H)
+	if _, err := ProveCCS08(new(big.Int).SetInt64(0), r, cm, 0, 1000, p); err == nil {
+		t.Error("Assert failure: expected an error for an interval larger than u^l")
+	}
+}
+
+/*
+Tests that the interval proof is bound to the commitment and to the interval, using marshal and unmarshal.
+*/
+func TestZKRPCCS08Marshal(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	x := new(big.Int).SetInt64(150)
+	cm, _ := Commit(x, r, p.H)
+	proof, _ := ProveCCS08(x, r, cm, 0, 500, p)
+	proofBytes := proof.Marshal()
+
+	result, err := paramsVerifier.VerifyIntervalCommitment(proofBytes, cm, 0, 500)
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+	// a tighter upper bound changes the derived commitments
+	result, _ = paramsVerifier.VerifyIntervalCommitment(proofBytes, cm, 0, 100)
+	if result != false {
+		t.Errorf("Assert failure: expected false for a different interval, actual: %t", result)
+	}
+	other, _ := Commit(new(big.Int).SetInt64(151), r, p.H)
+	result, _ = paramsVerifier.VerifyIntervalCommitment(proofBytes, other, 0, 500)
+	if result != false {
+		t.Errorf("Assert failure: expected false for a different commitment, actual: %t", result)
+	}
+	if _, err := paramsVerifier.VerifyIntervalCommitment(proofBytes[1:], cm, 0, 500); err == nil {
+		t.Error("Assert failure: expected an error for a truncated proof")
+	}
+}
+
+/*
+Tests the interval proof on BLS12-381, which is only written in the versioned encoding.
+*/
+func TestZKRPCCS08OnBLS12381(t *testing.T) {
+	c := pairing.MustLookup(pairing.BLS12381)
+	p, _ := SetupULOnCurve(c, 10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	r, _ := rand.Int(rand.Reader, c.Order())
+	x := new(big.Int).SetInt64(150)
+	cm, _ := Commit(x, r, p.H)
+	proof, err := ProveCCS08(x, r, cm, 0, 500, p)
+	if err != nil {
+		t.Fatalf("ProveCCS08 failed: %v", err)
+	}
+	proofBytes, err := proof.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto failed: %v", err)
+	}
+
+	result, err := paramsVerifier.VerifyIntervalCommitment(proofBytes, cm, 0, 500)
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+	result, _ = paramsVerifier.VerifyIntervalCommitment(proofBytes, cm, 0, 100)
+	if result != false {
+		t.Errorf("Assert failure: expected false for a different interval, actual: %t", result)
+	}
+}
@@ -0,0 +1,214 @@
+package zkrangeproof
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+VersionUL is the version of the protobuf encoding of UL proofs and params written by MarshalProto.
+*/
+const VersionUL = 1
+
+/*
+MarshalProto is for marshaling the ProofULVerifier into the versioned protobuf encoding,
+which carries u, l and the curve so that it can be read without knowing the params.
+The group elements are compressed, UnmarshalProto also accepts them uncompressed.
+*/
+func (p *ProofULVerifier) MarshalProto() ([]byte, error) {
+	msg, err := p.toProto()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+// toProto converts the proof into its protobuf message
+func (p *ProofULVerifier) toProto() (*pb.ProofULVerifier, error) {
+	if p.u == 0 {
+		return nil, errors.New("UL proof does not know the u it was made with")
+	}
+	msg := &pb.ProofULVerifier{
+		Scheme:  pb.RangeProofType_UL,
+		Version: VersionUL,
+		Curve:   pb.CurveType(p.curve.ID()),
+		U:       p.u,
+		L:       int64(len(p.V)),
+		D:       p.D.MarshalCompressed(),
+		Cm:      p.C.MarshalCompressed(),
+		C:       p.c.Bytes(),
+		Zr:      p.zr.Bytes(),
+	}
+	for i := range p.V {
+		msg.V = append(msg.V, p.V[i].MarshalCompressed())
+		msg.A = append(msg.A, p.a[i].MarshalCompressed())
+		msg.Zsig = append(msg.Zsig, p.zsig[i].Bytes())
+		msg.Zv = append(msg.Zv, p.zv[i].Bytes())
+	}
+	return msg, nil
+}
+
+/*
+UnmarshalProto is for converting the versioned protobuf encoding back into ProofULVerifier.
+It returns an error if the scheme, version, curve, u, l or an element is not valid.
+*/
+func (p *ProofULVerifier) UnmarshalProto(m []byte) (*ProofULVerifier, error) {
+	msg := &pb.ProofULVerifier{}
+	if err := proto.Unmarshal(m, msg); err != nil {
+		return nil, err
+	}
+	return p.fromProto(msg)
+}
+
+// fromProto reads the proof from its protobuf message
+func (p *ProofULVerifier) fromProto(msg *pb.ProofULVerifier) (*ProofULVerifier, error) {
+	if msg == nil || msg.Scheme != pb.RangeProofType_UL || msg.Version != VersionUL {
+		return nil, errors.New("UL proof has an unsupported scheme or version")
+	}
+	curve, err := pairing.Lookup(pairing.ID(msg.Curve))
+	if err != nil {
+		return nil, errors.New("UL proof is on an unknown curve")
+	}
+	if !validUL(msg.U, msg.L) {
+		return nil, errors.New("UL proof has an unsupported u or l")
+	}
+	l := int(msg.L)
+	if len(msg.V) != l || len(msg.A) != l || len(msg.Zsig) != l || len(msg.Zv) != l {
+		return nil, errors.New("UL proof has the wrong number of elements")
+	}
+
+	var ok bool
+	proof := ProofULVerifier{curve: curve, u: msg.U}
+	proof.V = make([]pairing.G2, l)
+	proof.a = make([]pairing.GT, l)
+	proof.zsig = make([]*big.Int, l)
+	proof.zv = make([]*big.Int, l)
+	for i := 0; i < l; i++ {
+		if proof.V[i], ok = curve.NewG2().Unmarshal(msg.V[i]); !ok {
+			return nil, errors.New("UL proof contains an invalid G2 element")
+		}
+		if proof.a[i], ok = curve.NewGT().Unmarshal(msg.A[i]); !ok {
+			return nil, errors.New("UL proof contains an invalid GT element")
+		}
+		if proof.zsig[i], ok = unmarshalScalar(curve, msg.Zsig[i]); !ok {
+			return nil, errors.New("UL proof contains an invalid scalar")
+		}
+		if proof.zv[i], ok = unmarshalScalar(curve, msg.Zv[i]); !ok {
+			return nil, errors.New("UL proof contains an invalid scalar")
+		}
+	}
+	if proof.D, ok = curve.NewG2().Unmarshal(msg.D); !ok {
+		return nil, errors.New("UL proof contains an invalid G2 element")
+	}
+	if proof.C, ok = curve.NewG2().Unmarshal(msg.Cm); !ok {
+		return nil, errors.New("UL proof contains an invalid G2 element")
+	}
+	if proof.c, ok = unmarshalScalar(curve, msg.C); !ok {
+		return nil, errors.New("UL proof contains an invalid scalar")
+	}
+	if proof.zr, ok = unmarshalScalar(curve, msg.Zr); !ok {
+		return nil, errors.New("UL proof contains an invalid scalar")
+	}
+	*p = proof
+	return p, nil
+}
+
+/*
+DecodeProof reads a UL proof made with the params p, in the versioned protobuf encoding or,
+for proofs on BN256 written before it existed, in the unversioned encoding. Either encoding
+is read, but a proof made before the challenge covered the commitment does not verify.
+*/
+func (p *ParamsULVerifier) DecodeProof(m []byte) (*ProofULVerifier, error) {
+	if p.curve.ID() == pairing.BN256 && int64(len(m)) == ulProofLength(p.l) {
+		if proof, err := new(ProofULVerifier).Unmarshal(m, p.l); err == nil {
+			return proof, nil
+		}
+	}
+	proof, err := new(ProofULVerifier).UnmarshalProto(m)
+	if err != nil {
+		return nil, err
+	}
+	return proof, p.checkProof(proof)
+}
+
+// checkProof checks that a proof read from the versioned encoding was made with the params p
+func (p *ParamsULVerifier) checkProof(proof *ProofULVerifier) error {
+	if proof.curve.ID() != p.curve.ID() {
+		return ErrCurveMismatch
+	}
+	if proof.u != p.u || int64(len(proof.V)) != p.l {
+		return errors.New("UL proof was made with different params")
+	}
+	return nil
+}
+
+/*
+MarshalProto is for marshaling the ParamsULVerifier into the versioned protobuf encoding,
+with compressed group elements
+*/
+func (p *ParamsULVerifier) MarshalProto() ([]byte, error) {
+	return proto.Marshal(&pb.ParamsULVerifier{
+		Scheme:  pb.RangeProofType_UL,
+		Version: VersionUL,
+		Curve:   pb.CurveType(p.curve.ID()),
+		U:       p.u,
+		L:       p.l,
+		H:       p.H.MarshalCompressed(),
+		Pubk:    p.pubk.MarshalCompressed(),
+	})
+}
+
+/*
+UnmarshalProto is for converting the versioned protobuf encoding back into ParamsULVerifier.
+It returns an error if the scheme, version, curve, a group element or u and l are not valid.
+*/
+func (p *ParamsULVerifier) UnmarshalProto(m []byte) (*ParamsULVerifier, error) {
+	msg := &pb.ParamsULVerifier{}
+	if err := proto.Unmarshal(m, msg); err != nil {
+		return nil, err
+	}
+	if msg.Scheme != pb.RangeProofType_UL || msg.Version != VersionUL {
+		return nil, errors.New("UL params have an unsupported scheme or version")
+	}
+	curve, err := pairing.Lookup(pairing.ID(msg.Curve))
+	if err != nil {
+		return nil, errors.New("UL params are on an unknown curve")
+	}
+	H, ok := curve.NewG2().Unmarshal(msg.H)
+	if !ok || H.IsZero() {
+		return nil, errors.New("UL params contain an invalid H")
+	}
+	pubk, ok := curve.NewG1().Unmarshal(msg.Pubk)
+	if !ok || pubk.IsZero() {
+		return nil, errors.New("UL params contain an invalid public key")
+	}
+	if !validUL(msg.U, msg.L) {
+		return nil, errors.New("UL params contain an unsupported u or l")
+	}
+	p.curve, p.H, p.pubk, p.u, p.l = curve, H, pubk, msg.U, msg.L
+	return p, nil
+}
+
+/*
+DecodeParamsUL reads UL params in the versioned protobuf encoding or,
+for BN256 params stored before it existed, in the unversioned encoding.
+*/
+func DecodeParamsUL(m []byte) (*ParamsULVerifier, error) {
+	if params, err := new(ParamsULVerifier).Unmarshal(m); err == nil {
+		return params, nil
+	}
+	return new(ParamsULVerifier).UnmarshalProto(m)
+}
+
+// unmarshalScalar reads a big-endian scalar and checks it is reduced modulo the group order of the curve
+func unmarshalScalar(curve pairing.Curve, m []byte) (*big.Int, bool) {
+	if len(m) > 32 {
+		return nil, false
+	}
+	e := new(big.Int).SetBytes(m)
+	return e, e.Cmp(curve.Order()) < 0
+}
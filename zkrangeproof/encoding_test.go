@@ -0,0 +1,172 @@
+package zkrangeproof
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+	pb "github.com/blockchain-research/gridlock/proto"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+Tests that a proof in the versioned encoding is read without knowing l, and that proofs
+in the unversioned encoding are still accepted.
+*/
+func TestProofULMarshalProto(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	proofs, cms := sampleProofsUL(t, p, []int64{176})
+
+	proofBytes, err := proofs[0].MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto failed: %v", err)
+	}
+	proof, err := new(ProofULVerifier).UnmarshalProto(proofBytes)
+	if err != nil {
+		t.Fatalf("UnmarshalProto failed: %v", err)
+	}
+	if proof.u != 10 || len(proof.V) != 3 || !bytes.Equal(proof.Marshal(), proofs[0].Marshal()) {
+		t.Error("Assert failure: the decoded proof differs")
+	}
+	for _, zkrp := range [][]byte{proofBytes, proofs[0].Marshal()} {
+		result, err := paramsVerifier.VerifyCommitment(zkrp, cms[0])
+		if result != true || err != nil {
+			t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+		}
+	}
+
+	// a proof made with other params is rejected before it is verified
+	other, _ := SetupUL(10, 4)
+	otherVerifier := GenerateParamsVefifier(&other)
+	if _, err := otherVerifier.VerifyCommitment(proofBytes, cms[0]); err == nil {
+		t.Error("Assert failure: expected an error for a proof made with a different l")
+	}
+
+	msg := &pb.ProofULVerifier{}
+	proto.Unmarshal(proofBytes, msg)
+	msg.Version = VersionUL + 1
+	unknown, _ := proto.Marshal(msg)
+	if _, err := new(ProofULVerifier).UnmarshalProto(unknown); err == nil {
+		t.Error("Assert failure: expected an error for an unknown version")
+	}
+	msg.Version = VersionUL
+	msg.Zv = msg.Zv[1:]
+	short, _ := proto.Marshal(msg)
+	if _, err := new(ProofULVerifier).UnmarshalProto(short); err == nil {
+		t.Error("Assert failure: expected an error for a missing element")
+	}
+}
+
+/*
+Tests that the versioned encodings written with uncompressed group elements, before
+MarshalProto compressed them, are still accepted.
+*/
+func TestMarshalProtoUncompressed(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	proofs, cms := sampleProofsUL(t, p, []int64{176})
+
+	msg, err := proofs[0].toProto()
+	if err != nil {
+		t.Fatalf("toProto failed: %v", err)
+	}
+	msg.D, msg.Cm = proofs[0].D.Marshal(), proofs[0].C.Marshal()
+	for i := range msg.V {
+		msg.V[i], msg.A[i] = proofs[0].V[i].Marshal(), proofs[0].a[i].Marshal()
+	}
+	proofBytes, _ := proto.Marshal(msg)
+	compressed, _ := proofs[0].MarshalProto()
+	if len(compressed) >= len(proofBytes) {
+		t.Errorf("compressed proof of %d bytes is not smaller than %d bytes", len(compressed), len(proofBytes))
+	}
+	result, err := paramsVerifier.VerifyCommitment(proofBytes, cms[0])
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+
+	paramsBytes, _ := proto.Marshal(&pb.ParamsULVerifier{
+		Scheme:  pb.RangeProofType_UL,
+		Version: VersionUL,
+		Curve:   pb.CurveType(paramsVerifier.curve.ID()),
+		U:       paramsVerifier.u,
+		L:       paramsVerifier.l,
+		H:       paramsVerifier.H.Marshal(),
+		Pubk:    paramsVerifier.pubk.Marshal(),
+	})
+	params, err := DecodeParamsUL(paramsBytes)
+	if err != nil {
+		t.Fatalf("DecodeParamsUL failed: %v", err)
+	}
+	if !bytes.Equal(params.Marshal(), paramsVerifier.Marshal()) {
+		t.Error("Assert failure: the decoded params differ")
+	}
+}
+
+/*
+Tests that params are read from both encodings.
+*/
+func TestDecodeParamsUL(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	paramsBytes, err := paramsVerifier.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto failed: %v", err)
+	}
+	for _, m := range [][]byte{paramsBytes, paramsVerifier.Marshal()} {
+		params, err := DecodeParamsUL(m)
+		if err != nil {
+			t.Fatalf("DecodeParamsUL failed: %v", err)
+		}
+		if !bytes.Equal(params.Marshal(), paramsVerifier.Marshal()) {
+			t.Error("Assert failure: the decoded params differ")
+		}
+	}
+	if _, err := DecodeParamsUL(paramsBytes[:len(paramsBytes)-1]); err == nil {
+		t.Error("Assert failure: expected an error for truncated params")
+	}
+}
+
+/*
+Tests UL proofs on BLS12-381: they are only read from the versioned encoding, which carries
+the curve, and are rejected by params or with commitments on another curve.
+*/
+func TestProofULOnBLS12381(t *testing.T) {
+	p, _ := SetupULOnCurve(pairing.MustLookup(pairing.BLS12381), 10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	proofs, cms := sampleProofsUL(t, p, []int64{176})
+
+	paramsBytes, err := paramsVerifier.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto failed: %v", err)
+	}
+	params, err := DecodeParamsUL(paramsBytes)
+	if err != nil {
+		t.Fatalf("DecodeParamsUL failed: %v", err)
+	}
+	if params.Curve().ID() != pairing.BLS12381 {
+		t.Errorf("Assert failure: expected params on BLS12-381, actual: %s", params.Curve().ID())
+	}
+
+	proofBytes, err := proofs[0].MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto failed: %v", err)
+	}
+	result, err := params.VerifyCommitment(proofBytes, cms[0])
+	if result != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", result, err)
+	}
+
+	bn, _ := SetupUL(10, 3)
+	bnVerifier := GenerateParamsVefifier(&bn)
+	bnProofs, bnCms := sampleProofsUL(t, bn, []int64{176})
+	if _, err := params.VerifyCommitment(proofBytes, bnCms[0]); err != ErrCurveMismatch {
+		t.Errorf("Assert failure: expected a curve mismatch for the commitment, actual: %v", err)
+	}
+	if _, err := bnVerifier.VerifyCommitment(proofBytes, bnCms[0]); err != ErrCurveMismatch {
+		t.Errorf("Assert failure: expected a curve mismatch for the proof, actual: %v", err)
+	}
+	if result, err := VerifyUL(bnProofs[0], *params); result != false || err != ErrCurveMismatch {
+		t.Errorf("Assert failure: expected a curve mismatch, actual: %t, %v", result, err)
+	}
+}
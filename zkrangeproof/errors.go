@@ -0,0 +1,54 @@
+package zkrangeproof
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+Error is the reason a range proof was rejected. Code is stable, so that clients can switch on it,
+while the message may carry more detail.
+*/
+type Error struct {
+	Code string
+	msg  string
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+var (
+	// ErrMalformedProof means the proof could not be decoded or misses elements
+	ErrMalformedProof = &Error{"ZKRP_MALFORMED_PROOF", "The range proof is malformed"}
+	// ErrChallengeMismatch means the Fiat-Shamir challenge is not the hash of the proof,
+	// or the responses do not open D under it
+	ErrChallengeMismatch = &Error{"ZKRP_CHALLENGE_MISMATCH", "The range proof challenge does not match"}
+	// ErrPairingCheck means a signature of a digit does not verify
+	ErrPairingCheck = &Error{"ZKRP_PAIRING_CHECK", "The range proof pairing check failed"}
+	// ErrCommitmentMismatch means the proof is about another commitment than the one expected
+	ErrCommitmentMismatch = &Error{"ZKRP_COMMITMENT_MISMATCH", "The range proof is not about the expected commitment"}
+	// ErrCurveMismatch means the proof or the commitment is on another curve than the params
+	ErrCurveMismatch = &Error{"ZKRP_CURVE_MISMATCH", "The range proof is not on the curve of the params"}
+	// ErrVerificationFailed means a scheme that does not report the reason rejected the proof
+	ErrVerificationFailed = &Error{"ZKRP_VERIFICATION_FAILED", "The range proof verification failed"}
+)
+
+// malformed wraps a decoding error into ErrMalformedProof, unless it already tells why the proof was rejected
+func malformed(err error) error {
+	if ErrorCode(err) != "" {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrMalformedProof, err)
+}
+
+/*
+ErrorCode returns the code of the range proof error wrapped in err, or "" when there is none.
+*/
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package zkrangeproof
+
+import (
+	"bytes"
+	"testing"
+)
+
+// The seed corpus of every decoder holds valid encodings, truncated and extended
+// encodings and encodings with flipped bits.
+func fuzzSeeds(f *testing.F, valid ...[]byte) {
+	for _, m := range valid {
+		f.Add(m)
+		f.Add(m[:len(m)-1])
+		f.Add(append(append([]byte{}, m...), 0))
+		flipped := append([]byte{}, m...)
+		flipped[len(m)/2] ^= 1
+		f.Add(flipped)
+	}
+	f.Add([]byte{})
+}
+
+func FuzzParamsULVerifierUnmarshal(f *testing.F) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	fuzzSeeds(f, paramsVerifier.Marshal())
+	f.Fuzz(func(t *testing.T, m []byte) {
+		params, err := new(ParamsULVerifier).Unmarshal(m)
+		if err != nil {
+			return
+		}
+		if !validUL(params.u, params.l) {
+			t.Fatalf("decoded unsupported params u=%d l=%d", params.u, params.l)
+		}
+		again, err := new(ParamsULVerifier).Unmarshal(params.Marshal())
+		if err != nil || !bytes.Equal(again.Marshal(), params.Marshal()) {
+			t.Fatal("decoded params do not round-trip")
+		}
+	})
+}
+
+func FuzzProofULVerifierUnmarshal(f *testing.F) {
+	p, _ := SetupUL(10, 3)
+	proofs, _ := sampleProofsUL(f, p, []int64{176})
+	fuzzSeeds(f, proofs[0].Marshal())
+	f.Fuzz(func(t *testing.T, m []byte) {
+		proof, err := new(ProofULVerifier).Unmarshal(m, 3)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(proof.Marshal(), m) {
+			t.Fatal("a decoded UL proof does not round-trip")
+		}
+	})
+}
+
+func FuzzProofCCS08Unmarshal(f *testing.F) {
+	p, _ := SetupUL(10, 3)
+	proofs, _ := sampleProofsUL(f, p, []int64{176, 500})
+	proof := ProofCCS08{P1: *proofs[0], P2: *proofs[1]}
+	fuzzSeeds(f, proof.Marshal())
+	f.Fuzz(func(t *testing.T, m []byte) {
+		proof, err := new(ProofCCS08).Unmarshal(m, 3)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(proof.Marshal(), m) {
+			t.Fatal("a decoded CCS08 proof does not round-trip")
+		}
+	})
+}
+
+func FuzzParamsULVerifierUnmarshalProto(f *testing.F) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	paramsBytes, _ := paramsVerifier.MarshalProto()
+	fuzzSeeds(f, paramsBytes)
+	f.Fuzz(func(t *testing.T, m []byte) {
+		params, err := new(ParamsULVerifier).UnmarshalProto(m)
+		if err != nil {
+			return
+		}
+		if !validUL(params.u, params.l) {
+			t.Fatalf("decoded unsupported params u=%d l=%d", params.u, params.l)
+		}
+	})
+}
+
+func FuzzProofULVerifierUnmarshalProto(f *testing.F) {
+	p, _ := SetupUL(10, 3)
+	proofs, _ := sampleProofsUL(f, p, []int64{176})
+	proofBytes, _ := proofs[0].MarshalProto()
+	fuzzSeeds(f, proofBytes)
+	f.Fuzz(func(t *testing.T, m []byte) {
+		proof, err := new(ProofULVerifier).UnmarshalProto(m)
+		if err != nil {
+			return
+		}
+		again, err := proof.MarshalProto()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(ProofULVerifier).UnmarshalProto(again); err != nil {
+			t.Fatalf("a decoded UL proof does not round-trip: %v", err)
+		}
+	})
+}
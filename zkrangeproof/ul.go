@@ -12,12 +12,13 @@ package zkrangeproof
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"math/big"
 	"strconv"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
 /*
@@ -25,8 +26,9 @@ paramsUL contains elements generated by the verifier, which are necessary for th
 This must be computed in a trusted setup.
 */
 type paramsUL struct {
-	signatures map[string]*bn256.G2
-	H          *bn256.G2
+	curve      pairing.Curve
+	signatures map[string]pairing.G2
+	H          pairing.G2
 	// TODO:must protect the private key
 	kp keypair
 	// u determines the amount of signatures we need in the public params.
@@ -42,53 +44,100 @@ type paramsUL struct {
 ParamsULVerifier contains the parameters required for verification of zkrp
 */
 type ParamsULVerifier struct {
-	H    *bn256.G2
-	pubk *bn256.G1
-	u, l int64
+	curve pairing.Curve
+	H     pairing.G2
+	pubk  pairing.G1
+	u, l  int64
+}
+
+/*
+Curve returns the curve of the params, which commitments and proofs verified with them must be on.
+*/
+func (p *ParamsULVerifier) Curve() pairing.Curve {
+	return p.curve
+}
+
+/*
+UpperBound returns u^l, the proofs verified with the params show committed values are in [0,u^l).
+*/
+func (p *ParamsULVerifier) UpperBound() *big.Int {
+	return new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(p.l), nil)
 }
 
 /*
 proofUL contains the necessary elements for the ZK proof.
 */
 type proofUL struct {
-	V              []*bn256.G2
-	D, C           *bn256.G2
-	a              []*bn256.GT
+	curve          pairing.Curve
+	V              []pairing.G2
+	D, C           pairing.G2
+	a              []pairing.GT
 	s, t, zsig, zv []*big.Int
 	c, m, zr       *big.Int
+	u              int64
 }
 
 /*
 ProofULVerifier contains the public output which will be used for verification
 */
 type ProofULVerifier struct {
-	V        []*bn256.G2
-	D, C     *bn256.G2
-	a        []*bn256.GT
+	curve    pairing.Curve
+	V        []pairing.G2
+	D, C     pairing.G2
+	a        []pairing.GT
 	zsig, zv []*big.Int
 	c, zr    *big.Int
+	// u of the params the proof was made with, 0 when it was read from the unversioned encoding
+	u int64
 }
 
 /*
-SetupUL generates the signature for the interval [0,u^l).
+SetupUL generates the signature for the interval [0,u^l) on BN256.
 The value of u should be roughly b/log(b), but we can choose smaller values in
 order to get smaller parameters, at the cost of having worse performance.
 */
 func SetupUL(u, l int64) (paramsUL, error) {
+	return SetupULOnCurve(pairing.MustLookup(pairing.BN256), u, l)
+}
+
+// domainH is hashed to the h of the commitments g^x.h^r, whose discrete logarithm to g nobody knows
+const domainH = "gridlock/ul/h"
+
+// legacyH is the discrete logarithm of the h of the params made before h was hashed. Commitments
+// with that h are not binding: whoever knows it opens them to any value.
+var legacyH = GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
+
+/*
+LegacyH returns the h that SetupULOnCurve used on the curve c before h was hashed, g^legacyH.
+*/
+func LegacyH(c pairing.Curve) pairing.G2 {
+	return c.NewG2().ScalarBaseMult(legacyH)
+}
+
+/*
+HasLegacyH reports whether the h of the params is LegacyH, with which the commitments are not binding.
+*/
+func (p *ParamsULVerifier) HasLegacyH() bool {
+	return bytes.Equal(p.H.Marshal(), LegacyH(p.curve).Marshal())
+}
+
+/*
+SetupULOnCurve generates the signature for the interval [0,u^l) on the curve c.
+*/
+func SetupULOnCurve(c pairing.Curve, u, l int64) (paramsUL, error) {
 	var (
 		i int64
 		p paramsUL
 	)
-	p.kp, _ = keygen()
+	p.curve = c
+	p.kp, _ = keygen(c)
 
-	p.signatures = make(map[string]*bn256.G2)
+	p.signatures = make(map[string]pairing.G2)
 	for i = 0; i < u; i++ {
-		sig_i, _ := sign(new(big.Int).SetInt64(i), p.kp.privk)
+		sig_i, _ := sign(c, new(big.Int).SetInt64(i), p.kp.privk)
 		p.signatures[strconv.FormatInt(i, 10)] = sig_i
 	}
-	//TODO: protect the 'master' key
-	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	p.H = new(bn256.G2).ScalarBaseMult(h)
+	p.H = c.HashToG2([]byte(domainH))
 	p.u = u
 	p.l = l
 	return p, nil
@@ -97,75 +146,81 @@ func SetupUL(u, l int64) (paramsUL, error) {
 // GenerateParamsVefifier generates the public output for verification later
 func GenerateParamsVefifier(p *paramsUL) ParamsULVerifier {
 	return ParamsULVerifier{
-		H:    p.H,
-		u:    p.u,
-		l:    p.l,
-		pubk: p.kp.pubk,
+		curve: p.curve,
+		H:     p.H,
+		u:     p.u,
+		l:     p.l,
+		pubk:  p.kp.pubk,
 	}
 }
 
 /*
 ProveUL method is used to produce the ZKRP proof that secret x belongs to the interval [0,U^L].
+The scalar multiplications by the secret randomness run in constant time. cm must be on the
+curve of the params.
 */
-func ProveUL(x, r *big.Int, cm *bn256.G2, p paramsUL) (proofUL, error) {
+func ProveUL(x, r *big.Int, cm pairing.G2, p paramsUL) (proofUL, error) {
 	var (
 		i         int64
 		v         []*big.Int
 		proof_out proofUL
 	)
+	c := p.curve
+	k := constantsOf(c)
 	decx, _ := Decompose(x, p.u, p.l)
 
 	// Initialize variables
 	v = make([]*big.Int, p.l, p.l)
-	proof_out.V = make([]*bn256.G2, p.l, p.l)
-	proof_out.a = make([]*bn256.GT, p.l, p.l)
+	proof_out.curve = c
+	proof_out.V = make([]pairing.G2, p.l, p.l)
+	proof_out.a = make([]pairing.GT, p.l, p.l)
 	proof_out.s = make([]*big.Int, p.l, p.l)
 	proof_out.t = make([]*big.Int, p.l, p.l)
 	proof_out.zsig = make([]*big.Int, p.l, p.l)
 	proof_out.zv = make([]*big.Int, p.l, p.l)
-	proof_out.D = new(bn256.G2)
+	proof_out.D = c.NewG2()
 	proof_out.D.SetInfinity()
-	proof_out.m, _ = rand.Int(rand.Reader, bn256.Order)
+	proof_out.m, _ = rand.Int(rand.Reader, c.Order())
+	proof_out.u = p.u
 
-	// D = H^m
-	D := new(bn256.G2).ScalarMult(p.H, proof_out.m)
+	// D = H^m.g^(sum of s_i.u^i), with a single multiplication of g
+	D := c.NewG2().ScalarMultTable(pairing.CachedG2Table(p.H), proof_out.m)
+	sum := new(big.Int)
 	for i = 0; i < p.l; i++ {
-		v[i], _ = rand.Int(rand.Reader, bn256.Order)
+		v[i], _ = rand.Int(rand.Reader, c.Order())
 		A, ok := p.signatures[strconv.FormatInt(decx[i], 10)]
 		if ok {
-			proof_out.V[i] = new(bn256.G2).ScalarMult(A, v[i])
-			proof_out.s[i], _ = rand.Int(rand.Reader, bn256.Order)
-			proof_out.t[i], _ = rand.Int(rand.Reader, bn256.Order)
-			proof_out.a[i] = bn256.Pair(G1, proof_out.V[i])
+			proof_out.V[i] = c.NewG2().ScalarMultCT(A, v[i])
+			proof_out.s[i], _ = rand.Int(rand.Reader, c.Order())
+			proof_out.t[i], _ = rand.Int(rand.Reader, c.Order())
+			proof_out.a[i] = c.Pair(k.G1, proof_out.V[i])
 			proof_out.a[i].ScalarMult(proof_out.a[i], proof_out.s[i])
 			proof_out.a[i].Invert(proof_out.a[i])
-			proof_out.a[i].Add(proof_out.a[i], new(bn256.GT).ScalarMult(E, proof_out.t[i]))
+			proof_out.a[i].Add(proof_out.a[i], c.NewGT().ScalarMult(k.E, proof_out.t[i]))
 
 			ui := new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(i), nil)
 			muisi := new(big.Int).Mul(proof_out.s[i], ui)
-			muisi = Mod(muisi, bn256.Order)
-			aux := new(bn256.G2).ScalarBaseMult(muisi)
-			D.Add(D, aux)
+			sum.Add(sum, muisi)
 		} else {
 			return proof_out, errors.New("Could not generate proof. Element does not belong to the interval.")
 		}
 	}
+	D.Add(D, c.NewG2().ScalarBaseMultCT(Mod(sum, c.Order())))
 	proof_out.D.Add(proof_out.D, D)
 
 	// Consider passing C as input,
 	// so that it is possible to delegate the commitment computation to an external party.
 	proof_out.C = cm //Commit(x, r, p.H)
 	// Fiat-Shamir heuristic
-	proof_out.c, _ = Hash(proof_out.a, proof_out.D)
-	proof_out.c = Mod(proof_out.c, bn256.Order)
+	proof_out.c = challengeUL(c, proof_out.a, proof_out.D, proof_out.C)
 
 	proof_out.zr = Sub(proof_out.m, Multiply(r, proof_out.c))
-	proof_out.zr = Mod(proof_out.zr, bn256.Order)
+	proof_out.zr = Mod(proof_out.zr, c.Order())
 	for i = 0; i < p.l; i++ {
 		proof_out.zsig[i] = Sub(proof_out.s[i], Multiply(new(big.Int).SetInt64(decx[i]), proof_out.c))
-		proof_out.zsig[i] = Mod(proof_out.zsig[i], bn256.Order)
+		proof_out.zsig[i] = Mod(proof_out.zsig[i], c.Order())
 		proof_out.zv[i] = Sub(proof_out.t[i], Multiply(v[i], proof_out.c))
-		proof_out.zv[i] = Mod(proof_out.zv[i], bn256.Order)
+		proof_out.zv[i] = Mod(proof_out.zv[i], c.Order())
 	}
 	return proof_out, nil
 }
@@ -175,62 +230,261 @@ func ProveUL(x, r *big.Int, cm *bn256.G2, p paramsUL) (proofUL, error) {
 //(l+2)|G2| + l|GT| + (2l+2)|BINT|
 func GenerateProofVerifier(p proofUL) ProofULVerifier {
 	return ProofULVerifier{
-		V:    p.V,
-		D:    p.D,
-		C:    p.C,
-		a:    p.a,
-		zsig: p.zsig,
-		zv:   p.zv,
-		c:    p.c,
-		zr:   p.zr,
+		curve: p.curve,
+		V:     p.V,
+		D:     p.D,
+		C:     p.C,
+		a:     p.a,
+		zsig:  p.zsig,
+		zv:    p.zv,
+		c:     p.c,
+		zr:    p.zr,
+		u:     p.u,
 	}
 }
 
 /*
-VerifyUL is used to validate the ZKRP proof. It returns true iff the proof is valid.
+VerifyUL is used to validate the ZKRP proof. It returns true iff the proof is valid,
+otherwise the error tells which check failed.
 */
 func VerifyUL(proof_out *ProofULVerifier, p ParamsULVerifier) (bool, error) {
 	var (
 		i      int64
-		D      *bn256.G2
+		D      pairing.G2
 		r1, r2 bool
-		p1, p2 *bn256.GT
+		p1     pairing.GT
 	)
+	if !wellFormedUL(proof_out, p.l) {
+		return false, ErrMalformedProof
+	}
+	if proof_out.curve.ID() != p.curve.ID() {
+		return false, ErrCurveMismatch
+	}
+	c := p.curve
+	k := constantsOf(c)
+	if proof_out.c.Cmp(challengeUL(c, proof_out.a, proof_out.D, proof_out.C)) != 0 {
+		return false, ErrChallengeMismatch
+	}
 	// D == C^c.h^ zr.g^zsig ?
-	D = new(bn256.G2).ScalarMult(proof_out.C, proof_out.c)
-	D.Add(D, new(bn256.G2).ScalarMult(p.H, proof_out.zr))
+	zsigSum := new(big.Int)
 	for i = 0; i < p.l; i++ {
 		ui := new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(i), nil)
-		muizsigi := new(big.Int).Mul(proof_out.zsig[i], ui)
-		muizsigi = Mod(muizsigi, bn256.Order)
-		aux := new(bn256.G2).ScalarBaseMult(muizsigi)
-		D.Add(D, aux)
+		zsigSum.Add(zsigSum, new(big.Int).Mul(proof_out.zsig[i], ui))
 	}
+	D = c.NewG2().MultiScalarMult([]pairing.G2{proof_out.C, p.H}, []*big.Int{proof_out.c, proof_out.zr})
+	D.Add(D, c.NewG2().ScalarBaseMult(Mod(zsigSum, c.Order())))
 
 	DBytes := D.Marshal()
 	pDBytes := proof_out.D.Marshal()
 	r1 = bytes.Equal(DBytes, pDBytes)
+	if !r1 {
+		return false, ErrChallengeMismatch
+	}
 
 	r2 = true
 	for i = 0; i < p.l; i++ {
-		// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
-		p1 = bn256.Pair(p.pubk, proof_out.V[i])
-		p1.ScalarMult(p1, proof_out.c)
-		p2 = bn256.Pair(G1, proof_out.V[i])
-		p2.ScalarMult(p2, proof_out.zsig[i])
-		p2.Invert(p2)
-		p1.Add(p1, p2)
-		p1.Add(p1, new(bn256.GT).ScalarMult(E, proof_out.zv[i]))
+		// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv] == e(y^c.g^-zsig, V).e(g^zv, g)
+		yg := c.NewG1().MultiScalarMult([]pairing.G1{p.pubk, k.G1}, []*big.Int{proof_out.c, Mod(new(big.Int).Neg(proof_out.zsig[i]), c.Order())})
+		gzv := c.NewG1().ScalarBaseMult(Mod(proof_out.zv[i], c.Order()))
+		p1 = c.PairingProduct([]pairing.G1{yg, gzv}, []pairing.G2{proof_out.V[i], k.G2})
 
 		pBytes := p1.Marshal()
 		aBytes := proof_out.a[i].Marshal()
 		r2 = r2 && bytes.Equal(pBytes, aBytes)
 	}
-	return r1 && r2, nil
+	if !r2 {
+		return false, ErrPairingCheck
+	}
+	return true, nil
+}
+
+// challengeUL is the Fiat-Shamir challenge of a UL proof on the curve c, computed on the canonical
+// encoding of the a_i, D and the commitment C so that the verifier can recompute it. It replaces
+// Hash(a, D), which did not bind the proof to C, so the proofs made with that challenge are rejected
+func challengeUL(c pairing.Curve, a []pairing.GT, D, C pairing.G2) *big.Int {
+	digest := sha256.New()
+	digest.Write([]byte("gridlock/ul"))
+	for i := range a {
+		digest.Write(a[i].Marshal())
+	}
+	digest.Write(D.Marshal())
+	digest.Write(C.Marshal())
+	return Mod(new(big.Int).SetBytes(digest.Sum(nil)), c.Order())
 }
 
 /*
-Marshal is for marshaling the ParamsULVerifier into []byte
+BatchVerifyUL is used to validate many ZKRP proofs for the same params at once.
+The equations of all the proofs are combined with random weights, so the verifier
+computes 2 pairings in total instead of 2l pairings per proof. A batch of valid proofs
+always passes, while an invalid proof passes only with negligible probability.
+When the batch fails, the proofs are verified one by one and the index of the first
+invalid proof is returned, otherwise the index is -1.
+*/
+func BatchVerifyUL(proofs []*ProofULVerifier, p ParamsULVerifier) (bool, int, error) {
+	if len(proofs) == 0 {
+		return false, -1, errors.New("No UL proofs to verify")
+	}
+	for k, proof := range proofs {
+		if !wellFormedUL(proof, p.l) {
+			return false, k, ErrMalformedProof
+		}
+		if proof.curve.ID() != p.curve.ID() {
+			return false, k, ErrCurveMismatch
+		}
+		if proof.c.Cmp(challengeUL(p.curve, proof.a, proof.D, proof.C)) != 0 {
+			return false, k, ErrChallengeMismatch
+		}
+	}
+	if batchCheckUL(proofs, p) {
+		return true, -1, nil
+	}
+
+	//fall back to identifying the invalid proof
+	for k, proof := range proofs {
+		result, err := VerifyUL(proof, p)
+		if err != nil || result != true {
+			return false, k, err
+		}
+	}
+	//unreachable: the combined equations hold whenever every proof is valid
+	return true, -1, nil
+}
+
+// wellFormedUL checks that the proof has all the elements VerifyUL reads,
+// a V at infinity would leave the digit unconstrained
+func wellFormedUL(proof *ProofULVerifier, l int64) bool {
+	if proof == nil || proof.curve == nil || proof.D == nil || proof.C == nil || proof.c == nil || proof.zr == nil {
+		return false
+	}
+	if int64(len(proof.V)) != l || int64(len(proof.a)) != l || int64(len(proof.zsig)) != l || int64(len(proof.zv)) != l {
+		return false
+	}
+	for i := int64(0); i < l; i++ {
+		if proof.V[i] == nil || proof.V[i].IsZero() || proof.a[i] == nil || proof.zsig[i] == nil || proof.zv[i] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+batchCheckUL checks the random linear combination of the equations of all the proofs:
+sum(delta_k.(D_k - C_k^c_k)) == h^sum(delta_k.zr_k).g^sum(delta_k.sum(u^i.zsig_ki))
+prod(a_ki^rho_ki) == e(y, sum(rho_ki.c_k.V_ki)).e(g, sum(-rho_ki.zsig_ki.V_ki) + g^sum(rho_ki.zv_ki))
+*/
+func batchCheckUL(proofs []*ProofULVerifier, p ParamsULVerifier) bool {
+	var i int64
+	ui := make([]*big.Int, p.l)
+	for i = 0; i < p.l; i++ {
+		ui[i] = new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(i), nil)
+	}
+
+	c := p.curve
+	//the sums of multiples of D_k and C_k and of V_ki are each computed with a multi-scalar multiplication
+	var dPoints, yPoints []pairing.G2
+	var dScalars, yScalars, xScalars []*big.Int
+	hExp := new(big.Int)
+	gExp := new(big.Int)
+	var a pairing.GT
+	zvExp := new(big.Int)
+	for _, proof := range proofs {
+		delta := batchWeight()
+		dPoints = append(dPoints, proof.D, proof.C)
+		dScalars = append(dScalars, delta, Mod(new(big.Int).Neg(Multiply(delta, proof.c)), c.Order()))
+		hExp = Mod(new(big.Int).Add(hExp, Multiply(delta, proof.zr)), c.Order())
+		zsigSum := new(big.Int)
+		for i = 0; i < p.l; i++ {
+			zsigSum.Add(zsigSum, Multiply(ui[i], proof.zsig[i]))
+		}
+		gExp = Mod(new(big.Int).Add(gExp, Multiply(delta, zsigSum)), c.Order())
+
+		for i = 0; i < p.l; i++ {
+			rho := batchWeight()
+			ai := c.NewGT().ScalarMult(proof.a[i], rho)
+			if a == nil {
+				a = ai
+			} else {
+				a.Add(a, ai)
+			}
+			yPoints = append(yPoints, proof.V[i])
+			yScalars = append(yScalars, Mod(Multiply(rho, proof.c), c.Order()))
+			xScalars = append(xScalars, Mod(Multiply(rho, new(big.Int).Neg(proof.zsig[i])), c.Order()))
+			zvExp = Mod(new(big.Int).Add(zvExp, Multiply(rho, proof.zv[i])), c.Order())
+		}
+	}
+	D := c.NewG2().MultiScalarMult(dPoints, dScalars)
+	Y := c.NewG2().MultiScalarMult(yPoints, yScalars)
+	X := c.NewG2().MultiScalarMult(yPoints, xScalars)
+
+	rhs := c.NewG2().Add(c.NewG2().ScalarMult(p.H, hExp), c.NewG2().ScalarBaseMult(gExp))
+	if !bytes.Equal(D.Marshal(), rhs.Marshal()) {
+		return false
+	}
+
+	X.Add(X, c.NewG2().ScalarBaseMult(zvExp))
+	e := c.PairingProduct([]pairing.G1{p.pubk, constantsOf(c).G1}, []pairing.G2{Y, X})
+	return bytes.Equal(a.Marshal(), e.Marshal())
+}
+
+// batchWeight returns a random 128-bit weight for the linear combination
+func batchWeight() *big.Int {
+	w, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return w
+}
+
+/*
+BatchVerifyCommitments unmarshals every zkrp and checks that zkrps[k] proves the value committed
+in cms[k] is in [0,u^l). When it fails, it also returns the index of the first invalid proof.
+*/
+func (p *ParamsULVerifier) BatchVerifyCommitments(zkrps [][]byte, cms []pairing.G2) (bool, int, error) {
+	if len(zkrps) != len(cms) {
+		return false, -1, errors.New("The number of UL proofs and commitments differ")
+	}
+	proofs := make([]*ProofULVerifier, len(zkrps))
+	for k, zkrp := range zkrps {
+		proof, err := p.DecodeProof(zkrp)
+		if err != nil {
+			return false, k, malformed(err)
+		}
+		proofs[k] = proof
+		if cms[k] == nil || cms[k].Curve().ID() != p.curve.ID() {
+			return false, k, ErrCurveMismatch
+		}
+		if !bytes.Equal(proofs[k].C.Marshal(), cms[k].Marshal()) {
+			return false, k, ErrCommitmentMismatch
+		}
+	}
+	return BatchVerifyUL(proofs, *p)
+}
+
+// ulProofLength returns the length of a marshaled proof: (l+2)|G2| + l|GT| + (2l+2)|BINT|
+func ulProofLength(l int64) int64 {
+	const bLG2 int64 = 128
+	const bLGT int64 = 384
+	const bLInt int64 = 32
+	return (l+2)*bLG2 + l*bLGT + (2*l+2)*bLInt
+}
+
+/*
+VerifyCommitment unmarshals zkrp and checks that it proves the value committed in cm is in [0,u^l).
+*/
+func (p *ParamsULVerifier) VerifyCommitment(zkrp []byte, cm pairing.G2) (bool, error) {
+	proof, err := p.DecodeProof(zkrp)
+	if err != nil {
+		return false, malformed(err)
+	}
+	if cm == nil || cm.Curve().ID() != p.curve.ID() {
+		return false, ErrCurveMismatch
+	}
+	if !bytes.Equal(proof.C.Marshal(), cm.Marshal()) {
+		return false, ErrCommitmentMismatch
+	}
+	return VerifyUL(proof, *p)
+}
+
+/*
+Marshal is for marshaling the ParamsULVerifier into the unversioned []byte encoding,
+see MarshalProto for the versioned one. The unversioned encoding only exists for BN256.
 */
 func (p *ParamsULVerifier) Marshal() []byte {
 	const bLInt64 int = binary.MaxVarintLen64
@@ -257,28 +511,67 @@ func (p *ParamsULVerifier) Marshal() []byte {
 }
 
 /*
-UnMarshal is for converting []byte back into ParamsULVerifier
+UnMarshal is for converting []byte back into ParamsULVerifier on BN256.
+It returns an error if the length, a group element or u and l are not valid.
 */
-func (p *ParamsULVerifier) Unmarshal(m []byte) *ParamsULVerifier {
+func (p *ParamsULVerifier) Unmarshal(m []byte) (*ParamsULVerifier, error) {
 	const bLInt64 int64 = binary.MaxVarintLen64
 	const bLG2 int64 = 128
 	const bLG1 int64 = 64
+	if int64(len(m)) != bLG2+bLG1+2*bLInt64 {
+		return nil, errors.New("UL params have the wrong length")
+	}
+	var ok bool
+	p.curve = pairing.MustLookup(pairing.BN256)
 	//getting H
-	p.H, _ = new(bn256.G2).Unmarshal(m[0:bLG2])
+	p.H, ok = p.curve.NewG2().Unmarshal(m[0:bLG2])
+	if !ok || p.H.IsZero() {
+		return nil, errors.New("UL params contain an invalid H")
+	}
 
 	//getting pubk
-	p.pubk, _ = new(bn256.G1).Unmarshal(m[bLG2 : bLG1+bLG2])
+	p.pubk, ok = p.curve.NewG1().Unmarshal(m[bLG2 : bLG1+bLG2])
+	if !ok || p.pubk.IsZero() {
+		return nil, errors.New("UL params contain an invalid public key")
+	}
 
 	//getting u
-	p.u, _ = binary.Varint(m[bLG1+bLG2 : bLG1+bLG2+bLInt64])
+	var read int
+	p.u, read = binary.Varint(m[bLG1+bLG2 : bLG1+bLG2+bLInt64])
+	if read <= 0 {
+		return nil, errors.New("UL params contain an invalid u")
+	}
 
 	//getting l
-	p.l, _ = binary.Varint(m[bLG1+bLG2+bLInt64 : bLG1+bLG2+2*bLInt64])
-	return p
+	p.l, read = binary.Varint(m[bLG1+bLG2+bLInt64 : bLG1+bLG2+2*bLInt64])
+	if read <= 0 {
+		return nil, errors.New("UL params contain an invalid l")
+	}
+	if !validUL(p.u, p.l) {
+		return nil, errors.New("UL params contain an unsupported u or l")
+	}
+	return p, nil
+}
+
+// limits on the params accepted from the ledger
+const (
+	maxL      = 64
+	maxBitsUL = 128
+)
+
+// validUL checks u >= 2, 1 <= l <= maxL and u^l < 2^maxBitsUL, so that sums of values in range do not wrap around the group order
+func validUL(u, l int64) bool {
+	if u < 2 || l < 1 || l > maxL {
+		return false
+	}
+	ul := new(big.Int).Exp(new(big.Int).SetInt64(u), new(big.Int).SetInt64(l), nil)
+	return ul.BitLen() <= maxBitsUL
 }
 
 /*
-Marshal is for marshaling the ProofULVerifier into []byte
+Marshal is for marshaling the ProofULVerifier into the unversioned []byte encoding,
+which can only be read back knowing l, see MarshalProto for the versioned one.
+The unversioned encoding only exists for BN256.
 */
 func (p *ProofULVerifier) Marshal() []byte {
 	const bLInt int = 32
@@ -356,45 +649,68 @@ func (p *ProofULVerifier) Marshal() []byte {
 }
 
 /*
-UnMarshal is for converting []byte back into proofUL
+UnMarshal is for converting []byte back into proofUL on BN256.
+It returns an error if the length does not match L or a group element is not valid.
 */
-func (p *ProofULVerifier) Unmarshal(m []byte, L int64) *ProofULVerifier {
+func (p *ProofULVerifier) Unmarshal(m []byte, L int64) (*ProofULVerifier, error) {
 	const bLG2 int64 = 128
 	const bLGT int64 = 384
 	const bLInt int64 = 32
 	var i int64
+	if L < 1 || L > maxL || int64(len(m)) != ulProofLength(L) {
+		return nil, errors.New("UL proof has the wrong length")
+	}
+	p.curve = pairing.MustLookup(pairing.BN256)
+	unmarshalG2 := func(b []byte) (pairing.G2, error) {
+		e, ok := p.curve.NewG2().Unmarshal(b)
+		if !ok {
+			return nil, errors.New("UL proof contains an invalid G2 element")
+		}
+		return e, nil
+	}
+	var err error
 
 	//getting V
+	p.V = make([]pairing.G2, L)
 	for i = 0; i < L; i++ {
-		v, _ := new(bn256.G2).Unmarshal(m[i*bLG2 : (i+1)*bLG2])
-		p.V = append(p.V, v)
+		if p.V[i], err = unmarshalG2(m[i*bLG2 : (i+1)*bLG2]); err != nil {
+			return nil, err
+		}
 	}
 
 	//getting D
-	p.D, _ = new(bn256.G2).Unmarshal(m[L*bLG2 : (L+1)*bLG2])
+	if p.D, err = unmarshalG2(m[L*bLG2 : (L+1)*bLG2]); err != nil {
+		return nil, err
+	}
 
 	//getting C
-	p.C, _ = new(bn256.G2).Unmarshal(m[(L+1)*bLG2 : (L+2)*bLG2])
+	if p.C, err = unmarshalG2(m[(L+1)*bLG2 : (L+2)*bLG2]); err != nil {
+		return nil, err
+	}
 
 	//getting a
 	index := (L + 2) * bLG2
+	p.a = make([]pairing.GT, L)
 	for i = 0; i < L; i++ {
-		a, _ := new(bn256.GT).Unmarshal(m[index+i*bLGT : index+(i+1)*bLGT])
-		p.a = append(p.a, a)
+		a, ok := p.curve.NewGT().Unmarshal(m[index+i*bLGT : index+(i+1)*bLGT])
+		if !ok {
+			return nil, errors.New("UL proof contains an invalid GT element")
+		}
+		p.a[i] = a
 	}
 
 	//get zsig
 	index = (L+2)*bLG2 + L*bLGT
+	p.zsig = make([]*big.Int, L)
 	for i = 0; i < L; i++ {
-		zsig := new(big.Int).SetBytes(m[index+i*bLInt : index+(i+1)*bLInt])
-		p.zsig = append(p.zsig, zsig)
+		p.zsig[i] = new(big.Int).SetBytes(m[index+i*bLInt : index+(i+1)*bLInt])
 	}
 
 	//get zv
 	index = (L+2)*bLG2 + L*bLGT + L*bLInt
+	p.zv = make([]*big.Int, L)
 	for i = 0; i < L; i++ {
-		zv := new(big.Int).SetBytes(m[index+i*bLInt : index+(i+1)*bLInt])
-		p.zv = append(p.zv, zv)
+		p.zv[i] = new(big.Int).SetBytes(m[index+i*bLInt : index+(i+1)*bLInt])
 	}
 
 	//get c, m, zr
@@ -404,5 +720,5 @@ func (p *ProofULVerifier) Unmarshal(m []byte, L int64) *ProofULVerifier {
 	index = (L+2)*bLG2 + L*bLGT + (L*2+1)*bLInt
 	p.zr = new(big.Int).SetBytes(m[index : index+bLInt])
 
-	return p
+	return p, nil
 }
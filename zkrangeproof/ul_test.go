@@ -18,6 +18,7 @@ package zkrangeproof
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
@@ -25,6 +26,7 @@ import (
 	"time"
 
 	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
 /*
@@ -71,12 +73,18 @@ func TestZKRP_ULMarshal(t *testing.T) {
 	proofOut := GenerateProofVerifier(proof)
 
 	proofOutBytes := proofOut.Marshal()
-	proofOut2 := new(ProofULVerifier).Unmarshal(proofOutBytes, p.l)
+	proofOut2, err := new(ProofULVerifier).Unmarshal(proofOutBytes, p.l)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
 	fmt.Println(len(proofOutBytes))
 	paramsVerifier := GenerateParamsVefifier(&p)
 
 	paramsBytes := paramsVerifier.Marshal()
-	paramsVerifier2 := new(ParamsULVerifier).Unmarshal(paramsBytes)
+	paramsVerifier2, err := new(ParamsULVerifier).Unmarshal(paramsBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal of params failed: %v", err)
+	}
 
 	start = time.Now()
 	result, _ := VerifyUL(proofOut2, *paramsVerifier2)
@@ -89,3 +97,184 @@ func TestZKRP_ULMarshal(t *testing.T) {
 		t.Errorf("Assert failure: expected true, actual: %t", result)
 	}
 }
+
+func sampleProofsUL(t testing.TB, p paramsUL, values []int64) ([]*ProofULVerifier, []pairing.G2) {
+	proofs := make([]*ProofULVerifier, len(values))
+	cms := make([]pairing.G2, len(values))
+	for k, x := range values {
+		r, _ := rand.Int(rand.Reader, p.curve.Order())
+		cms[k], _ = Commit(new(big.Int).SetInt64(x), r, p.H)
+		proof, err := ProveUL(new(big.Int).SetInt64(x), r, cms[k], p)
+		if err != nil {
+			t.Fatalf("ProveUL failed for %d: %v", x, err)
+		}
+		proofVerifier := GenerateProofVerifier(proof)
+		proofs[k] = &proofVerifier
+	}
+	return proofs, cms
+}
+
+/*
+Tests that a batch of valid proofs passes and that an invalid proof is identified.
+*/
+func TestBatchVerifyUL(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	proofs, _ := sampleProofsUL(t, p, []int64{0, 7, 176, 999})
+
+	result, bad, err := BatchVerifyUL(proofs, paramsVerifier)
+	if result != true || bad != -1 || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %d, %v", result, bad, err)
+	}
+
+	// tamper with the response of the third proof
+	proofs[2].zv[1] = Mod(new(big.Int).Add(proofs[2].zv[1], big.NewInt(1)), bn256.Order)
+	result, bad, err = BatchVerifyUL(proofs, paramsVerifier)
+	if result != false || bad != 2 || err != ErrPairingCheck {
+		t.Errorf("Assert failure: expected a pairing check failure for proof 2, actual: %t, %d, %v", result, bad, err)
+	}
+
+	// a malformed proof is rejected before any pairing
+	proofs[1].V = proofs[1].V[:2]
+	result, bad, err = BatchVerifyUL(proofs, paramsVerifier)
+	if result != false || bad != 1 || err != ErrMalformedProof {
+		t.Errorf("Assert failure: expected a malformed proof 1, actual: %t, %d, %v", result, bad, err)
+	}
+	if _, _, err := BatchVerifyUL(nil, paramsVerifier); err == nil {
+		t.Error("Assert failure: expected an error for an empty batch")
+	}
+}
+
+/*
+Tests that the batch binds every proof to its own commitment.
+*/
+func TestBatchVerifyCommitmentsUL(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	proofs, cms := sampleProofsUL(t, p, []int64{5, 6, 7})
+	zkrps := make([][]byte, len(proofs))
+	for k, proof := range proofs {
+		zkrps[k] = proof.Marshal()
+	}
+
+	result, bad, err := VerifyCommitments(&paramsVerifier, zkrps, cms)
+	if result != true || bad != -1 || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %d, %v", result, bad, err)
+	}
+
+	cms[0], cms[1] = cms[1], cms[0]
+	result, bad, err = VerifyCommitments(&paramsVerifier, zkrps, cms)
+	if result != false || bad != 0 || err != ErrCommitmentMismatch {
+		t.Errorf("Assert failure: expected a commitment mismatch for proof 0, actual: %t, %d, %v", result, bad, err)
+	}
+	cms[0], cms[1] = cms[1], cms[0]
+
+	zkrps[2] = zkrps[2][1:]
+	if _, _, err := paramsVerifier.BatchVerifyCommitments(zkrps, cms); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("Assert failure: expected a malformed proof, actual: %v", err)
+	}
+}
+
+/*
+Tests that SetupUL hashes h, and that the params with the legacy h are told apart.
+*/
+func TestSetupULHashesH(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	if paramsVerifier.HasLegacyH() {
+		t.Error("Assert failure: SetupUL made params with the legacy h")
+	}
+	paramsVerifier.H = LegacyH(paramsVerifier.Curve())
+	if !paramsVerifier.HasLegacyH() {
+		t.Error("Assert failure: the legacy h is not told apart")
+	}
+}
+
+/*
+Tests that VerifyUL tells which check failed.
+*/
+func TestVerifyULErrors(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	paramsVerifier := GenerateParamsVefifier(&p)
+	proofs, _ := sampleProofsUL(t, p, []int64{176})
+	proof := proofs[0]
+
+	// a challenge that is not the hash of the proof
+	c := proof.c
+	proof.c = Mod(new(big.Int).Add(c, big.NewInt(1)), bn256.Order)
+	if result, err := VerifyUL(proof, paramsVerifier); result != false || err != ErrChallengeMismatch {
+		t.Errorf("Assert failure: expected a challenge mismatch, actual: %t, %v", result, err)
+	}
+	proof.c = c
+
+	// the challenge of the proofs made before it covered the commitment
+	legacy, _ := Hash(proof.a, proof.D)
+	proof.c = Mod(legacy, bn256.Order)
+	if result, err := VerifyUL(proof, paramsVerifier); result != false || err != ErrChallengeMismatch {
+		t.Errorf("Assert failure: expected the legacy challenge to be rejected, actual: %t, %v", result, err)
+	}
+	proof.c = c
+
+	// responses that do not open D
+	zr := proof.zr
+	proof.zr = Mod(new(big.Int).Add(zr, big.NewInt(1)), bn256.Order)
+	if result, err := VerifyUL(proof, paramsVerifier); result != false || err != ErrChallengeMismatch {
+		t.Errorf("Assert failure: expected a challenge mismatch, actual: %t, %v", result, err)
+	}
+	proof.zr = zr
+
+	proof.zv[0] = Mod(new(big.Int).Add(proof.zv[0], big.NewInt(1)), bn256.Order)
+	if result, err := VerifyUL(proof, paramsVerifier); result != false || err != ErrPairingCheck {
+		t.Errorf("Assert failure: expected a pairing check failure, actual: %t, %v", result, err)
+	}
+	if code := ErrorCode(errors.New("Failed to read account from ledger")); code != "" {
+		t.Errorf("Assert failure: expected no code for another error, actual: %s", code)
+	}
+	if code := ErrorCode(fmt.Errorf("zkrp1: %w", ErrPairingCheck)); code != "ZKRP_PAIRING_CHECK" {
+		t.Errorf("Assert failure: expected the code of a wrapped error, actual: %s", code)
+	}
+}
+
+/*
+benchmarkProofsUL returns n proofs with the chaincode parameters, cycling through a
+few distinct proofs since the cost of verification does not depend on the values.
+*/
+func benchmarkProofsUL(b *testing.B, n int) ([]*ProofULVerifier, ParamsULVerifier) {
+	p, _ := SetupUL(10, 10)
+	distinct, _ := sampleProofsUL(b, p, []int64{1, 22, 333, 4444, 55555})
+	proofs := make([]*ProofULVerifier, n)
+	for k := range proofs {
+		proofs[k] = distinct[k%len(distinct)]
+	}
+	return proofs, GenerateParamsVefifier(&p)
+}
+
+func BenchmarkVerifyUL(b *testing.B) {
+	for _, n := range []int{5, 50, 500} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			proofs, paramsVerifier := benchmarkProofsUL(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, proof := range proofs {
+					if result, _ := VerifyUL(proof, paramsVerifier); result != true {
+						b.Fatal("verification failed")
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBatchVerifyUL(b *testing.B) {
+	for _, n := range []int{5, 50, 500} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			proofs, paramsVerifier := benchmarkProofsUL(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if result, _, _ := BatchVerifyUL(proofs, paramsVerifier); result != true {
+					b.Fatal("batch verification failed")
+				}
+			}
+		})
+	}
+}
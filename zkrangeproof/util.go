@@ -19,17 +19,42 @@ package zkrangeproof
 import (
 	"crypto/sha256"
 	"math/big"
+	"sync"
 
-	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
 )
 
-//Constants that are going to be used frequently, then we just need to compute them once.
+/*
+constants are the generators of a curve and their pairing, which are used frequently,
+then we just need to compute them once per curve. They must not be modified.
+*/
+type constants struct {
+	G1 pairing.G1
+	G2 pairing.G2
+	E  pairing.GT
+}
+
 var (
-	G1 = new(bn256.G1).ScalarBaseMult(new(big.Int).SetInt64(1))
-	G2 = new(bn256.G2).ScalarBaseMult(new(big.Int).SetInt64(1))
-	E  = bn256.Pair(G1, G2)
+	constantsMu      sync.Mutex
+	constantsByCurve = map[pairing.ID]*constants{}
 )
 
+// constantsOf returns the constants of the curve c
+func constantsOf(c pairing.Curve) *constants {
+	constantsMu.Lock()
+	defer constantsMu.Unlock()
+	k, ok := constantsByCurve[c.ID()]
+	if !ok {
+		k = &constants{
+			G1: c.NewG1().ScalarBaseMult(new(big.Int).SetInt64(1)),
+			G2: c.NewG2().ScalarBaseMult(new(big.Int).SetInt64(1)),
+		}
+		k.E = c.Pair(k.G1, k.G2)
+		constantsByCurve[c.ID()] = k
+	}
+	return k
+}
+
 /*
 Decompose receives as input a bigint x and outputs an array of integers such that
 x = sum(xi.u^i), i.e. it returns the decomposition of x into base u.
@@ -51,34 +76,40 @@ func Decompose(x *big.Int, u int64, l int64) ([]int64, error) {
 
 /*
 Commit method corresponds to the Pedersen commitment scheme. Namely, given input
-message x, and randomness r, it outputs g^x.h^r.
+message x, and randomness r, it outputs g^x.h^r. Both are secret, so the scalar
+multiplications run in constant time. The commitment is on the curve of h, whose
+multiples are precomputed on the first commitments with it.
 */
-func Commit(x, r *big.Int, h *bn256.G2) (*bn256.G2, error) {
+func Commit(x, r *big.Int, h pairing.G2) (pairing.G2, error) {
 	var (
-		C *bn256.G2
+		C pairing.G2
 	)
-	C = new(bn256.G2).ScalarBaseMult(x)
-	C.Add(C, new(bn256.G2).ScalarMult(h, r))
+	c := h.Curve()
+	C = c.NewG2().ScalarBaseMultCT(x)
+	C.Add(C, c.NewG2().ScalarMultTable(pairing.CachedG2Table(h), r))
 	return C, nil
 }
 
 /*
 CommitG1 method corresponds to the Pedersen commitment scheme. Namely, given input
-message x, and randomness r, it outputs g^x.h^r.
+message x, and randomness r, it outputs g^x.h^r. Both are secret, so the scalar
+multiplications run in constant time. The commitment is on the curve of h, whose
+multiples are precomputed on the first commitments with it.
 */
-func CommitG1(x, r *big.Int, h *bn256.G1) (*bn256.G1, error) {
+func CommitG1(x, r *big.Int, h pairing.G1) (pairing.G1, error) {
 	var (
-		C *bn256.G1
+		C pairing.G1
 	)
-	C = new(bn256.G1).ScalarBaseMult(x)
-	C.Add(C, new(bn256.G1).ScalarMult(h, r))
+	c := h.Curve()
+	C = c.NewG1().ScalarBaseMultCT(x)
+	C.Add(C, c.NewG1().ScalarMultTable(pairing.CachedG1Table(h), r))
 	return C, nil
 }
 
 /*
 HashSet is responsible for the computing a Zp element given elements from GT and G2.
 */
-func HashSet(a *bn256.GT, D *bn256.G2) (*big.Int, error) {
+func HashSet(a pairing.GT, D pairing.G2) (*big.Int, error) {
 	digest := sha256.New()
 	digest.Write([]byte(a.String()))
 	digest.Write([]byte(D.String()))
@@ -90,7 +121,7 @@ func HashSet(a *bn256.GT, D *bn256.G2) (*big.Int, error) {
 /*
 Hash is responsible for the computing a Zp element given elements from GT and G2.
 */
-func Hash(a []*bn256.GT, D *bn256.G2) (*big.Int, error) {
+func Hash(a []pairing.GT, D pairing.G2) (*big.Int, error) {
 	digest := sha256.New()
 	for i := range a {
 		digest.Write([]byte(a[i].String()))
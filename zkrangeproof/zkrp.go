@@ -23,6 +23,13 @@ Asiacrypt 2008
 
 package zkrangeproof
 
+import (
+	"errors"
+
+	"github.com/blockchain-research/gridlock/crypto/bn256"
+	"github.com/blockchain-research/gridlock/crypto/pairing"
+)
+
 type izkrp interface {
 	//Commit()
 	Setup(a,b int64)
@@ -30,4 +37,108 @@ type izkrp interface {
 	Verify() (bool, error)
 }
 
+/*
+RangeProofVerifier is implemented by the public parameters of every range proof
+scheme accepted by the chaincode. VerifyCommitment checks that zkrp proves the
+value committed in cm belongs to the range of the scheme. A commitment on another curve
+than the one of the scheme is rejected with ErrCurveMismatch.
+*/
+type RangeProofVerifier interface {
+	VerifyCommitment(zkrp []byte, cm pairing.G2) (bool, error)
+}
+
+/*
+AggregateRangeProofVerifier is implemented by the schemes that can prove several
+committed values are in range with a single proof. The commitments are bound in order.
+*/
+type AggregateRangeProofVerifier interface {
+	RangeProofVerifier
+	VerifyAggregateCommitment(zkrp []byte, cms []pairing.G2) (bool, error)
+}
+
+/*
+BatchRangeProofVerifier is implemented by the schemes that can check several separate
+proofs faster than one by one. When the batch fails, the index of the first invalid proof
+is returned, otherwise the index is -1.
+*/
+type BatchRangeProofVerifier interface {
+	RangeProofVerifier
+	BatchVerifyCommitments(zkrps [][]byte, cms []pairing.G2) (bool, int, error)
+}
+
+/*
+VerifyCommitments checks that zkrps[k] proves the value committed in cms[k] is in range.
+The proofs are batch verified when the scheme supports it. When it fails, it also returns
+the index of the first invalid proof.
+*/
+func VerifyCommitments(verifier RangeProofVerifier, zkrps [][]byte, cms []pairing.G2) (bool, int, error) {
+	if len(zkrps) != len(cms) {
+		return false, -1, errors.New("The number of proofs and commitments differ")
+	}
+	if batchVerifier, ok := verifier.(BatchRangeProofVerifier); ok && len(zkrps) > 1 {
+		return batchVerifier.BatchVerifyCommitments(zkrps, cms)
+	}
+	for k := range zkrps {
+		result, err := verifier.VerifyCommitment(zkrps[k], cms[k])
+		if err != nil || result != true {
+			return false, k, err
+		}
+	}
+	return true, -1, nil
+}
+
+/*
+BN256RangeProofVerifier is implemented by the schemes that only exist on BN256,
+OnBN256 adapts them to RangeProofVerifier.
+*/
+type BN256RangeProofVerifier interface {
+	VerifyCommitment(zkrp []byte, cm *bn256.G2) (bool, error)
+}
+
+/*
+BN256AggregateRangeProofVerifier is the BN256 counterpart of AggregateRangeProofVerifier.
+*/
+type BN256AggregateRangeProofVerifier interface {
+	BN256RangeProofVerifier
+	VerifyAggregateCommitment(zkrp []byte, cms []*bn256.G2) (bool, error)
+}
+
+/*
+OnBN256 adapts a verifier of a scheme that only exists on BN256 to RangeProofVerifier.
+The result is an AggregateRangeProofVerifier when the verifier supports aggregation.
+*/
+func OnBN256(verifier BN256RangeProofVerifier) RangeProofVerifier {
+	if aggregate, ok := verifier.(BN256AggregateRangeProofVerifier); ok {
+		return onBN256Aggregate{onBN256{verifier}, aggregate}
+	}
+	return onBN256{verifier}
+}
 
+type onBN256 struct {
+	verifier BN256RangeProofVerifier
+}
+
+func (v onBN256) VerifyCommitment(zkrp []byte, cm pairing.G2) (bool, error) {
+	e, ok := pairing.ToBN256G2(cm)
+	if !ok {
+		return false, ErrCurveMismatch
+	}
+	return v.verifier.VerifyCommitment(zkrp, e)
+}
+
+type onBN256Aggregate struct {
+	onBN256
+	aggregate BN256AggregateRangeProofVerifier
+}
+
+func (v onBN256Aggregate) VerifyAggregateCommitment(zkrp []byte, cms []pairing.G2) (bool, error) {
+	es := make([]*bn256.G2, len(cms))
+	for k, cm := range cms {
+		e, ok := pairing.ToBN256G2(cm)
+		if !ok {
+			return false, ErrCurveMismatch
+		}
+		es[k] = e
+	}
+	return v.aggregate.VerifyAggregateCommitment(zkrp, es)
+}